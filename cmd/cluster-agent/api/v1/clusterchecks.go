@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 
@@ -96,7 +97,8 @@ func getCheckConfigs(sc clusteragent.ServerContext) func(w http.ResponseWriter,
 	}
 }
 
-// postRebalanceChecks requests that the cluster checks be rebalanced
+// postRebalanceChecks requests that the cluster checks be rebalanced,
+// only the planned moves are returned when the dry_run parameter is true
 func postRebalanceChecks(sc clusteragent.ServerContext) func(w http.ResponseWriter, r *http.Request) {
 	if sc.ClusterCheckHandler == nil {
 		return clusterChecksDisabledHandler
@@ -107,7 +109,17 @@ func postRebalanceChecks(sc clusteragent.ServerContext) func(w http.ResponseWrit
 			return
 		}
 
-		response, err := sc.ClusterCheckHandler.RebalanceClusterChecks()
+		dryRun := false
+		if value := r.URL.Query().Get("dry_run"); value != "" {
+			var err error
+			if dryRun, err = strconv.ParseBool(value); err != nil {
+				http.Error(w, fmt.Sprintf("invalid dry_run parameter: %v", err), http.StatusBadRequest)
+				incrementRequestMetric("postRebalanceChecks", http.StatusBadRequest)
+				return
+			}
+		}
+
+		response, err := sc.ClusterCheckHandler.RebalanceClusterChecks(dryRun)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			incrementRequestMetric("postRebalanceChecks", http.StatusInternalServerError)
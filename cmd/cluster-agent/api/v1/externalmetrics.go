@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/externalmetrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// InstallExternalMetricsEndpoints registers endpoints for external metrics
+func InstallExternalMetricsEndpoints(r *mux.Router) {
+	log.Debug("Registering external metrics endpoints")
+	r.HandleFunc("/externalmetrics/preview", postQueryPreview).Methods("POST")
+}
+
+// postQueryPreview validates and executes a DatadogMetric query
+func postQueryPreview(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5005/api/v1/externalmetrics/preview
+			Body: {"query": "avg:nginx.net.request_per_s{kube_container_name:nginx}", "backend": "datadog"}
+		Outputs
+			Status: 200
+			Returns: externalmetrics.QueryPreview
+			Example: {"query": "avg:nginx.net.request_per_s{kube_container_name:nginx}", "backend": "datadog", "valid": true, "value": 12.5, "timestamp": 1634567890}
+
+			Status: 400
+			Returns: string
+			Example: "invalid character 'q' looking for beginning of value"
+	*/
+	var req externalmetrics.QueryPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		incrementRequestMetric("postQueryPreview", http.StatusBadRequest)
+		return
+	}
+
+	preview := externalmetrics.PreviewQuery(req)
+	if preview.Error != "" {
+		log.Debugf("DatadogMetric query preview failed for %q: %s", req.Query, preview.Error)
+	}
+
+	previewBytes, err := json.Marshal(preview)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		incrementRequestMetric("postQueryPreview", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(previewBytes)
+	incrementRequestMetric("postQueryPreview", http.StatusOK)
+}
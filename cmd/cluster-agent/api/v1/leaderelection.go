@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// InstallLeaderElectionEndpoints registers endpoints for the leader election
+func InstallLeaderElectionEndpoints(r *mux.Router, le *leaderelection.LeaderEngine) {
+	log.Debug("Registering leader election endpoints")
+	r.HandleFunc("/leader", getLeaderState(le)).Methods("GET")
+}
+
+// getLeaderState is used by the other cluster agent replicas to detect split-brains
+func getLeaderState(le *leaderelection.LeaderEngine) func(w http.ResponseWriter, r *http.Request) {
+	/*
+		Input
+			localhost:5005/api/v1/leader
+		Outputs
+			Status: 200
+			Returns: leaderelection.LeaderState
+			Example: {"identity": "datadog-cluster-agent-1", "leader": "datadog-cluster-agent-1", "is_leader": true}
+	*/
+	return func(w http.ResponseWriter, r *http.Request) {
+		stateBytes, err := json.Marshal(le.GetState())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			incrementRequestMetric("getLeaderState", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(stateBytes)
+		incrementRequestMetric("getLeaderState", http.StatusOK)
+	}
+}
@@ -235,6 +235,11 @@ func start(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Expose the leader election state to the other replicas to detect split-brains
+	api.ModifyAPIRouter(func(r *mux.Router) {
+		dcav1.InstallLeaderElectionEndpoints(r, le)
+	})
+
 	// Create event recorder
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(log.Infof)
@@ -320,6 +325,10 @@ func start(cmd *cobra.Command, args []string) error {
 	wg := sync.WaitGroup{}
 	// Autoscaler Controller Goroutine
 	if config.Datadog.GetBool("external_metrics_provider.enabled") {
+		api.ModifyAPIRouter(func(r *mux.Router) {
+			dcav1.InstallExternalMetricsEndpoints(r)
+		})
+
 		// Start the k8s custom metrics server. This is a blocking call
 		wg.Add(1)
 		go func() {
@@ -338,6 +347,8 @@ func start(cmd *cobra.Command, args []string) error {
 		server := admissioncmd.NewServer()
 		server.Register(config.Datadog.GetString("admission_controller.inject_config.endpoint"), mutate.InjectConfig, apiCl.DynamicCl)
 		server.Register(config.Datadog.GetString("admission_controller.inject_tags.endpoint"), mutate.InjectTags, apiCl.DynamicCl)
+		server.Register(config.Datadog.GetString("admission_controller.auto_instrumentation.endpoint"), mutate.InjectAutoInstrumentation, apiCl.DynamicCl)
+		server.Register(config.Datadog.GetString("admission_controller.agent_sidecar.endpoint"), mutate.InjectAgentSidecar, apiCl.DynamicCl)
 
 		// Start the k8s admission webhook server
 		wg.Add(1)
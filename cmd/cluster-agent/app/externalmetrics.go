@@ -0,0 +1,17 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package app
+
+import "github.com/DataDog/datadog-agent/cmd/cluster-agent/commands"
+
+func init() {
+	datadogMetricCmd := commands.GetDatadogMetricCobraCmd()
+	datadogMetricCmd.AddCommand(commands.PreviewDatadogMetricCobraCmd(&flagNoColor, &confPath, loggerName))
+
+	ClusterAgentCmd.AddCommand(datadogMetricCmd)
+}
@@ -24,6 +24,7 @@ import (
 
 var (
 	checkName string
+	dryRun    bool
 )
 
 func GetClusterChecksCobraCmd(flagNoColor *bool, confPath *string, loggerName config.LoggerName) *cobra.Command {
@@ -86,14 +87,19 @@ func RebalanceClusterChecksCobraCmd(flagNoColor *bool, confPath *string, loggerN
 			return rebalanceChecks()
 		},
 	}
+	clusterChecksCmd.Flags().BoolVarP(&dryRun, "dry-run", "", false, "only print the planned check moves without applying them")
 
 	return clusterChecksCmd
 }
 
 func rebalanceChecks() error {
-	fmt.Println("Requesting a cluster check rebalance...")
+	if dryRun {
+		fmt.Println("Requesting a cluster check rebalance plan...")
+	} else {
+		fmt.Println("Requesting a cluster check rebalance...")
+	}
 	c := util.GetClient(false) // FIX: get certificates right then make this true
-	urlstr := fmt.Sprintf("https://localhost:%v/api/v1/clusterchecks/rebalance", config.Datadog.GetInt("cluster_agent.cmd_port"))
+	urlstr := fmt.Sprintf("https://localhost:%v/api/v1/clusterchecks/rebalance?dry_run=%t", config.Datadog.GetInt("cluster_agent.cmd_port"), dryRun)
 
 	// Set session token
 	err := util.SetAuthToken()
@@ -121,11 +127,16 @@ func rebalanceChecks() error {
 	checksMoved := make([]types.RebalanceResponse, 0)
 	json.Unmarshal(r, &checksMoved) //nolint:errcheck
 
-	fmt.Printf("%d cluster checks rebalanced successfully\n", len(checksMoved))
-
+	verb := "moved"
+	if dryRun {
+		verb = "would move"
+		fmt.Printf("%d cluster checks would be moved\n", len(checksMoved))
+	} else {
+		fmt.Printf("%d cluster checks rebalanced successfully\n", len(checksMoved))
+	}
 	for _, check := range checksMoved {
-		fmt.Printf("Check %s with weight %d moved from node %s to %s. source diff: %d, dest diff: %d\n",
-			check.CheckID, check.CheckWeight, check.SourceNodeName, check.DestNodeName, check.SourceDiff, check.DestDiff)
+		fmt.Printf("Check %s with weight %d %s from node %s to %s. source diff: %d, dest diff: %d\n",
+			check.CheckID, check.CheckWeight, verb, check.SourceNodeName, check.DestNodeName, check.SourceDiff, check.DestDiff)
 	}
 
 	return nil
@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/externalmetrics"
+	"github.com/DataDog/datadog-agent/pkg/config"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	previewBackend   string
+	previewMockValue float64
+)
+
+// GetDatadogMetricCobraCmd returns the command grouping the DatadogMetric tools
+func GetDatadogMetricCobraCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "datadogmetric",
+		Short: "DatadogMetric tools",
+	}
+}
+
+// PreviewDatadogMetricCobraCmd returns the command validating and executing a DatadogMetric query
+func PreviewDatadogMetricCobraCmd(flagNoColor *bool, confPath *string, loggerName config.LoggerName) *cobra.Command {
+	previewCmd := &cobra.Command{
+		Use:   "preview <query>",
+		Short: "Validates a DatadogMetric query and prints the value that would be served to the autoscalers",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if *flagNoColor {
+				color.NoColor = true
+			}
+
+			// we'll search for a config file named `datadog-cluster.yaml`
+			config.Datadog.SetConfigName("datadog-cluster")
+			err := common.SetupConfig(*confPath)
+			if err != nil {
+				return fmt.Errorf("unable to set up global cluster agent configuration: %v", err)
+			}
+
+			err = config.SetupLogger(loggerName, config.GetEnvDefault("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+			if err != nil {
+				fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+				return err
+			}
+
+			return previewQuery(externalmetrics.QueryPreviewRequest{
+				Query:     args[0],
+				Backend:   previewBackend,
+				MockValue: previewMockValue,
+			})
+		},
+	}
+	previewCmd.Flags().StringVarP(&previewBackend, "backend", "", externalmetrics.QueryPreviewBackendDatadog, fmt.Sprintf("the backend executing the query: %s or %s", externalmetrics.QueryPreviewBackendDatadog, externalmetrics.QueryPreviewBackendMock))
+	previewCmd.Flags().Float64VarP(&previewMockValue, "mock-value", "", 0, "the value returned by the mock backend")
+
+	return previewCmd
+}
+
+func previewQuery(req externalmetrics.QueryPreviewRequest) error {
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	urlstr := fmt.Sprintf("https://localhost:%v/api/v1/externalmetrics/preview", config.Datadog.GetInt("cluster_agent.cmd_port"))
+
+	// Set session token
+	err := util.SetAuthToken()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	r, err := util.DoPost(c, urlstr, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		fmt.Printf(`
+		Could not reach agent: %v
+		Make sure the cluster agent is running with the external metrics provider enabled before previewing a query.
+		Contact support if you continue having issues.`, err)
+
+		return err
+	}
+
+	var preview externalmetrics.QueryPreview
+	if err = json.Unmarshal(r, &preview); err != nil {
+		return err
+	}
+
+	fmt.Printf("Query: %s\n", preview.Query)
+	if preview.ResolvedQuery != "" && preview.ResolvedQuery != preview.Query {
+		fmt.Printf("Resolved query: %s\n", preview.ResolvedQuery)
+	}
+	fmt.Printf("Backend: %s\n", preview.Backend)
+
+	if !preview.Valid {
+		fmt.Printf("%s: %s\n", color.RedString("Invalid"), preview.Error)
+		return fmt.Errorf("invalid query")
+	}
+
+	fmt.Printf("%s: %v (at %s)\n", color.GreenString("Value served to the autoscalers"), preview.Value, time.Unix(preview.Timestamp, 0).UTC().Format(time.RFC3339))
+
+	return nil
+}
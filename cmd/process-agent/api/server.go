@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"net"
 	"net/http"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 
 	ddconfig "github.com/DataDog/datadog-agent/pkg/config"
 	settingshttp "github.com/DataDog/datadog-agent/pkg/config/settings/http"
+	"github.com/DataDog/datadog-agent/pkg/process/checks"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -17,6 +19,15 @@ func setupHandlers(r *mux.Router) {
 	r.HandleFunc("/config/list-runtime", settingshttp.Server.ListConfigurable).Methods("GET")
 	r.HandleFunc("/config/{setting}", settingshttp.Server.GetValue).Methods("GET")
 	r.HandleFunc("/config/{setting}", settingshttp.Server.SetValue).Methods("POST")
+	r.HandleFunc("/discovery/services", getDiscoveredServices).Methods("GET")
+}
+
+// getDiscoveredServices returns the services detected by the last run of the process discovery check
+func getDiscoveredServices(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(checks.ProcessDiscovery.Services()); err != nil {
+		_ = log.Errorf("unable to encode the discovered services: %s", err)
+	}
 }
 
 // StartServer starts the config server
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/spf13/cobra"
+)
+
+var scrubCommand = &cobra.Command{
+	Use:   "scrub [command line]",
+	Short: "Print a command line as it is reported once scrubbed",
+	Long: `Scrub the given command line with the sensitive words and custom scrub rules of the
+configuration, honoring scrub_args and strip_proc_arguments, and print the result along with
+the custom scrub rules matching it.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runScrub,
+}
+
+func init() {
+	rootCmd.AddCommand(scrubCommand)
+}
+
+func runScrub(_ *cobra.Command, args []string) error {
+	// Quietly load the configuration, the same way as the config commands do
+	cfg := config.NewDefaultAgentConfig(false)
+	if opts.configPath != "" {
+		if err := config.LoadConfigIfExists(opts.configPath); err != nil {
+			return err
+		}
+	}
+	if err := cfg.LoadProcessYamlConfig(opts.configPath); err != nil {
+		return err
+	}
+
+	fmt.Println(strings.Join(cfg.Scrubber.ScrubCmdline(args), " "))
+
+	if cfg.Scrubber.StripAllArguments {
+		fmt.Println("\nAll the arguments are stripped, as strip_proc_arguments is enabled")
+		return nil
+	}
+	if !cfg.Scrubber.Enabled {
+		fmt.Println("\nCommand lines are not scrubbed, as scrub_args is disabled")
+		return nil
+	}
+
+	matching := cfg.Scrubber.Rules.MatchingRules(args)
+	if len(matching) == 0 {
+		fmt.Println("\nNo custom scrub rule matches this command line")
+		return nil
+	}
+
+	fmt.Println("\nMatching custom scrub rules:")
+	for _, rule := range matching {
+		b, err := json.Marshal(rule)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  %s\n", b)
+	}
+	return nil
+}
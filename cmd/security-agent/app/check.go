@@ -12,12 +12,15 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-agent/cmd/security-agent/common"
+	"github.com/DataDog/datadog-agent/pkg/compliance"
 	"github.com/DataDog/datadog-agent/pkg/compliance/agent"
 	"github.com/DataDog/datadog-agent/pkg/compliance/checks"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/export"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
@@ -25,6 +28,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/flavor"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
 	"github.com/cihub/seelog"
 	"github.com/spf13/cobra"
 )
@@ -37,7 +41,10 @@ var (
 		report            bool
 		overrideRegoInput string
 		dumpRegoInput     string
+		showRegoTrace     bool
 		dumpReports       string
+		exportDir         string
+		exportFormats     string
 	}{}
 )
 
@@ -48,7 +55,10 @@ func setupCheckCmd(cmd *cobra.Command) {
 	cmd.Flags().BoolVarP(&checkArgs.report, "report", "r", false, "Send report")
 	cmd.Flags().StringVarP(&checkArgs.overrideRegoInput, "override-rego-input", "", "", "Rego input to use when running rego checks")
 	cmd.Flags().StringVarP(&checkArgs.dumpRegoInput, "dump-rego-input", "", "", "Path to file where to dump the Rego input JSON")
+	cmd.Flags().BoolVarP(&checkArgs.showRegoTrace, "show-rego-trace", "", false, "Print the evaluation trace of Rego checks")
 	cmd.Flags().StringVarP(&checkArgs.dumpReports, "dump-reports", "", "", "Path to file where to dump reports")
+	cmd.Flags().StringVarP(&checkArgs.exportDir, "export-dir", "", "", "Path to directory where to write local result reports")
+	cmd.Flags().StringVarP(&checkArgs.exportFormats, "export-formats", "", strings.Join(export.Formats, ","), "Comma separated list of formats of the local result reports")
 }
 
 // CheckCmd returns a cobra command to run security agent checks
@@ -84,7 +94,12 @@ func runCheck(cmd *cobra.Command, confPathArray []string, args []string) error {
 
 	options := []checks.BuilderOption{}
 
-	if flavor.GetFlavor() == flavor.ClusterAgent {
+	if checkArgs.overrideRegoInput != "" {
+		// the provided rego input is a snapshot of the environment, checks are
+		// evaluated offline and don't need access to docker, audit or kubernetes
+		log.Infof("Running on provided rego input: path=%s", checkArgs.overrideRegoInput)
+		options = append(options, checks.WithRegoInput(checkArgs.overrideRegoInput))
+	} else if flavor.GetFlavor() == flavor.ClusterAgent {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
@@ -111,6 +126,13 @@ func runCheck(cmd *cobra.Command, confPathArray []string, args []string) error {
 		}
 	}
 
+	var exportFormats []string
+	if checkArgs.exportDir != "" {
+		if exportFormats, err = export.ParseFormats(checkArgs.exportFormats); err != nil {
+			return err
+		}
+	}
+
 	var ruleID string
 	if len(args) != 0 {
 		ruleID = args[0]
@@ -141,20 +163,20 @@ func runCheck(cmd *cobra.Command, confPathArray []string, args []string) error {
 		options = append(options, checks.WithMatchSuite(checks.IsFramework(checkArgs.framework)))
 	}
 
-	if checkArgs.overrideRegoInput != "" {
-		log.Infof("Running on provided rego input: path=%s", checkArgs.overrideRegoInput)
-		options = append(options, checks.WithRegoInput(checkArgs.overrideRegoInput))
-	}
-
 	if checkArgs.dumpRegoInput != "" {
 		options = append(options, checks.WithRegoInputDumpPath(checkArgs.dumpRegoInput))
 	}
 
+	if checkArgs.showRegoTrace {
+		options = append(options, checks.WithRegoTrace(os.Stdout))
+	}
+
+	var statuses compliance.CheckStatusList
 	if checkArgs.file != "" {
-		err = agent.RunChecksFromFile(reporter, checkArgs.file, options...)
+		statuses, err = agent.RunChecksFromFile(reporter, checkArgs.file, options...)
 	} else {
 		configDir := config.Datadog.GetString("compliance_config.dir")
-		err = agent.RunChecks(reporter, configDir, options...)
+		statuses, err = agent.RunChecks(reporter, configDir, options...)
 	}
 
 	if err != nil {
@@ -167,6 +189,14 @@ func runCheck(cmd *cobra.Command, confPathArray []string, args []string) error {
 		return err
 	}
 
+	if checkArgs.exportDir != "" {
+		results := export.NewResults(hostname, version.AgentVersion, time.Now(), statuses, reporter.events)
+		if err := export.WriteReports(checkArgs.exportDir, results, exportFormats); err != nil {
+			log.Errorf("Failed to export reports: %v", err)
+			return err
+		}
+	}
+
 	return nil
 }
 
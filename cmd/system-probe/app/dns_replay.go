@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build linux_bpf windows
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	networkconfig "github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	debugCommand.AddCommand(dnsReplayCommand)
+	dnsReplayCommand.Flags().BoolVar(&dnsReplayCollectLocal, "collect-local-dns", false, "include DNS traffic to servers on the loopback interface")
+}
+
+var (
+	dnsReplayCollectLocal bool
+
+	dnsReplayCommand = &cobra.Command{
+		Use:   "dns-replay <capture file>",
+		Short: "Replay a pcap or pcapng capture through the DNS snooper and print the resulting DNS stats",
+		Long: `Replay a pcap or pcapng capture through the DNS parser and stat keeper of system-probe,
+without requiring a running system-probe, and print the DNS stats computed from it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: dnsReplay,
+	}
+)
+
+func dnsReplay(_ *cobra.Command, args []string) error {
+	if _, err := setupConfig(); err != nil {
+		return err
+	}
+
+	cfg := networkconfig.New()
+	cfg.CollectLocalDNS = cfg.CollectLocalDNS || dnsReplayCollectLocal
+
+	stats, telemetry, err := dns.ReplayCapture(cfg, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := dns.WriteStats(os.Stdout, stats); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(telemetry))
+	for name := range telemetry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println()
+	for _, name := range names {
+		fmt.Printf("%s: %d\n", name, telemetry[name])
+	}
+	return nil
+}
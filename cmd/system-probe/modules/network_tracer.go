@@ -18,8 +18,10 @@ import (
 	"github.com/DataDog/datadog-agent/cmd/system-probe/utils"
 	"github.com/DataDog/datadog-agent/pkg/network"
 	networkconfig "github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/encoding"
 	"github.com/DataDog/datadog-agent/pkg/network/http/debugging"
+	protocoldebugging "github.com/DataDog/datadog-agent/pkg/network/protocols/debugging"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -120,6 +122,34 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 		utils.WriteAsJSON(w, debugging.HTTP(cs.HTTP, cs.DNS))
 	})
 
+	httpMux.HandleFunc("/debug/protocol_monitoring", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, protocoldebugging.Protocols(cs.Conns, cs.Kafka, cs.Postgres))
+	})
+
+	httpMux.HandleFunc("/debug/dns_resolvers", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		rollups := make(map[string]*dns.ResolverStats)
+		for resolver, stats := range dns.RollupByResolver(cs.DNSStats) {
+			rollups[resolver.String()] = stats
+		}
+		utils.WriteAsJSON(w, rollups)
+	})
+
 	// /debug/ebpf_maps as default will dump all registered maps/perfmaps
 	// an optional ?maps= argument could be pass with a list of map name : ?maps=map1,map2,map3
 	httpMux.HandleFunc("/debug/ebpf_maps", func(w http.ResponseWriter, req *http.Request) {
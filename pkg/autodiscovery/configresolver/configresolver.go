@@ -24,12 +24,13 @@ import (
 type variableGetter func(ctx context.Context, key []byte, svc listeners.Service) ([]byte, error)
 
 var templateVariables = map[string]variableGetter{
-	"host":     getHost,
-	"pid":      getPid,
-	"port":     getPort,
-	"hostname": getHostname,
-	"extra":    getAdditionalTplVariables,
-	"kube":     getAdditionalTplVariables,
+	"host":      getHost,
+	"pid":       getPid,
+	"port":      getPort,
+	"hostname":  getHostname,
+	"extra":     getAdditionalTplVariables,
+	"kube":      getAdditionalTplVariables,
+	"container": getContainerTplVariables,
 }
 
 // SubstituteTemplateEnvVars replaces %%ENV_VARIABLE%% from environment
@@ -161,7 +162,11 @@ func resolveDataWithTemplateVars(ctx context.Context, data integration.Data, svc
 		if f, found := templateVariables[string(tVar.Name)]; found {
 			resolvedVar, err := f(ctx, tVar.Key, svc)
 			if err != nil {
-				return res, err
+				if tVar.Default == nil {
+					return res, err
+				}
+				log.Debugf("using default value %q for template variable %s: %s", tVar.Default, tVar.Raw, err)
+				resolvedVar = tVar.Default
 			}
 			res = bytes.Replace(res, tVar.Raw, resolvedVar, -1)
 		}
@@ -178,6 +183,9 @@ func resolveDataWithEnvs(data integration.Data) ([]byte, error) {
 	for _, tVar := range templateVars {
 		if "env" == string(tVar.Name) {
 			resolvedVar, err := getEnvvar(tVar.Key)
+			if err != nil && tVar.Default != nil {
+				resolvedVar, err = tVar.Default, nil
+			}
 			if err != nil {
 				log.Warnf("variable not replaced: %s", err)
 				if retErr == nil {
@@ -307,6 +315,16 @@ func getAdditionalTplVariables(_ context.Context, tplVar []byte, svc listeners.S
 	return value, nil
 }
 
+// getContainerTplVariables resolves template variables prefixed with container_,
+// like %%container_name%%, from the extra config of the service
+func getContainerTplVariables(_ context.Context, tplVar []byte, svc listeners.Service) ([]byte, error) {
+	value, err := svc.GetExtraConfig(append([]byte("container_"), tplVar...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container info for service %s, skipping config - %s", svc.GetEntity(), err)
+	}
+	return value, nil
+}
+
 // getEnvvar returns a system environment variable if found
 func getEnvvar(envVar []byte) ([]byte, error) {
 	if len(envVar) == 0 {
@@ -96,7 +96,11 @@ func (s *dummyService) HasFilter(filter containers.FilterType) bool {
 
 // GetExtraConfig returns extra configuration
 func (s *dummyService) GetExtraConfig(key []byte) ([]byte, error) {
-	return []byte(s.ExtraConfig[string(key)]), nil
+	value, found := s.ExtraConfig[string(key)]
+	if !found {
+		return nil, fmt.Errorf("extra config %q is not supported", key)
+	}
+	return []byte(value), nil
 }
 
 func TestGetFallbackHost(t *testing.T) {
@@ -706,6 +710,65 @@ func TestResolve(t *testing.T) {
 				Entity:        "a5901276aed1",
 			},
 		},
+		{
+			testName: "pod labels, pod annotations and container name",
+			svc: &dummyService{
+				ID:            "a5901276aed1",
+				ADIdentifiers: []string{"redis"},
+				ExtraConfig: map[string]string{
+					"namespace":                          "default",
+					"container_name":                     "redis-master",
+					"pod_label_app.kubernetes.io/name":   "redis",
+					"pod_annotation_example.com/cluster": "cache",
+				},
+			},
+			tpl: integration.Config{
+				Name:          "redis",
+				ADIdentifiers: []string{"redis"},
+				Instances:     []integration.Data{integration.Data("app: %%kube_pod_label_app.kubernetes.io/name%%\ncluster: %%kube_pod_annotation_example.com/cluster%%\ncontainer: %%container_name%%\nnamespace: %%kube_namespace%%")},
+			},
+			out: integration.Config{
+				Name:          "redis",
+				ADIdentifiers: []string{"redis"},
+				Instances:     []integration.Data{integration.Data("app: redis\ncluster: cache\ncontainer: redis-master\nnamespace: default\ntags:\n- foo:bar\n")},
+				Entity:        "a5901276aed1",
+			},
+		},
+		{
+			testName: "missing pod label",
+			svc: &dummyService{
+				ID:            "a5901276aed1",
+				ADIdentifiers: []string{"redis"},
+				ExtraConfig:   map[string]string{"namespace": "default"},
+			},
+			tpl: integration.Config{
+				Name:          "redis",
+				ADIdentifiers: []string{"redis"},
+				Instances:     []integration.Data{integration.Data("app: %%kube_pod_label_app%%")},
+			},
+			errorString: "failed to get extra info for service a5901276aed1, skipping config - extra config \"pod_label_app\" is not supported",
+		},
+		{
+			testName: "default values",
+			svc: &dummyService{
+				ID:            "a5901276aed1",
+				ADIdentifiers: []string{"redis"},
+				Hosts:         map[string]string{"bridge": "127.0.0.1"},
+				Ports:         newFakeContainerPorts(),
+				ExtraConfig:   map[string]string{"namespace": "default"},
+			},
+			tpl: integration.Config{
+				Name:          "redis",
+				ADIdentifiers: []string{"redis"},
+				Instances:     []integration.Data{integration.Data("host: %%host%%\nport: %%port_http|8080%%\nmetrics_port: %%port_bar|9090%%\napp: %%kube_pod_label_app|unknown%%\nnamespace: %%kube_namespace|none%%\nenv: \"%%env_UNDEFINED_AD_VAR|%%\"")},
+			},
+			out: integration.Config{
+				Name:          "redis",
+				ADIdentifiers: []string{"redis"},
+				Instances:     []integration.Data{integration.Data("app: unknown\nenv: \"\"\nhost: 127.0.0.1\nmetrics_port: 2\nnamespace: default\nport: 8080\ntags:\n- foo:bar\n")},
+				Entity:        "a5901276aed1",
+			},
+		},
 	}
 
 	for i, tc := range testCases {
@@ -94,9 +94,10 @@ func (l *ContainerListener) createContainerService(
 			containerImg.RawName,
 			container.Labels,
 		),
-		ports:    ports,
-		pid:      container.PID,
-		hostname: container.Hostname,
+		ports:       ports,
+		pid:         container.PID,
+		hostname:    container.Hostname,
+		extraConfig: map[string]string{"container_name": container.Name},
 	}
 
 	if findKubernetesInLabels(container.Labels) {
@@ -104,6 +105,19 @@ func (l *ContainerListener) createContainerService(
 		if err == nil {
 			svc.hosts = map[string]string{"pod": pod.IP}
 			svc.ready = pod.Ready
+			svc.extraConfig = podExtraConfig(pod)
+			svc.extraConfig["container_name"] = container.Name
+			svc.podLabels = pod.Labels
+			svc.podAnnotations = pod.Annotations
+
+			// the runtime name of kubernetes containers is generated,
+			// the name from the pod spec is the one users know
+			for _, podContainer := range pod.Containers {
+				if podContainer.ID == container.ID {
+					svc.extraConfig["container_name"] = podContainer.Name
+					break
+				}
+			}
 		} else {
 			log.Debugf("container %q belongs to a pod but was not found: %s", container.ID, err)
 		}
@@ -84,6 +84,9 @@ func TestCreateContainerService(t *testing.T) {
 						creationTime: integration.After,
 						ports:        []ContainerPort{},
 						ready:        true,
+						extraConfig: map[string]string{
+							"container_name": containerName,
+						},
 					},
 				},
 			},
@@ -125,6 +128,9 @@ func TestCreateContainerService(t *testing.T) {
 						},
 						creationTime: integration.After,
 						ready:        true,
+						extraConfig: map[string]string{
+							"container_name": containerName,
+						},
 					},
 				},
 			},
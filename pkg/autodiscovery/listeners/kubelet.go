@@ -102,12 +102,15 @@ func (l *KubeletListener) createPodService(
 
 	entity := kubelet.PodUIDToEntityName(pod.ID)
 	svc := &service{
-		entity:        pod,
-		adIdentifiers: []string{entity},
-		hosts:         map[string]string{"pod": pod.IP},
-		ports:         ports,
-		creationTime:  creationTime,
-		ready:         true,
+		entity:         pod,
+		adIdentifiers:  []string{entity},
+		hosts:          map[string]string{"pod": pod.IP},
+		ports:          ports,
+		creationTime:   creationTime,
+		ready:          true,
+		extraConfig:    podExtraConfig(pod),
+		podLabels:      pod.Labels,
+		podAnnotations: pod.Annotations,
 	}
 
 	svcID := buildSvcID(pod.GetID())
@@ -158,18 +161,19 @@ func (l *KubeletListener) createContainerService(
 		return ports[i].Port < ports[j].Port
 	})
 
+	extraConfig := podExtraConfig(pod)
+	extraConfig["container_name"] = containerName
+
 	entity := containers.BuildEntityName(string(container.Runtime), container.ID)
 	svc := &service{
-		entity:       container,
-		creationTime: creationTime,
-		ready:        pod.Ready,
-		ports:        ports,
-		extraConfig: map[string]string{
-			"pod_name":  pod.Name,
-			"namespace": pod.Namespace,
-			"pod_uid":   pod.ID,
-		},
-		hosts: map[string]string{"pod": pod.IP},
+		entity:         container,
+		creationTime:   creationTime,
+		ready:          pod.Ready,
+		ports:          ports,
+		extraConfig:    extraConfig,
+		podLabels:      pod.Labels,
+		podAnnotations: pod.Annotations,
+		hosts:          map[string]string{"pod": pod.IP},
 
 		// Exclude non-running containers (including init containers)
 		// from metrics collection but keep them for collecting logs.
@@ -218,6 +222,18 @@ func (l *KubeletListener) createContainerService(
 	l.AddService(svcID, svc, podSvcID)
 }
 
+// podExtraConfig returns the pod metadata exposed to the kube_ template
+// variables, like %%kube_namespace%%. The labels and annotations of the pod,
+// exposed to %%kube_pod_label_<key>%% and %%kube_pod_annotation_<key>%%, are
+// looked up in the maps of the pod set on the service instead of being copied.
+func podExtraConfig(pod *workloadmeta.KubernetesPod) map[string]string {
+	return map[string]string{
+		"pod_name":  pod.Name,
+		"namespace": pod.Namespace,
+		"pod_uid":   pod.ID,
+	}
+}
+
 // podHasADTemplate looks in pod annotations and looks for annotations containing an
 // AD template. It does not try to validate it, just having the `instance` fields is
 // OK to return true.
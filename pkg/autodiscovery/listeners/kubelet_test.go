@@ -13,6 +13,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
@@ -35,6 +38,9 @@ func TestKubeletCreatePodService(t *testing.T) {
 		EntityMeta: workloadmeta.EntityMeta{
 			Name:      podName,
 			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "agent",
+			},
 		},
 		IP: "127.0.0.1",
 	}
@@ -85,6 +91,14 @@ func TestKubeletCreatePodService(t *testing.T) {
 						},
 						creationTime: integration.After,
 						ready:        true,
+						extraConfig: map[string]string{
+							"namespace": podNamespace,
+							"pod_name":  podName,
+							"pod_uid":   podID,
+						},
+						podLabels: map[string]string{
+							"app": "agent",
+						},
 					},
 				},
 			},
@@ -233,9 +247,10 @@ func TestKubeletCreateContainerService(t *testing.T) {
 						ports:        []ContainerPort{},
 						creationTime: integration.After,
 						extraConfig: map[string]string{
-							"namespace": podNamespace,
-							"pod_name":  podName,
-							"pod_uid":   podID,
+							"namespace":      podNamespace,
+							"pod_name":       podName,
+							"pod_uid":        podID,
+							"container_name": containerName,
 						},
 					},
 				},
@@ -266,9 +281,10 @@ func TestKubeletCreateContainerService(t *testing.T) {
 						creationTime:    integration.After,
 						metricsExcluded: true,
 						extraConfig: map[string]string{
-							"namespace": podNamespace,
-							"pod_name":  podName,
-							"pod_uid":   podID,
+							"namespace":      podNamespace,
+							"pod_name":       podName,
+							"pod_uid":        podID,
+							"container_name": containerName,
 						},
 					},
 				},
@@ -326,9 +342,10 @@ func TestKubeletCreateContainerService(t *testing.T) {
 						},
 						creationTime: integration.After,
 						extraConfig: map[string]string{
-							"namespace": podNamespace,
-							"pod_name":  podName,
-							"pod_uid":   podID,
+							"namespace":      podNamespace,
+							"pod_name":       podName,
+							"pod_uid":        podID,
+							"container_name": containerName,
 						},
 					},
 				},
@@ -360,9 +377,15 @@ func TestKubeletCreateContainerService(t *testing.T) {
 						creationTime: integration.After,
 						checkNames:   []string{"customcheck"},
 						extraConfig: map[string]string{
-							"namespace": podNamespace,
-							"pod_name":  podName,
-							"pod_uid":   podID,
+							"namespace":      podNamespace,
+							"pod_name":       podName,
+							"pod_uid":        podID,
+							"container_name": containerName,
+						},
+						podAnnotations: map[string]string{
+							"ad.datadoghq.com/agent.check.id":       "customid",
+							"ad.datadoghq.com/customid.instances":   "[{}]",
+							"ad.datadoghq.com/customid.check_names": `["customcheck"]`,
 						},
 					},
 				},
@@ -381,6 +404,58 @@ func TestKubeletCreateContainerService(t *testing.T) {
 	}
 }
 
+func TestKubeletPodLabelChange(t *testing.T) {
+	pod := &workloadmeta.KubernetesPod{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindKubernetesPod,
+			ID:   podID,
+		},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:      podName,
+			Namespace: podNamespace,
+			Labels: map[string]string{
+				"app": "agent",
+			},
+		},
+		IP: "127.0.0.1",
+	}
+
+	newSvc := make(chan Service, 10)
+	delSvc := make(chan Service, 10)
+	listener := &KubeletListener{workloadmetaListener: &workloadmetaListenerImpl{
+		name:       "test",
+		services:   make(map[string]Service),
+		children:   make(map[string]map[string]struct{}),
+		newService: newSvc,
+		delService: delSvc,
+	}}
+
+	listener.createPodService(pod, nil, integration.After)
+	require.Len(t, newSvc, 1)
+	svc := <-newSvc
+	label, err := svc.GetExtraConfig([]byte("pod_label_app"))
+	require.NoError(t, err)
+	assert.Equal(t, "agent", string(label))
+
+	// The same pod is ignored
+	listener.createPodService(pod, nil, integration.After)
+	assert.Len(t, newSvc, 0)
+	assert.Len(t, delSvc, 0)
+
+	// The service is replaced when a label changes, for the templates to be resolved again
+	updatedPod := *pod
+	updatedPod.Labels = map[string]string{
+		"app": "cluster-agent",
+	}
+	listener.createPodService(&updatedPod, nil, integration.After)
+	require.Len(t, delSvc, 1)
+	assert.Equal(t, svc, <-delSvc)
+	require.Len(t, newSvc, 1)
+	label, err = (<-newSvc).GetExtraConfig([]byte("pod_label_app"))
+	require.NoError(t, err)
+	assert.Equal(t, "cluster-agent", string(label))
+}
+
 func newKubeletListener(t *testing.T) (*KubeletListener, *testWorkloadmetaListener) {
 	wlm := newTestWorkloadmetaListener(t)
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
@@ -13,19 +14,28 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
+const (
+	podLabelExtraConfigPrefix      = "pod_label_"
+	podAnnotationExtraConfigPrefix = "pod_annotation_"
+)
+
 // service implements the Service interface and stores data collected from
 // workloadmeta.Store.
 type service struct {
-	entity          workloadmeta.Entity
-	adIdentifiers   []string
-	hosts           map[string]string
-	ports           []ContainerPort
-	pid             int
-	hostname        string
-	creationTime    integration.CreationTime
-	ready           bool
-	checkNames      []string
-	extraConfig     map[string]string
+	entity        workloadmeta.Entity
+	adIdentifiers []string
+	hosts         map[string]string
+	ports         []ContainerPort
+	pid           int
+	hostname      string
+	creationTime  integration.CreationTime
+	ready         bool
+	checkNames    []string
+	extraConfig   map[string]string
+	// podLabels and podAnnotations are exposed as extra config with the pod_label_ and pod_annotation_
+	// prefixes, they are the maps of the workloadmeta pod rather than copies
+	podLabels       map[string]string
+	podAnnotations  map[string]string
 	metricsExcluded bool
 	logsExcluded    bool
 }
@@ -121,17 +131,28 @@ func (s *service) HasFilter(filter containers.FilterType) bool {
 
 // GetExtraConfig returns extra configuration associated with the service.
 func (s *service) GetExtraConfig(key []byte) ([]byte, error) {
-	result, found := s.extraConfig[string(key)]
-	if !found {
-		return []byte{}, fmt.Errorf("extra config %q is not supported", key)
+	if result, found := s.extraConfig[string(key)]; found {
+		return []byte(result), nil
+	}
+
+	if label := strings.TrimPrefix(string(key), podLabelExtraConfigPrefix); label != string(key) {
+		if result, found := s.podLabels[label]; found {
+			return []byte(result), nil
+		}
 	}
 
-	return []byte(result), nil
+	if annotation := strings.TrimPrefix(string(key), podAnnotationExtraConfigPrefix); annotation != string(key) {
+		if result, found := s.podAnnotations[annotation]; found {
+			return []byte(result), nil
+		}
+	}
+
+	return []byte{}, fmt.Errorf("extra config %q is not supported", key)
 }
 
 // svcEqual checks that two Services are equal to each other by doing a deep
-// equality check on data returned by most of Service's methods. Methods not
-// checked are HasFilter and GetExtraConfig.
+// equality check on data returned by most of Service's methods, and on the
+// extra config of the services. The only method not checked is HasFilter.
 func svcEqual(a, b Service) bool {
 	ctx := context.Background()
 
@@ -180,6 +201,24 @@ func svcEqual(a, b Service) bool {
 		return false
 	}
 
+	if !extraConfigEqual(a, b) {
+		return false
+	}
+
 	return a.GetCreationTime() == b.GetCreationTime() &&
 		a.IsReady(ctx) == b.IsReady(ctx)
 }
+
+// extraConfigEqual checks that two Services have the same extra config, which
+// cannot be listed through the Service interface
+func extraConfigEqual(a, b Service) bool {
+	svcA, okA := a.(*service)
+	svcB, okB := b.(*service)
+	if !okA || !okB {
+		return okA == okB
+	}
+
+	return reflect.DeepEqual(svcA.extraConfig, svcB.extraConfig) &&
+		reflect.DeepEqual(svcA.podLabels, svcB.podLabels) &&
+		reflect.DeepEqual(svcA.podAnnotations, svcB.podAnnotations)
+}
@@ -0,0 +1,422 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/common/utils"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/providers/names"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	datadogChecksResyncPeriod = 5 * time.Minute
+)
+
+var datadogCheckGVR = schema.GroupVersionResource{
+	Group:    "datadoghq.com",
+	Version:  "v1alpha1",
+	Resource: "datadogchecks",
+}
+
+// datadogCheckSpec is the spec of a DatadogCheck custom resource.
+// The check configuration fields follow the format of the check configuration files.
+type datadogCheckSpec struct {
+	CheckName string `json:"check_name"`
+
+	// ADIdentifiers are matched against the containers of the selected pods,
+	// like the ad_identifiers of the auto_conf.yaml files
+	ADIdentifiers []string `json:"ad_identifiers"`
+
+	// Selector selects the pods of the namespace of the DatadogCheck, all of them if empty
+	Selector *metav1.LabelSelector `json:"selector"`
+
+	InitConfig json.RawMessage   `json:"init_config"`
+	Instances  []json.RawMessage `json:"instances"`
+	Logs       json.RawMessage   `json:"logs"`
+}
+
+// datadogCheck is a parsed DatadogCheck custom resource
+type datadogCheck struct {
+	namespace     string
+	name          string
+	adIdentifiers map[string]struct{}
+	selector      labels.Selector
+	template      integration.Config
+}
+
+// DatadogCheckConfigProvider implements the ConfigProvider interface for the DatadogCheck custom resources.
+// The check configurations are applied to the containers of the pods running on the node,
+// only if they belong to the namespace of the DatadogCheck.
+type DatadogCheckConfigProvider struct {
+	lister            cache.GenericLister
+	workloadmetaStore workloadmeta.Store
+	podCache          map[string]*workloadmeta.KubernetesPod
+	configErrors      map[string]ErrorMsgSet
+	upToDate          bool
+	streaming         bool
+	once              sync.Once
+	sync.RWMutex
+}
+
+// NewDatadogCheckConfigProvider returns a new ConfigProvider watching the DatadogCheck custom resources.
+// Connectivity is not checked at this stage to allow for retries, Collect will do it.
+func NewDatadogCheckConfigProvider(config config.ConfigurationProviders) (ConfigProvider, error) {
+	client, err := apiserver.GetKubeDynamicClient(0) // No timeout for the Informers, to allow long watch.
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to apiserver: %s", err)
+	}
+
+	informer := dynamicinformer.NewDynamicSharedInformerFactory(client, datadogChecksResyncPeriod).ForResource(datadogCheckGVR)
+
+	p := &DatadogCheckConfigProvider{
+		lister:            informer.Lister(),
+		workloadmetaStore: workloadmeta.GetGlobalStore(),
+		podCache:          make(map[string]*workloadmeta.KubernetesPod),
+		configErrors:      make(map[string]ErrorMsgSet),
+	}
+
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    p.invalidate,
+		UpdateFunc: p.invalidateIfChanged,
+		DeleteFunc: p.invalidate,
+	})
+	go informer.Informer().Run(wait.NeverStop)
+
+	return p, nil
+}
+
+// String returns a string representation of the DatadogCheckConfigProvider
+func (d *DatadogCheckConfigProvider) String() string {
+	return names.DatadogChecks
+}
+
+// Collect retrieves the DatadogChecks and builds the configs of the matching containers
+func (d *DatadogCheckConfigProvider) Collect(ctx context.Context) ([]integration.Config, error) {
+	d.once.Do(func() {
+		go d.listen()
+	})
+
+	objects, err := d.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	d.upToDate = true
+	d.Unlock()
+
+	return d.generateConfigs(objects)
+}
+
+// IsUpToDate allows to cache configs as long as no changes are detected
+// in the DatadogChecks or in the pods running on the node
+func (d *DatadogCheckConfigProvider) IsUpToDate(ctx context.Context) (bool, error) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.streaming && d.upToDate, nil
+}
+
+// GetConfigErrors returns a map of configuration errors for each namespace/DatadogCheck
+func (d *DatadogCheckConfigProvider) GetConfigErrors() map[string]ErrorMsgSet {
+	d.RLock()
+	defer d.RUnlock()
+	return d.configErrors
+}
+
+func (d *DatadogCheckConfigProvider) listen() {
+	const name = "ad-datadogchecksprovider"
+
+	d.Lock()
+	d.streaming = true
+	health := health.RegisterLiveness(name)
+	d.Unlock()
+
+	ch := d.workloadmetaStore.Subscribe(name, workloadmeta.NewFilter(
+		[]workloadmeta.Kind{workloadmeta.KindKubernetesPod},
+		[]workloadmeta.Source{workloadmeta.SourceKubelet},
+	))
+
+	for {
+		select {
+		case evBundle := <-ch:
+			d.processEvents(evBundle)
+
+		case <-health.C:
+
+		}
+	}
+}
+
+func (d *DatadogCheckConfigProvider) processEvents(evBundle workloadmeta.EventBundle) {
+	close(evBundle.Ch)
+
+	d.Lock()
+	defer d.Unlock()
+
+	for _, event := range evBundle.Events {
+		switch event.Type {
+		case workloadmeta.EventTypeSet:
+			pod := event.Entity.(*workloadmeta.KubernetesPod)
+			d.podCache[pod.GetID().ID] = pod
+		case workloadmeta.EventTypeUnset:
+			delete(d.podCache, event.Entity.GetID().ID)
+		default:
+			log.Errorf("cannot handle event of type %d", event.Type)
+			continue
+		}
+		d.upToDate = false
+	}
+}
+
+func (d *DatadogCheckConfigProvider) invalidate(obj interface{}) {
+	if obj != nil {
+		log.Trace("Invalidating configs on new/deleted DatadogCheck")
+		d.Lock()
+		d.upToDate = false
+		d.Unlock()
+	}
+}
+
+func (d *DatadogCheckConfigProvider) invalidateIfChanged(old, obj interface{}) {
+	castedObj, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		log.Errorf("Expected an Unstructured type, got: %v", obj)
+		return
+	}
+	castedOld, ok := old.(*unstructured.Unstructured)
+	if !ok {
+		log.Errorf("Expected an Unstructured type, got: %v", old)
+		d.invalidate(obj)
+		return
+	}
+	// Quick exit if resversion did not change
+	if castedObj.GetResourceVersion() == castedOld.GetResourceVersion() {
+		return
+	}
+	d.invalidate(obj)
+}
+
+func (d *DatadogCheckConfigProvider) generateConfigs(objects []runtime.Object) ([]integration.Config, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	configErrors := make(map[string]ErrorMsgSet)
+	checksByNamespace := make(map[string][]*datadogCheck)
+	for _, obj := range objects {
+		cr, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			log.Errorf("Expected an Unstructured type, got: %v", obj)
+			continue
+		}
+
+		check, err := parseDatadogCheck(cr)
+		if err != nil {
+			namespacedName := cr.GetNamespace() + "/" + cr.GetName()
+			log.Errorf("Cannot parse DatadogCheck %s: %s", namespacedName, err)
+			configErrors[namespacedName] = ErrorMsgSet{err.Error(): {}}
+			continue
+		}
+		checksByNamespace[check.namespace] = append(checksByNamespace[check.namespace], check)
+	}
+	d.configErrors = configErrors
+
+	var configs []integration.Config
+	for _, pod := range d.podCache {
+		checks := checksByNamespace[pod.Namespace]
+		if len(checks) == 0 {
+			continue
+		}
+
+		podLabels := labels.Set(pod.Labels)
+		for _, podContainer := range pod.Containers {
+			container, err := d.workloadmetaStore.GetContainer(podContainer.ID)
+			if err != nil {
+				log.Debugf("Pod %q has reference to non-existing container %q", pod.Name, podContainer.ID)
+				continue
+			}
+			containerEntity := containers.BuildEntityName(string(container.Runtime), container.ID)
+			identifiers := containerIdentifiers(pod, &podContainer)
+
+			for _, check := range checks {
+				if !check.selector.Matches(podLabels) || !check.matches(identifiers) {
+					continue
+				}
+
+				conf := check.template
+				conf.ADIdentifiers = []string{containerEntity}
+				conf.Source = "datadog_checks:" + check.namespace + "/" + check.name
+				configs = append(configs, conf)
+			}
+		}
+	}
+
+	// The pods are stored in a map, sort the configs to keep the same order between two collections
+	sort.SliceStable(configs, func(i, j int) bool {
+		if configs[i].Source != configs[j].Source {
+			return configs[i].Source < configs[j].Source
+		}
+		return configs[i].ADIdentifiers[0] < configs[j].ADIdentifiers[0]
+	})
+
+	return configs, nil
+}
+
+// containerIdentifiers returns the identifiers of a container matched against the ad_identifiers of the DatadogChecks,
+// they are the same as the ones used to match the check configuration files
+func containerIdentifiers(pod *workloadmeta.KubernetesPod, podContainer *workloadmeta.OrchestratorContainer) []string {
+	identifiers := []string{podContainer.Image.RawName}
+	if podContainer.Image.ShortName != "" && podContainer.Image.ShortName != podContainer.Image.RawName {
+		identifiers = append(identifiers, podContainer.Image.ShortName)
+	}
+	if customADID, found := utils.GetCustomCheckID(pod.Annotations, podContainer.Name); found {
+		identifiers = append(identifiers, customADID)
+	}
+
+	return identifiers
+}
+
+// matches returns true if one of the container identifiers is an ad_identifier of the DatadogCheck
+func (c *datadogCheck) matches(identifiers []string) bool {
+	for _, identifier := range identifiers {
+		if _, found := c.adIdentifiers[identifier]; found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseDatadogCheck validates a DatadogCheck and builds its config template
+func parseDatadogCheck(cr *unstructured.Unstructured) (*datadogCheck, error) {
+	rawSpec, found := cr.Object["spec"]
+	if !found {
+		return nil, errors.New("missing spec")
+	}
+
+	// Going through JSON to keep the configurations as raw messages
+	specJSON, err := json.Marshal(rawSpec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec: %s", err)
+	}
+	spec := datadogCheckSpec{}
+	if err = json.Unmarshal(specJSON, &spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %s", err)
+	}
+
+	if len(spec.ADIdentifiers) == 0 {
+		return nil, errors.New("missing ad_identifiers")
+	}
+
+	selector := labels.Everything()
+	if spec.Selector != nil {
+		if selector, err = metav1.LabelSelectorAsSelector(spec.Selector); err != nil {
+			return nil, fmt.Errorf("invalid selector: %s", err)
+		}
+	}
+
+	template, err := buildDatadogCheckTemplate(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &datadogCheck{
+		namespace:     cr.GetNamespace(),
+		name:          cr.GetName(),
+		adIdentifiers: make(map[string]struct{}, len(spec.ADIdentifiers)),
+		selector:      selector,
+		template:      template,
+	}
+	for _, identifier := range spec.ADIdentifiers {
+		check.adIdentifiers[identifier] = struct{}{}
+	}
+
+	return check, nil
+}
+
+// buildDatadogCheckTemplate builds the config of a DatadogCheck, without its AD identifiers
+func buildDatadogCheckTemplate(spec datadogCheckSpec) (integration.Config, error) {
+	template := integration.Config{
+		Name: spec.CheckName,
+	}
+
+	if len(spec.Instances) > 0 && spec.CheckName == "" {
+		return template, errors.New("missing check_name")
+	}
+	if len(spec.Instances) == 0 && isEmptyJSON(spec.Logs) {
+		return template, errors.New("no instances nor logs defined")
+	}
+
+	if len(spec.Instances) > 0 {
+		template.InitConfig = integration.Data("{}")
+		if !isEmptyJSON(spec.InitConfig) {
+			if err := checkJSONObject(spec.InitConfig); err != nil {
+				return template, fmt.Errorf("in init_config: %s", err)
+			}
+			template.InitConfig = integration.Data(spec.InitConfig)
+		}
+	}
+
+	for _, instance := range spec.Instances {
+		if err := checkJSONObject(instance); err != nil {
+			return template, fmt.Errorf("in instances: %s", err)
+		}
+		template.Instances = append(template.Instances, integration.Data(instance))
+	}
+
+	if !isEmptyJSON(spec.Logs) {
+		var logs []interface{}
+		if err := json.Unmarshal(spec.Logs, &logs); err != nil {
+			return template, fmt.Errorf("in logs: invalid format, expected an array: %s", err)
+		}
+		template.LogsConfig = integration.Data(spec.Logs)
+	}
+
+	return template, nil
+}
+
+// isEmptyJSON returns true if an optional field is missing or null
+func isEmptyJSON(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
+
+// checkJSONObject returns an error if data is not a JSON object
+func checkJSONObject(data json.RawMessage) error {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil || obj == nil {
+		return fmt.Errorf("found non JSON object type, value is: '%s'", data)
+	}
+
+	return nil
+}
+
+func init() {
+	RegisterProvider("datadog_checks", NewDatadogCheckConfigProvider)
+}
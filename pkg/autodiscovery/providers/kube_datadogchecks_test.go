@@ -0,0 +1,210 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+	workloadmetatesting "github.com/DataDog/datadog-agent/pkg/workloadmeta/testing"
+)
+
+func newDatadogCheck(t *testing.T, namespace, name, spec string) *unstructured.Unstructured {
+	cr := &unstructured.Unstructured{}
+	cr.SetAPIVersion("datadoghq.com/v1alpha1")
+	cr.SetKind("DatadogCheck")
+	cr.SetNamespace(namespace)
+	cr.SetName(name)
+
+	if spec != "" {
+		var obj map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(spec), &obj))
+		cr.Object["spec"] = obj
+	}
+
+	return cr
+}
+
+func TestParseDatadogCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        string
+		expected    integration.Config
+		expectedErr string
+	}{
+		{
+			name: "check and logs",
+			spec: `{"check_name": "redisdb", "ad_identifiers": ["redis"], "init_config": {"service": "cache"}, "instances": [{"host": "%%host%%", "port": "6379"}], "logs": [{"source": "redis"}]}`,
+			expected: integration.Config{
+				Name:       "redisdb",
+				InitConfig: integration.Data(`{"service":"cache"}`),
+				Instances:  []integration.Data{integration.Data(`{"host":"%%host%%","port":"6379"}`)},
+				LogsConfig: integration.Data(`[{"source":"redis"}]`),
+			},
+		},
+		{
+			name: "default init_config",
+			spec: `{"check_name": "redisdb", "ad_identifiers": ["redis"], "instances": [{"host": "%%host%%"}, {"host": "%%host%%", "port": "%%port_replica|6380%%"}]}`,
+			expected: integration.Config{
+				Name:       "redisdb",
+				InitConfig: integration.Data("{}"),
+				Instances: []integration.Data{
+					integration.Data(`{"host":"%%host%%"}`),
+					integration.Data(`{"host":"%%host%%","port":"%%port_replica|6380%%"}`),
+				},
+			},
+		},
+		{
+			name: "logs only",
+			spec: `{"ad_identifiers": ["redis"], "logs": [{"source": "redis"}]}`,
+			expected: integration.Config{
+				LogsConfig: integration.Data(`[{"source":"redis"}]`),
+			},
+		},
+		{
+			name:        "missing spec",
+			expectedErr: "missing spec",
+		},
+		{
+			name:        "missing ad_identifiers",
+			spec:        `{"check_name": "redisdb", "instances": [{}]}`,
+			expectedErr: "missing ad_identifiers",
+		},
+		{
+			name:        "missing check_name",
+			spec:        `{"ad_identifiers": ["redis"], "instances": [{}]}`,
+			expectedErr: "missing check_name",
+		},
+		{
+			name:        "nothing to schedule",
+			spec:        `{"check_name": "redisdb", "ad_identifiers": ["redis"], "logs": null}`,
+			expectedErr: "no instances nor logs defined",
+		},
+		{
+			name:        "invalid instance",
+			spec:        `{"check_name": "redisdb", "ad_identifiers": ["redis"], "instances": ["host"]}`,
+			expectedErr: "in instances: found non JSON object type, value is: '\"host\"'",
+		},
+		{
+			name:        "invalid logs",
+			spec:        `{"ad_identifiers": ["redis"], "logs": {"source": "redis"}}`,
+			expectedErr: "in logs: invalid format, expected an array: json: cannot unmarshal object into Go value of type []interface {}",
+		},
+		{
+			name:        "invalid selector",
+			spec:        `{"check_name": "redisdb", "ad_identifiers": ["redis"], "selector": {"matchExpressions": [{"key": "app", "operator": "Unknown"}]}, "instances": [{}]}`,
+			expectedErr: `invalid selector: "Unknown" is not a valid pod selector operator`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := parseDatadogCheck(newDatadogCheck(t, "default", "redis", tt.spec))
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "default", check.namespace)
+			assert.Equal(t, "redis", check.name)
+			assert.Equal(t, tt.expected, check.template)
+		})
+	}
+}
+
+func TestDatadogCheckGenerateConfigs(t *testing.T) {
+	store := workloadmetatesting.NewStore()
+	for _, id := range []string{"redis-a", "redis-b", "redis-c", "sidecar-a"} {
+		store.Set(&workloadmeta.Container{
+			EntityID: workloadmeta.EntityID{
+				Kind: workloadmeta.KindContainer,
+				ID:   id,
+			},
+			Runtime: workloadmeta.ContainerRuntimeContainerd,
+		})
+	}
+
+	redisImage := workloadmeta.ContainerImage{RawName: "docker.io/library/redis:6", ShortName: "redis"}
+	provider := &DatadogCheckConfigProvider{
+		workloadmetaStore: store,
+		podCache: map[string]*workloadmeta.KubernetesPod{
+			"pod-a": {
+				EntityMeta: workloadmeta.EntityMeta{
+					Name:      "redis-a",
+					Namespace: "team-a",
+					Labels:    map[string]string{"app": "redis"},
+				},
+				Containers: []workloadmeta.OrchestratorContainer{
+					{ID: "redis-a", Name: "redis", Image: redisImage},
+					{ID: "sidecar-a", Name: "sidecar", Image: workloadmeta.ContainerImage{RawName: "envoy", ShortName: "envoy"}},
+				},
+			},
+			"pod-b": {
+				EntityMeta: workloadmeta.EntityMeta{
+					Name:      "redis-b",
+					Namespace: "team-a",
+					Labels:    map[string]string{"app": "redis-canary"},
+					Annotations: map[string]string{
+						"ad.datadoghq.com/redis.check.id": "redis-canary",
+					},
+				},
+				Containers: []workloadmeta.OrchestratorContainer{
+					{ID: "redis-b", Name: "redis", Image: redisImage},
+				},
+			},
+			"pod-c": {
+				EntityMeta: workloadmeta.EntityMeta{
+					Name:      "redis-c",
+					Namespace: "team-b",
+					Labels:    map[string]string{"app": "redis"},
+				},
+				Containers: []workloadmeta.OrchestratorContainer{
+					{ID: "redis-c", Name: "redis", Image: redisImage},
+					{ID: "missing", Name: "redis", Image: redisImage},
+				},
+			},
+		},
+	}
+
+	objects := []runtime.Object{
+		newDatadogCheck(t, "team-a", "redis", `{"check_name": "redisdb", "ad_identifiers": ["redis"], "selector": {"matchLabels": {"app": "redis"}}, "instances": [{"host": "%%host%%"}]}`),
+		newDatadogCheck(t, "team-a", "canary", `{"ad_identifiers": ["redis-canary"], "logs": [{"source": "redis"}]}`),
+		newDatadogCheck(t, "team-a", "invalid", `{"check_name": "redisdb", "instances": [{}]}`),
+		newDatadogCheck(t, "team-c", "redis", `{"check_name": "redisdb", "ad_identifiers": ["redis"], "instances": [{}]}`),
+	}
+
+	configs, err := provider.generateConfigs(objects)
+	require.NoError(t, err)
+
+	assert.Equal(t, []integration.Config{
+		{
+			ADIdentifiers: []string{"containerd://redis-b"},
+			LogsConfig:    integration.Data(`[{"source":"redis"}]`),
+			Source:        "datadog_checks:team-a/canary",
+		},
+		{
+			Name:          "redisdb",
+			ADIdentifiers: []string{"containerd://redis-a"},
+			InitConfig:    integration.Data("{}"),
+			Instances:     []integration.Data{integration.Data(`{"host":"%%host%%"}`)},
+			Source:        "datadog_checks:team-a/redis",
+		},
+	}, configs)
+
+	assert.Equal(t, map[string]ErrorMsgSet{
+		"team-a/invalid": {"missing ad_identifiers": {}},
+	}, provider.GetConfigErrors())
+}
@@ -8,9 +8,15 @@
 package webhook
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/common"
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -36,3 +42,56 @@ func buildLabelSelector() *metav1.LabelSelector {
 		},
 	}
 }
+
+// agentSidecarSelectors holds the selectors of the pods getting the agent sidecar
+type agentSidecarSelectors struct {
+	ObjectSelector    *metav1.LabelSelector `json:"objectSelector,omitempty"`
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// buildAgentSidecarSelectors returns the agent sidecar webhook selectors based on the configuration.
+// Selectors are required, as the agent sidecar must not be injected into all the pods of the cluster.
+func buildAgentSidecarSelectors(namespaceSelectorOnly bool) (objectSelector, namespaceSelector *metav1.LabelSelector, err error) {
+	raw := config.Datadog.GetString("admission_controller.agent_sidecar.selectors")
+	if raw == "" {
+		return nil, nil, errors.New("no selectors configured in admission_controller.agent_sidecar.selectors")
+	}
+
+	var selectors agentSidecarSelectors
+	if err := json.Unmarshal([]byte(raw), &selectors); err != nil {
+		return nil, nil, fmt.Errorf("cannot parse the agent sidecar selectors: %v", err)
+	}
+
+	if namespaceSelectorOnly && selectors.ObjectSelector != nil {
+		log.Warn("Ignoring the objectSelector of the agent sidecar webhook, it's not supported by this Kubernetes version")
+		selectors.ObjectSelector = nil
+	}
+
+	if selectors.ObjectSelector == nil && selectors.NamespaceSelector == nil {
+		return nil, nil, errors.New("no supported selectors configured in admission_controller.agent_sidecar.selectors")
+	}
+
+	return selectors.ObjectSelector, selectors.NamespaceSelector, nil
+}
+
+// buildAutoInstrumentationNamespaceSelector restricts a namespace selector to the namespaces listed in
+// admission_controller.auto_instrumentation.enabled_namespaces, matched on the name label Kubernetes sets on namespaces.
+// The tracing libraries must not be injected into any namespace when none is listed.
+func buildAutoInstrumentationNamespaceSelector(namespaceSelector *metav1.LabelSelector) (*metav1.LabelSelector, error) {
+	enabledNamespaces := config.Datadog.GetStringSlice("admission_controller.auto_instrumentation.enabled_namespaces")
+	if len(enabledNamespaces) == 0 {
+		return nil, errors.New("no namespaces configured in admission_controller.auto_instrumentation.enabled_namespaces")
+	}
+
+	selector := &metav1.LabelSelector{}
+	if namespaceSelector != nil {
+		selector = namespaceSelector.DeepCopy()
+	}
+	selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+		Key:      corev1.LabelMetadataName,
+		Operator: metav1.LabelSelectorOpIn,
+		Values:   enabledNamespaces,
+	})
+
+	return selector, nil
+}
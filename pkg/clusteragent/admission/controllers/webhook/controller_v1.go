@@ -193,6 +193,31 @@ func (c *ControllerV1) generateTemplates() {
 		webhooks = append(webhooks, webhook)
 	}
 
+	// JAVA_TOOL_OPTIONS, NODE_OPTIONS, PYTHONPATH and init containers injection
+	if config.Datadog.GetBool("admission_controller.auto_instrumentation.enabled") {
+		webhook := c.getWebhookSkeleton("auto.instrumentation", config.Datadog.GetString("admission_controller.auto_instrumentation.endpoint"))
+		namespaceSelector, err := buildAutoInstrumentationNamespaceSelector(webhook.NamespaceSelector)
+		if err != nil {
+			log.Errorf("Not registering the auto instrumentation webhook: %v", err)
+		} else {
+			webhook.NamespaceSelector = namespaceSelector
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	// Agent sidecar injection
+	if config.Datadog.GetBool("admission_controller.agent_sidecar.enabled") {
+		objectSelector, namespaceSelector, err := buildAgentSidecarSelectors(c.config.useNamespaceSelector())
+		if err != nil {
+			log.Errorf("Not registering the agent sidecar webhook: %v", err)
+		} else {
+			webhook := c.getWebhookSkeleton("agent.sidecar", config.Datadog.GetString("admission_controller.agent_sidecar.endpoint"))
+			webhook.ObjectSelector = objectSelector
+			webhook.NamespaceSelector = namespaceSelector
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
 	c.webhookTemplates = webhooks
 }
 
@@ -313,6 +313,139 @@ func TestGenerateTemplatesV1(t *testing.T) {
 				return []admiv1.MutatingWebhook{webhookConfig, webhookTags}
 			},
 		},
+		{
+			name: "auto instrumentation, mutate labelled",
+			setupConfig: func() {
+				mockConfig.Set("admission_controller.mutate_unlabelled", false)
+				mockConfig.Set("admission_controller.inject_config.enabled", false)
+				mockConfig.Set("admission_controller.inject_tags.enabled", false)
+				mockConfig.Set("admission_controller.namespace_selector_fallback", false)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled", true)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled_namespaces", []string{"application"})
+			},
+			configFunc: func() Config { return NewConfig(false, false) },
+			want: func() []admiv1.MutatingWebhook {
+				webhook := webhook("datadog.webhook.auto.instrumentation", "/injectlib", &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"admission.datadoghq.com/enabled": "true",
+					},
+				}, &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{
+							Key:      "kubernetes.io/metadata.name",
+							Operator: metav1.LabelSelectorOpIn,
+							Values:   []string{"application"},
+						},
+					},
+				})
+				return []admiv1.MutatingWebhook{webhook}
+			},
+		},
+		{
+			name: "auto instrumentation, namespace selector fallback",
+			setupConfig: func() {
+				mockConfig.Set("admission_controller.mutate_unlabelled", false)
+				mockConfig.Set("admission_controller.inject_config.enabled", false)
+				mockConfig.Set("admission_controller.inject_tags.enabled", false)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled", true)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled_namespaces", []string{"application"})
+			},
+			configFunc: func() Config { return NewConfig(false, true) },
+			want: func() []admiv1.MutatingWebhook {
+				webhook := webhook("datadog.webhook.auto.instrumentation", "/injectlib", nil, &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"admission.datadoghq.com/enabled": "true",
+					},
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{
+							Key:      "kubernetes.io/metadata.name",
+							Operator: metav1.LabelSelectorOpIn,
+							Values:   []string{"application"},
+						},
+					},
+				})
+				return []admiv1.MutatingWebhook{webhook}
+			},
+		},
+		{
+			name: "auto instrumentation, no namespaces enabled",
+			setupConfig: func() {
+				mockConfig.Set("admission_controller.inject_config.enabled", false)
+				mockConfig.Set("admission_controller.inject_tags.enabled", false)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled", true)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled_namespaces", []string{})
+			},
+			configFunc: func() Config { return NewConfig(false, false) },
+			want: func() []admiv1.MutatingWebhook {
+				return []admiv1.MutatingWebhook{}
+			},
+		},
+		{
+			name: "agent sidecar, no selectors",
+			setupConfig: func() {
+				mockConfig.Set("admission_controller.mutate_unlabelled", false)
+				mockConfig.Set("admission_controller.inject_config.enabled", false)
+				mockConfig.Set("admission_controller.inject_tags.enabled", false)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled", false)
+				mockConfig.Set("admission_controller.agent_sidecar.enabled", true)
+				mockConfig.Set("admission_controller.agent_sidecar.selectors", "")
+			},
+			configFunc: func() Config { return NewConfig(false, false) },
+			want: func() []admiv1.MutatingWebhook {
+				return []admiv1.MutatingWebhook{}
+			},
+		},
+		{
+			name: "agent sidecar, configured selectors",
+			setupConfig: func() {
+				mockConfig.Set("admission_controller.inject_config.enabled", false)
+				mockConfig.Set("admission_controller.inject_tags.enabled", false)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled", false)
+				mockConfig.Set("admission_controller.agent_sidecar.enabled", true)
+				mockConfig.Set("admission_controller.agent_sidecar.selectors", `{"objectSelector": {"matchLabels": {"app": "fargate"}}, "namespaceSelector": {"matchLabels": {"compute": "fargate"}}}`)
+			},
+			configFunc: func() Config { return NewConfig(false, false) },
+			want: func() []admiv1.MutatingWebhook {
+				webhook := webhook("datadog.webhook.agent.sidecar", "/agentsidecar", &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"app": "fargate",
+					},
+				}, &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"compute": "fargate",
+					},
+				})
+				return []admiv1.MutatingWebhook{webhook}
+			},
+		},
+		{
+			name: "agent sidecar, object selector only with namespace selector fallback",
+			setupConfig: func() {
+				mockConfig.Set("admission_controller.inject_config.enabled", false)
+				mockConfig.Set("admission_controller.inject_tags.enabled", false)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled", false)
+				mockConfig.Set("admission_controller.agent_sidecar.enabled", true)
+				mockConfig.Set("admission_controller.agent_sidecar.selectors", `{"objectSelector": {"matchLabels": {"app": "fargate"}}}`)
+			},
+			configFunc: func() Config { return NewConfig(false, true) },
+			want: func() []admiv1.MutatingWebhook {
+				return []admiv1.MutatingWebhook{}
+			},
+		},
+		{
+			name: "agent sidecar, invalid selectors",
+			setupConfig: func() {
+				mockConfig.Set("admission_controller.inject_config.enabled", false)
+				mockConfig.Set("admission_controller.inject_tags.enabled", false)
+				mockConfig.Set("admission_controller.auto_instrumentation.enabled", false)
+				mockConfig.Set("admission_controller.agent_sidecar.enabled", true)
+				mockConfig.Set("admission_controller.agent_sidecar.selectors", "{invalid")
+			},
+			configFunc: func() Config { return NewConfig(false, false) },
+			want: func() []admiv1.MutatingWebhook {
+				return []admiv1.MutatingWebhook{}
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,325 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package mutate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/metrics"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	apiCommon "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/common"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	agentSidecarContainerName        = "datadog-agent-injected"
+	agentSidecarProfileAnnotationKey = "admission.datadoghq.com/agent-sidecar.profile"
+	defaultAgentSidecarProfileName   = "default"
+	agentSidecarLocalhost            = "localhost"
+	// agentSidecarProfilesKey is the key of the profiles in the ConfigMap named by admission_controller.agent_sidecar.profiles_configmap
+	agentSidecarProfilesKey      = "profiles.yaml"
+	agentSidecarProfilesCacheTTL = time.Minute
+	agentSidecarSecretCacheTTL   = time.Minute
+)
+
+var (
+	configMapsGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	secretsGVR    = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+)
+
+// sidecarProfile overrides the defaults of the agent sidecar, profiles are defined as a YAML
+// or JSON list in a ConfigMap of the cluster agent namespace
+type sidecarProfile struct {
+	Name                 string                      `json:"name"`
+	EnvVars              []corev1.EnvVar             `json:"env,omitempty"`
+	ResourceRequirements corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// InjectAgentSidecar adds an agent sidecar container to the pod and points
+// the DD_AGENT_HOST env var of the other containers to it
+func InjectAgentSidecar(rawPod []byte, ns string, dc dynamic.Interface) ([]byte, error) {
+	return mutate(rawPod, ns, injectAgentSidecar, dc)
+}
+
+// injectAgentSidecar injects the agent sidecar into a pod template if needed
+func injectAgentSidecar(pod *corev1.Pod, ns string, dc dynamic.Interface) error {
+	var injected bool
+	defer func() {
+		metrics.MutationAttempts.Inc(metrics.AgentSidecarMutationType, strconv.FormatBool(injected))
+	}()
+
+	if pod == nil {
+		metrics.MutationErrors.Inc(metrics.AgentSidecarMutationType, "nil pod")
+		return errors.New("cannot inject agent sidecar into nil pod")
+	}
+
+	for _, ctr := range pod.Spec.Containers {
+		if ctr.Name == agentSidecarContainerName {
+			log.Debugf("Ignoring pod %s: container '%s' already exists", podString(pod), agentSidecarContainerName)
+			return nil
+		}
+	}
+
+	profiles, err := getSidecarProfiles(config.Datadog.GetString("admission_controller.agent_sidecar.profiles_configmap"), dc)
+	if err != nil {
+		metrics.MutationErrors.Inc(metrics.AgentSidecarMutationType, "invalid profiles")
+		return err
+	}
+
+	profile, err := selectSidecarProfile(pod, profiles)
+	if err != nil {
+		metrics.MutationErrors.Inc(metrics.AgentSidecarMutationType, "unknown profile")
+		return err
+	}
+
+	sidecar := newAgentSidecar(profile)
+
+	// the API key secret is referenced from the namespace of the pod, the sidecar would never start without it
+	if !apiKeySecretExists(sidecar, ns, dc) {
+		metrics.MutationErrors.Inc(metrics.AgentSidecarMutationType, "missing api key secret")
+		return fmt.Errorf("cannot inject agent sidecar into pod %s: the API key secret does not exist in namespace %s", podString(pod), ns)
+	}
+
+	// the agent is reachable on localhost from the other containers of the pod
+	for i := range pod.Spec.Containers {
+		setAgentHostToLocalhost(&pod.Spec.Containers[i])
+	}
+
+	pod.Spec.Containers = append(pod.Spec.Containers, sidecar)
+	injected = true
+
+	return nil
+}
+
+// getSidecarProfiles returns the profiles of the agent sidecar defined in the given ConfigMap of the
+// cluster agent namespace. The profiles are cached to avoid querying the api server on every pod creation.
+func getSidecarProfiles(configMapName string, dc dynamic.Interface) ([]sidecarProfile, error) {
+	if configMapName == "" {
+		return nil, nil
+	}
+
+	ns := apiCommon.GetResourcesNamespace()
+	cacheKey := cache.BuildAgentKey("admission", "agent_sidecar_profiles", ns, configMapName)
+	if cached, hit := cache.Cache.Get(cacheKey); hit {
+		if profiles, valid := cached.([]sidecarProfile); valid {
+			return profiles, nil
+		}
+		log.Debugf("Invalid agent sidecar profiles for '%s', forcing a cache miss", cacheKey)
+	}
+
+	if dc == nil {
+		return nil, errors.New("cannot get the agent sidecar profiles: no api server client")
+	}
+
+	cm, err := dc.Resource(configMapsGVR).Namespace(ns).Get(context.TODO(), configMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get the agent sidecar profiles ConfigMap %s/%s: %v", ns, configMapName, err)
+	}
+
+	raw, _, err := unstructured.NestedString(cm.Object, "data", agentSidecarProfilesKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the agent sidecar profiles ConfigMap %s/%s: %v", ns, configMapName, err)
+	}
+
+	profiles, err := loadSidecarProfiles(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Cache.Set(cacheKey, profiles, agentSidecarProfilesCacheTTL)
+	return profiles, nil
+}
+
+// apiKeySecretExists returns false when the DD_API_KEY env var of the sidecar references a secret that
+// does not exist in the given namespace. It returns true when the existence of the secret cannot be checked,
+// e.g. when the cluster agent is not allowed to get it, to not prevent the injection.
+// The result is cached to avoid querying the api server on every pod creation.
+func apiKeySecretExists(sidecar corev1.Container, ns string, dc dynamic.Interface) bool {
+	var secretName string
+	for _, env := range sidecar.Env {
+		if env.Name == "DD_API_KEY" && env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+			secretName = env.ValueFrom.SecretKeyRef.Name
+		}
+	}
+
+	if secretName == "" || ns == "" || dc == nil {
+		return true
+	}
+
+	cacheKey := cache.BuildAgentKey("admission", "agent_sidecar_api_key_secret", ns, secretName)
+	if cached, hit := cache.Cache.Get(cacheKey); hit {
+		if exists, valid := cached.(bool); valid {
+			return exists
+		}
+		log.Debugf("Invalid agent sidecar API key secret existence for '%s', forcing a cache miss", cacheKey)
+	}
+
+	// only the metadata of the secret is used, its data is never read
+	exists := true
+	if _, err := dc.Resource(secretsGVR).Namespace(ns).Get(context.TODO(), secretName, metav1.GetOptions{}); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.Debugf("Cannot check that the agent sidecar API key secret %s/%s exists: %v", ns, secretName, err)
+			return true
+		}
+		exists = false
+	}
+
+	cache.Cache.Set(cacheKey, exists, agentSidecarSecretCacheTTL)
+	return exists
+}
+
+// loadSidecarProfiles parses the YAML or JSON list of profiles of the agent sidecar
+func loadSidecarProfiles(raw string) ([]sidecarProfile, error) {
+	var profiles []sidecarProfile
+	if strings.TrimSpace(raw) == "" {
+		return profiles, nil
+	}
+
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(raw), len(raw)).Decode(&profiles); err != nil {
+		return nil, fmt.Errorf("cannot parse the agent sidecar profiles: %v", err)
+	}
+
+	return profiles, nil
+}
+
+// selectSidecarProfile returns the profile requested by the pod annotation,
+// or the default profile if it's defined
+func selectSidecarProfile(pod *corev1.Pod, profiles []sidecarProfile) (*sidecarProfile, error) {
+	name, requested := pod.GetAnnotations()[agentSidecarProfileAnnotationKey]
+	if !requested {
+		name = defaultAgentSidecarProfileName
+	}
+
+	for i := range profiles {
+		if profiles[i].Name == name {
+			return &profiles[i], nil
+		}
+	}
+
+	if requested {
+		return nil, fmt.Errorf("agent sidecar profile %q requested by pod %s is not defined", name, podString(pod))
+	}
+
+	return nil, nil
+}
+
+// setAgentHostToLocalhost sets DD_AGENT_HOST to localhost, replacing the value
+// that may have been injected by the config webhook
+func setAgentHostToLocalhost(ctr *corev1.Container) {
+	for i, env := range ctr.Env {
+		if env.Name == agentHostEnvVarName {
+			ctr.Env[i].Value = agentSidecarLocalhost
+			ctr.Env[i].ValueFrom = nil
+			return
+		}
+	}
+
+	ctr.Env = append(ctr.Env, corev1.EnvVar{
+		Name:  agentHostEnvVarName,
+		Value: agentSidecarLocalhost,
+	})
+}
+
+// newAgentSidecar returns the agent sidecar container built from the cluster agent config,
+// the env vars and the resources of the profile override the defaults
+func newAgentSidecar(profile *sidecarProfile) corev1.Container {
+	image := fmt.Sprintf("%s/%s:%s",
+		config.Datadog.GetString("admission_controller.agent_sidecar.container_registry"),
+		config.Datadog.GetString("admission_controller.agent_sidecar.image_name"),
+		config.Datadog.GetString("admission_controller.agent_sidecar.image_tag"),
+	)
+
+	env := []corev1.EnvVar{
+		{
+			Name: "DD_API_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: config.Datadog.GetString("admission_controller.agent_sidecar.api_key_secret.name"),
+					},
+					Key: config.Datadog.GetString("admission_controller.agent_sidecar.api_key_secret.key"),
+				},
+			},
+		},
+		{
+			Name:  "DD_EKS_FARGATE",
+			Value: "true",
+		},
+		{
+			Name: "DD_KUBERNETES_KUBELET_NODENAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					FieldPath: "spec.nodeName",
+				},
+			},
+		},
+	}
+
+	if site := config.Datadog.GetString("site"); site != "" {
+		env = append(env, corev1.EnvVar{Name: "DD_SITE", Value: site})
+	}
+
+	if clusterName := config.Datadog.GetString("cluster_name"); clusterName != "" {
+		env = append(env, corev1.EnvVar{Name: "DD_CLUSTER_NAME", Value: clusterName})
+	}
+
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("200m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("200m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+	}
+
+	if profile != nil {
+		for _, profileEnv := range profile.EnvVars {
+			env = overrideEnv(env, profileEnv)
+		}
+
+		if len(profile.ResourceRequirements.Requests) > 0 || len(profile.ResourceRequirements.Limits) > 0 {
+			resources = profile.ResourceRequirements
+		}
+	}
+
+	return corev1.Container{
+		Name:      agentSidecarContainerName,
+		Image:     image,
+		Env:       env,
+		Resources: resources,
+	}
+}
+
+// overrideEnv replaces the env var with the same name, or appends it
+func overrideEnv(envs []corev1.EnvVar, env corev1.EnvVar) []corev1.EnvVar {
+	for i := range envs {
+		if envs[i].Name == env.Name {
+			envs[i] = env
+			return envs
+		}
+	}
+
+	return append(envs, env)
+}
@@ -0,0 +1,312 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package mutate
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func newProfilesConfigMap(name, profiles string) runtime.Object {
+	cm := newUnstructured("v1", "ConfigMap", testNamespace, name)
+	cm.Object["data"] = map[string]interface{}{"profiles.yaml": profiles}
+	return cm
+}
+
+func newAPIKeySecret(namespace string) runtime.Object {
+	return newUnstructured("v1", "Secret", namespace, "datadog-secret")
+}
+
+func defaultAgentSidecar(env ...corev1.EnvVar) corev1.Container {
+	return corev1.Container{
+		Name:  "datadog-agent-injected",
+		Image: "gcr.io/datadoghq/agent:latest",
+		Env: append([]corev1.EnvVar{
+			{
+				Name: "DD_API_KEY",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "datadog-secret"},
+						Key:                  "api-key",
+					},
+				},
+			},
+			{
+				Name:  "DD_EKS_FARGATE",
+				Value: "true",
+			},
+			{
+				Name: "DD_KUBERNETES_KUBELET_NODENAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"},
+				},
+			},
+		}, env...),
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("200m"),
+				corev1.ResourceMemory: resource.MustParse("256Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("200m"),
+				corev1.ResourceMemory: resource.MustParse("256Mi"),
+			},
+		},
+	}
+}
+
+func Test_injectAgentSidecar(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("admission_controller.agent_sidecar.container_registry", "gcr.io/datadoghq")
+	mockConfig.Set("admission_controller.agent_sidecar.image_name", "agent")
+	mockConfig.Set("admission_controller.agent_sidecar.image_tag", "latest")
+	mockConfig.Set("admission_controller.agent_sidecar.api_key_secret.name", "datadog-secret")
+	mockConfig.Set("admission_controller.agent_sidecar.api_key_secret.key", "api-key")
+	mockConfig.Set("site", "")
+	mockConfig.Set("cluster_name", "")
+	mockConfig.Set("kube_resources_namespace", testNamespace)
+
+	profiles := `
+- name: default
+  env:
+  - name: DD_LOG_LEVEL
+    value: warn
+- name: large
+  env:
+  - name: DD_EKS_FARGATE
+    value: "false"
+  resources:
+    limits:
+      cpu: "1"
+      memory: 1Gi
+`
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		configMap     string
+		profiles      string
+		missingSecret bool
+		wantErr       bool
+		wantPodFunc   func() *corev1.Pod
+	}{
+		{
+			name: "no profile",
+			pod:  fakePod("foo-pod"),
+			wantPodFunc: func() *corev1.Pod {
+				pod := fakePod("foo-pod")
+				pod.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "DD_AGENT_HOST", Value: "localhost"}}
+				pod.Spec.Containers = append(pod.Spec.Containers, defaultAgentSidecar())
+				return pod
+			},
+		},
+		{
+			name: "DD_AGENT_HOST injected by the config webhook",
+			pod:  fakePodWithContainer("foo-pod", corev1.Container{Name: "foo-container", Env: []corev1.EnvVar{agentHostEnvVar, fakeEnv("foo")}}),
+			wantPodFunc: func() *corev1.Pod {
+				pod := fakePodWithContainer("foo-pod", corev1.Container{
+					Name: "foo-container",
+					Env:  []corev1.EnvVar{{Name: "DD_AGENT_HOST", Value: "localhost"}, fakeEnv("foo")},
+				})
+				pod.Spec.Containers = append(pod.Spec.Containers, defaultAgentSidecar())
+				return pod
+			},
+		},
+		{
+			name:      "default profile",
+			pod:       fakePod("foo-pod"),
+			configMap: "agent-sidecar-profiles",
+			profiles:  profiles,
+			wantPodFunc: func() *corev1.Pod {
+				pod := fakePod("foo-pod")
+				pod.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "DD_AGENT_HOST", Value: "localhost"}}
+				pod.Spec.Containers = append(pod.Spec.Containers, defaultAgentSidecar(corev1.EnvVar{Name: "DD_LOG_LEVEL", Value: "warn"}))
+				return pod
+			},
+		},
+		{
+			name: "profile from annotation",
+			pod: func() *corev1.Pod {
+				pod := fakePod("foo-pod")
+				pod.Annotations = map[string]string{"admission.datadoghq.com/agent-sidecar.profile": "large"}
+				return pod
+			}(),
+			configMap: "agent-sidecar-profiles",
+			profiles:  profiles,
+			wantPodFunc: func() *corev1.Pod {
+				pod := fakePod("foo-pod")
+				pod.Annotations = map[string]string{"admission.datadoghq.com/agent-sidecar.profile": "large"}
+				pod.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "DD_AGENT_HOST", Value: "localhost"}}
+				sidecar := defaultAgentSidecar()
+				sidecar.Env[1].Value = "false"
+				sidecar.Resources = corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				}
+				pod.Spec.Containers = append(pod.Spec.Containers, sidecar)
+				return pod
+			},
+		},
+		{
+			name: "unknown profile",
+			pod: func() *corev1.Pod {
+				pod := fakePod("foo-pod")
+				pod.Annotations = map[string]string{"admission.datadoghq.com/agent-sidecar.profile": "unknown"}
+				return pod
+			}(),
+			configMap: "agent-sidecar-profiles",
+			profiles:  profiles,
+			wantErr:   true,
+			wantPodFunc: func() *corev1.Pod {
+				pod := fakePod("foo-pod")
+				pod.Annotations = map[string]string{"admission.datadoghq.com/agent-sidecar.profile": "unknown"}
+				return pod
+			},
+		},
+		{
+			name:      "invalid profiles",
+			pod:       fakePod("foo-pod"),
+			configMap: "agent-sidecar-profiles",
+			profiles:  "{invalid",
+			wantErr:   true,
+			wantPodFunc: func() *corev1.Pod {
+				return fakePod("foo-pod")
+			},
+		},
+		{
+			name:      "profiles ConfigMap not found",
+			pod:       fakePod("foo-pod"),
+			configMap: "unknown",
+			profiles:  profiles,
+			wantErr:   true,
+			wantPodFunc: func() *corev1.Pod {
+				return fakePod("foo-pod")
+			},
+		},
+		{
+			name:          "API key secret not in the pod namespace",
+			pod:           fakePod("foo-pod"),
+			missingSecret: true,
+			wantErr:       true,
+			wantPodFunc: func() *corev1.Pod {
+				return fakePod("foo-pod")
+			},
+		},
+		{
+			name:          "API key set by the profile",
+			pod:           fakePod("foo-pod"),
+			configMap:     "agent-sidecar-profiles",
+			profiles:      `[{"name": "default", "env": [{"name": "DD_API_KEY", "value": "123"}]}]`,
+			missingSecret: true,
+			wantPodFunc: func() *corev1.Pod {
+				pod := fakePod("foo-pod")
+				pod.Spec.Containers[0].Env = []corev1.EnvVar{{Name: "DD_AGENT_HOST", Value: "localhost"}}
+				sidecar := defaultAgentSidecar()
+				sidecar.Env[0] = corev1.EnvVar{Name: "DD_API_KEY", Value: "123"}
+				pod.Spec.Containers = append(pod.Spec.Containers, sidecar)
+				return pod
+			},
+		},
+		{
+			name: "sidecar already injected",
+			pod:  fakePodWithContainer("foo-pod", fakeContainer("foo-container"), defaultAgentSidecar()),
+			wantPodFunc: func() *corev1.Pod {
+				return fakePodWithContainer("foo-pod", fakeContainer("foo-container"), defaultAgentSidecar())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache.Cache.Flush()
+			mockConfig.Set("admission_controller.agent_sidecar.profiles_configmap", tt.configMap)
+			// the secret of the cluster agent namespace cannot be referenced by the pods of other namespaces
+			objects := []runtime.Object{newProfilesConfigMap("agent-sidecar-profiles", tt.profiles), newAPIKeySecret(testNamespace)}
+			if !tt.missingSecret {
+				objects = append(objects, newAPIKeySecret("app-ns"))
+			}
+			dc := fake.NewSimpleDynamicClient(scheme, objects...)
+
+			err := injectAgentSidecar(tt.pod, "app-ns", dc)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantPodFunc(), tt.pod)
+		})
+	}
+}
+
+func Test_getSidecarProfilesCache(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("kube_resources_namespace", testNamespace)
+	cache.Cache.Flush()
+	defer cache.Cache.Flush()
+
+	dc := fake.NewSimpleDynamicClient(scheme, newProfilesConfigMap("agent-sidecar-profiles", `[{"name": "default"}]`))
+
+	// Cache miss
+	profiles, err := getSidecarProfiles("agent-sidecar-profiles", dc)
+	require.NoError(t, err)
+	assert.Equal(t, []sidecarProfile{{Name: "default"}}, profiles)
+	assert.Len(t, dc.Actions(), 1)
+
+	// Cache hit
+	profiles, err = getSidecarProfiles("agent-sidecar-profiles", dc)
+	require.NoError(t, err)
+	assert.Equal(t, []sidecarProfile{{Name: "default"}}, profiles)
+	assert.Len(t, dc.Actions(), 1)
+
+	// No ConfigMap configured
+	profiles, err = getSidecarProfiles("", dc)
+	require.NoError(t, err)
+	assert.Nil(t, profiles)
+}
+
+func Test_apiKeySecretExistsCache(t *testing.T) {
+	cache.Cache.Flush()
+	defer cache.Cache.Flush()
+
+	dc := fake.NewSimpleDynamicClient(scheme, newAPIKeySecret("app-ns"))
+	sidecar := defaultAgentSidecar()
+
+	// Cache miss
+	assert.True(t, apiKeySecretExists(sidecar, "app-ns", dc))
+	assert.False(t, apiKeySecretExists(sidecar, "other-ns", dc))
+	assert.Len(t, dc.Actions(), 2)
+
+	// Cache hit
+	assert.True(t, apiKeySecretExists(sidecar, "app-ns", dc))
+	assert.False(t, apiKeySecretExists(sidecar, "other-ns", dc))
+	assert.Len(t, dc.Actions(), 2)
+}
+
+func Test_newAgentSidecarClusterConfig(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("admission_controller.agent_sidecar.container_registry", "public.ecr.aws/datadog")
+	mockConfig.Set("admission_controller.agent_sidecar.image_name", "agent")
+	mockConfig.Set("admission_controller.agent_sidecar.image_tag", "7")
+	mockConfig.Set("site", "datadoghq.eu")
+	mockConfig.Set("cluster_name", "fargate-cluster")
+
+	sidecar := newAgentSidecar(nil)
+	assert.Equal(t, "public.ecr.aws/datadog/agent:7", sidecar.Image)
+	assert.Contains(t, sidecar.Env, corev1.EnvVar{Name: "DD_SITE", Value: "datadoghq.eu"})
+	assert.Contains(t, sidecar.Env, corev1.EnvVar{Name: "DD_CLUSTER_NAME", Value: "fargate-cluster"})
+}
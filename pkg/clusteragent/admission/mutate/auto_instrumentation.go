@@ -0,0 +1,264 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package mutate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/admission/metrics"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	libVersionAnnotationKeyFormat = "admission.datadoghq.com/%s-lib.version"
+	customLibAnnotationKeyFormat  = "admission.datadoghq.com/%s-lib.custom-image"
+	libInitContainerNameFormat    = "datadog-lib-%s-init"
+	libImageNameFormat            = "%s/dd-lib-%s-init:%s"
+	libVolumeName                 = "datadog-auto-instrumentation"
+	libMountPath                  = "/datadog-lib"
+	libInitContainerSourcePath    = "/datadog-init/."
+	javaToolOptionsEnvVarName     = "JAVA_TOOL_OPTIONS"
+	nodeOptionsEnvVarName         = "NODE_OPTIONS"
+	pythonPathEnvVarName          = "PYTHONPATH"
+)
+
+type language string
+
+const (
+	java   language = "java"
+	js     language = "js"
+	python language = "python"
+)
+
+// supportedLanguages lists the languages in the order their libraries are injected
+var supportedLanguages = []language{java, js, python}
+
+// libConfig holds the per-language defaults used to load a tracing library
+type libConfig struct {
+	// envVarName is the env var the language runtime reads to load the library
+	envVarName string
+	// envVarValue is added to the existing value of the env var, if any
+	envVarValue string
+	// separator is used to join envVarValue and the existing value of the env var
+	separator string
+	// prepend is true when envVarValue must come before the existing value of the env var
+	prepend bool
+}
+
+var libConfigs = map[language]libConfig{
+	java: {
+		envVarName:  javaToolOptionsEnvVarName,
+		envVarValue: "-javaagent:" + libMountPath + "/dd-java-agent.jar",
+		separator:   " ",
+	},
+	js: {
+		envVarName:  nodeOptionsEnvVarName,
+		envVarValue: "--require=" + libMountPath + "/node_modules/dd-trace/init",
+		separator:   " ",
+	},
+	python: {
+		envVarName:  pythonPathEnvVarName,
+		envVarValue: libMountPath + "/",
+		separator:   ":",
+		prepend:     true,
+	},
+}
+
+// libInfo describes a tracing library requested by a pod
+type libInfo struct {
+	lang  language
+	image string
+}
+
+// InjectAutoInstrumentation adds the init containers, the volume and the env vars
+// loading the tracing libraries requested by the pod annotations
+func InjectAutoInstrumentation(rawPod []byte, ns string, dc dynamic.Interface) ([]byte, error) {
+	return mutate(rawPod, ns, injectAutoInstrumentation, dc)
+}
+
+// injectAutoInstrumentation injects the tracing libraries into a pod template if needed
+func injectAutoInstrumentation(pod *corev1.Pod, ns string, _ dynamic.Interface) error {
+	var injected bool
+	defer func() {
+		metrics.MutationAttempts.Inc(metrics.LibInjectionMutationType, strconv.FormatBool(injected))
+	}()
+
+	if pod == nil {
+		metrics.MutationErrors.Inc(metrics.LibInjectionMutationType, "nil pod")
+		return errors.New("cannot inject lib into nil pod")
+	}
+
+	if !shouldInjectConf(pod) || !isNamespaceEnabled(ns) {
+		return nil
+	}
+
+	libs := extractLibInfo(pod, config.Datadog.GetString("admission_controller.auto_instrumentation.container_registry"))
+	if len(libs) == 0 {
+		return nil
+	}
+
+	if err := injectLibs(pod, libs); err != nil {
+		metrics.MutationErrors.Inc(metrics.LibInjectionMutationType, "cannot inject lib")
+		return err
+	}
+
+	injected = true
+	return nil
+}
+
+// isNamespaceEnabled returns whether the libraries can be injected into pods of the given namespace,
+// no namespace is enabled when admission_controller.auto_instrumentation.enabled_namespaces is empty
+func isNamespaceEnabled(ns string) bool {
+	enabledNamespaces := config.Datadog.GetStringSlice("admission_controller.auto_instrumentation.enabled_namespaces")
+	for _, enabledNs := range enabledNamespaces {
+		if enabledNs == ns {
+			return true
+		}
+	}
+
+	return false
+}
+
+// extractLibInfo returns the tracing libraries requested by the pod annotations,
+// a custom image annotation takes precedence over a version annotation
+func extractLibInfo(pod *corev1.Pod, containerRegistry string) []libInfo {
+	var libs []libInfo
+	annotations := pod.GetAnnotations()
+	for _, lang := range supportedLanguages {
+		if image, found := annotations[fmt.Sprintf(customLibAnnotationKeyFormat, lang)]; found && image != "" {
+			libs = append(libs, libInfo{lang: lang, image: image})
+			continue
+		}
+
+		if version, found := annotations[fmt.Sprintf(libVersionAnnotationKeyFormat, lang)]; found && version != "" {
+			libs = append(libs, libInfo{lang: lang, image: fmt.Sprintf(libImageNameFormat, containerRegistry, lang, version)})
+		}
+	}
+
+	return libs
+}
+
+// injectLibs adds a shared volume, an init container copying each library into it,
+// and mounts the volume into the containers of the pod with the env vars loading the libraries
+func injectLibs(pod *corev1.Pod, libs []libInfo) error {
+	// the env vars are checked first to leave the pod untouched on error
+	for _, lib := range libs {
+		envVarName := libConfigs[lib.lang].envVarName
+		for _, ctr := range pod.Spec.Containers {
+			for _, env := range ctr.Env {
+				if env.Name == envVarName && env.ValueFrom != nil {
+					return fmt.Errorf("cannot inject %s lib into pod %s: env var %s of container %s is set with valueFrom", lib.lang, podString(pod), envVarName, ctr.Name)
+				}
+			}
+		}
+	}
+
+	podStr := podString(pod)
+	injectLibVolume(pod)
+	for _, lib := range libs {
+		log.Debugf("Injecting %s lib with image %s into pod %s", lib.lang, lib.image, podStr)
+		injectLibInitContainer(pod, lib)
+
+		cfg := libConfigs[lib.lang]
+		for i := range pod.Spec.Containers {
+			injectLibEnv(&pod.Spec.Containers[i], cfg)
+		}
+	}
+
+	for i := range pod.Spec.Containers {
+		injectLibVolumeMount(&pod.Spec.Containers[i])
+	}
+
+	return nil
+}
+
+// injectLibVolume adds the volume shared by the init containers and the containers of the pod
+func injectLibVolume(pod *corev1.Pod) {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.Name == libVolumeName {
+			return
+		}
+	}
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: libVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+}
+
+// injectLibInitContainer adds the init container copying a library into the shared volume
+func injectLibInitContainer(pod *corev1.Pod, lib libInfo) {
+	name := fmt.Sprintf(libInitContainerNameFormat, lib.lang)
+	for _, ctr := range pod.Spec.InitContainers {
+		if ctr.Name == name {
+			log.Debugf("Ignoring init container '%s' in pod %s: it already exists", name, podString(pod))
+			return
+		}
+	}
+
+	pod.Spec.InitContainers = append(pod.Spec.InitContainers, corev1.Container{
+		Name:    name,
+		Image:   lib.image,
+		Command: []string{"sh", "-c", fmt.Sprintf("cp -r %s %s", libInitContainerSourcePath, libMountPath)},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      libVolumeName,
+				MountPath: libMountPath,
+			},
+		},
+	})
+}
+
+// injectLibVolumeMount mounts the shared volume into a container
+func injectLibVolumeMount(ctr *corev1.Container) {
+	for _, mount := range ctr.VolumeMounts {
+		if mount.Name == libVolumeName {
+			return
+		}
+	}
+
+	ctr.VolumeMounts = append(ctr.VolumeMounts, corev1.VolumeMount{
+		Name:      libVolumeName,
+		MountPath: libMountPath,
+	})
+}
+
+// injectLibEnv sets the env var loading a library, keeping its existing value if any
+func injectLibEnv(ctr *corev1.Container, cfg libConfig) {
+	for i, env := range ctr.Env {
+		if env.Name != cfg.envVarName {
+			continue
+		}
+
+		switch {
+		case env.Value == "":
+			ctr.Env[i].Value = cfg.envVarValue
+		case strings.Contains(env.Value, cfg.envVarValue):
+			// already injected
+		case cfg.prepend:
+			ctr.Env[i].Value = cfg.envVarValue + cfg.separator + env.Value
+		default:
+			ctr.Env[i].Value = env.Value + cfg.separator + cfg.envVarValue
+		}
+		return
+	}
+
+	ctr.Env = append(ctr.Env, corev1.EnvVar{
+		Name:  cfg.envVarName,
+		Value: cfg.envVarValue,
+	})
+}
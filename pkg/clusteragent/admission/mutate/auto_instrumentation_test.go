@@ -0,0 +1,199 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package mutate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func loadPodFixture(t *testing.T, name string) *corev1.Pod {
+	raw, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	var pod corev1.Pod
+	require.NoError(t, json.Unmarshal(raw, &pod))
+	return &pod
+}
+
+func libVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{Name: "datadog-auto-instrumentation", MountPath: "/datadog-lib"}
+}
+
+func libInitContainer(lang, image string) corev1.Container {
+	return corev1.Container{
+		Name:         "datadog-lib-" + lang + "-init",
+		Image:        image,
+		Command:      []string{"sh", "-c", "cp -r /datadog-init/. /datadog-lib"},
+		VolumeMounts: []corev1.VolumeMount{libVolumeMount()},
+	}
+}
+
+func Test_injectAutoInstrumentation(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("admission_controller.auto_instrumentation.container_registry", "gcr.io/datadoghq")
+
+	tests := []struct {
+		name              string
+		fixture           string
+		ns                string
+		enabledNamespaces []string
+		wantErr           bool
+		wantPodFunc       func(pod *corev1.Pod)
+	}{
+		{
+			name:              "java lib, existing JAVA_TOOL_OPTIONS",
+			fixture:           "pod_java_lib.json",
+			ns:                "application",
+			enabledNamespaces: []string{"application"},
+			wantPodFunc: func(pod *corev1.Pod) {
+				pod.Spec.Volumes = []corev1.Volume{
+					{
+						Name:         "datadog-auto-instrumentation",
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					},
+				}
+				pod.Spec.InitContainers = []corev1.Container{libInitContainer("java", "gcr.io/datadoghq/dd-lib-java-init:v0.95.1")}
+				pod.Spec.Containers[0].Env[0].Value = "-Xmx512m -javaagent:/datadog-lib/dd-java-agent.jar"
+				pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{libVolumeMount()}
+			},
+		},
+		{
+			name:              "js and python libs, custom image, several containers",
+			fixture:           "pod_js_python_libs.json",
+			ns:                "application",
+			enabledNamespaces: []string{"application"},
+			wantPodFunc: func(pod *corev1.Pod) {
+				pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+					Name:         "datadog-auto-instrumentation",
+					VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				})
+				pod.Spec.InitContainers = []corev1.Container{
+					libInitContainer("js", "gcr.io/datadoghq/dd-lib-js-init:v2.0.0"),
+					libInitContainer("python", "registry.example.com/dd-lib-python-init:custom"),
+				}
+				pod.Spec.Containers[0].Env = []corev1.EnvVar{
+					{Name: "NODE_OPTIONS", Value: "--require=/datadog-lib/node_modules/dd-trace/init"},
+					{Name: "PYTHONPATH", Value: "/datadog-lib/"},
+				}
+				pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{libVolumeMount()}
+				pod.Spec.Containers[1].Env = []corev1.EnvVar{
+					{Name: "PYTHONPATH", Value: "/datadog-lib/:/app"},
+					{Name: "NODE_OPTIONS", Value: "--require=/datadog-lib/node_modules/dd-trace/init"},
+				}
+				pod.Spec.Containers[1].VolumeMounts = append(pod.Spec.Containers[1].VolumeMounts, libVolumeMount())
+			},
+		},
+		{
+			name:        "no lib annotation",
+			fixture:     "pod_no_lib.json",
+			ns:          "application",
+			wantPodFunc: func(pod *corev1.Pod) {},
+		},
+		{
+			name:              "namespace enabled",
+			fixture:           "pod_java_lib.json",
+			ns:                "application",
+			enabledNamespaces: []string{"default", "application"},
+			wantPodFunc: func(pod *corev1.Pod) {
+				pod.Spec.Volumes = []corev1.Volume{
+					{
+						Name:         "datadog-auto-instrumentation",
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					},
+				}
+				pod.Spec.InitContainers = []corev1.Container{libInitContainer("java", "gcr.io/datadoghq/dd-lib-java-init:v0.95.1")}
+				pod.Spec.Containers[0].Env[0].Value = "-Xmx512m -javaagent:/datadog-lib/dd-java-agent.jar"
+				pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{libVolumeMount()}
+			},
+		},
+		{
+			name:              "namespace not enabled",
+			fixture:           "pod_java_lib.json",
+			ns:                "application",
+			enabledNamespaces: []string{"default"},
+			wantPodFunc:       func(pod *corev1.Pod) {},
+		},
+		{
+			name:              "no namespace enabled",
+			fixture:           "pod_java_lib.json",
+			ns:                "application",
+			enabledNamespaces: []string{},
+			wantPodFunc:       func(pod *corev1.Pod) {},
+		},
+		{
+			name:              "JAVA_TOOL_OPTIONS set with valueFrom",
+			fixture:           "pod_java_lib_value_from.json",
+			ns:                "application",
+			enabledNamespaces: []string{"application"},
+			wantErr:           true,
+			wantPodFunc:       func(pod *corev1.Pod) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockConfig.Set("admission_controller.auto_instrumentation.enabled_namespaces", tt.enabledNamespaces)
+
+			pod := loadPodFixture(t, tt.fixture)
+			wantPod := loadPodFixture(t, tt.fixture)
+			tt.wantPodFunc(wantPod)
+
+			err := injectAutoInstrumentation(pod, tt.ns, nil)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, wantPod, pod)
+		})
+	}
+}
+
+func Test_injectAutoInstrumentationIdempotent(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("admission_controller.auto_instrumentation.container_registry", "gcr.io/datadoghq")
+	mockConfig.Set("admission_controller.auto_instrumentation.enabled_namespaces", []string{"application"})
+
+	pod := loadPodFixture(t, "pod_js_python_libs.json")
+	require.NoError(t, injectAutoInstrumentation(pod, "application", nil))
+	injectedPod := pod.DeepCopy()
+
+	require.NoError(t, injectAutoInstrumentation(pod, "application", nil))
+	assert.Equal(t, injectedPod, pod)
+}
+
+func TestInjectAutoInstrumentation(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("admission_controller.auto_instrumentation.container_registry", "gcr.io/datadoghq")
+	mockConfig.Set("admission_controller.auto_instrumentation.enabled_namespaces", []string{"application"})
+
+	raw, err := ioutil.ReadFile(filepath.Join("testdata", "pod_java_lib.json"))
+	require.NoError(t, err)
+
+	patch, err := InjectAutoInstrumentation(raw, "application", nil)
+	require.NoError(t, err)
+
+	var operations []map[string]interface{}
+	require.NoError(t, json.Unmarshal(patch, &operations))
+	paths := make([]string, 0, len(operations))
+	for _, op := range operations {
+		paths = append(paths, op["path"].(string))
+	}
+	assert.Contains(t, paths, "/spec/initContainers")
+	assert.Contains(t, paths, "/spec/volumes")
+	assert.Contains(t, paths, "/spec/containers/0/volumeMounts")
+	assert.Contains(t, paths, "/spec/containers/0/env/0/value")
+}
@@ -89,23 +89,16 @@ func (h *Handler) GetAllEndpointsCheckConfigs() (types.ConfigResponse, error) {
 	return response, err
 }
 
-func (h *Handler) RebalanceClusterChecks() ([]types.RebalanceResponse, error) {
+// RebalanceClusterChecks rebalances the cluster checks and returns the moved checks,
+// in dry-run mode the planned moves are returned without being applied
+func (h *Handler) RebalanceClusterChecks(dryRun bool) ([]types.RebalanceResponse, error) {
 	if !h.dispatcher.advancedDispatching {
 		return nil, fmt.Errorf("no checks to rebalance: advanced dispatching is not enabled")
 	}
 
-	rebalancingDecisions := h.dispatcher.rebalance()
-	response := []types.RebalanceResponse{}
-
-	for _, decision := range rebalancingDecisions {
-		response = append(response, types.RebalanceResponse{
-			CheckID:        decision.CheckID,
-			CheckWeight:    decision.CheckWeight,
-			SourceNodeName: decision.SourceNodeName,
-			SourceDiff:     decision.SourceDiff,
-			DestNodeName:   decision.DestNodeName,
-			DestDiff:       decision.DestDiff,
-		})
+	response := h.dispatcher.rebalance(dryRun)
+	if response == nil {
+		response = []types.RebalanceResponse{}
 	}
 
 	return response, nil
@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build clusterchecks
+
+package clusterchecks
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// nodeLabelsCacheExpiration is how long the labels of the node running an agent are cached
+	nodeLabelsCacheExpiration = 10 * time.Minute
+	// nodeLabelsErrorCacheExpiration is how long a failure to get the labels of the node running
+	// an agent is cached, so the pods of the cluster aren't listed on every dispatching
+	nodeLabelsErrorCacheExpiration = 1 * time.Minute
+)
+
+// affinityRule pins the checks with a given name to the nodes having the given labels
+type affinityRule struct {
+	CheckName  string            `mapstructure:"check_name"`
+	NodeLabels map[string]string `mapstructure:"node_labels"`
+}
+
+// matchAffinity returns whether a check is allowed to run on a node per the affinity rules.
+// Nodes with unknown labels are only allowed to run the checks without affinity rules.
+func matchAffinity(rules []affinityRule, checkName string, labels map[string]string, found bool) bool {
+	for _, rule := range rules {
+		if rule.CheckName != checkName {
+			continue
+		}
+		if !found {
+			return false
+		}
+		for key, value := range rule.NodeLabels {
+			if labels[key] != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hasAffinityRules returns whether some affinity rules apply to a check
+func (d *dispatcher) hasAffinityRules(checkName string) bool {
+	for _, rule := range d.affinityRules {
+		if rule.CheckName == checkName {
+			return true
+		}
+	}
+	return false
+}
+
+// getNodeLabels returns the labels of the kubernetes node running the agent with the given IP.
+// The labels are cached, as they are needed on every dispatching and rebalancing, and
+// the failures are cached for a shorter time.
+func (d *dispatcher) getNodeLabels(clientIP string) (map[string]string, bool) {
+	if d.nodeLabelsGetter == nil || clientIP == "" {
+		return nil, false
+	}
+
+	cacheKey := cache.BuildAgentKey("clusterchecks", "node_labels", clientIP)
+	if labels, found := cache.Cache.Get(cacheKey); found {
+		return labels.(map[string]string), true
+	}
+
+	errCacheKey := cache.BuildAgentKey("clusterchecks", "node_labels_error", clientIP)
+	if _, found := cache.Cache.Get(errCacheKey); found {
+		return nil, false
+	}
+
+	labels, err := d.nodeLabelsGetter(clientIP)
+	if err != nil {
+		log.Debugf("Cannot get the node labels of the agent with IP %s, checks with affinity rules won't be dispatched to it: %v", clientIP, err)
+		cache.Cache.Set(errCacheKey, struct{}{}, nodeLabelsErrorCacheExpiration)
+		return nil, false
+	}
+	cache.Cache.Set(cacheKey, labels, nodeLabelsCacheExpiration)
+	return labels, true
+}
+
+// getNodesLabels returns the labels of the kubernetes nodes running the given agents, by agent name
+func (d *dispatcher) getNodesLabels(clientIPs map[string]string) map[string]map[string]string {
+	nodeLabels := make(map[string]map[string]string, len(clientIPs))
+	for nodeName, clientIP := range clientIPs {
+		if labels, found := d.getNodeLabels(clientIP); found {
+			nodeLabels[nodeName] = labels
+		}
+	}
+	return nodeLabels
+}
+
+// nodesMatchingAffinity returns the nodes allowed to run a check per the affinity
+// rules, or nil if no rules apply to the check
+func (d *dispatcher) nodesMatchingAffinity(checkName string) map[string]struct{} {
+	if !d.hasAffinityRules(checkName) {
+		return nil
+	}
+
+	// the labels are retrieved without holding the store lock, as it may query the API server
+	d.store.RLock()
+	clientIPs := make(map[string]string, len(d.store.nodes))
+	for nodeName, node := range d.store.nodes {
+		if nodeName != "" {
+			clientIPs[nodeName] = node.clientIP
+		}
+	}
+	d.store.RUnlock()
+
+	matching := make(map[string]struct{})
+	for nodeName, labels := range d.getNodesLabels(clientIPs) {
+		if matchAffinity(d.affinityRules, checkName, labels, true) {
+			matching[nodeName] = struct{}{}
+		}
+	}
+	return matching
+}
+
+// nodeMatchesAffinity returns whether a node is allowed to run a check per the affinity rules
+func (d *dispatcher) nodeMatchesAffinity(checkName, nodeName string) bool {
+	if !d.hasAffinityRules(checkName) {
+		return true
+	}
+
+	clientIP := ""
+	d.store.RLock()
+	if node, found := d.store.getNodeStore(nodeName); found {
+		clientIP = node.clientIP
+	}
+	d.store.RUnlock()
+
+	labels, found := d.getNodeLabels(clientIP)
+	return matchAffinity(d.affinityRules, checkName, labels, found)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	le "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // getAllConfigs returns all configurations known to the store, for reporting
@@ -42,6 +43,12 @@ func (d *dispatcher) getState() (types.StateResponse, error) {
 }
 
 func (d *dispatcher) addConfig(config integration.Config, targetNodeName string) {
+	// Checks with affinity rules are kept dangling until a matching node is available
+	if targetNodeName != "" && !d.nodeMatchesAffinity(config.Name, targetNodeName) {
+		log.Infof("Node %s doesn't match the affinity rules of %s:%s, will retry later", targetNodeName, config.Name, config.Digest())
+		targetNodeName = ""
+	}
+
 	d.store.Lock()
 	defer d.store.Unlock()
 
@@ -32,6 +32,9 @@ type dispatcher struct {
 	extraTags             []string
 	clcRunnersClient      clusteragent.CLCRunnerClientInterface
 	advancedDispatching   bool
+	affinityRules         []affinityRule
+	rebalanceNodeCapacity int
+	nodeLabelsGetter      nodeLabelsGetter
 }
 
 func newDispatcher() *dispatcher {
@@ -62,7 +65,18 @@ func newDispatcher() *dispatcher {
 	if err != nil {
 		log.Warnf("Cannot create CLC runners client, advanced dispatching will be disabled: %v", err)
 		d.advancedDispatching = false
+		return d
+	}
+
+	d.rebalanceNodeCapacity = config.Datadog.GetInt("cluster_checks.rebalance_node_capacity")
+	if err = config.Datadog.UnmarshalKey("cluster_checks.rebalance_affinity_rules", &d.affinityRules); err != nil {
+		log.Warnf("Cannot parse the rebalancing affinity rules, they will be ignored: %v", err)
+		d.affinityRules = nil
 	}
+	if len(d.affinityRules) > 0 {
+		d.nodeLabelsGetter = getNodeLabelsGetter()
+	}
+
 	return d
 }
 
@@ -130,7 +144,7 @@ func (d *dispatcher) reschedule(configs []integration.Config) {
 
 // add stores and delegates a given configuration
 func (d *dispatcher) add(config integration.Config) {
-	target := d.getLeastBusyNode()
+	target := d.getLeastBusyNode(config.Name)
 	if target == "" {
 		// If no node is found, store it in the danglingConfigs map for retrying later.
 		log.Warnf("No available node to dispatch %s:%s on, will retry later", config.Name, config.Digest())
@@ -199,7 +213,7 @@ func (d *dispatcher) run(ctx context.Context) {
 			// Rebalance if needed
 			if d.advancedDispatching {
 				// Rebalance checks distribution
-				d.rebalance()
+				d.rebalance(false)
 			}
 		}
 	}
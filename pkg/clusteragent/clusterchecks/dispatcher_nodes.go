@@ -71,11 +71,14 @@ func (d *dispatcher) processNodeStatus(nodeName, clientIP string, status types.N
 // getLeastBusyNode returns the name of the node that is assigned
 // the lowest number of checks. In case of equality, one is chosen
 // randomly, based on map iterations being randomized.
-func (d *dispatcher) getLeastBusyNode() string {
+// Only the nodes matching the affinity rules of the check are considered.
+func (d *dispatcher) getLeastBusyNode(checkName string) string {
 	var leastBusyNode string
 	minCheckCount := int(-1)
 	minBusyness := int(-1)
 
+	allowed := d.nodesMatchingAffinity(checkName)
+
 	d.store.RLock()
 	defer d.store.RUnlock()
 
@@ -83,6 +86,9 @@ func (d *dispatcher) getLeastBusyNode() string {
 		if name == "" {
 			continue
 		}
+		if _, found := allowed[name]; allowed != nil && !found {
+			continue
+		}
 		if d.advancedDispatching && store.busyness > defaultBusynessValue {
 			// dispatching based on clc runners stats
 			// only when advancedDispatching is true and
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/clusterchecks/types"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	le "github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -33,52 +34,83 @@ func (w Weights) Len() int           { return len(w) }
 func (w Weights) Less(i, j int) bool { return w[i].busyness > w[j].busyness }
 func (w Weights) Swap(i, j int)      { w[i], w[j] = w[j], w[i] }
 
-type RebalancingDecision struct {
-	CheckID     string
-	CheckWeight int
-
-	SourceNodeName string
-	SourceDiff     int
-
-	DestNodeName string
-	DestDiff     int
+// rebalancePlanner simulates check moves on a copy of the runner stats
+// so the rebalancing decisions can be planned without modifying the store
+type rebalancePlanner struct {
+	nodes        map[string]types.CLCRunnersStats
+	checkNames   map[string]string
+	nodeLabels   map[string]map[string]string
+	affinity     []affinityRule
+	nodeCapacity int
 }
 
-func (d *dispatcher) calculateAvg() (int, error) {
-	busyness := 0
-	length := 0
+// newRebalancePlanner takes a snapshot of the runner stats of the nodes
+func (d *dispatcher) newRebalancePlanner() *rebalancePlanner {
+	p := &rebalancePlanner{
+		nodes:        make(map[string]types.CLCRunnersStats),
+		checkNames:   make(map[string]string),
+		nodeLabels:   make(map[string]map[string]string),
+		affinity:     d.affinityRules,
+		nodeCapacity: d.rebalanceNodeCapacity,
+	}
+
+	clientIPs := make(map[string]string)
 
 	d.store.RLock()
-	defer d.store.RUnlock()
+	for nodeName, node := range d.store.nodes {
+		node.RLock()
+		stats := make(types.CLCRunnersStats, len(node.clcRunnerStats))
+		for id, s := range node.clcRunnerStats {
+			stats[id] = s
+			if digest, found := d.store.idToDigest[check.ID(id)]; found {
+				p.checkNames[id] = d.store.digestToConfig[digest].Name
+			}
+		}
+		node.RUnlock()
+		p.nodes[nodeName] = stats
+		if nodeName != "" {
+			clientIPs[nodeName] = node.clientIP
+		}
+	}
+	d.store.RUnlock()
 
-	for _, node := range d.store.nodes {
-		busyness = node.GetBusyness(busynessFunc)
-		length++
+	// the labels are retrieved without holding the store lock, as it may query the API server
+	if len(p.affinity) > 0 {
+		p.nodeLabels = d.getNodesLabels(clientIPs)
 	}
 
-	if length == 0 {
+	return p
+}
+
+// busyness returns the busyness value of a node
+func (p *rebalancePlanner) busyness(nodeName string) int {
+	return calculateBusyness(p.nodes[nodeName])
+}
+
+// calculateAvg returns the average busyness of the nodes
+func (p *rebalancePlanner) calculateAvg() (int, error) {
+	if len(p.nodes) == 0 {
 		return -1, fmt.Errorf("zero nodes reporting")
 	}
 
-	return busyness / length, nil
+	busyness := 0
+	for nodeName := range p.nodes {
+		busyness += p.busyness(nodeName)
+	}
+
+	return busyness / len(p.nodes), nil
 }
 
 // getDiffAndWeights creates a map that contains the difference between
 // the busyness on each node and the total average busyness, and a Weights
 // struct containing nodes and their busyness values
-func (d *dispatcher) getDiffAndWeights(avg int) (map[string]int, Weights) {
-	diffMap := make(map[string]int)
+func (p *rebalancePlanner) getDiffAndWeights(avg int) (map[string]int, Weights) {
+	diffMap := p.updateDiff(avg)
 	weights := Weights{}
-
-	d.store.RLock()
-	defer d.store.RUnlock()
-
-	for nodeName, node := range d.store.nodes {
-		busyness := node.GetBusyness(busynessFunc)
-		diffMap[nodeName] = busyness - avg
+	for _, nodeName := range orderedKeys(diffMap) {
 		weights = append(weights, Weight{
 			nodeName: nodeName,
-			busyness: busyness,
+			busyness: diffMap[nodeName] + avg,
 		})
 	}
 	return diffMap, weights
@@ -86,17 +118,11 @@ func (d *dispatcher) getDiffAndWeights(avg int) (map[string]int, Weights) {
 
 // updateDiff creates a map that contains the difference between
 // the busyness on each node and the total average busyness.
-func (d *dispatcher) updateDiff(avg int) map[string]int {
-	diffMap := make(map[string]int)
-
-	d.store.RLock()
-	defer d.store.RUnlock()
-
-	for nodeName, node := range d.store.nodes {
-		busyness := node.GetBusyness(busynessFunc)
-		diffMap[nodeName] = busyness - avg
+func (p *rebalancePlanner) updateDiff(avg int) map[string]int {
+	diffMap := make(map[string]int, len(p.nodes))
+	for nodeName := range p.nodes {
+		diffMap[nodeName] = p.busyness(nodeName) - avg
 	}
-
 	return diffMap
 }
 
@@ -104,17 +130,50 @@ func (d *dispatcher) updateDiff(avg int) map[string]int {
 // A check Xi running on a node N is chosen to move to another node if it satisfies the following
 // Weight(Xi) >  Weight(Xj) (for each j != i, 0 <= j < len(weights))
 // where Weight(X) is the busyness value caused by running the check X.
-func (d *dispatcher) pickCheckToMove(nodeName string) (string, int, error) {
-	d.store.RLock()
-	node, found := d.store.getNodeStore(nodeName)
-	d.store.RUnlock()
-
+// Checks in the excluded set are not considered.
+func (p *rebalancePlanner) pickCheckToMove(nodeName string, excluded map[string]struct{}) (string, int, error) {
+	stats, found := p.nodes[nodeName]
 	if !found {
-		log.Debugf("Node %s not found in store. Won't consider moving check", nodeName)
 		return "", -1, fmt.Errorf("node %s not found in store", nodeName)
 	}
 
-	return node.GetMostWeightedClusterCheck(busynessFunc)
+	checkID := ""
+	checkWeight := -1
+	for _, id := range orderedCheckIDs(stats) {
+		if _, skip := excluded[id]; skip || !stats[id].IsClusterCheck {
+			continue
+		}
+		if weight := busynessFunc(stats[id]); weight > checkWeight {
+			checkID = id
+			checkWeight = weight
+		}
+	}
+
+	if checkID == "" {
+		return "", -1, fmt.Errorf("no cluster checks to move on node %s", nodeName)
+	}
+	return checkID, checkWeight, nil
+}
+
+// matchesAffinity returns whether a check is allowed to run on a node per the affinity rules
+func (p *rebalancePlanner) matchesAffinity(checkID, nodeName string) bool {
+	labels, found := p.nodeLabels[nodeName]
+	return matchAffinity(p.affinity, p.checkNames[checkID], labels, found)
+}
+
+// canReceive returns whether a node can receive a check without
+// breaking the affinity rules or exceeding the node capacity
+func (p *rebalancePlanner) canReceive(nodeName, checkID string, checkWeight int) bool {
+	if !p.matchesAffinity(checkID, nodeName) {
+		return false
+	}
+	return p.nodeCapacity <= 0 || p.busyness(nodeName)+checkWeight <= p.nodeCapacity
+}
+
+// move simulates moving a check from a node to another
+func (p *rebalancePlanner) move(src, dest, checkID string) {
+	p.nodes[dest][checkID] = p.nodes[src][checkID]
+	delete(p.nodes[src], checkID)
 }
 
 // pickNode select the most appropriate node to receive a specific check.
@@ -122,12 +181,13 @@ func (d *dispatcher) pickCheckToMove(nodeName string) (string, int, error) {
 // if it satisfies the following
 // Diff(Ni) < Diff(Nj) (for each j != i, 0 <= j < len(nodes))
 // where Diff(N) is the difference between the busyness on N and the total average busyness.
-func pickNode(diffMap map[string]int, sourceNode string) string {
+// Only the nodes accepted by the canReceive function are considered.
+func pickNode(diffMap map[string]int, sourceNode string, canReceive func(node string) bool) string {
 	firstItr := true
 	minDiff := 0
 	pickedNode := ""
 	for _, node := range orderedKeys(diffMap) {
-		if node == sourceNode {
+		if node == sourceNode || !canReceive(node) {
 			continue
 		}
 		if diffMap[node] < minDiff || firstItr {
@@ -139,6 +199,110 @@ func pickNode(diffMap map[string]int, sourceNode string) string {
 	return pickedNode
 }
 
+// planAffinityMoves plans moving the checks running on nodes that don't match their affinity rules
+func (p *rebalancePlanner) planAffinityMoves(avg int) []types.RebalanceResponse {
+	if len(p.affinity) == 0 {
+		return nil
+	}
+
+	moves := []types.RebalanceResponse{}
+	for _, sourceNodeName := range orderedNodeNames(p.nodes) {
+		for _, checkID := range orderedCheckIDs(p.nodes[sourceNodeName]) {
+			stats := p.nodes[sourceNodeName][checkID]
+			if !stats.IsClusterCheck || p.matchesAffinity(checkID, sourceNodeName) {
+				continue
+			}
+
+			checkWeight := busynessFunc(stats)
+			diffMap := p.updateDiff(avg)
+			destNodeName := pickNode(diffMap, sourceNodeName, func(node string) bool {
+				return node != "" && p.canReceive(node, checkID, checkWeight)
+			})
+			if destNodeName == "" {
+				log.Debugf("No node matches the affinity rules of check %s running on node %s", checkID, sourceNodeName)
+				continue
+			}
+
+			p.move(sourceNodeName, destNodeName, checkID)
+			moves = append(moves, types.RebalanceResponse{
+				CheckID:        checkID,
+				CheckWeight:    checkWeight,
+				SourceNodeName: sourceNodeName,
+				SourceDiff:     diffMap[sourceNodeName],
+				DestNodeName:   destNodeName,
+				DestDiff:       diffMap[destNodeName],
+			})
+		}
+	}
+	return moves
+}
+
+// plan returns the check moves optimizing the checks repartition
+// with less possible check moves based on the runner stats.
+func (p *rebalancePlanner) plan() ([]types.RebalanceResponse, error) {
+	totalAvg, err := p.calculateAvg()
+	if err != nil {
+		return nil, err
+	}
+
+	moves := p.planAffinityMoves(totalAvg)
+
+	diffMap, weights := p.getDiffAndWeights(totalAvg)
+	sort.Stable(weights)
+
+	for _, nodeWeight := range weights {
+		// checks that cannot move from the node because of the affinity rules or the node capacity
+		excluded := make(map[string]struct{})
+
+		for diffMap[nodeWeight.nodeName] > 0 {
+			// try to move checks from a node only of the node busyness is above the average
+			sourceNodeName := nodeWeight.nodeName
+			checkID, checkWeight, err := p.pickCheckToMove(sourceNodeName, excluded)
+			if err != nil {
+				log.Debugf("Cannot pick a check to move from node %s: %v", sourceNodeName, err)
+				break
+			}
+
+			destNodeName := pickNode(diffMap, sourceNodeName, func(node string) bool {
+				return p.canReceive(node, checkID, checkWeight)
+			})
+			if destNodeName == "" {
+				log.Tracef("No node can receive check %s from node %s", checkID, sourceNodeName)
+				excluded[checkID] = struct{}{}
+				continue
+			}
+
+			sourceDiff := diffMap[sourceNodeName]
+			destDiff := diffMap[destNodeName]
+
+			// move a check to a new node only if it keeps the
+			// busyness of the new node lower than the original
+			// node's busyness multiplied by the tolerationMargin
+			// value the toleration margin is used to lean towards
+			// stability over perfectly optimal balance
+			if destDiff+checkWeight >= int(float64(sourceDiff)*tolerationMargin) {
+				break
+			}
+
+			p.move(sourceNodeName, destNodeName, checkID)
+			log.Tracef("Check %s with weight %d planned to move, total avg: %d, source diff: %d, dest diff: %d",
+				checkID, checkWeight, totalAvg, sourceDiff, destDiff)
+			// diffMap needs to be updated on every check moved
+			diffMap = p.updateDiff(totalAvg)
+			moves = append(moves, types.RebalanceResponse{
+				CheckID:        checkID,
+				CheckWeight:    checkWeight,
+				SourceNodeName: sourceNodeName,
+				SourceDiff:     sourceDiff,
+				DestNodeName:   destNodeName,
+				DestDiff:       destDiff,
+			})
+		}
+	}
+
+	return moves, nil
+}
+
 // moveCheck moves a check by its ID from a node to another
 func (d *dispatcher) moveCheck(src, dest, checkID string) error {
 	log.Debugf("Moving %s from %s to %s", checkID, src, dest)
@@ -175,7 +339,18 @@ func (d *dispatcher) moveCheck(src, dest, checkID string) error {
 
 // rebalance tries to optimize the checks repartition on cluster level check
 // runners with less possible check moves based on the runner stats.
-func (d *dispatcher) rebalance() []types.RebalanceResponse {
+// In dry-run mode, the moves are planned from the runner stats collected by the last
+// rebalancing and returned without being applied, the dispatcher state is left untouched.
+func (d *dispatcher) rebalance(dryRun bool) []types.RebalanceResponse {
+	if dryRun {
+		moves, err := d.newRebalancePlanner().plan()
+		if err != nil {
+			log.Debugf("Cannot plan the rebalancing of the checks: %v", err)
+			return nil
+		}
+		return moves
+	}
+
 	// Collect CLC runners stats and update cache before rebalancing
 	d.updateRunnersStats()
 
@@ -185,60 +360,24 @@ func (d *dispatcher) rebalance() []types.RebalanceResponse {
 	}()
 
 	log.Trace("Trying to rebalance cluster checks distribution if needed")
-	totalAvg, err := d.calculateAvg()
+	moves, err := d.newRebalancePlanner().plan()
 	if err != nil {
 		log.Debugf("Cannot rebalance checks: %v", err)
 		return nil
 	}
 
 	checksMoved := []types.RebalanceResponse{}
-	diffMap, weights := d.getDiffAndWeights(totalAvg)
-	sort.Sort(weights)
-
-	for _, nodeWeight := range weights {
-		for diffMap[nodeWeight.nodeName] > 0 {
-			// try to move checks from a node only of the node busyness is above the average
-			sourceNodeName := nodeWeight.nodeName
-			checkID, checkWeight, err := d.pickCheckToMove(sourceNodeName)
-			if err != nil {
-				log.Debugf("Cannot pick a check to move from node %s: %v", sourceNodeName, err)
-				break
-			}
-
-			destNodeName := pickNode(diffMap, sourceNodeName)
-			sourceDiff := diffMap[sourceNodeName]
-			destDiff := diffMap[destNodeName]
-
-			// move a check to a new node only if it keeps the
-			// busyness of the new node lower than the original
-			// node's busyness multiplied by the tolerationMargin
-			// value the toleration margin is used to lean towards
-			// stability over perfectly optimal balance
-			if destDiff+checkWeight < int(float64(sourceDiff)*tolerationMargin) {
-				rebalancingDecisions.Inc(le.JoinLeaderValue)
-				err = d.moveCheck(sourceNodeName, destNodeName, checkID)
-				if err != nil {
-					log.Debugf("Cannot move check %s: %v", checkID, err)
-					continue
-				}
-
-				successfulRebalancing.Inc(le.JoinLeaderValue)
-				log.Tracef("Check %s with weight %d moved, total avg: %d, source diff: %d, dest diff: %d",
-					checkID, checkWeight, totalAvg, sourceDiff, destDiff)
-				// diffMap needs to be updated on every check moved
-				diffMap = d.updateDiff(totalAvg)
-				checksMoved = append(checksMoved, types.RebalanceResponse{
-					CheckID:        checkID,
-					CheckWeight:    checkWeight,
-					SourceNodeName: sourceNodeName,
-					SourceDiff:     sourceDiff,
-					DestNodeName:   destNodeName,
-					DestDiff:       destDiff,
-				})
-			} else {
-				break
-			}
+	for _, move := range moves {
+		rebalancingDecisions.Inc(le.JoinLeaderValue)
+		if err := d.moveCheck(move.SourceNodeName, move.DestNodeName, move.CheckID); err != nil {
+			log.Debugf("Cannot move check %s: %v", move.CheckID, err)
+			continue
 		}
+
+		successfulRebalancing.Inc(le.JoinLeaderValue)
+		log.Tracef("Check %s with weight %d moved, source diff: %d, dest diff: %d",
+			move.CheckID, move.CheckWeight, move.SourceDiff, move.DestDiff)
+		checksMoved = append(checksMoved, move)
 	}
 
 	return checksMoved
@@ -1370,7 +1370,7 @@ func TestRebalance(t *testing.T) {
 			}
 
 			// rebalance checks
-			dispatcher.rebalance()
+			dispatcher.rebalance(false)
 
 			// assert runner stats repartition is updated correctly
 			for node, store := range tc.out {
@@ -1382,6 +1382,32 @@ func TestRebalance(t *testing.T) {
 	}
 }
 
+func TestCalculateAvg(t *testing.T) {
+	dispatcher := newDispatcher()
+
+	// no nodes reporting
+	_, err := dispatcher.newRebalancePlanner().calculateAvg()
+	assert.NotNil(t, err)
+
+	dispatcher.store.active = true
+	dispatcher.store.nodes["A"] = newNodeStore("A", "")
+	dispatcher.store.nodes["A"].clcRunnerStats = types.CLCRunnersStats{
+		"checkA0": {AverageExecutionTime: 500, MetricSamples: 10, IsClusterCheck: true},
+		"checkA1": {AverageExecutionTime: 300, MetricSamples: 10, IsClusterCheck: true},
+	}
+	dispatcher.store.nodes["B"] = newNodeStore("B", "")
+	dispatcher.store.nodes["B"].clcRunnerStats = types.CLCRunnersStats{
+		"checkB0": {AverageExecutionTime: 100, MetricSamples: 10, IsClusterCheck: true},
+	}
+
+	// the average is computed over the busyness of all the nodes
+	avg, err := dispatcher.newRebalancePlanner().calculateAvg()
+	assert.Nil(t, err)
+	assert.Equal(t, (402+242+82)/2, avg)
+
+	requireNotLocked(t, dispatcher.store)
+}
+
 func TestMoveCheck(t *testing.T) {
 	type checkInfo struct {
 		config integration.Config
@@ -1448,3 +1474,151 @@ func TestMoveCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestRebalanceDryRun(t *testing.T) {
+	dispatcher := newDispatcher()
+	dispatcher.store.active = true
+	dispatcher.store.nodes["A"] = newNodeStore("A", "")
+	dispatcher.store.nodes["A"].clcRunnerStats = types.CLCRunnersStats{
+		"checkA0": {AverageExecutionTime: 500, MetricSamples: 10, IsClusterCheck: true},
+		"checkA1": {AverageExecutionTime: 300, MetricSamples: 10, IsClusterCheck: true},
+		"checkA2": {AverageExecutionTime: 100, MetricSamples: 10, IsClusterCheck: true},
+	}
+	dispatcher.store.nodes["B"] = newNodeStore("B", "")
+	dispatcher.store.nodes["B"].clcRunnerStats = types.CLCRunnersStats{}
+
+	expected := []types.RebalanceResponse{
+		{
+			CheckID:        "checkA0",
+			CheckWeight:    402,
+			SourceNodeName: "A",
+			SourceDiff:     363,
+			DestNodeName:   "B",
+			DestDiff:       -363,
+		},
+	}
+
+	// the planned moves are not applied, and the runner stats aren't refreshed
+	client := &countingClcRunnerClient{}
+	dispatcher.clcRunnersClient = client
+	assert.Equal(t, expected, dispatcher.rebalance(true))
+	assert.Len(t, dispatcher.store.nodes["A"].clcRunnerStats, 3)
+	assert.Len(t, dispatcher.store.nodes["B"].clcRunnerStats, 0)
+	assert.Equal(t, 0, client.statsCalls)
+	dispatcher.clcRunnersClient = nil
+
+	// the planned moves are the ones applied
+	assert.Equal(t, expected, dispatcher.rebalance(false))
+	assert.Len(t, dispatcher.store.nodes["A"].clcRunnerStats, 2)
+	assert.Contains(t, dispatcher.store.nodes["B"].clcRunnerStats, "checkA0")
+
+	requireNotLocked(t, dispatcher.store)
+}
+
+func TestRebalancePlannerNodeCapacity(t *testing.T) {
+	newPlanner := func(capacity int) *rebalancePlanner {
+		return &rebalancePlanner{
+			nodes: map[string]types.CLCRunnersStats{
+				"A": {
+					"checkA0": {AverageExecutionTime: 500, IsClusterCheck: true},
+					"checkA1": {AverageExecutionTime: 250, IsClusterCheck: true},
+					"checkA2": {AverageExecutionTime: 250, IsClusterCheck: true},
+				},
+				"B": {
+					"checkB0": {AverageExecutionTime: 100, IsClusterCheck: true},
+				},
+			},
+			checkNames:   map[string]string{},
+			nodeLabels:   map[string]map[string]string{},
+			nodeCapacity: capacity,
+		}
+	}
+
+	// without capacity limit, the heaviest check moves
+	moves, err := newPlanner(0).plan()
+	assert.NoError(t, err)
+	assert.Len(t, moves, 1)
+	assert.Equal(t, "checkA0", moves[0].CheckID)
+
+	// the heaviest check would exceed the capacity of B, a lighter one moves instead
+	moves, err = newPlanner(400).plan()
+	assert.NoError(t, err)
+	assert.Len(t, moves, 1)
+	assert.Equal(t, "checkA1", moves[0].CheckID)
+	assert.Equal(t, "B", moves[0].DestNodeName)
+
+	// no check fits in B
+	moves, err = newPlanner(150).plan()
+	assert.NoError(t, err)
+	assert.Empty(t, moves)
+}
+
+func TestRebalancePlannerAffinity(t *testing.T) {
+	planner := &rebalancePlanner{
+		nodes: map[string]types.CLCRunnersStats{
+			"A": {
+				"postgres:1": {AverageExecutionTime: 500, IsClusterCheck: true},
+				"http:1":     {AverageExecutionTime: 300, IsClusterCheck: true},
+				"http:2":     {AverageExecutionTime: 100, IsClusterCheck: true},
+			},
+			"B": {},
+			"C": {
+				"redis:1": {AverageExecutionTime: 50, IsClusterCheck: true},
+			},
+		},
+		checkNames: map[string]string{
+			"postgres:1": "postgres",
+			"http:1":     "http_check",
+			"http:2":     "http_check",
+			"redis:1":    "redisdb",
+		},
+		nodeLabels: map[string]map[string]string{
+			"A": {"zone": "a"},
+			"B": {"zone": "b"},
+			"C": {"zone": "c"},
+		},
+		affinity: []affinityRule{
+			// postgres must stay in zone a
+			{CheckName: "postgres", NodeLabels: map[string]string{"zone": "a"}},
+			// redisdb must run in zone b
+			{CheckName: "redisdb", NodeLabels: map[string]string{"zone": "b"}},
+		},
+	}
+
+	moves, err := planner.plan()
+	assert.NoError(t, err)
+	assert.Equal(t, []types.RebalanceResponse{
+		// redisdb is moved to zone b first
+		{
+			CheckID:        "redis:1",
+			CheckWeight:    40,
+			SourceNodeName: "C",
+			SourceDiff:     -213,
+			DestNodeName:   "B",
+			DestDiff:       -253,
+		},
+		// postgres is the heaviest check on A, but it's pinned to zone a
+		{
+			CheckID:        "http:1",
+			CheckWeight:    240,
+			SourceNodeName: "A",
+			SourceDiff:     467,
+			DestNodeName:   "C",
+			DestDiff:       -253,
+		},
+		{
+			CheckID:        "http:2",
+			CheckWeight:    80,
+			SourceNodeName: "A",
+			SourceDiff:     227,
+			DestNodeName:   "B",
+			DestDiff:       -213,
+		},
+	}, moves)
+
+	// the planned moves are simulated
+	assert.Contains(t, planner.nodes["A"], "postgres:1")
+	assert.Contains(t, planner.nodes["B"], "redis:1")
+	assert.Contains(t, planner.nodes["B"], "http:2")
+	assert.Contains(t, planner.nodes["C"], "http:1")
+}
@@ -241,22 +241,73 @@ func TestGetLeastBusyNode(t *testing.T) {
 	dispatcher := newDispatcher()
 
 	// No node registered -> empty string
-	assert.Equal(t, "", dispatcher.getLeastBusyNode())
+	assert.Equal(t, "", dispatcher.getLeastBusyNode(""))
 
 	// 1 config on node1, 2 on node2
 	dispatcher.addConfig(generateIntegration("A"), "node1")
 	dispatcher.addConfig(generateIntegration("B"), "node2")
 	dispatcher.addConfig(generateIntegration("C"), "node2")
-	assert.Equal(t, "node1", dispatcher.getLeastBusyNode())
+	assert.Equal(t, "node1", dispatcher.getLeastBusyNode(""))
 
 	// 3 configs on node1, 2 on node2
 	dispatcher.addConfig(generateIntegration("D"), "node1")
 	dispatcher.addConfig(generateIntegration("E"), "node1")
-	assert.Equal(t, "node2", dispatcher.getLeastBusyNode())
+	assert.Equal(t, "node2", dispatcher.getLeastBusyNode(""))
 
 	// Add an empty node3
 	dispatcher.processNodeStatus("node3", "10.0.0.3", types.NodeStatus{})
-	assert.Equal(t, "node3", dispatcher.getLeastBusyNode())
+	assert.Equal(t, "node3", dispatcher.getLeastBusyNode(""))
+
+	requireNotLocked(t, dispatcher.store)
+}
+
+func TestAffinityDispatching(t *testing.T) {
+	dispatcher := newDispatcher()
+	dispatcher.affinityRules = []affinityRule{
+		{CheckName: "postgres", NodeLabels: map[string]string{"zone": "a"}},
+	}
+	getterCalls := make(map[string]int)
+	dispatcher.nodeLabelsGetter = func(clientIP string) (map[string]string, error) {
+		getterCalls[clientIP]++
+		switch clientIP {
+		case "10.1.0.1":
+			return map[string]string{"zone": "a"}, nil
+		case "10.1.0.2":
+			return map[string]string{"zone": "b"}, nil
+		default:
+			return nil, fmt.Errorf("no pod with IP %s", clientIP)
+		}
+	}
+
+	postgres := generateIntegration("postgres")
+
+	// No node matching the affinity rules -> dangling
+	dispatcher.processNodeStatus("nodeB", "10.1.0.2", types.NodeStatus{})
+	dispatcher.processNodeStatus("nodeC", "10.1.0.3", types.NodeStatus{})
+	dispatcher.add(postgres)
+	assert.Len(t, dispatcher.store.danglingConfigs, 1)
+
+	// Explicit target not matching the affinity rules -> dangling
+	dispatcher.addConfig(postgres, "nodeB")
+	assert.Len(t, dispatcher.store.danglingConfigs, 1)
+	assert.Empty(t, dispatcher.store.nodes["nodeB"].digestToConfig)
+
+	// The postgres check only goes to nodeA, even if it's the busiest node
+	dispatcher.processNodeStatus("nodeA", "10.1.0.1", types.NodeStatus{})
+	dispatcher.addConfig(generateIntegration("A1"), "nodeA")
+	dispatcher.addConfig(generateIntegration("A2"), "nodeA")
+	assert.Equal(t, "nodeA", dispatcher.getLeastBusyNode("postgres"))
+	dispatcher.reschedule(dispatcher.retrieveAndClearDangling())
+	assert.Empty(t, dispatcher.store.danglingConfigs)
+	assert.Contains(t, dispatcher.store.nodes["nodeA"].digestToConfig, postgres.Digest())
+
+	// Checks without affinity rules can go anywhere
+	assert.NotEqual(t, "nodeA", dispatcher.getLeastBusyNode("redisdb"))
+
+	// The node labels and the failures to get them are cached
+	assert.Equal(t, 1, getterCalls["10.1.0.1"])
+	assert.Equal(t, 1, getterCalls["10.1.0.2"])
+	assert.Equal(t, 1, getterCalls["10.1.0.3"])
 
 	requireNotLocked(t, dispatcher.store)
 }
@@ -569,6 +620,17 @@ func (d *dummyClientStruct) GetRunnerStats(IP string) (types.CLCRunnersStats, er
 	return stats[IP], nil
 }
 
+// countingClcRunnerClient counts the runner stats queries
+type countingClcRunnerClient struct {
+	dummyClientStruct
+	statsCalls int
+}
+
+func (c *countingClcRunnerClient) GetRunnerStats(IP string) (types.CLCRunnersStats, error) {
+	c.statsCalls++
+	return c.dummyClientStruct.GetRunnerStats(IP)
+}
+
 func TestUpdateRunnersStats(t *testing.T) {
 	dispatcher := newDispatcher()
 	status := types.NodeStatus{LastChange: 10}
@@ -63,3 +63,23 @@ func orderedKeys(m map[string]int) []string {
 	sort.Strings(keys)
 	return keys
 }
+
+// orderedCheckIDs sorts the check IDs of runner stats and return them in a slice
+func orderedCheckIDs(stats types.CLCRunnersStats) []string {
+	ids := make([]string, 0, len(stats))
+	for id := range stats {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// orderedNodeNames sorts the node names of runner stats by node and return them in a slice
+func orderedNodeNames(nodes map[string]types.CLCRunnersStats) []string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build clusterchecks
+// +build kubeapiserver
+
+package clusterchecks
+
+import (
+	"context"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+// nodeLabelsGetter returns the labels of the kubernetes node running the agent
+// with the given IP, used by the affinity rules
+type nodeLabelsGetter func(clientIP string) (map[string]string, error)
+
+func getNodeLabelsGetter() nodeLabelsGetter {
+	return func(clientIP string) (map[string]string, error) {
+		cl, err := apiserver.GetAPIClient()
+		if err != nil {
+			return nil, err
+		}
+		// the names reported by the agents are their hostnames, which
+		// don't always match the kubernetes node names
+		nodeName, err := cl.GetNodeForPodIP(context.TODO(), clientIP)
+		if err != nil {
+			return nil, err
+		}
+		return cl.NodeLabels(nodeName)
+	}
+}
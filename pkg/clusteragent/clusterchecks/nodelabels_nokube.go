@@ -0,0 +1,23 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build clusterchecks
+// +build !kubeapiserver
+
+package clusterchecks
+
+import (
+	"errors"
+)
+
+// nodeLabelsGetter returns the labels of the kubernetes node running the agent
+// with the given IP, used by the affinity rules
+type nodeLabelsGetter func(clientIP string) (map[string]string, error)
+
+func getNodeLabelsGetter() nodeLabelsGetter {
+	return func(string) (map[string]string, error) {
+		return nil, errors.New("No API server client compiled in")
+	}
+}
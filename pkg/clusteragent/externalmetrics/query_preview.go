@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package externalmetrics
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/custommetrics"
+	"github.com/DataDog/datadog-agent/pkg/clusteragent/externalmetrics/model"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/autoscalers"
+	datadoghq "github.com/DataDog/datadog-operator/api/v1alpha1"
+)
+
+const (
+	// QueryPreviewBackendDatadog executes the previewed queries against the Datadog API
+	QueryPreviewBackendDatadog = "datadog"
+	// QueryPreviewBackendMock executes the previewed queries against a local mock returning a fixed value
+	QueryPreviewBackendMock = "mock"
+
+	queryPreviewID = "preview"
+)
+
+var (
+	queryAggregatorFormat = regexp.MustCompile(`(^|[^a-z0-9_.])(avg|sum|min|max):`)
+	queryGroupByFormat    = regexp.MustCompile(`\}\s*by\s*\{`)
+	queryRollupFormat     = regexp.MustCompile(`\.rollup\(([^)]*)\)`)
+)
+
+// QueryPreviewRequest is the payload of a DatadogMetric query preview
+type QueryPreviewRequest struct {
+	Query     string  `json:"query"`
+	Backend   string  `json:"backend,omitempty"`
+	MockValue float64 `json:"mock_value,omitempty"`
+}
+
+// QueryPreview is the result of the validation and execution of a DatadogMetric query
+type QueryPreview struct {
+	Query         string  `json:"query"`
+	ResolvedQuery string  `json:"resolved_query,omitempty"`
+	Backend       string  `json:"backend"`
+	Valid         bool    `json:"valid"`
+	Value         float64 `json:"value"`
+	Timestamp     int64   `json:"timestamp,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// PreviewQuery validates a DatadogMetric query then executes it against the requested backend.
+// The result holds the value that would be served to the autoscalers referencing the query.
+func PreviewQuery(req QueryPreviewRequest) QueryPreview {
+	preview := QueryPreview{
+		Query:   req.Query,
+		Backend: req.Backend,
+	}
+	if preview.Backend == "" {
+		preview.Backend = QueryPreviewBackendDatadog
+	}
+
+	// Resolving the query the same way as the DatadogMetric controller
+	datadogMetric := model.NewDatadogMetricInternal(queryPreviewID, datadoghq.DatadogMetric{Spec: datadoghq.DatadogMetricSpec{Query: req.Query}})
+	if datadogMetric.Error != nil {
+		preview.Error = datadogMetric.Error.Error()
+		return preview
+	}
+	preview.ResolvedQuery = datadogMetric.Query()
+
+	bucketSize := config.Datadog.GetInt64("external_metrics_provider.bucket_size")
+	if err := validateQuery(preview.ResolvedQuery, bucketSize); err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+
+	processor, err := newPreviewProcessor(preview.Backend, req.MockValue)
+	if err != nil {
+		preview.Error = err.Error()
+		return preview
+	}
+
+	rollup := config.Datadog.GetInt("external_metrics_provider.rollup")
+	maxAge := time.Duration(math.Max(config.Datadog.GetFloat64("external_metrics_provider.max_age"), float64(3*rollup))) * time.Second
+
+	return executeQueryPreview(preview, processor, maxAge)
+}
+
+// validateQuery checks that the query can be used to autoscale:
+// it must be syntactically correct, return a single serie and be computable in the query window
+func validateQuery(query string, bucketSize int64) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("query is empty")
+	}
+
+	if err := checkQueryDelimiters(query); err != nil {
+		return err
+	}
+
+	if !queryAggregatorFormat.MatchString(query) {
+		return fmt.Errorf("query has no space aggregator, expected a query like avg:metric{scope}")
+	}
+
+	if queryGroupByFormat.MatchString(query) {
+		return fmt.Errorf("query uses a group by, it must return a single serie to be used by autoscalers")
+	}
+
+	for _, match := range queryRollupFormat.FindAllStringSubmatch(query, -1) {
+		args := strings.Split(match[1], ",")
+		interval, err := strconv.ParseInt(strings.TrimSpace(args[len(args)-1]), 10, 64)
+		if err != nil {
+			// rollup without interval, like .rollup(max)
+			continue
+		}
+
+		if interval <= 0 {
+			return fmt.Errorf("invalid rollup interval %d, it must be positive", interval)
+		}
+
+		if interval > bucketSize {
+			return fmt.Errorf("rollup interval %ds is larger than the query window %ds (external_metrics_provider.bucket_size), no value can be computed", interval, bucketSize)
+		}
+	}
+
+	return nil
+}
+
+// checkQueryDelimiters checks that parentheses and braces are balanced
+// and that the query is not a list of queries, which would return several series
+func checkQueryDelimiters(query string) error {
+	closing := map[rune]rune{')': '(', '}': '{', ']': '['}
+	stack := make([]rune, 0)
+
+	for i, c := range query {
+		switch c {
+		case '(', '{', '[':
+			stack = append(stack, c)
+		case ')', '}', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != closing[c] {
+				return fmt.Errorf("unexpected %q at position %d", c, i)
+			}
+			stack = stack[:len(stack)-1]
+		case ',':
+			if len(stack) == 0 {
+				return fmt.Errorf("query contains several queries separated by a comma, it must return a single serie to be used by autoscalers")
+			}
+		}
+	}
+
+	if len(stack) > 0 {
+		return fmt.Errorf("unclosed %q", stack[len(stack)-1])
+	}
+
+	return nil
+}
+
+// newPreviewProcessor returns the processor used to execute the previewed queries
+func newPreviewProcessor(backend string, mockValue float64) (autoscalers.ProcessorInterface, error) {
+	switch backend {
+	case QueryPreviewBackendDatadog:
+		datadogClient, err := autoscalers.NewDatadogClient()
+		if err != nil {
+			return nil, fmt.Errorf("unable to create Datadog client: %v", err)
+		}
+		return autoscalers.NewProcessor(datadogClient), nil
+	case QueryPreviewBackendMock:
+		return &mockPreviewProcessor{value: mockValue}, nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q, expected %q or %q", backend, QueryPreviewBackendDatadog, QueryPreviewBackendMock)
+	}
+}
+
+// executeQueryPreview executes the query and validates its result like the MetricsRetriever does
+func executeQueryPreview(preview QueryPreview, processor autoscalers.ProcessorInterface, maxAge time.Duration) QueryPreview {
+	query := preview.ResolvedQuery
+	results, err := processor.QueryExternalMetric([]string{query})
+
+	result, found := results[query]
+	switch {
+	case !found && err != nil:
+		preview.Error = fmt.Sprintf("%s: %v", invalidMetricGlobalErrorMessage, err)
+	case !found:
+		preview.Error = fmt.Sprintf(invalidMetricNoDataErrorMessage, query)
+	case !result.Valid:
+		preview.Error = fmt.Sprintf(invalidMetricBackendErrorMessage, query)
+	case time.Duration(time.Now().Unix()-result.Timestamp)*time.Second > maxAge:
+		preview.Value = result.Value
+		preview.Timestamp = result.Timestamp
+		preview.Error = fmt.Sprintf(invalidMetricOutdatedErrorMessage, query)
+	default:
+		preview.Valid = true
+		preview.Value = result.Value
+		preview.Timestamp = result.Timestamp
+	}
+
+	return preview
+}
+
+// mockPreviewProcessor returns a fixed and fresh value for every query
+type mockPreviewProcessor struct {
+	value float64
+}
+
+func (p *mockPreviewProcessor) UpdateExternalMetrics(emList map[string]custommetrics.ExternalMetricValue) map[string]custommetrics.ExternalMetricValue {
+	return emList
+}
+
+func (p *mockPreviewProcessor) QueryExternalMetric(queries []string) (map[string]autoscalers.Point, error) {
+	results := make(map[string]autoscalers.Point, len(queries))
+	for _, query := range queries {
+		results[query] = autoscalers.Point{
+			Value:     p.value,
+			Timestamp: time.Now().Unix(),
+			Valid:     true,
+		}
+	}
+
+	return results, nil
+}
+
+func (p *mockPreviewProcessor) ProcessEMList(emList []custommetrics.ExternalMetricValue) map[string]custommetrics.ExternalMetricValue {
+	return nil
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package externalmetrics
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/autoscalers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{query: "avg:nginx.net.request_per_s{kube_container_name:nginx}.rollup(60)", wantErr: false},
+		{query: "avg:requests{*}.rollup(avg, 30) / sum:hosts{env:prod,service:web}", wantErr: false},
+		{query: "abs(max:requests{*})", wantErr: false},
+		{query: "avg:requests{*}.rollup(max)", wantErr: false},
+		{query: "", wantErr: true},
+		{query: "avg:requests{*", wantErr: true},
+		{query: "avg:requests{*})", wantErr: true},
+		{query: "avg:requests{*}, avg:errors{*}", wantErr: true},
+		{query: "requests{*}", wantErr: true},
+		{query: "avg:requests{*} by {host}", wantErr: true},
+		{query: "avg:requests{*}.rollup(600)", wantErr: true},
+		{query: "avg:requests{*}.rollup(0)", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			err := validateQuery(tt.query, 300)
+			assert.Equal(t, tt.wantErr, err != nil, "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestExecuteQueryPreview(t *testing.T) {
+	query := "avg:requests{*}"
+	now := time.Now().Unix()
+
+	tests := []struct {
+		desc      string
+		processor autoscalers.ProcessorInterface
+		expected  QueryPreview
+	}{
+		{
+			desc:      "valid value",
+			processor: &mockedProcessor{points: map[string]autoscalers.Point{query: {Value: 42, Timestamp: now, Valid: true}}},
+			expected:  QueryPreview{ResolvedQuery: query, Valid: true, Value: 42, Timestamp: now},
+		},
+		{
+			desc:      "outdated value",
+			processor: &mockedProcessor{points: map[string]autoscalers.Point{query: {Value: 42, Timestamp: now - 3600, Valid: true}}},
+			expected:  QueryPreview{ResolvedQuery: query, Value: 42, Timestamp: now - 3600, Error: fmt.Sprintf(invalidMetricOutdatedErrorMessage, query)},
+		},
+		{
+			desc:      "invalid value",
+			processor: &mockedProcessor{points: map[string]autoscalers.Point{query: {Timestamp: now}}},
+			expected:  QueryPreview{ResolvedQuery: query, Error: fmt.Sprintf(invalidMetricBackendErrorMessage, query)},
+		},
+		{
+			desc:      "no data",
+			processor: &mockedProcessor{points: map[string]autoscalers.Point{}},
+			expected:  QueryPreview{ResolvedQuery: query, Error: fmt.Sprintf(invalidMetricNoDataErrorMessage, query)},
+		},
+		{
+			desc:      "backend error",
+			processor: &mockedProcessor{err: fmt.Errorf("forbidden")},
+			expected:  QueryPreview{ResolvedQuery: query, Error: invalidMetricGlobalErrorMessage + ": forbidden"},
+		},
+		{
+			desc:      "mock backend",
+			processor: &mockPreviewProcessor{value: 12.5},
+			expected:  QueryPreview{ResolvedQuery: query, Valid: true, Value: 12.5, Timestamp: now},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			preview := executeQueryPreview(QueryPreview{ResolvedQuery: query}, tt.processor, 5*time.Minute)
+			// The mock backend returns the current time
+			if preview.Timestamp >= now && tt.expected.Timestamp == now {
+				preview.Timestamp = now
+			}
+			assert.Equal(t, tt.expected, preview)
+		})
+	}
+}
@@ -8,6 +8,8 @@
 package externalmetrics
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
@@ -24,6 +26,9 @@ var (
 	ddmTelemetry = telemetry.NewGaugeWithOpts("external_metrics", "datadog_metrics",
 		[]string{"namespace", "name", "valid", le.JoinLeaderLabel}, "The label valid is true if the DatadogMetric CR is valid, false otherwise",
 		telemetry.Options{NoDoubleUnderscoreSep: true})
+	ddmErrorTelemetry = telemetry.NewGaugeWithOpts("external_metrics", "datadog_metrics_error_duration_seconds",
+		[]string{"namespace", "name", le.JoinLeaderLabel}, "Number of seconds since the DatadogMetric CR is in error state",
+		telemetry.Options{NoDoubleUnderscoreSep: true})
 )
 
 func setDatadogMetricTelemetry(ddm *datadoghq.DatadogMetric) {
@@ -38,11 +43,16 @@ func setDatadogMetricTelemetry(ddm *datadoghq.DatadogMetric) {
 	}
 
 	ddmTelemetry.Set(1.0, ddm.Namespace, ddm.Name, validValue, le.JoinLeaderValue)
+
+	if errorSince, inError := datadogMetricErrorSince(ddm); inError {
+		ddmErrorTelemetry.Set(time.Since(errorSince).Seconds(), ddm.Namespace, ddm.Name, le.JoinLeaderValue)
+	}
 }
 
 func unsetDatadogMetricTelemetry(ns, name string) {
 	ddmTelemetry.Delete(ns, name, ddmTelemetryValid, le.JoinLeaderValue)
 	ddmTelemetry.Delete(ns, name, ddmTelemetryInvalid, le.JoinLeaderValue)
+	ddmErrorTelemetry.Delete(ns, name, le.JoinLeaderValue)
 }
 
 func isDatadogMetricValid(ddm *datadoghq.DatadogMetric) bool {
@@ -54,3 +64,14 @@ func isDatadogMetricValid(ddm *datadoghq.DatadogMetric) bool {
 
 	return false
 }
+
+// datadogMetricErrorSince returns when the DatadogMetric CR entered its current error state
+func datadogMetricErrorSince(ddm *datadoghq.DatadogMetric) (time.Time, bool) {
+	for _, condition := range ddm.Status.Conditions {
+		if condition.Type == datadoghq.DatadogMetricConditionTypeError {
+			return condition.LastTransitionTime.Time, condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return time.Time{}, false
+}
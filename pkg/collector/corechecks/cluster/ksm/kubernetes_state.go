@@ -113,6 +113,19 @@ type KSMConfig struct {
 	// LeaderSkip forces ignoring the leader election when running the check
 	// Can be useful when running the check as cluster check
 	LeaderSkip bool `yaml:"skip_leader_election"`
+
+	// CustomResources defines gauges generated from custom resources, read from their fields with JSONPath expressions.
+	// The metrics are submitted as kubernetes_state.<name>.<metric>.
+	// Example: Monitor the available replicas of Argo Rollouts.
+	// custom_resources:
+	//   - group: argoproj.io
+	//     version: v1alpha1
+	//     resource: rollouts
+	//     name: rollout
+	//     metrics:
+	//       - name: replicas_available
+	//         path: "{.status.availableReplicas}"
+	CustomResources []CustomResourceConfig `yaml:"custom_resources"`
 }
 
 // KSMCheck wraps the config and the metric stores needed to run the check
@@ -124,6 +137,9 @@ type KSMCheck struct {
 	cancel      context.CancelFunc
 	isCLCRunner bool
 	clusterName string
+
+	// customResourceTransformers maps the KSM names of the custom resource metrics to their transformers
+	customResourceTransformers map[string]metricTransformerFunc
 }
 
 // JoinsConfig contains the config parameters for label joins
@@ -192,6 +208,12 @@ func (k *KSMCheck) Configure(config, initConfig integration.Data, source string)
 
 	k.initTags()
 
+	// Validate the custom resources before starting any informer
+	customResources, err := k.prepareCustomResources()
+	if err != nil {
+		return err
+	}
+
 	builder := kubestatemetrics.New()
 
 	// Prepare the collectors for the resources specified in the configuration file.
@@ -251,6 +273,15 @@ func (k *KSMCheck) Configure(config, initConfig integration.Data, source string)
 
 	builder.WithVPAClient(c.VPAClient)
 
+	if len(k.instance.CustomResources) > 0 {
+		dc, err := apiserver.GetKubeDynamicClient(0)
+		if err != nil {
+			return err
+		}
+
+		builder.WithDynamicClient(dc)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	k.cancel = cancel
 	builder.WithContext(ctx)
@@ -267,9 +298,40 @@ func (k *KSMCheck) Configure(config, initConfig integration.Data, source string)
 	// Start the collection process
 	k.allStores = builder.BuildStores()
 
+	for _, cr := range customResources {
+		k.allStores = append(k.allStores, builder.BuildCustomResourceStores(cr.gvr, cr.generate))
+	}
+
 	return nil
 }
 
+// prepareCustomResources validates the custom_resources config and registers the transformers of their metrics
+func (k *KSMCheck) prepareCustomResources() ([]*customResourceMetrics, error) {
+	k.customResourceTransformers = make(map[string]metricTransformerFunc)
+	customResources := make([]*customResourceMetrics, 0, len(k.instance.CustomResources))
+
+	names := make(map[string]struct{}, len(k.instance.CustomResources))
+	for i := range k.instance.CustomResources {
+		cr, err := newCustomResourceMetrics(&k.instance.CustomResources[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if _, found := names[cr.name]; found {
+			return nil, fmt.Errorf("the name %q is used by several custom resources", cr.name)
+		}
+		names[cr.name] = struct{}{}
+
+		for _, m := range cr.metrics {
+			k.customResourceTransformers[m.ksmName] = customResourceGaugeTransformer(m.ddName)
+		}
+
+		customResources = append(customResources, cr)
+	}
+
+	return customResources, nil
+}
+
 func (c *KSMConfig) parse(data []byte) error {
 	return yaml.Unmarshal(data, c)
 }
@@ -349,7 +411,11 @@ func (k *KSMCheck) processMetrics(sender aggregator.Sender, metrics map[string][
 				// Some metrics can be aggregated and consumed as-is or by a transformer.
 				// So, let’s continue the processing.
 			}
-			if transform, found := metricTransformers[metricFamily.Name]; found {
+			transform, found := metricTransformers[metricFamily.Name]
+			if !found {
+				transform, found = k.customResourceTransformers[metricFamily.Name]
+			}
+			if found {
 				lMapperOverride := labelsMapperOverride(metricFamily.Name)
 				for _, m := range metricFamily.ListMetrics {
 					hostname, tags := k.hostnameAndTags(m.Labels, labelJoiner, lMapperOverride)
@@ -561,7 +627,8 @@ func (k *KSMCheck) processTelemetry(metrics map[string][]ksmstore.DDMetricsFam)
 
 	for name, list := range metrics {
 		isMetadataMetric := metadataMetricsRegex.MatchString(name)
-		if !isKnownMetric(name) && !isMetadataMetric {
+		_, isCustomResourceMetric := k.customResourceTransformers[name]
+		if !isKnownMetric(name) && !isCustomResourceMetric && !isMetadataMetric {
 			k.telemetry.incUnknown()
 			continue
 		}
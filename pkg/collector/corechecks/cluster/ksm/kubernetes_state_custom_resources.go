@@ -0,0 +1,368 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package ksm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+	"k8s.io/kube-state-metrics/v2/pkg/metric"
+)
+
+var (
+	// customResourceNameRegex validates the names used to build the metric names of custom resources
+	customResourceNameRegex = regexp.MustCompile("^[a-z][a-z0-9_]*$")
+	// invalidLabelCharRegex matches the characters replaced in the label names, like KSM does for resource labels
+	invalidLabelCharRegex = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+	// builtinResourceNames are the names of the resources of the KSM built-in stores,
+	// their metrics are named kube_<name>_<metric>
+	builtinResourceNames = []string{
+		"certificatesigningrequest",
+		"configmap",
+		"cronjob",
+		"daemonset",
+		"deployment",
+		"endpoint",
+		"horizontalpodautoscaler",
+		"ingress",
+		"job",
+		"lease",
+		"limitrange",
+		"mutatingwebhookconfiguration",
+		"namespace",
+		"networkpolicy",
+		"node",
+		"persistentvolume",
+		"persistentvolumeclaim",
+		"pod",
+		"poddisruptionbudget",
+		"replicaset",
+		"replicationcontroller",
+		"resourcequota",
+		"secret",
+		"service",
+		"statefulset",
+		"storageclass",
+		"validatingwebhookconfiguration",
+		"verticalpodautoscaler",
+		"volumeattachment",
+	}
+)
+
+// CustomResourceConfig declares the metrics generated from a type of custom resources
+// like kube-state-metrics does with its custom resource state config.
+type CustomResourceConfig struct {
+	Group    string `yaml:"group"`
+	Version  string `yaml:"version"`
+	Resource string `yaml:"resource"`
+
+	// Name is used to name the metrics and the label holding the name of the custom resource.
+	// Example: rollout generates kube_rollout_<metric> KSM metrics, submitted as kubernetes_state.rollout.<metric>
+	// with the rollout:<name> and the kube_namespace:<namespace> tags.
+	Name string `yaml:"name"`
+
+	// LabelsFromPath adds labels to all the metrics of the custom resource.
+	// The keys are the label names and the values are JSONPath expressions evaluated against the custom resource.
+	LabelsFromPath map[string]string `yaml:"labels_from_path"`
+
+	// Metrics lists the gauges generated from the custom resource.
+	Metrics []CustomResourceMetricConfig `yaml:"metrics"`
+}
+
+// CustomResourceMetricConfig declares a gauge generated from a custom resource.
+type CustomResourceMetricConfig struct {
+	// Name of the metric, appended to the name of the custom resource.
+	Name string `yaml:"name"`
+
+	// Path is the JSONPath expression of the value of the metric.
+	// Numbers, booleans (1 or 0) and strings holding numbers or booleans are supported.
+	Path string `yaml:"path"`
+
+	// LabelsFromPath adds labels to the metric, in addition to the labels of the custom resource.
+	LabelsFromPath map[string]string `yaml:"labels_from_path"`
+}
+
+func (c *CustomResourceConfig) groupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    c.Group,
+		Version:  c.Version,
+		Resource: c.Resource,
+	}
+}
+
+// labelPath is a label whose value is read from a custom resource
+type labelPath struct {
+	key  string
+	path *jsonpath.JSONPath
+}
+
+// customResourceMetric is a gauge generated from a custom resource
+type customResourceMetric struct {
+	ksmName string
+	ddName  string
+	path    *jsonpath.JSONPath
+	labels  []labelPath
+}
+
+// customResourceMetrics generates the KSM metric families of a type of custom resources
+type customResourceMetrics struct {
+	// JSONPath expressions are not safe for concurrent use
+	// and the metrics are generated by the reflectors of all watched namespaces
+	mutex sync.Mutex
+
+	gvr     schema.GroupVersionResource
+	name    string
+	labels  []labelPath
+	metrics []customResourceMetric
+}
+
+// newCustomResourceMetrics validates the config of a custom resource and parses its JSONPath expressions
+func newCustomResourceMetrics(c *CustomResourceConfig) (*customResourceMetrics, error) {
+	if c.Version == "" || c.Resource == "" {
+		return nil, fmt.Errorf("version and resource are required for custom resource %q", c.Name)
+	}
+	if !customResourceNameRegex.MatchString(c.Name) {
+		return nil, fmt.Errorf("invalid name %q for custom resource %s, it must match %s", c.Name, c.Resource, customResourceNameRegex)
+	}
+	if builtin, collides := collidingBuiltinResource(c.Name); collides {
+		return nil, fmt.Errorf("invalid name %q for custom resource %s, its metrics would collide with the ones of the built-in %s resource", c.Name, c.Resource, builtin)
+	}
+	if len(c.Metrics) == 0 {
+		return nil, fmt.Errorf("no metric defined for custom resource %q", c.Name)
+	}
+
+	labels, err := parseLabelPaths(c.LabelsFromPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid labels for custom resource %q: %v", c.Name, err)
+	}
+
+	crm := &customResourceMetrics{
+		gvr:     c.groupVersionResource(),
+		name:    c.Name,
+		labels:  labels,
+		metrics: make([]customResourceMetric, 0, len(c.Metrics)),
+	}
+
+	for _, m := range c.Metrics {
+		if !customResourceNameRegex.MatchString(m.Name) {
+			return nil, fmt.Errorf("invalid metric name %q for custom resource %q, it must match %s", m.Name, c.Name, customResourceNameRegex)
+		}
+
+		path, err := parseJSONPath(m.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path for metric %q of custom resource %q: %v", m.Name, c.Name, err)
+		}
+
+		metricLabels, err := parseLabelPaths(m.LabelsFromPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid labels for metric %q of custom resource %q: %v", m.Name, c.Name, err)
+		}
+
+		crm.metrics = append(crm.metrics, customResourceMetric{
+			ksmName: "kube_" + c.Name + "_" + m.Name,
+			ddName:  c.Name + "." + m.Name,
+			path:    path,
+			labels:  metricLabels,
+		})
+	}
+
+	return crm, nil
+}
+
+// collidingBuiltinResource returns the built-in resource whose metric names may be generated for a custom resource of the given name.
+// Both kube_pod_<metric> and kube_pod_container_<metric> are metrics of the pods for instance.
+func collidingBuiltinResource(name string) (string, bool) {
+	for _, builtin := range builtinResourceNames {
+		if name == builtin || strings.HasPrefix(name, builtin+"_") {
+			return builtin, true
+		}
+	}
+	return "", false
+}
+
+// labelsMetricName returns the name of the metadata metric holding the labels of the custom resources,
+// it follows the kube_<resource>_labels KSM convention to be usable in label joins and labels_as_tags
+func (crm *customResourceMetrics) labelsMetricName() string {
+	return "kube_" + crm.name + "_labels"
+}
+
+// generate builds the metric families of a custom resource
+// its signature matches the generate function expected by the KSM metrics stores
+func (crm *customResourceMetrics) generate(obj interface{}) []metric.FamilyInterface {
+	cr, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+
+	crm.mutex.Lock()
+	defer crm.mutex.Unlock()
+
+	keys := []string{crm.name}
+	values := []string{cr.GetName()}
+	if namespace := cr.GetNamespace(); namespace != "" {
+		keys = append(keys, "namespace")
+		values = append(values, namespace)
+	}
+	keys, values = appendLabelsFromPath(keys, values, crm.labels, cr.Object)
+
+	families := make([]metric.FamilyInterface, 0, len(crm.metrics)+1)
+	families = append(families, crm.labelsFamily(cr, keys, values))
+
+	for _, m := range crm.metrics {
+		family := &metric.Family{Name: m.ksmName}
+		if value, found := lookupValue(m.path, cr.Object); found {
+			if val, ok := toFloat(value); ok {
+				metricKeys, metricValues := appendLabelsFromPath(copyStrings(keys), copyStrings(values), m.labels, cr.Object)
+				family.Metrics = []*metric.Metric{
+					{
+						LabelKeys:   metricKeys,
+						LabelValues: metricValues,
+						Value:       val,
+					},
+				}
+			}
+		}
+		families = append(families, family)
+	}
+
+	return families
+}
+
+// labelsFamily builds the metadata metric exposing the Kubernetes labels of a custom resource
+func (crm *customResourceMetrics) labelsFamily(cr *unstructured.Unstructured, keys, values []string) *metric.Family {
+	labels := cr.GetLabels()
+	labelKeys := make([]string, 0, len(labels))
+	for k := range labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+
+	keys = copyStrings(keys)
+	values = copyStrings(values)
+	for _, k := range labelKeys {
+		keys = append(keys, "label_"+invalidLabelCharRegex.ReplaceAllString(k, "_"))
+		values = append(values, labels[k])
+	}
+
+	return &metric.Family{
+		Name: crm.labelsMetricName(),
+		Metrics: []*metric.Metric{
+			{
+				LabelKeys:   keys,
+				LabelValues: values,
+				Value:       1,
+			},
+		},
+	}
+}
+
+// parseJSONPath parses a JSONPath expression, the enclosing braces are optional
+func parseJSONPath(path string) (*jsonpath.JSONPath, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	if !strings.HasPrefix(path, "{") {
+		path = "{" + path + "}"
+	}
+
+	parser := jsonpath.New(path).AllowMissingKeys(true)
+	if err := parser.Parse(path); err != nil {
+		return nil, err
+	}
+
+	return parser, nil
+}
+
+// parseLabelPaths parses the JSONPath expressions of labels, sorted by label name
+func parseLabelPaths(labels map[string]string) ([]labelPath, error) {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	paths := make([]labelPath, 0, len(labels))
+	for _, key := range keys {
+		path, err := parseJSONPath(labels[key])
+		if err != nil {
+			return nil, fmt.Errorf("label %q: %v", key, err)
+		}
+		paths = append(paths, labelPath{key: key, path: path})
+	}
+
+	return paths, nil
+}
+
+// appendLabelsFromPath appends the labels found in the object, missing labels are ignored
+func appendLabelsFromPath(keys, values []string, labels []labelPath, obj map[string]interface{}) ([]string, []string) {
+	for _, label := range labels {
+		value, found := lookupValue(label.path, obj)
+		if !found || value == nil {
+			continue
+		}
+		keys = append(keys, label.key)
+		values = append(values, fmt.Sprint(value))
+	}
+
+	return keys, values
+}
+
+// lookupValue returns the first value matching the JSONPath expression
+func lookupValue(path *jsonpath.JSONPath, obj map[string]interface{}) (interface{}, bool) {
+	results, err := path.FindResults(obj)
+	if err != nil || len(results) == 0 || len(results[0]) == 0 {
+		return nil, false
+	}
+
+	value := results[0][0]
+	if !value.IsValid() || !value.CanInterface() {
+		return nil, false
+	}
+
+	return value.Interface(), true
+}
+
+// toFloat converts the value of a custom resource field to a metric value
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, true
+		}
+		// condition statuses are "True" or "False"
+		if b, err := strconv.ParseBool(v); err == nil {
+			return toFloat(b)
+		}
+		return 0, false
+	default:
+		return 0, false
+	}
+}
+
+func copyStrings(s []string) []string {
+	return append(make([]string, 0, len(s)), s...)
+}
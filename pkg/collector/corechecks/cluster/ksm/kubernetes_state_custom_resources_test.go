@@ -0,0 +1,184 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package ksm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/kube-state-metrics/v2/pkg/metric"
+)
+
+func TestNewCustomResourceMetricsValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config CustomResourceConfig
+	}{
+		{
+			name:   "missing resource",
+			config: CustomResourceConfig{Version: "v1", Name: "foo", Metrics: []CustomResourceMetricConfig{{Name: "bar", Path: ".status.bar"}}},
+		},
+		{
+			name:   "invalid name",
+			config: CustomResourceConfig{Version: "v1", Resource: "foos", Name: "Foo-1", Metrics: []CustomResourceMetricConfig{{Name: "bar", Path: ".status.bar"}}},
+		},
+		{
+			name:   "built-in resource name",
+			config: CustomResourceConfig{Version: "v1", Resource: "foos", Name: "pod", Metrics: []CustomResourceMetricConfig{{Name: "bar", Path: ".status.bar"}}},
+		},
+		{
+			name:   "built-in resource metrics prefix",
+			config: CustomResourceConfig{Version: "v1", Resource: "foos", Name: "pod_container", Metrics: []CustomResourceMetricConfig{{Name: "bar", Path: ".status.bar"}}},
+		},
+		{
+			name:   "no metric",
+			config: CustomResourceConfig{Version: "v1", Resource: "foos", Name: "foo"},
+		},
+		{
+			name:   "missing path",
+			config: CustomResourceConfig{Version: "v1", Resource: "foos", Name: "foo", Metrics: []CustomResourceMetricConfig{{Name: "bar"}}},
+		},
+		{
+			name:   "invalid label path",
+			config: CustomResourceConfig{Version: "v1", Resource: "foos", Name: "foo", LabelsFromPath: map[string]string{"baz": "{.status["}, Metrics: []CustomResourceMetricConfig{{Name: "bar", Path: ".status.bar"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newCustomResourceMetrics(&tt.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPrepareCustomResources(t *testing.T) {
+	rollout := CustomResourceConfig{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts", Name: "rollout", Metrics: []CustomResourceMetricConfig{{Name: "replicas_available", Path: "{.status.availableReplicas}"}}}
+	builtin := CustomResourceConfig{Version: "v1", Resource: "foos", Name: "deployment", Metrics: []CustomResourceMetricConfig{{Name: "bar", Path: ".status.bar"}}}
+
+	k := &KSMCheck{instance: &KSMConfig{CustomResources: []CustomResourceConfig{rollout}}}
+	customResources, err := k.prepareCustomResources()
+	assert.NoError(t, err)
+	assert.Len(t, customResources, 1)
+	assert.Contains(t, k.customResourceTransformers, "kube_rollout_replicas_available")
+
+	k = &KSMCheck{instance: &KSMConfig{CustomResources: []CustomResourceConfig{rollout, builtin}}}
+	_, err = k.prepareCustomResources()
+	assert.Error(t, err)
+
+	k = &KSMCheck{instance: &KSMConfig{CustomResources: []CustomResourceConfig{rollout, rollout}}}
+	_, err = k.prepareCustomResources()
+	assert.Error(t, err)
+}
+
+func TestCollidingBuiltinResource(t *testing.T) {
+	for name, expected := range map[string]string{
+		"rollout":               "",
+		"podmonitor":            "",
+		"pod":                   "pod",
+		"pod_monitor":           "pod",
+		"persistentvolumeclaim": "persistentvolumeclaim",
+		"ingress_route":         "ingress",
+	} {
+		builtin, collides := collidingBuiltinResource(name)
+		assert.Equal(t, expected, builtin, name)
+		assert.Equal(t, expected != "", collides, name)
+	}
+}
+
+func TestCustomResourceMetricsGenerate(t *testing.T) {
+	crm, err := newCustomResourceMetrics(&CustomResourceConfig{
+		Group:    "argoproj.io",
+		Version:  "v1alpha1",
+		Resource: "rollouts",
+		Name:     "rollout",
+		LabelsFromPath: map[string]string{
+			"phase":   "{.status.phase}",
+			"missing": ".status.missing",
+		},
+		Metrics: []CustomResourceMetricConfig{
+			{Name: "replicas_available", Path: ".status.availableReplicas", LabelsFromPath: map[string]string{"strategy": "{.spec.strategy.type}"}},
+			{Name: "paused", Path: "{.spec.paused}"},
+			{Name: "ratio", Path: "{.status.ratio}"},
+			{Name: "ready", Path: `{.status.conditions[?(@.type=="Ready")].status}`},
+			{Name: "absent", Path: "{.status.absent}"},
+		},
+	})
+	require.NoError(t, err)
+
+	cr := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      "rollout-1",
+			"namespace": "default",
+			"labels":    map[string]interface{}{"app.kubernetes.io/name": "foo"},
+		},
+		"spec": map[string]interface{}{
+			"paused":   true,
+			"strategy": map[string]interface{}{"type": "canary"},
+		},
+		"status": map[string]interface{}{
+			"phase":             "Healthy",
+			"availableReplicas": int64(3),
+			"ratio":             "0.5",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True"},
+			},
+		},
+	}}
+
+	expected := []metric.FamilyInterface{
+		&metric.Family{
+			Name: "kube_rollout_labels",
+			Metrics: []*metric.Metric{{
+				LabelKeys:   []string{"rollout", "namespace", "phase", "label_app_kubernetes_io_name"},
+				LabelValues: []string{"rollout-1", "default", "Healthy", "foo"},
+				Value:       1,
+			}},
+		},
+		&metric.Family{
+			Name: "kube_rollout_replicas_available",
+			Metrics: []*metric.Metric{{
+				LabelKeys:   []string{"rollout", "namespace", "phase", "strategy"},
+				LabelValues: []string{"rollout-1", "default", "Healthy", "canary"},
+				Value:       3,
+			}},
+		},
+		&metric.Family{
+			Name: "kube_rollout_paused",
+			Metrics: []*metric.Metric{{
+				LabelKeys:   []string{"rollout", "namespace", "phase"},
+				LabelValues: []string{"rollout-1", "default", "Healthy"},
+				Value:       1,
+			}},
+		},
+		&metric.Family{
+			Name: "kube_rollout_ratio",
+			Metrics: []*metric.Metric{{
+				LabelKeys:   []string{"rollout", "namespace", "phase"},
+				LabelValues: []string{"rollout-1", "default", "Healthy"},
+				Value:       0.5,
+			}},
+		},
+		&metric.Family{
+			Name: "kube_rollout_ready",
+			Metrics: []*metric.Metric{{
+				LabelKeys:   []string{"rollout", "namespace", "phase"},
+				LabelValues: []string{"rollout-1", "default", "Healthy"},
+				Value:       1,
+			}},
+		},
+		&metric.Family{
+			Name: "kube_rollout_absent",
+		},
+	}
+
+	assert.Equal(t, expected, crm.generate(cr))
+	assert.Nil(t, crm.generate("not a custom resource"))
+}
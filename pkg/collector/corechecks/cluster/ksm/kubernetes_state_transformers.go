@@ -467,3 +467,10 @@ func pvPhaseTransformer(s aggregator.Sender, name string, metric ksmstore.DDMetr
 func serviceTypeTransformer(s aggregator.Sender, name string, metric ksmstore.DDMetric, hostname string, tags []string) {
 	submitActiveMetric(s, ksmMetricPrefix+"service.type", metric, hostname, tags)
 }
+
+// customResourceGaugeTransformer returns a transformer submitting a custom resource metric as a gauge named ddName
+func customResourceGaugeTransformer(ddName string) metricTransformerFunc {
+	return func(s aggregator.Sender, name string, metric ksmstore.DDMetric, hostname string, tags []string) {
+		s.Gauge(ksmMetricPrefix+ddName, metric.Val, hostname, tags)
+	}
+}
@@ -1952,3 +1952,21 @@ func Test_timestampTransformers(t *testing.T) {
 		})
 	}
 }
+
+func Test_customResourceGaugeTransformer(t *testing.T) {
+	s := mocksender.NewMockSender("ksm")
+	s.SetupAcceptAll()
+
+	metric := ksmstore.DDMetric{
+		Val: 3,
+		Labels: map[string]string{
+			"namespace": "default",
+			"rollout":   "rollout-1",
+		},
+	}
+	tags := []string{"kube_namespace:default", "rollout:rollout-1"}
+
+	customResourceGaugeTransformer("rollout.replicas_available")(s, "kube_rollout_replicas_available", metric, "", tags)
+	s.AssertMetric(t, "Gauge", "kubernetes_state.rollout.replicas_available", 3, "", tags)
+	s.AssertNumberOfCalls(t, "Gauge", 1)
+}
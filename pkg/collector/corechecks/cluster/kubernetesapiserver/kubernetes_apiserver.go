@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	cache "github.com/patrickmn/go-cache"
 	"gopkg.in/yaml.v2"
 	v1 "k8s.io/api/core/v1"
@@ -42,6 +43,8 @@ const (
 
 	defaultCacheExpire = 2 * time.Minute
 	defaultCachePurge  = 10 * time.Minute
+	// the events are kept by the api server for 1 hour by default (--event-ttl)
+	eventCountsCacheExpire = time.Hour
 )
 
 // KubeASConfig is the config of the API server.
@@ -54,6 +57,13 @@ type KubeASConfig struct {
 	LeaderSkip               bool     `yaml:"skip_leader_election"`
 	ResyncPeriodEvents       int      `yaml:"kubernetes_event_resync_period_s"`
 	UseComponentStatus       bool     `yaml:"use_component_status"`
+	// EventFilters excludes the events matching any of the filters
+	EventFilters []eventFilter `yaml:"event_filters"`
+	// BundleWindowSeconds is the time window during which the events of an involved object are bundled together,
+	// the events are bundled per check run when it's 0
+	BundleWindowSeconds int `yaml:"bundle_window_s"`
+	// EventsAsMetricsReasons lists the event reasons submitted as the kubernetes.events.count metric instead of events
+	EventsAsMetricsReasons []string `yaml:"events_as_metrics_reasons"`
 }
 
 // EventC holds the information pertaining to which event we collected last and when we last re-synced.
@@ -71,6 +81,12 @@ type KubeASCheck struct {
 	ac              *apiserver.APIClient
 	oshiftAPILevel  apiserver.OpenShiftAPILevel
 	providerIDCache *cache.Cache
+	bundles         map[string]*kubernetesEventBundle
+	// eventCounts holds the last count of the events submitted as metrics, by event UID
+	eventCounts *cache.Cache
+	// collectedFrom is the token the events being processed were collected from
+	collectedFrom EventC
+	clock         clock.Clock
 }
 
 func (c *KubeASConfig) parse(data []byte) error {
@@ -88,6 +104,9 @@ func NewKubeASCheck(base core.CheckBase, instance *KubeASConfig) *KubeASCheck {
 		CheckBase:       base,
 		instance:        instance,
 		providerIDCache: cache.New(defaultCacheExpire, defaultCachePurge),
+		bundles:         make(map[string]*kubernetesEventBundle),
+		eventCounts:     cache.New(eventCountsCacheExpire, defaultCachePurge),
+		clock:           clock.New(),
 	}
 }
 
@@ -158,6 +177,7 @@ func (k *KubeASCheck) Run() error {
 			if errLeader == apiserver.ErrNotLeader {
 				// Only the leader can instantiate the apiserver client.
 				log.Debugf("Not leader (leader is %q). Skipping the Kubernetes API Server check", leader)
+				k.resetEventCollection()
 				return nil
 			}
 
@@ -221,36 +241,64 @@ func (k *KubeASCheck) Run() error {
 	if err != nil {
 		k.Warnf("Could not submit new event %s", err.Error()) //nolint:errcheck
 	}
+
+	// The token is saved once the events are submitted, so that the bundled events are collected again
+	// by the next leader if the leadership is lost before the end of their bundling window.
+	token := k.submittedEventsToken()
+	configMapErr := k.ac.UpdateTokenInConfigmap(eventTokenKey, token.LastResVer, token.LastTime)
+	if configMapErr != nil {
+		k.Warnf("Could not store the LastEventToken in the ConfigMap: %s", configMapErr.Error()) //nolint:errcheck
+	}
 	return nil
 }
 
 func (k *KubeASCheck) eventCollectionCheck() (newEvents []*v1.Event, err error) {
-	resVer, lastTime, err := k.ac.GetTokenFromConfigmap(eventTokenKey)
-	if err != nil {
-		return nil, err
-	}
-
-	// This is to avoid getting in a situation where we list all the events for multiple runs in a row.
-	if resVer == "" && k.eventCollection.LastResVer != "" {
-		log.Errorf("Resource Version stored in the ConfigMap is incorrect. Will resume collecting from %s", k.eventCollection.LastResVer)
-		resVer = k.eventCollection.LastResVer
+	// The token stored in the ConfigMap lags behind the collection while events are bundled,
+	// it's only read when the collection starts, e.g. when the check becomes leader.
+	resVer, lastTime := k.eventCollection.LastResVer, k.eventCollection.LastTime
+	if resVer == "" {
+		resVer, lastTime, err = k.ac.GetTokenFromConfigmap(eventTokenKey)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	timeout := int64(k.instance.EventCollectionTimeoutMs / 1000)
 	limit := int64(k.instance.MaxEventCollection)
 	resync := int64(k.instance.ResyncPeriodEvents)
+	k.collectedFrom = EventC{LastResVer: resVer, LastTime: lastTime}
 	newEvents, k.eventCollection.LastResVer, k.eventCollection.LastTime, err = k.ac.RunEventCollection(resVer, lastTime, timeout, limit, resync, k.ignoredEvents)
 
 	if err != nil {
 		k.Warnf("Could not collect events from the api server: %s", err.Error()) //nolint:errcheck
 		return nil, err
 	}
+	return newEvents, nil
+}
 
-	configMapErr := k.ac.UpdateTokenInConfigmap(eventTokenKey, k.eventCollection.LastResVer, k.eventCollection.LastTime)
-	if configMapErr != nil {
-		k.Warnf("Could not store the LastEventToken in the ConfigMap: %s", configMapErr.Error()) //nolint:errcheck
+// submittedEventsToken returns the token up to which the events are submitted, that is the token the oldest
+// bundle still in its bundling window was collected from, or the token of the last collection otherwise
+func (k *KubeASCheck) submittedEventsToken() EventC {
+	token := k.eventCollection
+	var oldest *kubernetesEventBundle
+	for _, bundle := range k.bundles {
+		if oldest == nil || bundle.windowStart.Before(oldest.windowStart) {
+			oldest = bundle
+		}
 	}
-	return newEvents, nil
+	if oldest != nil {
+		token = oldest.collectedFrom
+	}
+	return token
+}
+
+// resetEventCollection drops the bundled events and the state of the collection when the leadership is lost,
+// the new leader collects the events from the token saved in the ConfigMap
+func (k *KubeASCheck) resetEventCollection() {
+	k.bundles = make(map[string]*kubernetesEventBundle)
+	k.eventCollection = EventC{}
+	k.collectedFrom = EventC{}
+	k.eventCounts.Flush()
 }
 
 func (k *KubeASCheck) parseComponentStatus(sender aggregator.Sender, componentsStatus *v1.ComponentStatusList) error {
@@ -295,26 +343,44 @@ func (k *KubeASCheck) parseComponentStatus(sender aggregator.Sender, componentsS
 
 // processEvents:
 // - iterates over the Kubernetes Events
+// - drops the filtered events and submits the events converted to metrics
 // - extracts some attributes and builds a structure ready to be submitted as a Datadog event (bundle)
-// - formats the bundle and submit the Datadog event
+// - formats the bundles whose time window is over and submit the Datadog events
 func (k *KubeASCheck) processEvents(sender aggregator.Sender, events []*v1.Event) error {
-	eventsByObject := make(map[string]*kubernetesEventBundle)
+	now := k.clock.Now()
 
 	for _, event := range events {
+		if k.isFilteredEvent(event) {
+			continue
+		}
+		if k.isMetricEvent(event) {
+			k.submitEventMetric(sender, event)
+			continue
+		}
+
 		id := bundleID(event)
-		bundle, found := eventsByObject[id]
+		bundle, found := k.bundles[id]
 		if found == false {
 			bundle = newKubernetesEventBundler(event)
-			eventsByObject[id] = bundle
+			bundle.windowStart = now
+			bundle.collectedFrom = k.collectedFrom
+			k.bundles[id] = bundle
 		}
 		err := bundle.addEvent(event)
 		if err != nil {
 			k.Warnf("Error while bundling events, %s.", err.Error()) //nolint:errcheck
 		}
 	}
+
+	window := time.Duration(k.instance.BundleWindowSeconds) * time.Second
 	hostname, _ := util.GetHostname(context.TODO())
 	clusterName := clustername.GetClusterName(context.TODO(), hostname)
-	for _, bundle := range eventsByObject {
+	for id, bundle := range k.bundles {
+		if now.Sub(bundle.windowStart) < window {
+			continue
+		}
+		delete(k.bundles, id)
+
 		datadogEv, err := bundle.formatEvents(clusterName, k.providerIDCache)
 		if err != nil {
 			k.Warnf("Error while formatting bundled events, %s. Not submitting", err.Error()) //nolint:errcheck
@@ -12,6 +12,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	v1 "k8s.io/api/core/v1"
@@ -333,3 +334,144 @@ func TestProcessEventsType(t *testing.T) {
 	mocked.AssertNumberOfCalls(t, "Event", 2)
 	mocked.AssertExpectations(t)
 }
+
+func TestProcessEventsFilters(t *testing.T) {
+	ev1 := createEvent(2, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "default-scheduler", "machine-blue", "Scheduled", "Successfully assigned dca-789976f5d7-2ljx6 to ip-10-0-0-54", "Normal", 709662600)
+	ev2 := createEvent(4, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "BackOff", "Back-off restarting failed container", "Warning", 709662600)
+	ev3 := createEvent(1, "kube-system", "coredns-5c98db65d4-4k4nh", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf5", "kubelet", "machine-blue", "Unhealthy", "Readiness probe failed", "Warning", 709662600)
+	ev4 := createEvent(1, "kube-system", "coredns", "Deployment", "e6417a7f-f566-11e7-9749-0e4863e1cbf6", "deployment-controller", "machine-blue", "ScalingReplicaSet", "Scaled up replica set coredns-5c98db65d4 to 2", "Normal", 709662600)
+
+	kubeASCheck := NewKubeASCheck(core.NewCheckBase(kubernetesAPIServerCheckName), &KubeASConfig{
+		EventFilters: []eventFilter{
+			{Reasons: []string{"BackOff"}},
+			{Namespaces: []string{"kube-system"}, Types: []string{"Warning"}},
+			{},
+		},
+	})
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.On("Event", mock.AnythingOfType("metrics.Event"))
+
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev1, ev2, ev3, ev4})
+
+	// Only the Scheduled and ScalingReplicaSet events are submitted, the empty filter doesn't match any event.
+	calls := []string{
+		(mocked.Calls[0].Arguments.Get(0)).(metrics.Event).Text,
+		(mocked.Calls[1].Arguments.Get(0)).(metrics.Event).Text,
+	}
+	sort.Strings(calls)
+
+	assert.Contains(t, calls[0], "1 **ScalingReplicaSet**")
+	assert.Contains(t, calls[1], "2 **Scheduled**")
+	mocked.AssertNumberOfCalls(t, "Event", 2)
+	mocked.AssertExpectations(t)
+}
+
+func TestProcessEventsAsMetrics(t *testing.T) {
+	ev1 := createEvent(2, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "default-scheduler", "machine-blue", "Scheduled", "Successfully assigned dca-789976f5d7-2ljx6 to ip-10-0-0-54", "Normal", 709662600)
+	ev2 := createEvent(4, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "BackOff", "Back-off restarting failed container", "Warning", 709662600)
+	ev3 := createEvent(5, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "BackOff", "Back-off restarting failed container", "Warning", 709662610)
+	ev4 := createEvent(1, "", "localhost", "Node", "e63e74fa-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "Unhealthy", "Node is unhealthy", "Warning", 709662600)
+	// ev3 is an update of ev2
+	ev2.UID = "backoff-event"
+	ev3.UID = "backoff-event"
+	ev4.UID = "unhealthy-event"
+
+	kubeASCheck := NewKubeASCheck(core.NewCheckBase(kubernetesAPIServerCheckName), &KubeASConfig{
+		EventsAsMetricsReasons: []string{"BackOff", "Unhealthy"},
+	})
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.On("Event", mock.AnythingOfType("metrics.Event"))
+	mocked.SetupAcceptAll()
+
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev1, ev2, ev3, ev4})
+
+	mocked.AssertNumberOfCalls(t, "Event", 1)
+	assert.Contains(t, (mocked.Calls[len(mocked.Calls)-1].Arguments.Get(0)).(metrics.Event).Text, "2 **Scheduled**")
+
+	mocked.AssertNumberOfCalls(t, "Count", 3)
+	mocked.AssertMetric(t, "Count", "kubernetes.events.count", 4, "", []string{"reason:BackOff", "kind:Pod", "namespace:default"})
+	mocked.AssertMetric(t, "Count", "kubernetes.events.count", 1, "", []string{"reason:BackOff", "kind:Pod", "namespace:default"})
+	mocked.AssertMetric(t, "Count", "kubernetes.events.count", 1, "", []string{"reason:Unhealthy", "kind:Node"})
+
+	// The events collected again without new occurrence are not counted
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev3, ev4})
+	mocked.AssertNumberOfCalls(t, "Count", 3)
+}
+
+func TestProcessEventsBundleWindow(t *testing.T) {
+	ev1 := createEvent(2, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "default-scheduler", "machine-blue", "Scheduled", "Successfully assigned dca-789976f5d7-2ljx6 to ip-10-0-0-54", "Normal", 709662600)
+	ev2 := createEvent(3, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "default-scheduler", "machine-blue", "Started", "Started container", "Normal", 709662630)
+
+	mockClock := clock.NewMock()
+	kubeASCheck := NewKubeASCheck(core.NewCheckBase(kubernetesAPIServerCheckName), &KubeASConfig{
+		BundleWindowSeconds: 60,
+	})
+	kubeASCheck.clock = mockClock
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.On("Event", mock.AnythingOfType("metrics.Event"))
+
+	// The bundle is kept until the end of the window
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev1})
+	mocked.AssertNotCalled(t, "Event", mock.AnythingOfType("metrics.Event"))
+
+	mockClock.Add(30 * time.Second)
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev2})
+	mocked.AssertNotCalled(t, "Event", mock.AnythingOfType("metrics.Event"))
+
+	// The window is over, both events are submitted in the same bundle
+	mockClock.Add(31 * time.Second)
+	kubeASCheck.processEvents(mocked, []*v1.Event{})
+	mocked.AssertNumberOfCalls(t, "Event", 1)
+	res := (mocked.Calls[0].Arguments.Get(0)).(metrics.Event).Text
+	assert.Contains(t, res, "2 **Scheduled**")
+	assert.Contains(t, res, "3 **Started**")
+
+	// A new bundle is started for the following events
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev2})
+	mocked.AssertNumberOfCalls(t, "Event", 1)
+	assert.Len(t, kubeASCheck.bundles, 1)
+}
+
+func TestSubmittedEventsToken(t *testing.T) {
+	ev1 := createEvent(2, "default", "dca-789976f5d7-2ljx6", "Pod", "e6417a7f-f566-11e7-9749-0e4863e1cbf4", "default-scheduler", "machine-blue", "Scheduled", "Successfully assigned dca-789976f5d7-2ljx6 to ip-10-0-0-54", "Normal", 709662600)
+	ev2 := createEvent(1, "default", "localhost", "Node", "e63e74fa-f566-11e7-9749-0e4863e1cbf4", "kubelet", "machine-blue", "MissingClusterDNS", "MountVolume.SetUp succeeded", "Normal", 709662630)
+
+	mockClock := clock.NewMock()
+	kubeASCheck := NewKubeASCheck(core.NewCheckBase(kubernetesAPIServerCheckName), &KubeASConfig{
+		BundleWindowSeconds: 60,
+	})
+	kubeASCheck.clock = mockClock
+	mocked := mocksender.NewMockSender(kubeASCheck.ID())
+	mocked.On("Event", mock.AnythingOfType("metrics.Event"))
+
+	// No bundled event, the token of the last collection is saved
+	kubeASCheck.collectedFrom = EventC{LastResVer: "100"}
+	kubeASCheck.eventCollection = EventC{LastResVer: "200"}
+	kubeASCheck.processEvents(mocked, []*v1.Event{})
+	assert.Equal(t, "200", kubeASCheck.submittedEventsToken().LastResVer)
+
+	// The events are bundled, the token they were collected from is saved
+	kubeASCheck.collectedFrom = EventC{LastResVer: "200"}
+	kubeASCheck.eventCollection = EventC{LastResVer: "300"}
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev1})
+	assert.Equal(t, "200", kubeASCheck.submittedEventsToken().LastResVer)
+
+	mockClock.Add(30 * time.Second)
+	kubeASCheck.collectedFrom = EventC{LastResVer: "300"}
+	kubeASCheck.eventCollection = EventC{LastResVer: "400"}
+	kubeASCheck.processEvents(mocked, []*v1.Event{ev2})
+	assert.Equal(t, "200", kubeASCheck.submittedEventsToken().LastResVer)
+
+	// The first bundle is submitted, the token of the second one is saved
+	mockClock.Add(31 * time.Second)
+	kubeASCheck.collectedFrom = EventC{LastResVer: "400"}
+	kubeASCheck.eventCollection = EventC{LastResVer: "500"}
+	kubeASCheck.processEvents(mocked, []*v1.Event{})
+	mocked.AssertNumberOfCalls(t, "Event", 1)
+	assert.Equal(t, "300", kubeASCheck.submittedEventsToken().LastResVer)
+
+	// The bundles are dropped when the leadership is lost
+	kubeASCheck.resetEventCollection()
+	assert.Empty(t, kubeASCheck.bundles)
+	assert.Equal(t, EventC{}, kubeASCheck.submittedEventsToken())
+}
@@ -37,6 +37,8 @@ type kubernetesEventBundle struct {
 	countByAction map[string]int         // Map of count per action to aggregate several events from the same ObjUid in one event
 	nodename      string                 // Stores the nodename that should be used to submit the events
 	alertType     metrics.EventAlertType // The Datadog event type
+	windowStart   time.Time              // When the bundle was created, used to flush it at the end of the bundling window
+	collectedFrom EventC                 // The token the first event of the bundle was collected from
 }
 
 func newKubernetesEventBundler(event *v1.Event) *kubernetesEventBundle {
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package kubernetesapiserver
+
+import (
+	"fmt"
+
+	cache "github.com/patrickmn/go-cache"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+)
+
+const eventsCountMetricName = "kubernetes.events.count"
+
+// eventFilter excludes the events matching all of its non-empty fields.
+// Each field matches if the corresponding attribute of the event is one of the listed values.
+type eventFilter struct {
+	Reasons    []string `yaml:"reasons"`
+	Kinds      []string `yaml:"kinds"`
+	Namespaces []string `yaml:"namespaces"`
+	Types      []string `yaml:"types"`
+}
+
+// isEmpty returns whether the filter has no field set, an empty filter matches no event
+func (f *eventFilter) isEmpty() bool {
+	return len(f.Reasons) == 0 && len(f.Kinds) == 0 && len(f.Namespaces) == 0 && len(f.Types) == 0
+}
+
+func (f *eventFilter) match(event *v1.Event) bool {
+	if f.isEmpty() {
+		return false
+	}
+
+	return matchValues(f.Reasons, event.Reason) &&
+		matchValues(f.Kinds, event.InvolvedObject.Kind) &&
+		matchValues(f.Namespaces, event.InvolvedObject.Namespace) &&
+		matchValues(f.Types, event.Type)
+}
+
+// matchValues returns true if values is empty or contains value
+func matchValues(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isFilteredEvent returns whether the event is excluded by one of the event_filters
+func (k *KubeASCheck) isFilteredEvent(event *v1.Event) bool {
+	for i := range k.instance.EventFilters {
+		if k.instance.EventFilters[i].match(event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isMetricEvent returns whether the event must be submitted as a metric instead of a Datadog event
+func (k *KubeASCheck) isMetricEvent(event *v1.Event) bool {
+	return len(k.instance.EventsAsMetricsReasons) > 0 && matchValues(k.instance.EventsAsMetricsReasons, event.Reason)
+}
+
+// submitEventMetric counts the occurrences of an event since it was last collected, by reason and involved object kind.
+// The occurrences of an event collected for the first time are all counted.
+func (k *KubeASCheck) submitEventMetric(sender aggregator.Sender, event *v1.Event) {
+	count := event.Count
+	if count == 0 {
+		count = 1
+	}
+	occurrences := count
+	uid := string(event.UID)
+	if previous, found := k.eventCounts.Get(uid); found {
+		if previousCount, ok := previous.(int32); ok {
+			// the event may be collected again without new occurrence, e.g. when resuming from an older token
+			if count <= previousCount {
+				return
+			}
+			occurrences = count - previousCount
+		}
+	}
+	k.eventCounts.Set(uid, count, cache.DefaultExpiration)
+
+	tags := []string{
+		fmt.Sprintf("reason:%s", event.Reason),
+		fmt.Sprintf("kind:%s", event.InvolvedObject.Kind),
+	}
+	if event.InvolvedObject.Namespace != "" {
+		tags = append(tags, fmt.Sprintf("namespace:%s", event.InvolvedObject.Namespace))
+	}
+
+	sender.Count(eventsCountMetricName, float64(occurrences), "", tags)
+}
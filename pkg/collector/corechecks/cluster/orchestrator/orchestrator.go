@@ -10,7 +10,9 @@ package orchestrator
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/rand"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -33,7 +35,9 @@ import (
 
 	"gopkg.in/yaml.v2"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	appslisters "k8s.io/client-go/listers/apps/v1"
 	batchlisters "k8s.io/client-go/listers/batch/v1"
 	batchlistersBeta1 "k8s.io/client-go/listers/batch/v1beta1"
@@ -76,6 +80,28 @@ type OrchestratorInstance struct {
 	//   - nodes
 	//   - services
 	Collectors []string `yaml:"collectors"`
+	// CustomResources defines the custom resources to collect, identified by their group, version and resource.
+	// Example: Collect DatadogMetrics.
+	// custom_resources:
+	//   - group: datadoghq.com
+	//     version: v1alpha1
+	//     resource: datadogmetrics
+	CustomResources []CustomResource `yaml:"custom_resources"`
+}
+
+// CustomResource identifies a type of custom resources to collect.
+type CustomResource struct {
+	Group    string `yaml:"group"`
+	Version  string `yaml:"version"`
+	Resource string `yaml:"resource"`
+}
+
+func (c CustomResource) groupVersionResource() schema.GroupVersionResource {
+	return schema.GroupVersionResource{
+		Group:    c.Group,
+		Version:  c.Version,
+		Resource: c.Resource,
+	}
 }
 
 func (c *OrchestratorInstance) parse(data []byte) error {
@@ -108,6 +134,7 @@ type OrchestratorCheck struct {
 	clusterRolesLister           rbaclisters.ClusterRoleLister
 	clusterRoleBindingsLister    rbaclisters.ClusterRoleBindingLister
 	serviceAccountsLister        corelisters.ServiceAccountLister
+	customResourcesListers       map[schema.GroupVersionResource]cache.GenericLister
 }
 
 func newOrchestratorCheck(base core.CheckBase, instance *OrchestratorInstance) *OrchestratorCheck {
@@ -270,7 +297,83 @@ func (o *OrchestratorCheck) Configure(config, initConfig integration.Data, sourc
 		go informer.Run(o.stopCh)
 	}
 
-	return apiserver.SyncInformers(informersToSync)
+	if err := apiserver.SyncInformers(informersToSync); err != nil {
+		return err
+	}
+
+	o.configureCustomResources(apiCl)
+	return nil
+}
+
+// configureCustomResources starts the informers of the configured custom resources.
+// Unlike the other resources, a custom resource that cannot be listed (e.g. its CRD is
+// not installed or the cluster agent isn't allowed to list it) is skipped with a warning,
+// so that it doesn't prevent the collection of the other resources.
+func (o *OrchestratorCheck) configureCustomResources(apiCl *apiserver.APIClient) {
+	if len(o.instance.CustomResources) == 0 {
+		return
+	}
+	if apiCl.DynamicInformerFactory == nil {
+		_ = o.Warnf("No dynamic informer factory available, custom resources won't be collected")
+		return
+	}
+
+	var (
+		wg   sync.WaitGroup
+		m    sync.Mutex
+		errs = make(map[schema.GroupVersionResource]error)
+	)
+	o.customResourcesListers = make(map[schema.GroupVersionResource]cache.GenericLister)
+	for _, cr := range o.instance.CustomResources {
+		if cr.Version == "" || cr.Resource == "" {
+			_ = o.Warnf("Invalid custom resource, version and resource are required: %+v", cr)
+			continue
+		}
+
+		// the informers are synced concurrently so that the ones failing don't delay the others
+		gvr := cr.groupVersionResource()
+		customResourceInformer := apiCl.DynamicInformerFactory.ForResource(gvr)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := apiserver.InformerName(fmt.Sprintf("%s/%s", orchestrator.K8sCustomResource, gvr))
+			err := o.runCustomResourceInformer(name, customResourceInformer.Informer())
+
+			m.Lock()
+			defer m.Unlock()
+			if err != nil {
+				errs[gvr] = err
+				return
+			}
+			o.customResourcesListers[gvr] = customResourceInformer.Lister()
+		}()
+	}
+	wg.Wait()
+
+	for gvr, err := range errs {
+		_ = o.Warnf("Skipping the collection of custom resources %s: %s", gvr, err)
+	}
+}
+
+// runCustomResourceInformer runs the informer of a custom resource until the check
+// is cancelled. The informer is stopped right away if it fails to sync.
+func (o *OrchestratorCheck) runCustomResourceInformer(name apiserver.InformerName, informer cache.SharedIndexInformer) error {
+	stopCh := make(chan struct{})
+	syncFailed := make(chan struct{})
+	go func() {
+		defer close(stopCh)
+		select {
+		case <-o.stopCh:
+		case <-syncFailed:
+		}
+	}()
+	go informer.Run(stopCh)
+
+	if err := apiserver.SyncInformers(map[apiserver.InformerName]cache.SharedInformer{name: informer}); err != nil {
+		close(syncFailed)
+		return err
+	}
+	return nil
 }
 
 // Run runs the orchestrator check
@@ -321,6 +424,7 @@ func (o *OrchestratorCheck) Run() error {
 	o.processClusterRoles(sender)
 	o.processClusterRoleBindings(sender)
 	o.processServiceAccounts(sender)
+	o.processCustomResources(sender)
 
 	return nil
 }
@@ -776,6 +880,43 @@ func (o *OrchestratorCheck) processServiceAccounts(sender aggregator.Sender) {
 	sender.OrchestratorMetadata(messages, o.clusterID, int(orchestrator.K8sServiceAccount))
 }
 
+func (o *OrchestratorCheck) processCustomResources(sender aggregator.Sender) {
+	if len(o.customResourcesListers) == 0 {
+		return
+	}
+	var crList []*unstructured.Unstructured
+	for gvr, lister := range o.customResourcesListers {
+		objList, err := lister.List(labels.Everything())
+		if err != nil {
+			_ = o.Warnf("Unable to list custom resources %s: %s", gvr, err)
+			continue
+		}
+		for _, obj := range objList {
+			cr, ok := obj.(*unstructured.Unstructured)
+			if !ok {
+				_ = o.Warnf("Unexpected type %T for custom resource %s", obj, gvr)
+				continue
+			}
+			crList = append(crList, cr)
+		}
+	}
+	groupID := atomic.AddInt32(&o.groupID, 1)
+
+	messages, err := processCustomResourceList(crList, groupID, o.orchestratorConfig, o.clusterID)
+	if err != nil {
+		_ = o.Warnf("Unable to process custom resource list: %s", err)
+	}
+
+	stats := orchestrator.CheckStats{
+		CacheHits: len(crList) - len(messages),
+		CacheMiss: len(messages),
+		NodeType:  orchestrator.K8sCustomResource,
+	}
+	orchestrator.KubernetesResourceCache.Set(orchestrator.BuildStatsKey(orchestrator.K8sCustomResource), stats, orchestrator.NoExpiration)
+
+	sender.OrchestratorMetadata(messages, o.clusterID, int(orchestrator.K8sCustomResource))
+}
+
 // Cancel cancels the orchestrator check
 func (o *OrchestratorCheck) Cancel() {
 	log.Infof("Shutting down informers used by the check '%s'", o.ID())
@@ -29,6 +29,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 )
@@ -1042,3 +1043,71 @@ func chunkServiceAccounts(serviceAccounts []*model.ServiceAccount, chunkCount, c
 
 	return chunks
 }
+
+func processCustomResourceList(crList []*unstructured.Unstructured, groupID int32, cfg *config.OrchestratorConfig, clusterID string) ([]model.MessageBody, error) {
+	start := time.Now()
+	manifestMsgs := make([]*model.Manifest, 0, len(crList))
+
+	for _, cr := range crList {
+		if orchestrator.SkipKubernetesResource(cr.GetUID(), cr.GetResourceVersion(), orchestrator.K8sCustomResource) {
+			continue
+		}
+
+		// custom resources are shared with the informer cache,
+		// so they are copied before being scrubbed
+		cr = cr.DeepCopy()
+		if annotations := cr.GetAnnotations(); annotations != nil {
+			redact.RemoveLastAppliedConfigurationAnnotation(annotations)
+			cr.SetAnnotations(annotations)
+		}
+		if cfg.IsScrubbingEnabled {
+			redact.ScrubUnstructured(cr.Object, cfg.Scrubber)
+		}
+
+		jsonCR, err := jsoniter.Marshal(cr.Object)
+		if err != nil {
+			log.Warnf("Could not marshal custom resource %s to JSON: %s", cr.GroupVersionKind(), err)
+			continue
+		}
+
+		manifestMsgs = append(manifestMsgs, &model.Manifest{
+			Orchestrator: orchestrator.K8sCustomResource.Orchestrator(),
+			Type:         orchestrator.K8sCustomResource.String(),
+			Uid:          string(cr.GetUID()),
+			Content:      jsonCR,
+			ContentType:  "json",
+			Version:      cr.GetResourceVersion(),
+		})
+	}
+
+	groupSize := orchestrator.GroupSize(len(manifestMsgs), cfg.MaxPerMessage)
+
+	chunks := chunkManifests(manifestMsgs, groupSize, cfg.MaxPerMessage)
+	messages := make([]model.MessageBody, 0, groupSize)
+
+	for i := 0; i < groupSize; i++ {
+		messages = append(messages, &model.CollectorManifest{
+			ClusterName: cfg.KubeClusterName,
+			ClusterId:   clusterID,
+			GroupId:     groupID,
+			GroupSize:   int32(groupSize),
+			Manifests:   chunks[i],
+		})
+	}
+
+	log.Debugf("Collected & enriched %d out of %d custom resources in %s", len(manifestMsgs), len(crList), time.Since(start))
+	return messages, nil
+}
+
+// chunkManifests chunks the given list of manifests, honoring the given
+// chunk count and size.  The last chunk may be smaller than the others.
+func chunkManifests(manifests []*model.Manifest, chunkCount, chunkSize int) [][]*model.Manifest {
+	chunks := make([][]*model.Manifest, 0, chunkCount)
+
+	for counter := 1; counter <= chunkCount; counter++ {
+		chunkStart, chunkEnd := orchestrator.ChunkRange(len(manifests), chunkCount, chunkSize, counter)
+		chunks = append(chunks, manifests[chunkStart:chunkEnd])
+	}
+
+	return chunks
+}
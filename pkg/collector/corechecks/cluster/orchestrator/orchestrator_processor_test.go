@@ -8,10 +8,15 @@
 package orchestrator
 
 import (
+	"encoding/json"
 	"testing"
 
 	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/DataDog/datadog-agent/pkg/orchestrator/config"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 func TestChunkDeployments(t *testing.T) {
@@ -162,3 +167,76 @@ func TestConvertNodeStatusToTags(t *testing.T) {
 		})
 	}
 }
+
+func newCustomResource(uid, resourceVersion string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "datadoghq.com/v1alpha1",
+			"kind":       "DatadogMetric",
+			"metadata": map[string]interface{}{
+				"name":            "metric-" + uid,
+				"namespace":       "default",
+				"uid":             uid,
+				"resourceVersion": resourceVersion,
+				"annotations": map[string]interface{}{
+					"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				},
+			},
+			"spec": map[string]interface{}{
+				"query": "avg:requests{*}",
+				"env": []interface{}{
+					map[string]interface{}{"name": "API_KEY", "value": "1234"},
+				},
+			},
+		},
+	}
+}
+
+func TestProcessCustomResourceList(t *testing.T) {
+	cfg := config.NewDefaultOrchestratorConfig()
+	cfg.KubeClusterName = "cluster"
+	cfg.IsScrubbingEnabled = true
+	cfg.MaxPerMessage = 2
+
+	crList := []*unstructured.Unstructured{
+		newCustomResource("cr-uid-1", "1"),
+		newCustomResource("cr-uid-2", "1"),
+		newCustomResource("cr-uid-3", "1"),
+	}
+
+	messages, err := processCustomResourceList(crList, 1, cfg, "cluster-id")
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	first := messages[0].(*model.CollectorManifest)
+	assert.Equal(t, "cluster", first.ClusterName)
+	assert.Equal(t, "cluster-id", first.ClusterId)
+	assert.Equal(t, int32(2), first.GroupSize)
+	require.Len(t, first.Manifests, 2)
+	assert.Len(t, messages[1].(*model.CollectorManifest).Manifests, 1)
+
+	manifest := first.Manifests[0]
+	assert.Equal(t, "k8s", manifest.Orchestrator)
+	assert.Equal(t, "CustomResource", manifest.Type)
+	assert.Equal(t, "cr-uid-1", manifest.Uid)
+	assert.Equal(t, "1", manifest.Version)
+	assert.Equal(t, "json", manifest.ContentType)
+
+	var content map[string]interface{}
+	require.NoError(t, json.Unmarshal(manifest.Content, &content))
+	cr := unstructured.Unstructured{Object: content}
+	assert.Equal(t, "-", cr.GetAnnotations()["kubectl.kubernetes.io/last-applied-configuration"])
+	env, _, _ := unstructured.NestedSlice(content, "spec", "env")
+	assert.Equal(t, "********", env[0].(map[string]interface{})["value"])
+
+	// the objects of the informer cache are left untouched
+	assert.Equal(t, "{}", crList[0].GetAnnotations()["kubectl.kubernetes.io/last-applied-configuration"])
+
+	// unchanged custom resources are skipped thanks to the cache
+	crList[2] = newCustomResource("cr-uid-3", "2")
+	messages, err = processCustomResourceList(crList, 2, cfg, "cluster-id")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Len(t, messages[0].(*model.CollectorManifest).Manifests, 1)
+	assert.Equal(t, "cr-uid-3", messages[0].(*model.CollectorManifest).Manifests[0].Uid)
+}
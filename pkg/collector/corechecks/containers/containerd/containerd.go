@@ -14,10 +14,7 @@ import (
 
 	wstats "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/stats"
 	v1 "github.com/containerd/cgroups/stats/v1"
-	"github.com/containerd/containerd"
 	"github.com/containerd/containerd/containers"
-	"github.com/containerd/typeurl"
-	"github.com/opencontainers/runtime-spec/specs-go"
 	"gopkg.in/yaml.v2"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
@@ -25,15 +22,16 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/containers/generic"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	cutil "github.com/DataDog/datadog-agent/pkg/util/containerd"
 	ddContainers "github.com/DataDog/datadog-agent/pkg/util/containers"
-	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+	cmetrics "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
-	"github.com/DataDog/datadog-agent/pkg/util/system"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
 const (
@@ -43,9 +41,10 @@ const (
 // ContainerdCheck grabs containerd metrics and events
 type ContainerdCheck struct {
 	core.CheckBase
-	instance *ContainerdConfig
-	sub      *subscriber
-	filters  *ddContainers.Filter
+	instance  *ContainerdConfig
+	sub       *subscriber
+	filters   *ddContainers.Filter
+	processor generic.Processor
 }
 
 // ContainerdConfig contains the custom options and configurations set by the user.
@@ -90,6 +89,13 @@ func (c *ContainerdCheck) Configure(config, initConfig integration.Data, source
 	}
 	c.filters = fil
 
+	cuGetter := func() (cutil.ContainerdItf, error) {
+		return cutil.GetContainerdUtil()
+	}
+
+	c.processor = generic.NewProcessor(cmetrics.GetProvider(), containerLister{filter: fil}, metricsAdapter{cuGetter: cuGetter}, fil)
+	c.processor.RegisterExtension("containerd-custom-metrics", &containerdCustomMetricsExtension{cuGetter: cuGetter})
+
 	return nil
 }
 
@@ -99,13 +105,13 @@ func (c *ContainerdCheck) Run() error {
 	if err != nil {
 		return err
 	}
-	defer sender.Commit()
 
 	// As we do not rely on a singleton, we ensure connectivity every check run.
 	cu, errHealth := cutil.GetContainerdUtil()
 	if errHealth != nil {
 		sender.ServiceCheck("containerd.health", metrics.ServiceCheckCritical, "", nil, fmt.Sprintf("Connectivity error %v", errHealth))
 		log.Infof("Error ensuring connectivity with Containerd daemon %v", errHealth)
+		sender.Commit()
 		return errHealth
 	}
 	sender.ServiceCheck("containerd.health", metrics.ServiceCheckOK, "", nil, "")
@@ -125,7 +131,13 @@ func (c *ContainerdCheck) Run() error {
 		computeEvents(events, sender, c.filters)
 	}
 
-	computeMetrics(sender, cu, c.filters)
+	// The metrics of the running containers are generated by the generic processor, which commits the sender
+	if err := c.processor.Run(sender, c.Interval()/2); err != nil {
+		log.Warnf("Error collecting container metrics: %s", err)
+		sender.Commit()
+		return err
+	}
+
 	return nil
 }
 
@@ -170,118 +182,19 @@ func computeEvents(events []containerdEvent, sender aggregator.Sender, fil *ddCo
 	}
 }
 
-func computeMetrics(sender aggregator.Sender, cu cutil.ContainerdItf, fil *ddContainers.Filter) {
-	containers, err := cu.Containers()
-	if err != nil {
-		log.Errorf(err.Error())
-		return
-	}
-
-	for _, ctn := range containers {
-		info, err := cu.Info(ctn)
-		if err != nil {
-			log.Errorf("Could not retrieve the metadata of the container: %s", ctn.ID()[:12])
-			continue
-		}
-
-		if isExcluded(info, fil) {
-			continue
-		}
-
-		// we need a running task before calling tagger.Tag. if the
-		// task isn't running, no tags will ever be returned, and it
-		// will cause a lot of unnecessary and expensive cache misses
-		// in the tagger. see 6e2ee06db6e4394b728d527a596d4c3e3393054a
-		// for more context.
-		metricTask, errTask := cu.TaskMetrics(ctn)
-		if errTask != nil {
-			log.Tracef("Could not retrieve metrics from task %s: %s", ctn.ID()[:12], errTask.Error())
-			continue
-		}
-
-		tags, err := collectTags(info)
-		if err != nil {
-			log.Errorf("Could not collect tags for container %s: %s", ctn.ID()[:12], err)
-		}
-
-		// Tagger tags
-		taggerTags, err := tagger.Tag(ddContainers.ContainerEntityPrefix+ctn.ID(), collectors.HighCardinality)
-		if err != nil {
-			log.Errorf(err.Error())
-			continue
-		}
-		tags = append(tags, taggerTags...)
-
-		currentTime := time.Now()
-		computeUptime(sender, info, currentTime, tags)
-
-		anyMetrics, err := typeurl.UnmarshalAny(metricTask.Data)
-		if err != nil {
-			log.Errorf("Can't convert the metrics data from %s", ctn.ID())
-			continue
-		}
-
-		switch metricsVal := anyMetrics.(type) {
-		case *v1.Metrics:
-			computeLinuxSpecificMetrics(metricsVal, sender, cu, ctn, info.CreatedAt, currentTime, tags)
-		case *wstats.Statistics:
-			if windowsMetrics := metricsVal.GetWindows(); windowsMetrics != nil {
-				computeWindowsSpecificMetrics(windowsMetrics, sender, tags)
-			}
-		default:
-			log.Errorf("Can't convert the metrics data from %s", ctn.ID())
-			continue
-		}
-
-		size, err := cu.ImageSize(ctn)
-		if err != nil {
-			log.Errorf("Could not retrieve the size of the image of %s: %v", ctn.ID(), err.Error())
-			continue
-		}
-		sender.Gauge("containerd.image.size", float64(size), "", tags)
-
-		// Collect open file descriptor counts
-		processes, errTask := cu.TaskPids(ctn)
-		if errTask != nil {
-			log.Tracef("Could not retrieve pids from task %s: %s", ctn.ID()[:12], errTask.Error())
-			continue
-		}
-		fileDescCount := 0
-		for _, p := range processes {
-			pid := p.Pid
-			fdCount, err := providers.ContainerImpl().GetNumFileDescriptors(int(pid))
-			if err != nil {
-				log.Debugf("Failed to get file desc length for pid %d, container %s: %s", pid, ctn.ID()[:12], err)
-				continue
-			}
-			fileDescCount += fdCount
-		}
-		sender.Gauge("containerd.proc.open_fds", float64(fileDescCount), "", tags)
-	}
-}
-
-func isExcluded(ctn containers.Container, fil *ddContainers.Filter) bool {
+// isExcluded returns whether a container should be excluded from the metrics
+func isExcluded(ctn *workloadmeta.Container, fil *ddContainers.Filter) bool {
 	if config.Datadog.GetBool("exclude_pause_container") && ddContainers.IsPauseContainer(ctn.Labels) {
 		return true
 	}
 	// The container name is not available in Containerd, we only rely on image name and kube namespace based exclusion
-	return fil.IsExcluded("", ctn.Image, ctn.Labels["io.kubernetes.pod.namespace"])
+	return fil.IsExcluded("", ctn.Image.RawName, ctn.Labels["io.kubernetes.pod.namespace"])
 }
 
-func computeLinuxSpecificMetrics(metrics *v1.Metrics, sender aggregator.Sender, cu cutil.ContainerdItf, ctn containerd.Container, createdAt time.Time, currentTime time.Time, tags []string) {
+// computeLinuxSpecificMetrics submits the cgroup stats that are not generated by the generic processor
+func computeLinuxSpecificMetrics(metrics *v1.Metrics, sender aggregator.Sender, tags []string) {
 	computeMemLinux(sender, metrics.Memory, tags)
 
-	ociSpec, err := cu.Spec(ctn)
-	if err != nil {
-		log.Warnf("Could not retrieve OCI Spec from: %s: %v", ctn.ID(), err)
-	}
-
-	var cpuLimits *specs.LinuxCPU
-	if ociSpec != nil && ociSpec.Linux != nil && ociSpec.Linux.Resources != nil {
-		cpuLimits = ociSpec.Linux.Resources.CPU
-	}
-	computeCPULinux(sender, metrics.CPU, cpuLimits, createdAt, currentTime, tags)
-
 	if metrics.Blkio.Size() > 0 {
 		computeBlkio(sender, metrics.Blkio, tags)
 	}
@@ -291,9 +204,8 @@ func computeLinuxSpecificMetrics(metrics *v1.Metrics, sender aggregator.Sender,
 	}
 }
 
+// computeWindowsSpecificMetrics submits the stats that are not generated by the generic processor
 func computeWindowsSpecificMetrics(windowsStats *wstats.WindowsContainerStatistics, sender aggregator.Sender, tags []string) {
-	computeCPUWindows(sender, windowsStats.Processor, tags)
-	computeMemWindows(sender, windowsStats.Memory, tags)
 	computeStorageWindows(sender, windowsStats.Storage, tags)
 }
 
@@ -324,13 +236,6 @@ func computeHugetlb(sender aggregator.Sender, huge []*v1.HugetlbStat, tags []str
 	}
 }
 
-func computeUptime(sender aggregator.Sender, ctn containers.Container, currentTime time.Time, tags []string) {
-	uptime := currentTime.Sub(ctn.CreatedAt).Seconds()
-	if uptime > 0 {
-		sender.Gauge("containerd.uptime", uptime, "", tags)
-	}
-}
-
 func computeMemLinux(sender aggregator.Sender, mem *v1.MemoryStat, tags []string) {
 	if mem == nil {
 		return
@@ -361,50 +266,6 @@ func parseAndSubmitMem(metricName string, sender aggregator.Sender, stat *v1.Mem
 	sender.Gauge(fmt.Sprintf("%s.max", metricName), float64(stat.Max), "", tags)
 }
 
-func computeMemWindows(sender aggregator.Sender, memStats *wstats.WindowsContainerMemoryStatistics, tags []string) {
-	if memStats == nil {
-		return
-	}
-
-	sender.Gauge("containerd.mem.commit", float64(memStats.MemoryUsageCommitBytes), "", tags)
-	sender.Gauge("containerd.mem.commit_peak", float64(memStats.MemoryUsageCommitPeakBytes), "", tags)
-	sender.Gauge("containerd.mem.private_working_set", float64(memStats.MemoryUsagePrivateWorkingSetBytes), "", tags)
-}
-
-func computeCPULinux(sender aggregator.Sender, cpu *v1.CPUStat, cpuLimits *specs.LinuxCPU, startTime, currentTime time.Time, tags []string) {
-	if cpu == nil || cpu.Usage == nil {
-		return
-	}
-
-	sender.Rate("containerd.cpu.system", float64(cpu.Usage.Kernel), "", tags)
-	sender.Rate("containerd.cpu.total", float64(cpu.Usage.Total), "", tags)
-	sender.Rate("containerd.cpu.user", float64(cpu.Usage.User), "", tags)
-
-	if cpu.Throttling != nil {
-		sender.Rate("containerd.cpu.throttled.periods", float64(cpu.Throttling.ThrottledPeriods), "", tags)
-		sender.Rate("containerd.cpu.throttled.time", float64(cpu.Throttling.ThrottledTime), "", tags)
-	}
-
-	timeDiff := float64(currentTime.Sub(startTime).Nanoseconds()) // cpu.total is in nanoseconds
-	if timeDiff > 0 {
-		cpuLimitPct := float64(system.HostCPUCount())
-		if cpuLimits != nil && cpuLimits.Period != nil && *cpuLimits.Period > 0 && cpuLimits.Quota != nil && *cpuLimits.Quota > 0 {
-			cpuLimitPct = float64(*cpuLimits.Quota) / float64(*cpuLimits.Period)
-		}
-		sender.Rate("containerd.cpu.limit", cpuLimitPct*timeDiff, "", tags)
-	}
-}
-
-func computeCPUWindows(sender aggregator.Sender, cpuStats *wstats.WindowsContainerProcessorStatistics, tags []string) {
-	if cpuStats == nil {
-		return
-	}
-
-	sender.Rate("containerd.cpu.total", float64(cpuStats.TotalRuntimeNS), "", tags)
-	sender.Rate("containerd.cpu.system", float64(cpuStats.RuntimeKernelNS), "", tags)
-	sender.Rate("containerd.cpu.user", float64(cpuStats.RuntimeUserNS), "", tags)
-}
-
 func computeBlkio(sender aggregator.Sender, blkio *v1.BlkIOStat, tags []string) {
 	blkioList := map[string][]*v1.BlkIOEntry{
 		"containerd.blkio.merged_recursive":        blkio.IoMergedRecursive,
@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build containerd
+
+package containerd
+
+import (
+	"time"
+
+	wstats "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/stats"
+	v1 "github.com/containerd/cgroups/stats/v1"
+	"github.com/containerd/typeurl"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/containers/generic"
+	cutil "github.com/DataDog/datadog-agent/pkg/util/containerd"
+	ddContainers "github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+	cmetrics "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+// metricsNameMapping keeps the names of the metrics submitted by the check before it relied on the generic processor.
+// The other generic metrics are not submitted, the memory stats of Linux containers are submitted by the extension.
+var metricsNameMapping = map[string]string{
+	"container.uptime":                "containerd.uptime",
+	"container.cpu.usage":             "containerd.cpu.total",
+	"container.cpu.user":              "containerd.cpu.user",
+	"container.cpu.system":            "containerd.cpu.system",
+	"container.cpu.throttled":         "containerd.cpu.throttled.time",
+	"container.cpu.throttled.periods": "containerd.cpu.throttled.periods",
+	"container.cpu.limit":             "containerd.cpu.limit",
+	"container.memory.working_set":    "containerd.mem.private_working_set",
+	"container.memory.commit":         "containerd.mem.commit",
+	"container.memory.commit.peak":    "containerd.mem.commit_peak",
+}
+
+// containerLister lists the containerd containers that are not excluded
+type containerLister struct {
+	filter *ddContainers.Filter
+}
+
+// List returns the containers known by workloadmeta running with containerd
+func (l containerLister) List() ([]*workloadmeta.Container, error) {
+	allContainers, err := generic.RuntimeContainerLister{
+		Runtimes: []workloadmeta.ContainerRuntime{workloadmeta.ContainerRuntimeContainerd},
+	}.List()
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]*workloadmeta.Container, 0, len(allContainers))
+	for _, container := range allContainers {
+		if !isExcluded(container, l.filter) {
+			containers = append(containers, container)
+		}
+	}
+
+	return containers, nil
+}
+
+// metricsAdapter submits the legacy metrics of the check
+type metricsAdapter struct {
+	cuGetter func() (cutil.ContainerdItf, error)
+}
+
+// AdaptTags adds the image, labels and runtime tags of the container
+func (a metricsAdapter) AdaptTags(tags []string, c *workloadmeta.Container) []string {
+	cu, err := a.cuGetter()
+	if err != nil {
+		log.Debugf("Cannot get the containerd client, tags of container %s will be missing: %s", c.ID, err)
+		return tags
+	}
+
+	ctn, err := cu.Container(c.ID)
+	if err != nil {
+		log.Debugf("Could not retrieve the container %s: %s", c.ID, err)
+		return tags
+	}
+
+	info, err := cu.Info(ctn)
+	if err != nil {
+		log.Debugf("Could not retrieve the metadata of the container %s: %s", c.ID, err)
+		return tags
+	}
+
+	containerTags, err := collectTags(info)
+	if err != nil {
+		log.Errorf("Could not collect tags for container %s: %s", c.ID, err)
+	}
+
+	return append(tags, containerTags...)
+}
+
+// AdaptMetrics renames the generic metrics and drops the ones the check did not submit
+func (a metricsAdapter) AdaptMetrics(metricName string, value float64) (string, float64) {
+	return metricsNameMapping[metricName], value
+}
+
+// containerdCustomMetricsExtension submits the metrics of the check that are not generated by the generic processor
+type containerdCustomMetricsExtension struct {
+	cuGetter func() (cutil.ContainerdItf, error)
+	sender   aggregator.Sender
+	cu       cutil.ContainerdItf
+}
+
+// PreProcess gets the containerd client once per run
+func (e *containerdCustomMetricsExtension) PreProcess(sender aggregator.Sender) {
+	e.sender = sender
+
+	var err error
+	e.cu, err = e.cuGetter()
+	if err != nil {
+		log.Debugf("Cannot get the containerd client, containerd metrics will be missing: %s", err)
+	}
+}
+
+// Process submits the containerd specific metrics of a container
+func (e *containerdCustomMetricsExtension) Process(tags []string, container *workloadmeta.Container, collector cmetrics.Collector, cacheValidity time.Duration) {
+	if e.cu == nil {
+		return
+	}
+
+	ctn, err := e.cu.Container(container.ID)
+	if err != nil {
+		log.Debugf("Could not retrieve the container %s: %s", container.ID, err)
+		return
+	}
+
+	metricTask, err := e.cu.TaskMetrics(ctn)
+	if err != nil {
+		log.Tracef("Could not retrieve metrics from task %s: %s", container.ID[:12], err)
+		return
+	}
+
+	anyMetrics, err := typeurl.UnmarshalAny(metricTask.Data)
+	if err != nil {
+		log.Errorf("Can't convert the metrics data from %s", container.ID)
+		return
+	}
+
+	switch metricsVal := anyMetrics.(type) {
+	case *v1.Metrics:
+		computeLinuxSpecificMetrics(metricsVal, e.sender, tags)
+	case *wstats.Statistics:
+		if windowsMetrics := metricsVal.GetWindows(); windowsMetrics != nil {
+			computeWindowsSpecificMetrics(windowsMetrics, e.sender, tags)
+		}
+	default:
+		log.Errorf("Can't convert the metrics data from %s", container.ID)
+		return
+	}
+
+	size, err := e.cu.ImageSize(ctn)
+	if err != nil {
+		log.Errorf("Could not retrieve the size of the image of %s: %v", container.ID, err.Error())
+		return
+	}
+	e.sender.Gauge("containerd.image.size", float64(size), "", tags)
+
+	// Collect open file descriptor counts
+	processes, err := e.cu.TaskPids(ctn)
+	if err != nil {
+		log.Tracef("Could not retrieve pids from task %s: %s", container.ID[:12], err)
+		return
+	}
+	fileDescCount := 0
+	for _, p := range processes {
+		pid := p.Pid
+		fdCount, err := providers.ContainerImpl().GetNumFileDescriptors(int(pid))
+		if err != nil {
+			log.Debugf("Failed to get file desc length for pid %d, container %s: %s", pid, container.ID[:12], err)
+			continue
+		}
+		fileDescCount += fdCount
+	}
+	e.sender.Gauge("containerd.proc.open_fds", float64(fileDescCount), "", tags)
+}
+
+// PostProcess releases the client and sender of the run
+func (e *containerdCustomMetricsExtension) PostProcess() {
+	e.sender = nil
+	e.cu = nil
+}
@@ -15,7 +15,6 @@ import (
 	wstats "github.com/Microsoft/hcsshim/cmd/containerd-shim-runhcs-v1/stats"
 	v1 "github.com/containerd/cgroups/stats/v1"
 	"github.com/containerd/containerd/containers"
-	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -25,17 +24,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	containersutil "github.com/DataDog/datadog-agent/pkg/util/containers"
-	"github.com/DataDog/datadog-agent/pkg/util/system"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
-func int64Ptr(v int64) *int64 {
-	return &v
-}
-
-func uint64Ptr(v uint64) *uint64 {
-	return &v
-}
-
 // TestCollectTags checks the collectTags method
 func TestCollectTags(t *testing.T) {
 	tests := []struct {
@@ -180,131 +171,6 @@ func TestComputeEvents(t *testing.T) {
 	}
 }
 
-func TestComputeCPULinux(t *testing.T) {
-	containerdCheck := &ContainerdCheck{
-		instance:  &ContainerdConfig{},
-		CheckBase: corechecks.NewCheckBase("containerd"),
-	}
-	mocked := mocksender.NewMockSender(containerdCheck.ID())
-	mocked.SetupAcceptAll()
-	testTime := time.Now()
-
-	tests := []struct {
-		name        string
-		cpu         *v1.CPUStat
-		cpuLimit    *specs.LinuxCPU
-		startTime   time.Time
-		currentTime time.Time
-		expected    map[string]float64
-	}{
-		{
-			name: "CPU Usage, no limits, no throttling",
-			cpu: &v1.CPUStat{
-				Usage: &v1.CPUUsage{
-					Kernel: 10,
-					Total:  40,
-					User:   30,
-				},
-			},
-			startTime:   testTime.Add(-10 * time.Second),
-			currentTime: testTime,
-			expected: map[string]float64{
-				"containerd.cpu.system": 10,
-				"containerd.cpu.total":  40,
-				"containerd.cpu.user":   30,
-				"containerd.cpu.limit":  1e10 * float64(system.HostCPUCount()),
-			},
-		},
-		{
-			name: "CPU Usage, with limits, with throttling",
-			cpu: &v1.CPUStat{
-				Usage: &v1.CPUUsage{
-					Kernel: 10,
-					Total:  40,
-					User:   30,
-				},
-				Throttling: &v1.Throttle{
-					ThrottledPeriods: 1,
-					ThrottledTime:    2,
-				},
-			},
-			cpuLimit: &specs.LinuxCPU{
-				Period: uint64Ptr(100),
-				Quota:  int64Ptr(200),
-			},
-			startTime:   testTime.Add(-10 * time.Second),
-			currentTime: testTime,
-			expected: map[string]float64{
-				"containerd.cpu.system":            10,
-				"containerd.cpu.total":             40,
-				"containerd.cpu.user":              30,
-				"containerd.cpu.limit":             2e10,
-				"containerd.cpu.throttled.periods": 1,
-				"containerd.cpu.throttled.time":    2,
-			},
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			computeCPULinux(mocked, test.cpu, test.cpuLimit, test.startTime, test.currentTime, []string{})
-			for name, val := range test.expected {
-				mocked.AssertMetric(t, "Rate", name, val, "", []string{})
-			}
-		})
-	}
-}
-
-func TestComputeCPUWindows(t *testing.T) {
-	containerdCheck := &ContainerdCheck{
-		instance:  &ContainerdConfig{},
-		CheckBase: corechecks.NewCheckBase("containerd"),
-	}
-	mocked := mocksender.NewMockSender(containerdCheck.ID())
-	mocked.SetupAcceptAll()
-
-	tests := []struct {
-		name                    string
-		cpuStats                *wstats.WindowsContainerProcessorStatistics
-		tags                    []string
-		expectedMetricsReported map[string]float64
-	}{
-		{
-			name: "CPU stats not nil",
-			cpuStats: &wstats.WindowsContainerProcessorStatistics{
-				TotalRuntimeNS:  50,
-				RuntimeUserNS:   10,
-				RuntimeKernelNS: 40,
-			},
-			tags: []string{"foo:bar"},
-			expectedMetricsReported: map[string]float64{
-				"containerd.cpu.total":  50,
-				"containerd.cpu.user":   10,
-				"containerd.cpu.system": 40,
-			},
-		},
-		{
-			name:                    "CPU stats is nil",
-			cpuStats:                nil,
-			tags:                    []string{"foo:bar"},
-			expectedMetricsReported: nil,
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			computeCPUWindows(mocked, test.cpuStats, test.tags)
-
-			if test.cpuStats == nil {
-				mocked.AssertNotCalled(t, "Rate")
-			} else {
-				for metricName, metricVal := range test.expectedMetricsReported {
-					mocked.AssertMetric(t, "Rate", metricName, metricVal, "", test.tags)
-				}
-			}
-		})
-	}
-}
-
 func TestComputeMemLinux(t *testing.T) {
 	containerdCheck := &ContainerdCheck{
 		instance:  &ContainerdConfig{},
@@ -400,57 +266,6 @@ func TestComputeMemLinux(t *testing.T) {
 	}
 }
 
-func TestComputeMemWindows(t *testing.T) {
-	containerdCheck := &ContainerdCheck{
-		instance:  &ContainerdConfig{},
-		CheckBase: corechecks.NewCheckBase("containerd"),
-	}
-	mocked := mocksender.NewMockSender(containerdCheck.ID())
-	mocked.SetupAcceptAll()
-
-	tests := []struct {
-		name                    string
-		memStats                *wstats.WindowsContainerMemoryStatistics
-		tags                    []string
-		expectedMetricsReported map[string]float64
-	}{
-		{
-			name: "Memory stats not nil",
-			memStats: &wstats.WindowsContainerMemoryStatistics{
-				MemoryUsageCommitBytes:            1024,
-				MemoryUsageCommitPeakBytes:        2048,
-				MemoryUsagePrivateWorkingSetBytes: 512,
-			},
-			tags: []string{"foo:bar"},
-			expectedMetricsReported: map[string]float64{
-				"containerd.mem.commit":              1024,
-				"containerd.mem.commit_peak":         2048,
-				"containerd.mem.private_working_set": 512,
-			},
-		},
-		{
-			name:                    "Memory stats is nil",
-			memStats:                nil,
-			tags:                    []string{"foo:bar"},
-			expectedMetricsReported: nil,
-		},
-	}
-
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			computeMemWindows(mocked, test.memStats, test.tags)
-
-			if test.memStats == nil {
-				mocked.AssertNotCalled(t, "Gauge")
-			} else {
-				for metricName, metricVal := range test.expectedMetricsReported {
-					mocked.AssertMetric(t, "Gauge", metricName, metricVal, "", test.tags)
-				}
-			}
-		})
-	}
-}
-
 func TestComputeStorageWindows(t *testing.T) {
 	containerdCheck := &ContainerdCheck{
 		instance:  &ContainerdConfig{},
@@ -500,48 +315,6 @@ func TestComputeStorageWindows(t *testing.T) {
 	}
 }
 
-func TestComputeUptime(t *testing.T) {
-	containerdCheck := &ContainerdCheck{
-		instance:  &ContainerdConfig{},
-		CheckBase: corechecks.NewCheckBase("containerd"),
-	}
-	mocked := mocksender.NewMockSender(containerdCheck.ID())
-	mocked.SetupAcceptAll()
-
-	currentTime := time.Now()
-
-	tests := []struct {
-		name     string
-		ctn      containers.Container
-		expected map[string]float64
-	}{
-		{
-			name: "Normal check",
-			ctn: containers.Container{
-				CreatedAt: currentTime.Add(-60 * time.Second),
-			},
-			expected: map[string]float64{
-				"containerd.uptime": 60.0,
-			},
-		},
-		{
-			name: "Created in the future",
-			ctn: containers.Container{
-				CreatedAt: currentTime.Add(60 * time.Second),
-			},
-			expected: map[string]float64{},
-		},
-	}
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			computeUptime(mocked, test.ctn, currentTime, []string{})
-			for name, val := range test.expected {
-				mocked.AssertMetric(t, "Gauge", name, val, "", []string{})
-			}
-		})
-	}
-}
-
 // TestisExcluded tests the filtering of containers in the compute metrics method
 func TestIsExcluded(t *testing.T) {
 	containerdCheck := &ContainerdCheck{
@@ -555,35 +328,62 @@ func TestIsExcluded(t *testing.T) {
 	defer containersutil.ResetSharedFilter()
 	containerdCheck.filters, err = containersutil.GetSharedMetricFilter()
 	require.NoError(t, err)
-	c := containers.Container{
-		Image: "kubernetes/pause",
+	c := &workloadmeta.Container{
+		Image: workloadmeta.ContainerImage{RawName: "kubernetes/pause"},
 	}
 	// kubernetes/pause is excluded
 	isEc := isExcluded(c, containerdCheck.filters)
 	require.True(t, isEc)
 
-	c = containers.Container{
-		Image: "kubernetes/pawz",
+	c = &workloadmeta.Container{
+		Image: workloadmeta.ContainerImage{RawName: "kubernetes/pawz"},
 	}
 	// kubernetes/pawz although not an available image (yet ?) is not ignored
 	isEc = isExcluded(c, containerdCheck.filters)
 	require.False(t, isEc)
 
 	// Namespace based filtering
-	c = containers.Container{
-		Image: "kubernetes/pawz",
-		Labels: map[string]string{
-			"io.kubernetes.pod.namespace": "shouldexclude",
+	c = &workloadmeta.Container{
+		Image: workloadmeta.ContainerImage{RawName: "kubernetes/pawz"},
+		EntityMeta: workloadmeta.EntityMeta{
+			Labels: map[string]string{
+				"io.kubernetes.pod.namespace": "shouldexclude",
+			},
 		},
 	}
 	require.True(t, isExcluded(c, containerdCheck.filters))
 
 	// Pause container filtering
-	c = containers.Container{
-		Image: "foo",
-		Labels: map[string]string{
-			"io.kubernetes.pod.name": "foo",
+	c = &workloadmeta.Container{
+		Image: workloadmeta.ContainerImage{RawName: "foo"},
+		EntityMeta: workloadmeta.EntityMeta{
+			Labels: map[string]string{
+				"io.kubernetes.pod.name": "foo",
+			},
 		},
 	}
 	require.True(t, isExcluded(c, containerdCheck.filters))
 }
+
+func TestMetricsAdapter(t *testing.T) {
+	adapter := metricsAdapter{}
+
+	tests := []struct {
+		metricName string
+		expected   string
+	}{
+		{"container.uptime", "containerd.uptime"},
+		{"container.cpu.usage", "containerd.cpu.total"},
+		{"container.cpu.throttled", "containerd.cpu.throttled.time"},
+		{"container.memory.commit", "containerd.mem.commit"},
+		{"container.memory.rss", ""},
+		{"container.io.read", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.metricName, func(t *testing.T) {
+			name, value := adapter.AdaptMetrics(test.metricName, 42)
+			assert.Equal(t, test.expected, name)
+			assert.Equal(t, float64(42), value)
+		})
+	}
+}
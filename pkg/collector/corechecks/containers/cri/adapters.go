@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"time"
+
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/containers/generic"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/cri"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics"
+	criMetrics "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics/cri"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+// metricsNameMapping keeps the names of the metrics submitted by the check before it relied on the generic processor.
+// The other generic metrics are not submitted.
+var metricsNameMapping = map[string]string{
+	"container.uptime":     "cri.uptime",
+	"container.cpu.usage":  "cri.cpu.usage",
+	"container.memory.rss": "cri.mem.rss",
+}
+
+// criProvider always returns the CRI collector, whatever the runtime and the collectors available,
+// so that the check keeps reporting the stats exposed by the CRI (e.g. the working set as `cri.mem.rss`)
+type criProvider struct {
+	collector metrics.Collector
+}
+
+// GetCollector returns the CRI collector, creating it on the first successful call
+func (p *criProvider) GetCollector(runtime string) metrics.Collector {
+	if p.collector == nil {
+		collector, err := criMetrics.NewCollector()
+		if err != nil {
+			log.Debugf("Cannot create the CRI metrics collector: %s", err)
+			return nil
+		}
+		p.collector = collector
+	}
+
+	return p.collector
+}
+
+// RegisterCollector is a no-op, the check only relies on the CRI collector
+func (p *criProvider) RegisterCollector(collectorMeta metrics.CollectorMetadata) {}
+
+// containerLister lists the containers of the runtime behind the CRI socket
+type containerLister struct {
+	criGetter func() (cri.CRIClient, error)
+}
+
+// List returns the containers known by workloadmeta running with the CRI runtime
+func (l containerLister) List() ([]*workloadmeta.Container, error) {
+	criUtil, err := l.criGetter()
+	if err != nil {
+		return nil, err
+	}
+
+	return generic.RuntimeContainerLister{
+		Runtimes: []workloadmeta.ContainerRuntime{workloadmeta.ContainerRuntime(criUtil.GetRuntime())},
+	}.List()
+}
+
+// metricsAdapter submits the legacy metrics of the check
+type metricsAdapter struct {
+	generic.GenericMetricsAdapter
+}
+
+// AdaptMetrics renames the generic metrics and drops the ones the check did not submit
+func (a metricsAdapter) AdaptMetrics(metricName string, value float64) (string, float64) {
+	return metricsNameMapping[metricName], value
+}
+
+// diskMetricsExtension submits the writable layer usage of containers, only available through the CRI
+type diskMetricsExtension struct {
+	criGetter func() (cri.CRIClient, error)
+	sender    aggregator.Sender
+	stats     map[string]*pb.ContainerStats
+}
+
+// PreProcess lists the stats of all containers once per run
+func (e *diskMetricsExtension) PreProcess(sender aggregator.Sender) {
+	e.sender = sender
+	e.stats = nil
+
+	criUtil, err := e.criGetter()
+	if err != nil {
+		log.Debugf("Cannot get the CRI client, disk metrics will be missing: %s", err)
+		return
+	}
+
+	e.stats, err = criUtil.ListContainerStats()
+	if err != nil {
+		log.Debugf("Cannot get containers stats from the CRI, disk metrics will be missing: %s", err)
+	}
+}
+
+// Process submits the disk metrics of a container
+func (e *diskMetricsExtension) Process(tags []string, container *workloadmeta.Container, collector metrics.Collector, cacheValidity time.Duration) {
+	stats, found := e.stats[container.ID]
+	if !found || stats == nil {
+		return
+	}
+
+	e.sender.Gauge("cri.disk.used", float64(stats.GetWritableLayer().GetUsedBytes().GetValue()), "", tags)
+	e.sender.Gauge("cri.disk.inodes", float64(stats.GetWritableLayer().GetInodesUsed().GetValue()), "", tags)
+}
+
+// PostProcess releases the stats of the run
+func (e *diskMetricsExtension) PostProcess() {
+	e.stats = nil
+}
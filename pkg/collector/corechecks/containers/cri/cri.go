@@ -8,21 +8,15 @@
 package cri
 
 import (
-	"time"
-
 	yaml "gopkg.in/yaml.v2"
-	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
-	"github.com/DataDog/datadog-agent/pkg/config"
-	"github.com/DataDog/datadog-agent/pkg/tagger"
-	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/containers/generic"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/containers/cri"
-	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 const (
@@ -37,8 +31,8 @@ type CRIConfig struct {
 // CRICheck grabs CRI metrics
 type CRICheck struct {
 	core.CheckBase
-	instance *CRIConfig
-	filter   *containers.Filter
+	instance  *CRIConfig
+	processor generic.Processor
 }
 
 func init() {
@@ -72,98 +66,34 @@ func (c *CRICheck) Configure(config, initConfig integration.Data, source string)
 	if err != nil {
 		return err
 	}
-	c.filter = filter
-
-	return c.instance.Parse(config)
-}
 
-// Run executes the check
-func (c *CRICheck) Run() error {
-	sender, err := aggregator.GetSender(c.ID())
-	if err != nil {
+	if err = c.instance.Parse(config); err != nil {
 		return err
 	}
 
-	util, err := cri.GetUtil()
-	if err != nil {
-		c.Warnf("Error initialising check: %s", err) //nolint:errcheck
-		return err
+	criGetter := func() (cri.CRIClient, error) {
+		return cri.GetUtil()
 	}
 
-	containerStats, err := util.ListContainerStats()
-	if err != nil {
-		c.Warnf("Cannot get containers from the CRI: %s", err) //nolint:errcheck
-		return err
-	}
-	c.generateMetrics(sender, containerStats, util)
-
-	sender.Commit()
-	return nil
-}
-
-func (c *CRICheck) generateMetrics(sender aggregator.Sender, containerStats map[string]*pb.ContainerStats, criUtil cri.CRIClient) {
-	for cid, stats := range containerStats {
-		if stats == nil {
-			log.Warnf("Missing stats for container: %s", cid)
-			continue
-		}
-
-		ctnStatus, err := criUtil.GetContainerStatus(cid)
-		if err != nil {
-			log.Debugf("Could not retrieve the status of container %q: %s", cid, err)
-			continue
-		}
-
-		if ctnStatus == nil || ctnStatus.State != pb.ContainerState_CONTAINER_RUNNING || c.isExcluded(ctnStatus) {
-			continue
-		}
-
-		entityID := containers.BuildTaggerEntityName(cid)
-		tags, err := tagger.Tag(entityID, collectors.HighCardinality)
-		if err != nil {
-			log.Errorf("Could not collect tags for container %s: %s", cid[:12], err)
-		}
-		tags = append(tags, "runtime:"+criUtil.GetRuntime())
-
-		currentUnixTime := time.Now().UnixNano()
-		c.computeContainerUptime(sender, currentUnixTime, *ctnStatus, tags)
-
-		c.processContainerStats(sender, *stats, tags)
-	}
-}
-
-// processContainerStats extracts metrics from the protobuf object
-func (c *CRICheck) processContainerStats(sender aggregator.Sender, stats pb.ContainerStats, tags []string) {
-	sender.Gauge("cri.mem.rss", float64(stats.GetMemory().GetWorkingSetBytes().GetValue()), "", tags)
-	// Cumulative CPU usage (sum across all cores) since object creation.
-	sender.Rate("cri.cpu.usage", float64(stats.GetCpu().GetUsageCoreNanoSeconds().GetValue()), "", tags)
+	c.processor = generic.NewProcessor(&criProvider{}, containerLister{criGetter: criGetter}, metricsAdapter{}, filter)
 	if c.instance.CollectDisk {
-		sender.Gauge("cri.disk.used", float64(stats.GetWritableLayer().GetUsedBytes().GetValue()), "", tags)
-		sender.Gauge("cri.disk.inodes", float64(stats.GetWritableLayer().GetInodesUsed().GetValue()), "", tags)
+		c.processor.RegisterExtension("cri-disk-metrics", &diskMetricsExtension{criGetter: criGetter})
 	}
-}
 
-func (c *CRICheck) computeContainerUptime(sender aggregator.Sender, currentTime int64, ctnStatus pb.ContainerStatus, tags []string) {
-	if ctnStatus.StartedAt != 0 && currentTime-ctnStatus.StartedAt > 0 {
-		sender.Gauge("cri.uptime", float64((currentTime-ctnStatus.StartedAt)/int64(time.Second)), "", tags)
-	}
+	return nil
 }
 
-// isExcluded returns whether a container should be excluded based on its image, name and namespace
-func (c *CRICheck) isExcluded(ctr *pb.ContainerStatus) bool {
-	if config.Datadog.GetBool("exclude_pause_container") && containers.IsPauseContainer(ctr.Labels) {
-		return true
-	}
-
-	name := ""
-	if meta := ctr.GetMetadata(); meta != nil {
-		name = meta.GetName()
+// Run executes the check
+func (c *CRICheck) Run() error {
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
 	}
 
-	image := ""
-	if imSpec := ctr.GetImage(); imSpec != nil {
-		image = imSpec.GetImage()
+	if err := c.processor.Run(sender, c.Interval()/2); err != nil {
+		c.Warnf("Cannot get containers from the CRI: %s", err) //nolint:errcheck
+		return err
 	}
 
-	return c.filter.IsExcluded(name, image, ctr.GetLabels()["io.kubernetes.pod.namespace"])
+	return nil
 }
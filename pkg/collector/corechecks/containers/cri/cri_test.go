@@ -9,139 +9,68 @@ package cri
 
 import (
 	"testing"
-	"time"
-
-	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
-	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
-	"github.com/DataDog/datadog-agent/pkg/config"
-	containers "github.com/DataDog/datadog-agent/pkg/util/containers"
-	"github.com/DataDog/datadog-agent/pkg/util/containers/cri/crimock"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"github.com/stretchr/testify/require"
 	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
-)
-
-type generateMetricsTest struct {
-	name        string
-	stats       *pb.ContainerStats
-	status      *pb.ContainerStatus
-	senderCalls []senderCall
-}
-
-type senderCall struct {
-	method string
-	args   []interface{}
-}
 
-func TestCriGenerateMetrics(t *testing.T) {
-	criCheck := &CRICheck{
-		CheckBase: core.NewCheckBase(criCheckName),
-		instance: &CRIConfig{
-			CollectDisk: true,
-		},
-	}
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/cri"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/cri/crimock"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
 
-	tags := []string{"runtime:fakeruntime"}
-	tests := []generateMetricsTest{
-		{
-			name:  "container running generates metrics",
-			stats: &pb.ContainerStats{},
-			status: &pb.ContainerStatus{
-				State:     pb.ContainerState_CONTAINER_RUNNING,
-				StartedAt: time.Now().UnixNano() - int64(42*time.Second),
-			},
-			senderCalls: []senderCall{
-				{"Gauge", []interface{}{"cri.mem.rss", float64(0), "", tags}},
-				{"Rate", []interface{}{"cri.cpu.usage", float64(0), "", tags}},
-				{"Gauge", []interface{}{"cri.disk.used", float64(0), "", tags}},
-				{"Gauge", []interface{}{"cri.disk.inodes", float64(0), "", tags}},
-				{"Gauge", []interface{}{"cri.uptime", mock.MatchedBy(func(uptime float64) bool { return uptime >= 42.0 }), "", tags}},
-			},
-		},
-		{
-			name:  "container not running does not generate metrics",
-			stats: &pb.ContainerStats{},
-			status: &pb.ContainerStatus{
-				State: pb.ContainerState_CONTAINER_EXITED,
-			},
-		},
+func TestMetricsAdapter(t *testing.T) {
+	adapter := metricsAdapter{}
+
+	tests := []struct {
+		metricName string
+		expected   string
+	}{
+		{"container.uptime", "cri.uptime"},
+		{"container.cpu.usage", "cri.cpu.usage"},
+		{"container.memory.rss", "cri.mem.rss"},
+		{"container.memory.usage", ""},
+		{"container.io.read.operations", ""},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			const cid = "cri://foobar"
-
-			var err error
-			criCheck.filter, err = containers.GetSharedMetricFilter()
-			defer containers.ResetSharedFilter()
-
-			require.NoError(t, err)
-
-			stats := map[string]*pb.ContainerStats{
-				cid: tt.stats,
-			}
-
-			mockedCriUtil := new(crimock.MockCRIClient)
-			mockedCriUtil.On("GetContainerStatus", cid).Return(tt.status, nil)
-
-			mockedSender := mocksender.NewMockSender(criCheck.ID())
-			for _, call := range tt.senderCalls {
-				mockedSender.On(call.method, call.args...)
-			}
-
-			criCheck.generateMetrics(mockedSender, stats, mockedCriUtil)
-
-			mock.AssertExpectationsForObjects(t, mockedCriUtil, mockedSender)
+		t.Run(tt.metricName, func(t *testing.T) {
+			name, value := adapter.AdaptMetrics(tt.metricName, 42)
+			assert.Equal(t, tt.expected, name)
+			assert.Equal(t, float64(42), value)
 		})
 	}
-
 }
 
-func TestExcludedContainers(t *testing.T) {
-	criCheck := &CRICheck{
-		CheckBase: core.NewCheckBase(criCheckName),
-		instance: &CRIConfig{
-			CollectDisk: true,
+func TestDiskMetricsExtension(t *testing.T) {
+	tags := []string{"runtime:fakeruntime"}
+
+	mockedCriUtil := new(crimock.MockCRIClient)
+	mockedCriUtil.On("ListContainerStats").Return(map[string]*pb.ContainerStats{
+		"cID1": {
+			WritableLayer: &pb.FilesystemUsage{
+				UsedBytes:  &pb.UInt64Value{Value: 2048},
+				InodesUsed: &pb.UInt64Value{Value: 42},
+			},
 		},
-	}
+	}, nil)
 
-	ctr := &pb.ContainerStatus{
-		Labels:   map[string]string{"io.kubernetes.pod.namespace": "foo-ns"},
-		Image:    &pb.ImageSpec{Image: "foo-image"},
-		Metadata: &pb.ContainerMetadata{Name: "foo-name"},
-	}
+	mockedSender := mocksender.NewMockSender("cri")
+	mockedSender.On("Gauge", "cri.disk.used", float64(2048), "", tags)
+	mockedSender.On("Gauge", "cri.disk.inodes", float64(42), "", tags)
 
-	pauseCtr := &pb.ContainerStatus{
-		Labels:   map[string]string{"io.kubernetes.container.name": "POD"},
-		Image:    &pb.ImageSpec{Image: "pod"},
-		Metadata: &pb.ContainerMetadata{Name: "pod"},
+	extension := &diskMetricsExtension{
+		criGetter: func() (cri.CRIClient, error) {
+			return mockedCriUtil, nil
+		},
 	}
 
-	// Namespace based exclusion
-	config.Datadog.Set("container_exclude", "kube_namespace:foo*")
-	containers.ResetSharedFilter()
-	criCheck.filter, _ = containers.GetSharedMetricFilter()
-	require.True(t, criCheck.isExcluded(ctr))
-
-	// Container name based exclusion
-	config.Datadog.Set("container_exclude", "name:foo*")
-	containers.ResetSharedFilter()
-	criCheck.filter, _ = containers.GetSharedMetricFilter()
-	require.True(t, criCheck.isExcluded(ctr))
-
-	// Image based exclusion
-	config.Datadog.Set("container_exclude", "image:foo*")
-	containers.ResetSharedFilter()
-	criCheck.filter, _ = containers.GetSharedMetricFilter()
-	require.True(t, criCheck.isExcluded(ctr))
-
-	// Pause container exclusion
-	require.True(t, criCheck.isExcluded(pauseCtr))
+	extension.PreProcess(mockedSender)
+	extension.Process(tags, &workloadmeta.Container{EntityID: workloadmeta.EntityID{ID: "cID1"}}, nil, 0)
+	// Containers unknown to the CRI do not get disk metrics
+	extension.Process(tags, &workloadmeta.Container{EntityID: workloadmeta.EntityID{ID: "cID2"}}, nil, 0)
+	extension.PostProcess()
 
-	// Container not excluded
-	config.Datadog.Set("container_exclude", "image:bar* name:bar* kube_namespace:bar*")
-	containers.ResetSharedFilter()
-	criCheck.filter, _ = containers.GetSharedMetricFilter()
-	require.False(t, criCheck.isExcluded(ctr))
+	mock.AssertExpectationsForObjects(t, mockedCriUtil, mockedSender)
+	mockedSender.AssertNumberOfCalls(t, "Gauge", 2)
 }
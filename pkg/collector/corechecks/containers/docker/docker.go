@@ -16,18 +16,23 @@ import (
 	"strings"
 	"time"
 
+	"github.com/docker/docker/api/types"
+
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/containers/generic"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
-	cmetrics "github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
+	cmetrics "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/docker"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
 const dockerCheckName = "docker"
@@ -48,9 +53,12 @@ type DockerCheck struct {
 	collectContainerSizeCounter uint64
 	containerFilter             *containers.Filter
 	okExitCodes                 map[int]struct{}
+	processor                   generic.Processor
+	customMetricsExtension      *dockerCustomMetricsExtension
 }
 
-func updateContainerRunningCount(images map[string]*containerPerImage, c *containers.Container) {
+// updateContainerRunningCount counts the container with the containers of the same image and returns its tags
+func updateContainerRunningCount(images map[string]*containerPerImage, c *containers.Container) []string {
 	var containerTags []string
 	var err error
 
@@ -65,7 +73,7 @@ func updateContainerRunningCount(images map[string]*containerPerImage, c *contai
 		long, short, tag, err := containers.SplitImageName(c.Image)
 		if err != nil {
 			log.Errorf("Cannot split the image name %s: %v", c.Image, err)
-			return
+			return nil
 		}
 		containerTags = []string{
 			fmt.Sprintf("docker_image:%s", c.Image),
@@ -77,7 +85,7 @@ func updateContainerRunningCount(images map[string]*containerPerImage, c *contai
 		containerTags, err = tagger.Tag(c.EntityID, collectors.LowCardinality)
 		if err != nil {
 			log.Errorf("Could not collect tags for container %s: %s", c.ID[:12], err)
-			return
+			return nil
 		}
 		sort.Strings(containerTags)
 	}
@@ -92,6 +100,8 @@ func updateContainerRunningCount(images map[string]*containerPerImage, c *contai
 	} else if c.State == containers.ContainerExitedState {
 		images[key].stopped++
 	}
+
+	return containerTags
 }
 
 func (d *DockerCheck) countAndWeightImages(sender aggregator.Sender, imageTagsByImageID map[string][]string, du *docker.DockerUtil) error {
@@ -136,130 +146,68 @@ func (d *DockerCheck) Run() error {
 		d.Warnf("Error initialising check: %s", err) //nolint:errcheck
 		return err
 	}
-	cList, err := du.ListContainers(context.TODO(), &docker.ContainerListConfig{IncludeExited: true, FlagExcluded: true})
+	rawContainerList, err := du.RawContainerList(context.TODO(), types.ContainerListOptions{All: true})
 	if err != nil {
 		sender.ServiceCheck(DockerServiceUp, metrics.ServiceCheckCritical, "", nil, err.Error())
 		d.Warnf("Error collecting containers: %s", err) //nolint:errcheck
 		return err
 	}
 
-	collectingContainerSizeDuringThisRun := d.instance.CollectContainerSize && d.collectContainerSizeCounter == 0
-
-	imageTagsByImageID := make(map[string][]string)
-	images := map[string]*containerPerImage{}
-	for _, c := range cList {
-		updateContainerRunningCount(images, c)
-		if c.State != containers.ContainerRunningState || c.Excluded {
-			continue
-		}
-		tags, err := tagger.Tag(c.EntityID, collectors.HighCardinality)
-		if err != nil {
-			log.Errorf("Could not collect tags for container %s: %s", c.ID[:12], err)
-		}
-		// Track image_name and image_tag tags by image for use in countAndWeightImages
-		for _, t := range tags {
-			if strings.HasPrefix(t, "image_name:") || strings.HasPrefix(t, "image_tag:") {
-				if _, found := imageTagsByImageID[c.ImageID]; !found {
-					imageTagsByImageID[c.ImageID] = []string{t}
-				} else {
-					imageTagsByImageID[c.ImageID] = append(imageTagsByImageID[c.ImageID], t)
-				}
-			}
-		}
-
-		currentUnixTime := time.Now().Unix()
-		d.reportUptime(c.StartedAt, currentUnixTime, tags, sender)
-
-		if c.CPU != nil {
-			d.reportCPUMetrics(c.CPU, &c.Limits, c.StartedAt, tags, sender)
-		} else {
-			log.Debugf("Empty CPU metrics for container %s", c.ID[:12])
-		}
-
-		if c.Memory != nil {
-			sender.Gauge("docker.mem.cache", float64(c.Memory.Cache), "", tags)
-			sender.Gauge("docker.mem.rss", float64(c.Memory.RSS), "", tags)
-			if c.Memory.SwapPresent == true {
-				sender.Gauge("docker.mem.swap", float64(c.Memory.Swap), "", tags)
-			}
+	if err := d.runDockerCustom(sender, du, rawContainerList); err != nil {
+		return err
+	}
 
-			if c.Memory.HierarchicalMemoryLimit > 0 && c.Memory.HierarchicalMemoryLimit < uint64(math.Pow(2, 60)) {
-				sender.Gauge("docker.mem.limit", float64(c.Memory.HierarchicalMemoryLimit), "", tags)
-				sender.Gauge("docker.mem.in_use", float64(c.Memory.RSS)/float64(c.Memory.HierarchicalMemoryLimit), "", tags)
-			} else if c.Limits.MemLimit > 0 && c.Memory.CommitBytes > 0 {
-				// On Windows the mem limit is in container limits
-				sender.Gauge("docker.mem.limit", float64(c.Limits.MemLimit), "", tags)
-				sender.Gauge("docker.mem.in_use", float64(c.Memory.CommitBytes)/float64(c.Limits.MemLimit), "", tags)
-			}
+	// The metrics of the running containers are generated by the generic processor, which commits the sender
+	d.customMetricsExtension.du = du
+	d.customMetricsExtension.networkNames = du.GetContainerNetworkNames(context.TODO(), rawContainerList)
+	d.customMetricsExtension.collectContainerSize = d.instance.CollectContainerSize && d.collectContainerSizeCounter == 0
+	if d.instance.CollectContainerSize {
+		// Update the container size counter, used to collect them less often as they are costly
+		d.collectContainerSizeCounter =
+			(d.collectContainerSizeCounter + 1) % d.instance.CollectContainerSizeFreq
+	}
 
-			sender.Gauge("docker.mem.failed_count", float64(c.Memory.MemFailCnt), "", tags)
-			if c.Memory.HierarchicalMemSWLimit > 0 && c.Memory.HierarchicalMemSWLimit < uint64(math.Pow(2, 60)) {
-				sender.Gauge("docker.mem.sw_limit", float64(c.Memory.HierarchicalMemSWLimit), "", tags)
-				if c.Memory.HierarchicalMemSWLimit != 0 {
-					sender.Gauge("docker.mem.sw_in_use",
-						float64(c.Memory.Swap+c.Memory.RSS)/float64(c.Memory.HierarchicalMemSWLimit), "", tags)
-				}
-			}
+	if err := d.processor.Run(sender, d.Interval()/2); err != nil {
+		d.Warnf("Error collecting container metrics: %s", err) //nolint:errcheck
+		sender.Commit()
+		return err
+	}
 
-			sender.Gauge("docker.kmem.usage", float64(c.Memory.KernMemUsage), "", tags)
-			if c.Memory.SoftMemLimit > 0 && c.Memory.SoftMemLimit < uint64(math.Pow(2, 60)) {
-				sender.Gauge("docker.mem.soft_limit", float64(c.Memory.SoftMemLimit), "", tags)
-			}
+	return nil
+}
 
-			if c.Memory.PrivateWorkingSet > 0 {
-				sender.Gauge("docker.mem.private_working_set", float64(c.Memory.PrivateWorkingSet), "", tags)
-			}
-			if c.Memory.CommitBytes > 0 {
-				sender.Gauge("docker.mem.commit_bytes", float64(c.Memory.CommitBytes), "", tags)
-			}
-			if c.Memory.CommitPeakBytes > 0 {
-				sender.Gauge("docker.mem.commit_peak_bytes", float64(c.Memory.CommitPeakBytes), "", tags)
-			}
-		} else {
-			log.Debugf("Empty memory metrics for container %s", c.ID[:12])
+// runDockerCustom submits the metrics and events that are not related to the stats of running containers
+func (d *DockerCheck) runDockerCustom(sender aggregator.Sender, du *docker.DockerUtil, rawContainerList []types.Container) error {
+	imageTagsByImageID := make(map[string][]string)
+	images := map[string]*containerPerImage{}
+	for _, rawContainer := range rawContainerList {
+		if len(rawContainer.Names) == 0 {
+			continue
 		}
 
-		if c.IO != nil {
-			d.reportIOMetrics(c.IO, tags, sender)
-		} else {
-			log.Debugf("Empty IO metrics for container %s", c.ID[:12])
+		image, err := du.ResolveImageName(context.TODO(), rawContainer.Image)
+		if err != nil {
+			log.Debugf("Can't resolve image name %s: %s", rawContainer.Image, err)
 		}
 
-		if c.Limits.ThreadLimit != 0 {
-			sender.Gauge("docker.thread.limit", float64(c.Limits.ThreadLimit), "", tags)
+		c := &containers.Container{
+			ID:       rawContainer.ID,
+			EntityID: docker.ContainerIDToTaggerEntityName(rawContainer.ID),
+			Image:    image,
+			ImageID:  rawContainer.ImageID,
+			State:    rawContainer.State,
+			Excluded: d.isExcluded(rawContainer.Names[0], image, rawContainer.Labels),
 		}
+		containerTags := updateContainerRunningCount(images, c)
 
-		if c.Network != nil {
-			for _, netStat := range c.Network {
-				if netStat.NetworkName == "" {
-					log.Debugf("Ignore network stat with empty name for container %s", c.ID[:12])
-					continue
+		// Track image_name and image_tag tags by image for use in countAndWeightImages
+		if c.State == containers.ContainerRunningState && !c.Excluded {
+			for _, t := range containerTags {
+				if strings.HasPrefix(t, "image_name:") || strings.HasPrefix(t, "image_tag:") {
+					imageTagsByImageID[c.ImageID] = append(imageTagsByImageID[c.ImageID], t)
 				}
-				ifaceTags := append(tags, fmt.Sprintf("docker_network:%s", netStat.NetworkName))
-				sender.Rate("docker.net.bytes_sent", float64(netStat.BytesSent), "", ifaceTags)
-				sender.Rate("docker.net.bytes_rcvd", float64(netStat.BytesRcvd), "", ifaceTags)
 			}
-		} else {
-			log.Debugf("Empty network metrics for container %s", c.ID[:12])
 		}
-
-		if collectingContainerSizeDuringThisRun {
-			info, err := du.Inspect(context.TODO(), c.ID, true)
-			if err != nil {
-				log.Errorf("Failed to inspect container %s - %s", c.ID[:12], err)
-			} else if info.SizeRw == nil || info.SizeRootFs == nil {
-				log.Warnf("Docker inspect did not return the container size: %s", c.ID[:12])
-			} else {
-				sender.Gauge("docker.container.size_rw", float64(*info.SizeRw), "", tags)
-				sender.Gauge("docker.container.size_rootfs", float64(*info.SizeRootFs), "", tags)
-			}
-		}
-	}
-
-	if d.instance.CollectContainerSize {
-		// Update the container size counter, used to collect them less often as they are costly
-		d.collectContainerSizeCounter =
-			(d.collectContainerSizeCounter + 1) % d.instance.CollectContainerSizeFreq
 	}
 
 	var totalRunning, totalStopped int64
@@ -334,76 +282,16 @@ func (d *DockerCheck) Run() error {
 		}
 	}
 
-	sender.Commit()
 	return nil
 }
 
-func (d *DockerCheck) reportUptime(startTime, currentUnixTime int64, tags []string, sender aggregator.Sender) {
-	if startTime != 0 && currentUnixTime-startTime > 0 {
-		sender.Gauge("docker.uptime", float64(currentUnixTime-startTime), "", tags)
-	}
-}
-
-func (d *DockerCheck) reportCPUMetrics(cpu *cmetrics.ContainerCPUStats, limits *cmetrics.ContainerLimits, startTime int64, tags []string, sender aggregator.Sender) {
-	if cpu == nil {
-		return
-	}
-
-	if cpu.System != -1 {
-		sender.Rate("docker.cpu.system", cpu.System, "", tags)
-	}
-
-	if cpu.User != -1 {
-		sender.Rate("docker.cpu.user", cpu.User, "", tags)
-	}
-
-	if cpu.UsageTotal != -1 {
-		sender.Rate("docker.cpu.usage", cpu.UsageTotal, "", tags)
-	}
-
-	if cpu.Shares != 0 {
-		sender.Gauge("docker.cpu.shares", cpu.Shares, "", tags)
-	}
-
-	sender.Rate("docker.cpu.throttled", float64(cpu.NrThrottled), "", tags)
-	sender.Rate("docker.cpu.throttled.time", cpu.ThrottledTime, "", tags)
-	if cpu.ThreadCount != 0 {
-		sender.Gauge("docker.thread.count", float64(cpu.ThreadCount), "", tags)
-	}
-
-	// limits.CPULimit is a percentage (i.e. 100.0%, not 1.0)
-	timeDiff := cpu.Timestsamp.Unix() - startTime
-	if limits.CPULimit > 0 && timeDiff > 0 {
-		availableCPUTimeHz := 100 * float64(timeDiff) // Converted to Hz to be consistent with UsageTotal
-		sender.Rate("docker.cpu.limit", limits.CPULimit/100*availableCPUTimeHz, "", tags)
-	}
-}
-
-func (d *DockerCheck) reportIOMetrics(io *cmetrics.ContainerIOStats, tags []string, sender aggregator.Sender) {
-	if io == nil {
-		return
+// isExcluded returns whether a container is excluded from the metrics, like the generic processor does
+func (d *DockerCheck) isExcluded(name, image string, labels map[string]string) bool {
+	if config.Datadog.GetBool("exclude_pause_container") && containers.IsPauseContainer(labels) {
+		return true
 	}
 
-	reportDeviceStat := func(metricName string, deviceMap map[string]uint64, fallbackValue uint64) {
-		if len(deviceMap) > 0 {
-			for dev, value := range deviceMap {
-				sender.Rate(metricName, float64(value), "", append(tags, "device:"+dev, "device_name:"+dev))
-			}
-		} else {
-			sender.Rate(metricName, float64(fallbackValue), "", tags)
-		}
-	}
-
-	// Throughput
-	reportDeviceStat("docker.io.read_bytes", io.DeviceReadBytes, io.ReadBytes)
-	reportDeviceStat("docker.io.write_bytes", io.DeviceWriteBytes, io.WriteBytes)
-
-	// IOPS
-	reportDeviceStat("docker.io.read_operations", io.DeviceReadOperations, io.ReadOperations)
-	reportDeviceStat("docker.io.write_operations", io.DeviceWriteOperations, io.WriteOperations)
-
-	// Collect open file descriptor counts
-	sender.Gauge("docker.container.open_fds", float64(io.OpenFiles), "", tags)
+	return d.containerFilter.IsExcluded(name, image, labels["io.kubernetes.pod.namespace"])
 }
 
 // Configure parses the check configuration and init the check
@@ -429,11 +317,17 @@ func (d *DockerCheck) Configure(config, initConfig integration.Data, source stri
 
 	d.containerFilter, err = containers.GetSharedMetricFilter()
 	if err != nil {
-		log.Warnf("Can't get container include/exclude filter, no filtering will be applied: %w", err)
+		return err
 	}
 
 	d.setOkExitCodes()
 
+	d.customMetricsExtension = &dockerCustomMetricsExtension{}
+	d.processor = generic.NewProcessor(cmetrics.GetProvider(), generic.RuntimeContainerLister{
+		Runtimes: []workloadmeta.ContainerRuntime{workloadmeta.ContainerRuntimeDocker},
+	}, metricsAdapter{}, d.containerFilter)
+	d.processor.RegisterExtension("docker-custom-metrics", d.customMetricsExtension)
+
 	return nil
 }
 
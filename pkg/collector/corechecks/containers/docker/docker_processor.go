@@ -0,0 +1,262 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build docker,!darwin
+
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+	cmetrics "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+const (
+	// nanoToUserHZDivisor converts the CPU times in nanoseconds to the USER_HZ unit (1/100th of second)
+	// used by the check before it relied on the generic processor
+	nanoToUserHZDivisor float64 = 1e9 / 100
+	// memoryUnlimitedValue is above the values reported for memory limits when there is no limit
+	memoryUnlimitedValue float64 = 1 << 60
+)
+
+// metricsNameMapping keeps the names of the metrics submitted by the check before it relied on the generic processor.
+// The other generic metrics are not submitted.
+var metricsNameMapping = map[string]string{
+	"container.uptime":                "docker.uptime",
+	"container.cpu.usage":             "docker.cpu.usage",
+	"container.cpu.user":              "docker.cpu.user",
+	"container.cpu.system":            "docker.cpu.system",
+	"container.cpu.throttled":         "docker.cpu.throttled.time",
+	"container.cpu.throttled.periods": "docker.cpu.throttled",
+	"container.cpu.limit":             "docker.cpu.limit",
+	"container.memory.kernel":         "docker.kmem.usage",
+	"container.memory.limit":          "docker.mem.limit",
+	"container.memory.soft_limit":     "docker.mem.soft_limit",
+	"container.memory.rss":            "docker.mem.rss",
+	"container.memory.cache":          "docker.mem.cache",
+	"container.memory.swap":           "docker.mem.swap",
+	"container.memory.oom_events":     "docker.mem.failed_count",
+	"container.memory.working_set":    "docker.mem.private_working_set",
+	"container.memory.commit":         "docker.mem.commit_bytes",
+	"container.memory.commit.peak":    "docker.mem.commit_peak_bytes",
+	"container.pid.thread_count":      "docker.thread.count",
+	"container.pid.thread_limit":      "docker.thread.limit",
+}
+
+// metricsValuesConverter converts the values of the generic metrics to the unit used by the check
+var metricsValuesConverter = map[string]func(float64) float64{
+	"docker.cpu.usage":          convertNanoToUserHZ,
+	"docker.cpu.user":           convertNanoToUserHZ,
+	"docker.cpu.system":         convertNanoToUserHZ,
+	"docker.cpu.throttled.time": convertNanoToUserHZ,
+	"docker.cpu.limit":          convertNanoToUserHZ,
+}
+
+func convertNanoToUserHZ(value float64) float64 {
+	return value / nanoToUserHZDivisor
+}
+
+// metricsAdapter submits the legacy metrics of the check
+type metricsAdapter struct{}
+
+// AdaptTags keeps the tagger tags, the check does not add a `runtime` tag
+func (a metricsAdapter) AdaptTags(tags []string, c *workloadmeta.Container) []string {
+	return tags
+}
+
+// AdaptMetrics renames and converts the generic metrics and drops the ones the check did not submit
+func (a metricsAdapter) AdaptMetrics(metricName string, value float64) (string, float64) {
+	legacyName, found := metricsNameMapping[metricName]
+	if !found {
+		return "", value
+	}
+
+	// Memory limits are not reported when there is no limit
+	if (legacyName == "docker.mem.limit" || legacyName == "docker.mem.soft_limit") && !isMemoryLimited(value) {
+		return "", value
+	}
+
+	if converter, found := metricsValuesConverter[legacyName]; found {
+		value = converter(value)
+	}
+
+	return legacyName, value
+}
+
+// dockerCustomMetricsExtension submits the metrics of the check that are not generated by the generic processor
+type dockerCustomMetricsExtension struct {
+	sender aggregator.Sender
+
+	// Set by the check before each run
+	du                   *docker.DockerUtil
+	networkNames         map[string]map[string]string
+	collectContainerSize bool
+}
+
+// PreProcess keeps the sender of the run
+func (e *dockerCustomMetricsExtension) PreProcess(sender aggregator.Sender) {
+	e.sender = sender
+}
+
+// Process submits the custom metrics of a container
+func (e *dockerCustomMetricsExtension) Process(tags []string, container *workloadmeta.Container, collector cmetrics.Collector, cacheValidity time.Duration) {
+	// The stats have just been retrieved by the processor, the collector serves them from its cache
+	containerStats, err := collector.GetContainerStats(container.ID, cacheValidity)
+	if err != nil {
+		log.Debugf("Container stats for: %s not available through collector %q, err: %v", container.ID, collector.ID(), err)
+	} else if containerStats != nil {
+		e.processCPU(tags, containerStats.CPU)
+		e.processMemory(tags, containerStats.Memory)
+		e.processIO(tags, containerStats.IO)
+		e.processOpenFiles(tags, containerStats.IO, containerStats.PID)
+	}
+
+	if networks, found := e.networkNames[container.ID]; found {
+		networkStats, err := collector.GetContainerNetworkStats(container.ID, cacheValidity, networks)
+		if err != nil {
+			log.Debugf("Network stats for: %s not available through collector %q, err: %v", container.ID, collector.ID(), err)
+		} else {
+			e.processNetwork(tags, networks, networkStats)
+		}
+	}
+
+	if e.collectContainerSize && e.du != nil {
+		e.processContainerSize(tags, container.ID)
+	}
+}
+
+// PostProcess releases the sender of the run
+func (e *dockerCustomMetricsExtension) PostProcess() {
+	e.sender = nil
+}
+
+func (e *dockerCustomMetricsExtension) processCPU(tags []string, cpuStats *cmetrics.ContainerCPUStats) {
+	if cpuStats == nil {
+		return
+	}
+
+	if cpuStats.Shares != nil && *cpuStats.Shares != 0 {
+		e.sender.Gauge("docker.cpu.shares", *cpuStats.Shares, "", tags)
+	}
+}
+
+func (e *dockerCustomMetricsExtension) processMemory(tags []string, memStats *cmetrics.ContainerMemStats) {
+	if memStats == nil {
+		return
+	}
+
+	if memStats.Limit != nil && isMemoryLimited(*memStats.Limit) {
+		if memStats.RSS != nil {
+			e.sender.Gauge("docker.mem.in_use", *memStats.RSS / *memStats.Limit, "", tags)
+		} else if memStats.CommitBytes != nil {
+			// On Windows there is no RSS
+			e.sender.Gauge("docker.mem.in_use", *memStats.CommitBytes / *memStats.Limit, "", tags)
+		}
+	}
+
+	if memStats.SwapLimit != nil && isMemoryLimited(*memStats.SwapLimit) {
+		e.sender.Gauge("docker.mem.sw_limit", *memStats.SwapLimit, "", tags)
+		if memStats.Swap != nil && memStats.RSS != nil {
+			e.sender.Gauge("docker.mem.sw_in_use", (*memStats.Swap+*memStats.RSS) / *memStats.SwapLimit, "", tags)
+		}
+	}
+}
+
+// processIO submits the I/O metrics with both the `device` and `device_name` tags, as the check did
+func (e *dockerCustomMetricsExtension) processIO(tags []string, ioStats *cmetrics.ContainerIOStats) {
+	if ioStats == nil {
+		return
+	}
+
+	if len(ioStats.Devices) == 0 {
+		sendRate(e.sender, "docker.io.read_bytes", ioStats.ReadBytes, tags)
+		sendRate(e.sender, "docker.io.write_bytes", ioStats.WriteBytes, tags)
+		sendRate(e.sender, "docker.io.read_operations", ioStats.ReadOperations, tags)
+		sendRate(e.sender, "docker.io.write_operations", ioStats.WriteOperations, tags)
+		return
+	}
+
+	for device, deviceStats := range ioStats.Devices {
+		deviceTags := make([]string, 0, len(tags)+2)
+		deviceTags = append(deviceTags, tags...)
+		deviceTags = append(deviceTags, "device:"+device, "device_name:"+device)
+
+		sendRate(e.sender, "docker.io.read_bytes", deviceStats.ReadBytes, deviceTags)
+		sendRate(e.sender, "docker.io.write_bytes", deviceStats.WriteBytes, deviceTags)
+		sendRate(e.sender, "docker.io.read_operations", deviceStats.ReadOperations, deviceTags)
+		sendRate(e.sender, "docker.io.write_operations", deviceStats.WriteOperations, deviceTags)
+	}
+}
+
+func (e *dockerCustomMetricsExtension) processOpenFiles(tags []string, ioStats *cmetrics.ContainerIOStats, pidStats *cmetrics.ContainerPIDStats) {
+	if ioStats != nil && ioStats.OpenFiles != nil {
+		e.sender.Gauge("docker.container.open_fds", *ioStats.OpenFiles, "", tags)
+		return
+	}
+
+	if pidStats == nil || len(pidStats.PIDs) == 0 {
+		return
+	}
+
+	fileDescCount := 0
+	for _, pid := range pidStats.PIDs {
+		fdCount, err := providers.ContainerImpl().GetNumFileDescriptors(pid)
+		if err != nil {
+			log.Debugf("Failed to get file desc length for pid %d: %s", pid, err)
+			continue
+		}
+		fileDescCount += fdCount
+	}
+	e.sender.Gauge("docker.container.open_fds", float64(fileDescCount), "", tags)
+}
+
+// processNetwork submits the network metrics of the interfaces attached to a docker network
+func (e *dockerCustomMetricsExtension) processNetwork(tags []string, networks map[string]string, networkStats *cmetrics.ContainerNetworkStats) {
+	if networkStats == nil {
+		return
+	}
+
+	for _, networkName := range networks {
+		interfaceStats, found := networkStats.Interfaces[networkName]
+		if networkName == "" || !found {
+			continue
+		}
+
+		networkTags := make([]string, 0, len(tags)+1)
+		networkTags = append(networkTags, tags...)
+		networkTags = append(networkTags, "docker_network:"+networkName)
+
+		sendRate(e.sender, "docker.net.bytes_sent", interfaceStats.BytesSent, networkTags)
+		sendRate(e.sender, "docker.net.bytes_rcvd", interfaceStats.BytesRcvd, networkTags)
+	}
+}
+
+func (e *dockerCustomMetricsExtension) processContainerSize(tags []string, containerID string) {
+	info, err := e.du.Inspect(context.TODO(), containerID, true)
+	if err != nil {
+		log.Errorf("Failed to inspect container %s - %s", containerID[:12], err)
+	} else if info.SizeRw == nil || info.SizeRootFs == nil {
+		log.Warnf("Docker inspect did not return the container size: %s", containerID[:12])
+	} else {
+		e.sender.Gauge("docker.container.size_rw", float64(*info.SizeRw), "", tags)
+		e.sender.Gauge("docker.container.size_rootfs", float64(*info.SizeRootFs), "", tags)
+	}
+}
+
+func sendRate(sender aggregator.Sender, metricName string, value *float64, tags []string) {
+	if value != nil {
+		sender.Rate(metricName, *value, "", tags)
+	}
+}
+
+func isMemoryLimited(limit float64) bool {
+	return limit > 0 && limit < memoryUnlimitedValue
+}
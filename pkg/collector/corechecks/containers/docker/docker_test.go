@@ -9,129 +9,140 @@ package docker
 
 import (
 	"testing"
-	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
-	cmetrics "github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util"
+	cmetrics "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
-func TestReportIOMetrics(t *testing.T) {
-	dockerCheck := &DockerCheck{
-		instance: &DockerConfig{},
+func TestMetricsAdapter(t *testing.T) {
+	adapter := metricsAdapter{}
+
+	tests := []struct {
+		metricName    string
+		value         float64
+		expectedName  string
+		expectedValue float64
+	}{
+		{"container.uptime", 60, "docker.uptime", 60},
+		{"container.cpu.usage", 2e9, "docker.cpu.usage", 200},
+		{"container.cpu.throttled", 1e7, "docker.cpu.throttled.time", 1},
+		{"container.cpu.throttled.periods", 3, "docker.cpu.throttled", 3},
+		{"container.cpu.limit", 5e8, "docker.cpu.limit", 50},
+		{"container.memory.rss", 300, "docker.mem.rss", 300},
+		{"container.memory.limit", 42000, "docker.mem.limit", 42000},
+		{"container.memory.limit", 1 << 62, "", 1 << 62},
+		{"container.memory.oom_events", 10, "docker.mem.failed_count", 10},
+		{"container.pid.thread_count", 10, "docker.thread.count", 10},
+		{"container.memory.usage", 100, "", 100},
+		{"container.io.read", 100, "", 100},
 	}
-	mockSender := mocksender.NewMockSender(dockerCheck.ID())
-	mockSender.SetupAcceptAll()
+	for _, tt := range tests {
+		t.Run(tt.metricName, func(t *testing.T) {
+			name, value := adapter.AdaptMetrics(tt.metricName, tt.value)
+			assert.Equal(t, tt.expectedName, name)
+			assert.Equal(t, tt.expectedValue, value)
+		})
+	}
+
+	tags := []string{"container_name:dummy"}
+	assert.Equal(t, tags, adapter.AdaptTags(tags, &workloadmeta.Container{Runtime: workloadmeta.ContainerRuntimeDocker}))
+}
 
+func TestCustomMetricsExtension(t *testing.T) {
 	tags := []string{"constant:tags", "container_name:dummy"}
 
-	// Test fallback to sums when per-device is not available
-	ioSum := &cmetrics.ContainerIOStats{
-		ReadBytes:  uint64(38989367),
-		WriteBytes: uint64(671846455),
-	}
-	dockerCheck.reportIOMetrics(ioSum, tags, mockSender)
-	mockSender.AssertMetric(t, "Rate", "docker.io.read_bytes", float64(38989367), "", tags)
-	mockSender.AssertMetric(t, "Rate", "docker.io.write_bytes", float64(671846455), "", tags)
-
-	// Test per-device when available
-	ioPerDevice := &cmetrics.ContainerIOStats{
-		ReadBytes:  uint64(38989367),
-		WriteBytes: uint64(671846455),
-		DeviceReadBytes: map[string]uint64{
-			"sda": 37858816,
-			"sdb": 1130496,
+	mockCollector := cmetrics.NewMockCollector("docker")
+	mockCollector.SetContainerEntry("cID1", cmetrics.MockContainerEntry{
+		ContainerStats: cmetrics.ContainerStats{
+			CPU: &cmetrics.ContainerCPUStats{
+				Shares: util.Float64Ptr(1024),
+			},
+			Memory: &cmetrics.ContainerMemStats{
+				Limit:     util.Float64Ptr(1000),
+				RSS:       util.Float64Ptr(300),
+				Swap:      util.Float64Ptr(100),
+				SwapLimit: util.Float64Ptr(2000),
+			},
+			IO: &cmetrics.ContainerIOStats{
+				ReadBytes:  util.Float64Ptr(38989367),
+				WriteBytes: util.Float64Ptr(671846455),
+				OpenFiles:  util.Float64Ptr(42),
+			},
 		},
-		DeviceWriteBytes: map[string]uint64{
-			"sda": 671846400,
-			"sdb": 0,
+		NetworkStats: cmetrics.ContainerNetworkStats{
+			Interfaces: map[string]cmetrics.InterfaceNetStats{
+				"bridge": {
+					BytesSent: util.Float64Ptr(42),
+					BytesRcvd: util.Float64Ptr(43),
+				},
+			},
 		},
-		DeviceReadOperations: map[string]uint64{
-			"sda": 1042,
-			"sdb": 42,
+	})
+	mockCollector.SetContainerEntry("cID2", cmetrics.MockContainerEntry{
+		ContainerStats: cmetrics.ContainerStats{
+			Memory: &cmetrics.ContainerMemStats{
+				Limit: util.Float64Ptr(1 << 62),
+				RSS:   util.Float64Ptr(300),
+			},
+			IO: &cmetrics.ContainerIOStats{
+				ReadBytes:  util.Float64Ptr(38989367),
+				WriteBytes: util.Float64Ptr(671846455),
+				Devices: map[string]cmetrics.DeviceIOStats{
+					"sda": {
+						ReadBytes:       util.Float64Ptr(37858816),
+						WriteBytes:      util.Float64Ptr(671846400),
+						ReadOperations:  util.Float64Ptr(1042),
+						WriteOperations: util.Float64Ptr(2042),
+					},
+				},
+			},
 		},
-		DeviceWriteOperations: map[string]uint64{
-			"sda": 2042,
-			"sdb": 1042,
-		},
-	}
-	sdaTags := append(tags, "device:sda", "device_name:sda")
-	sdbTags := append(tags, "device:sdb", "device_name:sdb")
-	dockerCheck.reportIOMetrics(ioPerDevice, tags, mockSender)
-	mockSender.AssertMetric(t, "Rate", "docker.io.read_bytes", float64(37858816), "", sdaTags)
-	mockSender.AssertMetric(t, "Rate", "docker.io.write_bytes", float64(671846400), "", sdaTags)
-	mockSender.AssertMetric(t, "Rate", "docker.io.read_bytes", float64(1130496), "", sdbTags)
-	mockSender.AssertMetric(t, "Rate", "docker.io.write_bytes", float64(0), "", sdbTags)
-	mockSender.AssertMetric(t, "Rate", "docker.io.read_operations", float64(1042), "", sdaTags)
-	mockSender.AssertMetric(t, "Rate", "docker.io.write_operations", float64(2042), "", sdaTags)
-	mockSender.AssertMetric(t, "Rate", "docker.io.read_operations", float64(42), "", sdbTags)
-	mockSender.AssertMetric(t, "Rate", "docker.io.write_operations", float64(1042), "", sdbTags)
-}
+	})
 
-func TestReportUptime(t *testing.T) {
-	dockerCheck := &DockerCheck{
-		instance: &DockerConfig{},
-	}
-	mockSender := mocksender.NewMockSender(dockerCheck.ID())
+	mockSender := mocksender.NewMockSender("docker")
 	mockSender.SetupAcceptAll()
 
-	tags := []string{"constant:tags", "container_name:dummy"}
-	currentTime := time.Now().Unix()
-
-	startTime := currentTime - 60
-	dockerCheck.reportUptime(startTime, currentTime, tags, mockSender)
-	mockSender.AssertMetric(t, "Gauge", "docker.uptime", 60.0, "", tags)
-}
-
-func TestReportCPUNoLimit(t *testing.T) {
-	dockerCheck := &DockerCheck{
-		instance: &DockerConfig{},
-	}
-	mockSender := mocksender.NewMockSender(dockerCheck.ID())
-	mockSender.SetupAcceptAll()
-
-	tags := []string{"constant:tags", "container_name:dummy"}
-	testTime := time.Now()
-	startTime := testTime.Add(-10 * time.Second)
-
-	cpu := cmetrics.ContainerCPUStats{
-		Timestsamp: testTime,
-		System:     10,
-		User:       10,
-		UsageTotal: 20.0,
-	}
-
-	// 100% is 1 CPU, 200% is 2 CPUs, etc.
-	// So no limit is # of host CPU
-	limits := cmetrics.ContainerLimits{
-		CPULimit: 100.0,
-	}
-
-	dockerCheck.reportCPUMetrics(&cpu, &limits, startTime.Unix(), tags, mockSender)
-	mockSender.AssertMetric(t, "Rate", "docker.cpu.limit", 1000, "", tags)
-}
-
-func TestReportCPULimit(t *testing.T) {
-	dockerCheck := &DockerCheck{
-		instance: &DockerConfig{},
+	extension := &dockerCustomMetricsExtension{
+		networkNames: map[string]map[string]string{
+			"cID1": {"eth0": "bridge"},
+		},
 	}
-	mockSender := mocksender.NewMockSender(dockerCheck.ID())
+	extension.PreProcess(mockSender)
+	extension.Process(tags, &workloadmeta.Container{EntityID: workloadmeta.EntityID{ID: "cID1"}}, mockCollector, 0)
+	extension.PostProcess()
+
+	mockSender.AssertMetric(t, "Gauge", "docker.cpu.shares", 1024, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "docker.mem.in_use", 0.3, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "docker.mem.sw_limit", 2000, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "docker.mem.sw_in_use", 0.2, "", tags)
+	mockSender.AssertMetric(t, "Gauge", "docker.container.open_fds", 42, "", tags)
+	// Fallback to sums when per-device stats are not available
+	mockSender.AssertMetric(t, "Rate", "docker.io.read_bytes", 38989367, "", tags)
+	mockSender.AssertMetric(t, "Rate", "docker.io.write_bytes", 671846455, "", tags)
+	networkTags := append(tags, "docker_network:bridge")
+	mockSender.AssertMetric(t, "Rate", "docker.net.bytes_sent", 42, "", networkTags)
+	mockSender.AssertMetric(t, "Rate", "docker.net.bytes_rcvd", 43, "", networkTags)
+
+	mockSender = mocksender.NewMockSender("docker")
 	mockSender.SetupAcceptAll()
 
-	tags := []string{"constant:tags", "container_name:dummy"}
-	testTime := time.Now()
-	startTime := testTime.Add(-10 * time.Second)
-
-	cpu := cmetrics.ContainerCPUStats{
-		Timestsamp: testTime,
-		System:     10,
-		User:       10,
-		UsageTotal: 20.0,
-	}
+	extension.PreProcess(mockSender)
+	extension.Process(tags, &workloadmeta.Container{EntityID: workloadmeta.EntityID{ID: "cID2"}}, mockCollector, 0)
+	extension.PostProcess()
 
-	limits := cmetrics.ContainerLimits{
-		CPULimit: 50,
-	}
-
-	dockerCheck.reportCPUMetrics(&cpu, &limits, startTime.Unix(), tags, mockSender)
-	mockSender.AssertMetric(t, "Rate", "docker.cpu.limit", 500, "", tags)
+	// No memory limit
+	mockSender.AssertNotCalled(t, "Gauge", "docker.mem.in_use", mock.Anything, "", tags)
+	// Per-device stats when available
+	sdaTags := append(tags, "device:sda", "device_name:sda")
+	mockSender.AssertMetric(t, "Rate", "docker.io.read_bytes", 37858816, "", sdaTags)
+	mockSender.AssertMetric(t, "Rate", "docker.io.write_bytes", 671846400, "", sdaTags)
+	mockSender.AssertMetric(t, "Rate", "docker.io.read_operations", 1042, "", sdaTags)
+	mockSender.AssertMetric(t, "Rate", "docker.io.write_operations", 2042, "", sdaTags)
+	mockSender.AssertNotCalled(t, "Rate", "docker.io.read_bytes", mock.Anything, "", tags)
+	mockSender.AssertNotCalled(t, "Rate", "docker.net.bytes_sent", mock.Anything, "", networkTags)
 }
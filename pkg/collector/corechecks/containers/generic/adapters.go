@@ -14,6 +14,7 @@ type MetricsAdapter interface {
 	// AdaptTags can be used to change Tagger tags before submitting the metrics
 	AdaptTags(tags []string, c *workloadmeta.Container) []string
 	// AdaptMetrics can be used to change metrics (change name or value) before submitting the metric.
+	// Returning an empty metric name drops the metric.
 	AdaptMetrics(metricName string, value float64) (string, float64)
 }
 
@@ -30,6 +31,36 @@ func (l MetadataContainerLister) List() ([]*workloadmeta.Container, error) {
 	return workloadmeta.GetGlobalStore().ListContainers()
 }
 
+// RuntimeContainerLister implements ContainerLister interface using Workload meta service,
+// only returning the containers of the given runtimes
+type RuntimeContainerLister struct {
+	Runtimes []workloadmeta.ContainerRuntime
+}
+
+// List returns all known containers of the lister runtimes
+func (l RuntimeContainerLister) List() ([]*workloadmeta.Container, error) {
+	allContainers, err := MetadataContainerLister{}.List()
+	if err != nil {
+		return nil, err
+	}
+
+	return FilterByRuntime(allContainers, l.Runtimes...), nil
+}
+
+// FilterByRuntime returns the containers running with one of the given runtimes
+func FilterByRuntime(containers []*workloadmeta.Container, runtimes ...workloadmeta.ContainerRuntime) []*workloadmeta.Container {
+	filtered := make([]*workloadmeta.Container, 0, len(containers))
+	for _, container := range containers {
+		for _, runtime := range runtimes {
+			if container.Runtime == runtime {
+				filtered = append(filtered, container)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
 // GenericMetricsAdapter implements MetricsAdapter API in a basic way.
 // Adds `runtime` tag and do not change metrics.
 type GenericMetricsAdapter struct{}
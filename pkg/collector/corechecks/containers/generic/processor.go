@@ -19,12 +19,24 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
 
+// ProcessorExtension allows runtime specific checks to submit additional metrics
+// on top of the ones generated by the processor
+type ProcessorExtension interface {
+	// PreProcess is called once during each check run, before any call to Process
+	PreProcess(sender aggregator.Sender)
+	// Process is called for every container once its generic metrics have been submitted
+	Process(tags []string, container *workloadmeta.Container, collector metrics.Collector, cacheValidity time.Duration)
+	// PostProcess is called once during each check run, after all calls to Process
+	PostProcess()
+}
+
 // Processor contains the core logic of the generic check, allowing reusability
 type Processor struct {
 	metricsProvider metrics.Provider
 	ctrLister       ContainerLister
 	metricsAdapter  MetricsAdapter
 	ctrFilter       *containers.Filter
+	extensions      map[string]ProcessorExtension
 }
 
 // NewProcessor creates a new processor
@@ -34,9 +46,18 @@ func NewProcessor(provider metrics.Provider, lister ContainerLister, adapter Met
 		ctrLister:       lister,
 		metricsAdapter:  adapter,
 		ctrFilter:       filter,
+		extensions:      make(map[string]ProcessorExtension),
 	}
 }
 
+// RegisterExtension registers an extension, replacing any extension previously registered with the same ID
+func (p *Processor) RegisterExtension(id string, extension ProcessorExtension) {
+	if p.extensions == nil {
+		p.extensions = make(map[string]ProcessorExtension)
+	}
+	p.extensions[id] = extension
+}
+
 // Run executes the check
 func (p *Processor) Run(sender aggregator.Sender, cacheValidity time.Duration) error {
 	allContainers, err := p.ctrLister.List()
@@ -57,6 +78,10 @@ func (p *Processor) Run(sender aggregator.Sender, cacheValidity time.Duration) e
 		return collector
 	}
 
+	for _, extension := range p.extensions {
+		extension.PreProcess(sender)
+	}
+
 	for _, container := range allContainers {
 		// We surely won't get stats for not running containers
 		if !container.State.Running {
@@ -93,9 +118,17 @@ func (p *Processor) Run(sender aggregator.Sender, cacheValidity time.Duration) e
 			continue
 		}
 
+		for _, extension := range p.extensions {
+			extension.Process(tags, container, collector, cacheValidity)
+		}
+
 		// TODO: Implement container stats. We currently don't have enough information from Metadata service to do it.
 	}
 
+	for _, extension := range p.extensions {
+		extension.PostProcess()
+	}
+
 	sender.Commit()
 	return nil
 }
@@ -111,6 +144,7 @@ func (p *Processor) processContainer(sender aggregator.Sender, tags []string, co
 		p.sendMetric(sender.Rate, "container.cpu.system", containerStats.CPU.System, tags)
 		p.sendMetric(sender.Rate, "container.cpu.throttled", containerStats.CPU.ThrottledTime, tags)
 		p.sendMetric(sender.Rate, "container.cpu.throttled.periods", containerStats.CPU.ThrottledPeriods, tags)
+		p.sendMetric(sender.Rate, "container.cpu.partial_stall", containerStats.CPU.PartialStallTime, tags)
 		// Convert CPU Limit to nanoseconds to allow easy percentage computation in the App.
 		if containerStats.CPU.Limit != nil {
 			p.sendMetric(sender.Gauge, "container.cpu.limit", util.Float64Ptr(*containerStats.CPU.Limit*float64(time.Second/100)), tags)
@@ -126,6 +160,10 @@ func (p *Processor) processContainer(sender aggregator.Sender, tags []string, co
 		p.sendMetric(sender.Gauge, "container.memory.cache", containerStats.Memory.Cache, tags)
 		p.sendMetric(sender.Gauge, "container.memory.swap", containerStats.Memory.Swap, tags)
 		p.sendMetric(sender.Gauge, "container.memory.oom_events", containerStats.Memory.OOMEvents, tags)
+		p.sendMetric(sender.Gauge, "container.memory.oom_kill_events", containerStats.Memory.OOMKillEvents, tags)
+		p.sendMetric(sender.Gauge, "container.memory.high_events", containerStats.Memory.HighEvents, tags)
+		p.sendMetric(sender.Rate, "container.memory.partial_stall", containerStats.Memory.PartialStallTime, tags)
+		p.sendMetric(sender.Rate, "container.memory.full_stall", containerStats.Memory.FullStallTime, tags)
 		p.sendMetric(sender.Gauge, "container.memory.working_set", containerStats.Memory.PrivateWorkingSet, tags)
 		p.sendMetric(sender.Gauge, "container.memory.commit", containerStats.Memory.CommitBytes, tags)
 		p.sendMetric(sender.Gauge, "container.memory.commit.peak", containerStats.Memory.CommitPeakBytes, tags)
@@ -138,6 +176,7 @@ func (p *Processor) processContainer(sender aggregator.Sender, tags []string, co
 			p.sendMetric(sender.Rate, "container.io.read.operations", deviceStats.ReadOperations, deviceTags)
 			p.sendMetric(sender.Rate, "container.io.write", deviceStats.WriteBytes, deviceTags)
 			p.sendMetric(sender.Rate, "container.io.write.operations", deviceStats.WriteOperations, deviceTags)
+			p.sendMetric(sender.Gauge, "container.io.latency", deviceStats.AvgLatency, deviceTags)
 		}
 
 		if len(containerStats.IO.Devices) == 0 {
@@ -146,6 +185,9 @@ func (p *Processor) processContainer(sender aggregator.Sender, tags []string, co
 			p.sendMetric(sender.Rate, "container.io.write", containerStats.IO.WriteBytes, tags)
 			p.sendMetric(sender.Rate, "container.io.write.operations", containerStats.IO.WriteOperations, tags)
 		}
+
+		p.sendMetric(sender.Rate, "container.io.partial_stall", containerStats.IO.PartialStallTime, tags)
+		p.sendMetric(sender.Rate, "container.io.full_stall", containerStats.IO.FullStallTime, tags)
 	}
 
 	if containerStats.PID != nil {
@@ -162,6 +204,9 @@ func (p *Processor) sendMetric(senderFunc func(string, float64, string, []string
 	}
 
 	metricName, val := p.metricsAdapter.AdaptMetrics(metricName, *value)
+	if metricName == "" {
+		return
+	}
 	senderFunc(metricName, val, "", tags)
 }
 
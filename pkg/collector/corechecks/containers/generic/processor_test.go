@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
@@ -103,16 +104,21 @@ func TestProcessorRunFullStatsLinux(t *testing.T) {
 					ElapsedPeriods:   util.Float64Ptr(500),
 					ThrottledPeriods: util.Float64Ptr(0),
 					ThrottledTime:    util.Float64Ptr(100),
+					PartialStallTime: util.Float64Ptr(150),
 				},
 				Memory: &metrics.ContainerMemStats{
-					UsageTotal:   util.Float64Ptr(100),
-					KernelMemory: util.Float64Ptr(40),
-					Limit:        util.Float64Ptr(42000),
-					Softlimit:    util.Float64Ptr(40000),
-					RSS:          util.Float64Ptr(300),
-					Cache:        util.Float64Ptr(200),
-					Swap:         util.Float64Ptr(0),
-					OOMEvents:    util.Float64Ptr(10),
+					UsageTotal:       util.Float64Ptr(100),
+					KernelMemory:     util.Float64Ptr(40),
+					Limit:            util.Float64Ptr(42000),
+					Softlimit:        util.Float64Ptr(40000),
+					RSS:              util.Float64Ptr(300),
+					Cache:            util.Float64Ptr(200),
+					Swap:             util.Float64Ptr(0),
+					OOMEvents:        util.Float64Ptr(10),
+					OOMKillEvents:    util.Float64Ptr(1),
+					HighEvents:       util.Float64Ptr(5),
+					PartialStallTime: util.Float64Ptr(250),
+					FullStallTime:    util.Float64Ptr(50),
 				},
 				IO: &metrics.ContainerIOStats{
 					Devices: map[string]metrics.DeviceIOStats{
@@ -121,6 +127,7 @@ func TestProcessorRunFullStatsLinux(t *testing.T) {
 							WriteBytes:      util.Float64Ptr(200),
 							ReadOperations:  util.Float64Ptr(10),
 							WriteOperations: util.Float64Ptr(20),
+							AvgLatency:      util.Float64Ptr(2000),
 						},
 						"/dev/bar": {
 							ReadBytes:       util.Float64Ptr(100),
@@ -129,10 +136,12 @@ func TestProcessorRunFullStatsLinux(t *testing.T) {
 							WriteOperations: util.Float64Ptr(20),
 						},
 					},
-					ReadBytes:       util.Float64Ptr(200),
-					WriteBytes:      util.Float64Ptr(400),
-					ReadOperations:  util.Float64Ptr(20),
-					WriteOperations: util.Float64Ptr(40),
+					ReadBytes:        util.Float64Ptr(200),
+					WriteBytes:       util.Float64Ptr(400),
+					ReadOperations:   util.Float64Ptr(20),
+					WriteOperations:  util.Float64Ptr(40),
+					PartialStallTime: util.Float64Ptr(300),
+					FullStallTime:    util.Float64Ptr(100),
 				},
 				PID: &metrics.ContainerPIDStats{
 					PIDs:        []int{4, 2},
@@ -148,8 +157,8 @@ func TestProcessorRunFullStatsLinux(t *testing.T) {
 	assert.ErrorIs(t, err, nil)
 
 	expectedTags := []string{"runtime:docker"}
-	mockSender.AssertNumberOfCalls(t, "Rate", 13)
-	mockSender.AssertNumberOfCalls(t, "Gauge", 12)
+	mockSender.AssertNumberOfCalls(t, "Rate", 18)
+	mockSender.AssertNumberOfCalls(t, "Gauge", 15)
 
 	mockSender.AssertMetricInRange(t, "Gauge", "container.uptime", 0, 600, "", expectedTags)
 	mockSender.AssertMetric(t, "Rate", "container.cpu.usage", 100, "", expectedTags)
@@ -157,6 +166,7 @@ func TestProcessorRunFullStatsLinux(t *testing.T) {
 	mockSender.AssertMetric(t, "Rate", "container.cpu.system", 200, "", expectedTags)
 	mockSender.AssertMetric(t, "Rate", "container.cpu.throttled", 100, "", expectedTags)
 	mockSender.AssertMetric(t, "Rate", "container.cpu.throttled.periods", 0, "", expectedTags)
+	mockSender.AssertMetric(t, "Rate", "container.cpu.partial_stall", 150, "", expectedTags)
 	mockSender.AssertMetric(t, "Gauge", "container.cpu.limit", 500000000, "", expectedTags)
 
 	mockSender.AssertMetric(t, "Gauge", "container.memory.usage", 100, "", expectedTags)
@@ -167,17 +177,24 @@ func TestProcessorRunFullStatsLinux(t *testing.T) {
 	mockSender.AssertMetric(t, "Gauge", "container.memory.cache", 200, "", expectedTags)
 	mockSender.AssertMetric(t, "Gauge", "container.memory.swap", 0, "", expectedTags)
 	mockSender.AssertMetric(t, "Gauge", "container.memory.oom_events", 10, "", expectedTags)
+	mockSender.AssertMetric(t, "Gauge", "container.memory.oom_kill_events", 1, "", expectedTags)
+	mockSender.AssertMetric(t, "Gauge", "container.memory.high_events", 5, "", expectedTags)
+	mockSender.AssertMetric(t, "Rate", "container.memory.partial_stall", 250, "", expectedTags)
+	mockSender.AssertMetric(t, "Rate", "container.memory.full_stall", 50, "", expectedTags)
 
 	expectedFooTags := extraTags(expectedTags, "device_name:/dev/foo")
 	mockSender.AssertMetric(t, "Rate", "container.io.read", 100, "", expectedFooTags)
 	mockSender.AssertMetric(t, "Rate", "container.io.read.operations", 10, "", expectedFooTags)
 	mockSender.AssertMetric(t, "Rate", "container.io.write", 200, "", expectedFooTags)
 	mockSender.AssertMetric(t, "Rate", "container.io.write.operations", 20, "", expectedFooTags)
+	mockSender.AssertMetric(t, "Gauge", "container.io.latency", 2000, "", expectedFooTags)
 	expectedBarTags := extraTags(expectedTags, "device_name:/dev/bar")
 	mockSender.AssertMetric(t, "Rate", "container.io.read", 100, "", expectedBarTags)
 	mockSender.AssertMetric(t, "Rate", "container.io.read.operations", 10, "", expectedBarTags)
 	mockSender.AssertMetric(t, "Rate", "container.io.write", 200, "", expectedBarTags)
 	mockSender.AssertMetric(t, "Rate", "container.io.write.operations", 20, "", expectedBarTags)
+	mockSender.AssertMetric(t, "Rate", "container.io.partial_stall", 300, "", expectedTags)
+	mockSender.AssertMetric(t, "Rate", "container.io.full_stall", 100, "", expectedTags)
 
 	mockSender.AssertMetric(t, "Gauge", "container.pid.thread_count", 10, "", expectedTags)
 	mockSender.AssertMetric(t, "Gauge", "container.pid.thread_limit", 20, "", expectedTags)
@@ -204,3 +221,78 @@ func TestProcessorRunPartialStats(t *testing.T) {
 	mockSender.AssertNumberOfCalls(t, "Rate", 0)
 	mockSender.AssertNumberOfCalls(t, "Gauge", 0)
 }
+
+type mockExtension struct {
+	preProcessed  int
+	processed     []string
+	postProcessed int
+}
+
+func (e *mockExtension) PreProcess(sender aggregator.Sender) {
+	e.preProcessed++
+}
+
+func (e *mockExtension) Process(tags []string, container *workloadmeta.Container, collector metrics.Collector, cacheValidity time.Duration) {
+	e.processed = append(e.processed, container.ID)
+}
+
+func (e *mockExtension) PostProcess() {
+	e.postProcessed++
+}
+
+type dropAllButUptimeAdapter struct {
+	GenericMetricsAdapter
+}
+
+func (a dropAllButUptimeAdapter) AdaptMetrics(metricName string, value float64) (string, float64) {
+	if metricName != "container.uptime" {
+		return "", value
+	}
+	return "custom.uptime", value
+}
+
+func TestProcessorRunExtensionsAndAdapter(t *testing.T) {
+	containersMeta := []*workloadmeta.Container{
+		createContainerMeta("docker", "cID301"),
+		// Container without stats, not processed by extensions
+		createContainerMeta("docker", "cID302"),
+	}
+
+	containersStats := map[string]metrics.MockContainerEntry{
+		"cID301": {
+			ContainerStats: metrics.ContainerStats{
+				CPU: &metrics.ContainerCPUStats{
+					Total: util.Float64Ptr(100),
+				},
+			},
+		},
+	}
+
+	mockSender, processor := createTestProcessor(containersMeta, nil, containersStats)
+	processor.metricsAdapter = dropAllButUptimeAdapter{}
+	extension := &mockExtension{}
+	processor.RegisterExtension("mock", extension)
+
+	err := processor.Run(mockSender, 0)
+	assert.ErrorIs(t, err, nil)
+
+	mockSender.AssertNumberOfCalls(t, "Rate", 0)
+	mockSender.AssertNumberOfCalls(t, "Gauge", 1)
+	mockSender.AssertMetricInRange(t, "Gauge", "custom.uptime", 0, 600, "", []string{"runtime:docker"})
+
+	assert.Equal(t, 1, extension.preProcessed)
+	assert.Equal(t, []string{"cID301"}, extension.processed)
+	assert.Equal(t, 1, extension.postProcessed)
+}
+
+func TestFilterByRuntime(t *testing.T) {
+	containersMeta := []*workloadmeta.Container{
+		createContainerMeta("docker", "cID401"),
+		createContainerMeta("containerd", "cID402"),
+		createContainerMeta("cri-o", "cID403"),
+	}
+
+	filtered := FilterByRuntime(containersMeta, workloadmeta.ContainerRuntimeCRIO, workloadmeta.ContainerRuntimeContainerd)
+	assert.Equal(t, []*workloadmeta.Container{containersMeta[1], containersMeta[2]}, filtered)
+	assert.Empty(t, FilterByRuntime(containersMeta, workloadmeta.ContainerRuntimePodman))
+}
@@ -0,0 +1,237 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// FIXME: we require the `cgo` build tag because of this dep relationship:
+// github.com/DataDog/datadog-agent/pkg/process/net depends on `github.com/DataDog/agent-payload/v5/process`,
+// which has a hard dependency on `github.com/DataDog/zstd_0`, which requires CGO.
+// Should be removed once `github.com/DataDog/agent-payload/v5/process` can be imported with CGO disabled.
+// +build cgo
+// +build linux
+
+package net
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	process_net "github.com/DataDog/datadog-agent/pkg/process/net"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	processNetworkCheckName = "process_network"
+
+	// processNetworkClientID identifies the check to system-probe, which keeps track of
+	// the connections returned to each of its clients to compute the deltas of their stats
+	processNetworkClientID = "process_network_check"
+)
+
+// ProcessNetworkConfig is the config of the process network check
+type ProcessNetworkConfig struct {
+	// CollectPerProcess enables the submission of metrics tagged by process, on top of their container tags
+	CollectPerProcess bool `yaml:"collect_per_process"`
+}
+
+// ProcessNetworkCheck submits the TCP metrics of the connections tracked by system-probe,
+// aggregated by container and optionally by process
+type ProcessNetworkCheck struct {
+	core.CheckBase
+	instance *ProcessNetworkConfig
+
+	// the following are replaced in tests
+	getConnections    func() (*model.Connections, error)
+	containerIDForPID func(pid int) (string, error)
+	tagsForEntity     func(entityID string) ([]string, error)
+	processName       func(pid int32) string
+}
+
+func init() {
+	core.RegisterCheck(processNetworkCheckName, ProcessNetworkFactory)
+}
+
+// ProcessNetworkFactory is exported for integration testing
+func ProcessNetworkFactory() check.Check {
+	return &ProcessNetworkCheck{
+		CheckBase:         core.NewCheckBase(processNetworkCheckName),
+		instance:          &ProcessNetworkConfig{},
+		getConnections:    getSystemProbeConnections,
+		containerIDForPID: containerIDForPID,
+		tagsForEntity: func(entityID string) ([]string, error) {
+			return tagger.Tag(entityID, collectors.HighCardinality)
+		},
+		processName: processNameFromProcfs,
+	}
+}
+
+// Parse parses the check configuration
+func (c *ProcessNetworkConfig) Parse(data []byte) error {
+	return yaml.Unmarshal(data, c)
+}
+
+// Configure parses the check configuration and init the check
+func (c *ProcessNetworkCheck) Configure(data, initConfig integration.Data, source string) error {
+	process_net.SetSystemProbePath(config.Datadog.GetString("system_probe_config.sysprobe_socket"))
+
+	if err := c.CommonConfigure(data, source); err != nil {
+		return err
+	}
+
+	return c.instance.Parse(data)
+}
+
+// processNetworkKey identifies the group of connections metrics are aggregated over
+type processNetworkKey struct {
+	containerID string
+	// pid is only set when collecting metrics per process
+	pid int32
+}
+
+type processNetworkStats struct {
+	bytesSent     uint64
+	bytesReceived uint64
+	retransmits   uint32
+	established   int
+	rttSum        uint64
+	rttCount      int
+}
+
+// Run executes the check
+func (c *ProcessNetworkCheck) Run() error {
+	conns, err := c.getConnections()
+	if err != nil {
+		return err
+	}
+
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	for key, stats := range c.aggregate(conns) {
+		tags := c.tags(key)
+
+		sender.Count("network.tcp.bytes_sent", float64(stats.bytesSent), "", tags)
+		sender.Count("network.tcp.bytes_rcvd", float64(stats.bytesReceived), "", tags)
+		sender.Count("network.tcp.retransmits", float64(stats.retransmits), "", tags)
+		sender.Gauge("network.tcp.established", float64(stats.established), "", tags)
+		if stats.rttCount > 0 {
+			// system-probe reports the smoothed round trip time in microseconds
+			sender.Gauge("network.tcp.rtt", float64(stats.rttSum)/float64(stats.rttCount)/1000, "", tags)
+		}
+	}
+
+	sender.Commit()
+	return nil
+}
+
+// aggregate sums the stats of TCP connections by container, and by process if enabled
+func (c *ProcessNetworkCheck) aggregate(conns *model.Connections) map[processNetworkKey]*processNetworkStats {
+	containerIDs := make(map[int32]string)
+	all := make(map[processNetworkKey]*processNetworkStats)
+
+	for _, conn := range conns.GetConns() {
+		if conn.Type != model.ConnectionType_tcp {
+			continue
+		}
+
+		containerID, ok := containerIDs[conn.Pid]
+		if !ok {
+			if conn.Laddr != nil {
+				containerID = conn.Laddr.ContainerId
+			}
+			if containerID == "" && conn.Pid > 0 {
+				var err error
+				if containerID, err = c.containerIDForPID(int(conn.Pid)); err != nil {
+					log.Debugf("unable to resolve the container of pid %d: %s", conn.Pid, err)
+				}
+			}
+			containerIDs[conn.Pid] = containerID
+		}
+
+		key := processNetworkKey{containerID: containerID}
+		if c.instance.CollectPerProcess {
+			key.pid = conn.Pid
+		}
+
+		stats, ok := all[key]
+		if !ok {
+			stats = &processNetworkStats{}
+			all[key] = stats
+		}
+
+		stats.bytesSent += conn.LastBytesSent
+		stats.bytesReceived += conn.LastBytesReceived
+		stats.retransmits += conn.LastRetransmits
+		// connections closed since the last run are reported one last time
+		if conn.LastTcpClosed == 0 {
+			stats.established++
+		}
+		if conn.Rtt > 0 {
+			stats.rttSum += uint64(conn.Rtt)
+			stats.rttCount++
+		}
+	}
+
+	return all
+}
+
+func (c *ProcessNetworkCheck) tags(key processNetworkKey) []string {
+	var tags []string
+	if key.containerID != "" {
+		entityTags, err := c.tagsForEntity(containers.BuildTaggerEntityName(key.containerID))
+		if err != nil {
+			log.Errorf("Error collecting tags for container %s: %s", key.containerID, err)
+		}
+		tags = append(tags, entityTags...)
+	}
+
+	if key.pid > 0 {
+		tags = append(tags, fmt.Sprintf("pid:%d", key.pid))
+		if name := c.processName(key.pid); name != "" {
+			tags = append(tags, "process_name:"+name)
+		}
+	}
+	return tags
+}
+
+func getSystemProbeConnections() (*model.Connections, error) {
+	sysProbeUtil, err := process_net.GetRemoteSystemProbeUtil()
+	if err != nil {
+		return nil, err
+	}
+	return sysProbeUtil.GetConnections(processNetworkClientID)
+}
+
+func containerIDForPID(pid int) (string, error) {
+	return providers.ContainerImpl().ContainerIDForPID(pid)
+}
+
+func processNameFromProcfs(pid int32) string {
+	procfsPath := "/proc"
+	if config.Datadog.IsSet("procfs_path") {
+		procfsPath = config.Datadog.GetString("procfs_path")
+	}
+
+	comm, err := ioutil.ReadFile(filepath.Join(procfsPath, strconv.Itoa(int(pid)), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build cgo
+// +build linux
+
+package net
+
+import (
+	"testing"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProcessNetworkCheck(t *testing.T, config string, conns *model.Connections) *ProcessNetworkCheck {
+	c := ProcessNetworkFactory().(*ProcessNetworkCheck)
+	require.NoError(t, c.Configure([]byte(config), nil, "test"))
+
+	c.getConnections = func() (*model.Connections, error) {
+		return conns, nil
+	}
+	c.containerIDForPID = func(pid int) (string, error) {
+		if pid == 20 {
+			return "cid2", nil
+		}
+		return "", nil
+	}
+	c.tagsForEntity = func(entityID string) ([]string, error) {
+		return []string{"container_id:" + entityID[len("container_id://"):]}, nil
+	}
+	c.processName = func(pid int32) string {
+		return map[int32]string{10: "nginx", 11: "curl", 20: "redis-server"}[pid]
+	}
+	return c
+}
+
+func testConnections() *model.Connections {
+	return &model.Connections{
+		Conns: []*model.Connection{
+			{
+				Pid:               10,
+				Type:              model.ConnectionType_tcp,
+				Laddr:             &model.Addr{ContainerId: "cid1"},
+				LastBytesSent:     100,
+				LastBytesReceived: 200,
+				LastRetransmits:   1,
+				Rtt:               2000,
+			},
+			{
+				Pid:               11,
+				Type:              model.ConnectionType_tcp,
+				Laddr:             &model.Addr{ContainerId: "cid1"},
+				LastBytesSent:     50,
+				LastBytesReceived: 25,
+				Rtt:               4000,
+				LastTcpClosed:     1,
+			},
+			{
+				// the container of this connection is resolved from its pid
+				Pid:               20,
+				Type:              model.ConnectionType_tcp,
+				Laddr:             &model.Addr{},
+				LastBytesSent:     10,
+				LastBytesReceived: 20,
+				LastRetransmits:   3,
+			},
+			{
+				// UDP connections are ignored
+				Pid:               10,
+				Type:              model.ConnectionType_udp,
+				Laddr:             &model.Addr{ContainerId: "cid1"},
+				LastBytesSent:     1000,
+				LastBytesReceived: 1000,
+			},
+		},
+	}
+}
+
+func TestProcessNetworkByContainer(t *testing.T) {
+	c := newTestProcessNetworkCheck(t, ``, testConnections())
+
+	mockSender := mocksender.NewMockSender(c.ID())
+	mockSender.On("Count", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("Commit").Return()
+
+	require.NoError(t, c.Run())
+
+	cid1 := []string{"container_id:cid1"}
+	mockSender.AssertMetric(t, "Count", "network.tcp.bytes_sent", 150, "", cid1)
+	mockSender.AssertMetric(t, "Count", "network.tcp.bytes_rcvd", 225, "", cid1)
+	mockSender.AssertMetric(t, "Count", "network.tcp.retransmits", 1, "", cid1)
+	mockSender.AssertMetric(t, "Gauge", "network.tcp.established", 1, "", cid1)
+	mockSender.AssertMetric(t, "Gauge", "network.tcp.rtt", 3, "", cid1)
+
+	cid2 := []string{"container_id:cid2"}
+	mockSender.AssertMetric(t, "Count", "network.tcp.bytes_sent", 10, "", cid2)
+	mockSender.AssertMetric(t, "Count", "network.tcp.bytes_rcvd", 20, "", cid2)
+	mockSender.AssertMetric(t, "Count", "network.tcp.retransmits", 3, "", cid2)
+	mockSender.AssertMetric(t, "Gauge", "network.tcp.established", 1, "", cid2)
+	mockSender.AssertNotCalled(t, "Gauge", "network.tcp.rtt", mock.Anything, "", cid2)
+
+	mockSender.AssertNumberOfCalls(t, "Count", 6)
+	mockSender.AssertNumberOfCalls(t, "Gauge", 3)
+	mockSender.AssertMetricNotTaggedWith(t, "Count", "network.tcp.bytes_sent", []string{"pid:10"})
+}
+
+func TestProcessNetworkByProcess(t *testing.T) {
+	c := newTestProcessNetworkCheck(t, `collect_per_process: true`, testConnections())
+
+	mockSender := mocksender.NewMockSender(c.ID())
+	mockSender.On("Count", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("Commit").Return()
+
+	require.NoError(t, c.Run())
+
+	mockSender.AssertMetric(t, "Count", "network.tcp.bytes_sent", 100, "", []string{"container_id:cid1", "pid:10", "process_name:nginx"})
+	mockSender.AssertMetric(t, "Count", "network.tcp.bytes_sent", 50, "", []string{"container_id:cid1", "pid:11", "process_name:curl"})
+	mockSender.AssertMetric(t, "Gauge", "network.tcp.established", 0, "", []string{"container_id:cid1", "pid:11", "process_name:curl"})
+	mockSender.AssertMetric(t, "Count", "network.tcp.retransmits", 3, "", []string{"container_id:cid2", "pid:20", "process_name:redis-server"})
+
+	mockSender.AssertNumberOfCalls(t, "Count", 9)
+}
+
+func TestProcessNetworkHostConnections(t *testing.T) {
+	conns := &model.Connections{
+		Conns: []*model.Connection{
+			{Pid: 30, Type: model.ConnectionType_tcp, LastBytesSent: 5},
+		},
+	}
+	c := newTestProcessNetworkCheck(t, ``, conns)
+
+	mockSender := mocksender.NewMockSender(c.ID())
+	mockSender.On("Count", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("Commit").Return()
+
+	require.NoError(t, c.Run())
+
+	// connections outside of containers are reported without container tags
+	var noTags []string
+	mockSender.AssertCalled(t, "Count", "network.tcp.bytes_sent", float64(5), "", noTags)
+	assert.Len(t, mockSender.Calls, 5)
+}
@@ -9,6 +9,7 @@ package systemd
 
 import (
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/coreos/go-systemd/dbus"
+	"golang.org/x/sys/unix"
 	"gopkg.in/yaml.v2"
 
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
@@ -34,17 +36,24 @@ const (
 	typeUnit    = "unit"
 	typeService = "service"
 	typeSocket  = "socket"
+	typeTimer   = "timer"
 
-	canConnectServiceCheck   = "systemd.can_connect"
-	systemStateServiceCheck  = "systemd.system.state"
-	unitStateServiceCheck    = "systemd.unit.state"
-	unitSubStateServiceCheck = "systemd.unit.substate"
+	canConnectServiceCheck    = "systemd.can_connect"
+	systemStateServiceCheck   = "systemd.system.state"
+	unitStateServiceCheck     = "systemd.unit.state"
+	unitSubStateServiceCheck  = "systemd.unit.substate"
+	timerScheduleServiceCheck = "systemd.timer.schedule"
+
+	timerResultSuccess = "success"
+
+	defaultTimerScheduleGracePeriod = 60
 )
 
 var dbusTypeMap = map[string]string{
 	typeUnit:    "Unit",
 	typeService: "Service",
 	typeSocket:  "Socket",
+	typeTimer:   "Timer",
 }
 
 // metricConfigItem map a metric to a systemd unit property.
@@ -88,6 +97,12 @@ var metricConfigs = map[string][]metricConfigItem{
 			propertyName: "NRestarts",
 			optional:     true,
 		},
+		{
+			// exit code, or signal number, of the last run of the main process
+			metricName:   "systemd.service.last_exit_status",
+			propertyName: "ExecMainStatus",
+			optional:     true,
+		},
 	},
 	typeSocket: {
 		{
@@ -143,9 +158,10 @@ type SystemdCheck struct {
 type unitSubstateMapping = map[string]string
 
 type systemdInstanceConfig struct {
-	PrivateSocket         string                         `yaml:"private_socket"`
-	UnitNames             []string                       `yaml:"unit_names"`
-	SubstateStatusMapping map[string]unitSubstateMapping `yaml:"substate_status_mapping"`
+	PrivateSocket            string                         `yaml:"private_socket"`
+	UnitNames                []string                       `yaml:"unit_names"`
+	SubstateStatusMapping    map[string]unitSubstateMapping `yaml:"substate_status_mapping"`
+	TimerScheduleGracePeriod int64                          `yaml:"timer_schedule_grace_period"`
 }
 
 type systemdInitConfig struct{}
@@ -169,6 +185,7 @@ type systemdStats interface {
 
 	// Misc
 	UnixNow() int64
+	MonotonicNow() int64
 }
 
 type defaultSystemdStats struct{}
@@ -205,6 +222,16 @@ func (s *defaultSystemdStats) UnixNow() int64 {
 	return time.Now().Unix()
 }
 
+// MonotonicNow returns the number of seconds on the monotonic clock, which systemd uses for the timers relative to the boot or to units activation
+func (s *defaultSystemdStats) MonotonicNow() int64 {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		log.Debugf("Cannot read the monotonic clock: %v", err)
+		return 0
+	}
+	return ts.Sec
+}
+
 // Run executes the check
 func (c *SystemdCheck) Run() error {
 	sender, err := aggregator.GetSender(c.ID())
@@ -339,6 +366,9 @@ func (c *SystemdCheck) submitMetrics(sender aggregator.Sender, conn *dbus.Conn)
 
 		c.submitBasicUnitMetrics(sender, conn, unit, tags)
 		c.submitPropertyMetricsAsGauge(sender, conn, unit, tags)
+		if strings.HasSuffix(unit.Name, "."+typeTimer) {
+			c.submitTimerMetrics(sender, conn, unit, tags)
+		}
 	}
 
 	sender.Gauge("systemd.units_total", float64(len(units)), "", nil)
@@ -414,6 +444,142 @@ func (c *SystemdCheck) submitPropertyMetricsAsGauge(sender aggregator.Sender, co
 	}
 }
 
+func (c *SystemdCheck) submitTimerMetrics(sender aggregator.Sender, conn *dbus.Conn, unit dbus.UnitStatus, tags []string) {
+	timerProperties, err := c.stats.GetUnitTypeProperties(conn, unit.Name, dbusTypeMap[typeTimer])
+	if err != nil {
+		log.Warnf("Error getting timer properties for unit %s: %v", unit.Name, err)
+		return
+	}
+	now := c.stats.UnixNow()
+	monotonicNow := c.stats.MonotonicNow()
+
+	// LastTriggerUSec is 0 when the timer never elapsed
+	if lastTrigger, err := getPropertyUint64(timerProperties, "LastTriggerUSec"); err == nil && lastTrigger > 0 {
+		sender.Gauge("systemd.timer.time_since_last_trigger", float64(now-int64(lastTrigger/1000000)), "", tags)
+	}
+	if untilNextElapse, scheduled, err := getTimeUntilNextElapse(timerProperties, now, monotonicNow); err == nil && scheduled {
+		sender.Gauge("systemd.timer.time_until_next_trigger", float64(untilNextElapse), "", tags)
+	}
+
+	// the outcome of the last run of a timer is only known from the service it triggers
+	var serviceProperties map[string]interface{}
+	if triggeredUnit, err := getPropertyString(timerProperties, "Unit"); err == nil && strings.HasSuffix(triggeredUnit, "."+typeService) {
+		serviceProperties, err = c.stats.GetUnitTypeProperties(conn, triggeredUnit, dbusTypeMap[typeService])
+		if err != nil {
+			log.Warnf("Error getting service properties for unit %s triggered by timer %s: %v", triggeredUnit, unit.Name, err)
+		}
+	}
+
+	status, message := getTimerScheduleStatus(timerProperties, serviceProperties, now, monotonicNow, c.config.instance.TimerScheduleGracePeriod)
+	sender.ServiceCheck(timerScheduleServiceCheck, status, "", tags, message)
+}
+
+// getTimeUntilNextElapse returns the number of seconds until the next trigger of a timer, negative when it is overdue.
+// Timers are scheduled on the realtime clock (OnCalendar) and/or on the monotonic clock (OnBootSec, OnUnitActiveSec...),
+// in which case systemd triggers them at the earliest of both. scheduled is false when the timer has no next trigger.
+func getTimeUntilNextElapse(properties map[string]interface{}, unixNow int64, monotonicNow int64) (untilNextElapse int64, scheduled bool, err error) {
+	realtime, realtimeErr := getPropertyUint64(properties, "NextElapseUSecRealtime")
+	monotonic, monotonicErr := getPropertyUint64(properties, "NextElapseUSecMonotonic")
+	if realtimeErr != nil && monotonicErr != nil {
+		return 0, false, realtimeErr
+	}
+
+	if realtimeErr == nil && isTimerElapseScheduled(realtime) {
+		untilNextElapse, scheduled = int64(realtime/1000000)-unixNow, true
+	}
+	if monotonicErr == nil && isTimerElapseScheduled(monotonic) {
+		if untilMonotonic := int64(monotonic/1000000) - monotonicNow; !scheduled || untilMonotonic < untilNextElapse {
+			untilNextElapse, scheduled = untilMonotonic, true
+		}
+	}
+	return untilNextElapse, scheduled, nil
+}
+
+// isTimerElapseScheduled returns false for the 0 and infinity values systemd reports when a timer is not scheduled on a clock
+func isTimerElapseScheduled(nextElapse uint64) bool {
+	return nextElapse > 0 && nextElapse != math.MaxUint64
+}
+
+// getTimerPeriod returns the smallest number of seconds between two triggers of a timer set by OnUnitActiveSec.
+// periodic is false when the timer has no such setting (e.g. OnCalendar or OnBootSec only), OnUnitInactiveSec being
+// relative to the end of the triggered unit, whose run time is not bounded.
+func getTimerPeriod(properties map[string]interface{}) (period int64, periodic bool) {
+	// TimersMonotonic is an array of (base, value in microseconds, next elapse) structs
+	timers, ok := properties["TimersMonotonic"].([][]interface{})
+	if !ok {
+		return 0, false
+	}
+	for _, timer := range timers {
+		if len(timer) < 2 {
+			continue
+		}
+		base, ok := timer[0].(string)
+		if !ok || base != "OnUnitActiveUSec" {
+			continue
+		}
+		value, ok := timer[1].(uint64)
+		if !ok || value == 0 {
+			continue
+		}
+		if seconds := int64(value / 1000000); !periodic || seconds < period {
+			period, periodic = seconds, true
+		}
+	}
+	return period, periodic
+}
+
+// getTimerScheduleStatus returns a CRITICAL status when a timer or the last run of the service it triggers failed,
+// when its next trigger is overdue, or when it did not trigger for longer than its period.
+// serviceProperties is nil when the properties of the triggered service are unknown.
+func getTimerScheduleStatus(properties map[string]interface{}, serviceProperties map[string]interface{}, unixNow int64, monotonicNow int64, gracePeriod int64) (metrics.ServiceCheckStatus, string) {
+	result, err := getPropertyString(properties, "Result")
+	if err != nil {
+		return metrics.ServiceCheckUnknown, fmt.Sprintf("Cannot get the timer result: %v", err)
+	}
+	if result != timerResultSuccess {
+		return metrics.ServiceCheckCritical, fmt.Sprintf("Timer failed with result '%s'", result)
+	}
+
+	if serviceProperties != nil {
+		if serviceResult, err := getPropertyString(serviceProperties, "Result"); err == nil && serviceResult != timerResultSuccess {
+			return metrics.ServiceCheckCritical, fmt.Sprintf("Triggered service failed with result '%s'", serviceResult)
+		}
+		if exitStatus, err := getPropertyNumber(serviceProperties, "ExecMainStatus"); err == nil && exitStatus != 0 {
+			return metrics.ServiceCheckCritical, fmt.Sprintf("Triggered service exited with status %d", int64(exitStatus))
+		}
+	}
+
+	untilNextElapse, scheduled, err := getTimeUntilNextElapse(properties, unixNow, monotonicNow)
+	if err != nil {
+		return metrics.ServiceCheckUnknown, fmt.Sprintf("Cannot get the next trigger of the timer: %v", err)
+	}
+	if !scheduled {
+		return metrics.ServiceCheckOK, ""
+	}
+
+	// systemd may trigger a timer later than scheduled, up to its accuracy and its randomized delay
+	allowedDelay := gracePeriod
+	for _, delayProperty := range []string{"AccuracyUSec", "RandomizedDelayUSec"} {
+		if delay, err := getPropertyUint64(properties, delayProperty); err == nil {
+			allowedDelay += int64(delay / 1000000)
+		}
+	}
+
+	if overdue := -untilNextElapse; overdue > allowedDelay {
+		return metrics.ServiceCheckCritical, fmt.Sprintf("Timer missed its schedule, it was expected to trigger %d seconds ago", overdue)
+	}
+
+	// LastTriggerUSec is 0 when the timer never elapsed
+	if period, periodic := getTimerPeriod(properties); periodic {
+		if lastTrigger, err := getPropertyUint64(properties, "LastTriggerUSec"); err == nil && lastTrigger > 0 {
+			if sinceLastTrigger := unixNow - int64(lastTrigger/1000000); sinceLastTrigger > period+allowedDelay {
+				return metrics.ServiceCheckCritical, fmt.Sprintf("Timer last triggered %d seconds ago, while its period is %d seconds", sinceLastTrigger, period)
+			}
+		}
+	}
+	return metrics.ServiceCheckOK, ""
+}
+
 func sendServicePropertyAsGauge(sender aggregator.Sender, properties map[string]interface{}, service metricConfigItem, tags []string) error {
 	if service.accountingProperty != "" {
 		accounting, err := getPropertyBool(properties, service.accountingProperty)
@@ -425,11 +591,11 @@ func sendServicePropertyAsGauge(sender aggregator.Sender, properties map[string]
 			return nil
 		}
 	}
-	value, err := getPropertyUint64(properties, service.propertyName)
+	value, err := getPropertyNumber(properties, service.propertyName)
 	if err != nil {
 		return fmt.Errorf("error getting property %s: %v", service.propertyName, err)
 	}
-	sender.Gauge(service.metricName, float64(value), "", tags)
+	sender.Gauge(service.metricName, value, "", tags)
 	return nil
 }
 
@@ -461,6 +627,28 @@ func getPropertyUint64(properties map[string]interface{}, propertyName string) (
 	return 0, fmt.Errorf("property %s (%T) cannot be converted to uint64", propertyName, prop)
 }
 
+func getPropertyNumber(properties map[string]interface{}, propertyName string) (float64, error) {
+	prop, ok := properties[propertyName]
+	if !ok {
+		return 0, fmt.Errorf("property %s not found", propertyName)
+	}
+	switch typedProp := prop.(type) {
+	case uint:
+		return float64(typedProp), nil
+	case uint32:
+		return float64(typedProp), nil
+	case uint64:
+		return float64(typedProp), nil
+	case int:
+		return float64(typedProp), nil
+	case int32:
+		return float64(typedProp), nil
+	case int64:
+		return float64(typedProp), nil
+	}
+	return 0, fmt.Errorf("property %s (%T) cannot be converted to a number", propertyName, prop)
+}
+
 func getPropertyString(properties map[string]interface{}, propertyName string) (string, error) {
 	prop, ok := properties[propertyName]
 	if !ok {
@@ -531,6 +719,7 @@ func (c *SystemdCheck) Configure(rawInstance integration.Data, rawInitConfig int
 	if err != nil {
 		return err
 	}
+	c.config.instance.TimerScheduleGracePeriod = defaultTimerScheduleGracePeriod
 	err = yaml.Unmarshal(rawInstance, &c.config.instance)
 	if err != nil {
 		return err
@@ -540,6 +729,10 @@ func (c *SystemdCheck) Configure(rawInstance integration.Data, rawInitConfig int
 		return fmt.Errorf("instance config `unit_names` must not be empty")
 	}
 
+	if c.config.instance.TimerScheduleGracePeriod < 0 {
+		return fmt.Errorf("instance config `timer_schedule_grace_period` must not be negative")
+	}
+
 	for unitNameInMapping := range c.config.instance.SubstateStatusMapping {
 		if !c.isMonitored(unitNameInMapping) {
 			return fmt.Errorf("instance config specifies a custom substate mapping for unit '%s' but this unit is not monitored. Please add '%s' to 'unit_names'", unitNameInMapping, unitNameInMapping)
@@ -10,6 +10,7 @@ package systemd
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"testing"
 	"time"
@@ -76,6 +77,11 @@ func (s *mockSystemdStats) UnixNow() int64 {
 	return args.Get(0).(int64)
 }
 
+func (s *mockSystemdStats) MonotonicNow() int64 {
+	args := s.Mock.Called()
+	return args.Get(0).(int64)
+}
+
 func (s *mockSystemdStats) GetUnitTypeProperties(conn *dbus.Conn, unitName string, unitType string) (map[string]interface{}, error) {
 	args := s.Mock.Called(conn, unitName, unitType)
 	return args.Get(0).(map[string]interface{}), args.Error(1)
@@ -104,6 +110,7 @@ unit_names:
 
 	assert.Nil(t, err)
 	assert.ElementsMatch(t, []string{"ssh.service", "syslog.socket"}, check.config.instance.UnitNames)
+	assert.Equal(t, int64(defaultTimerScheduleGracePeriod), check.config.instance.TimerScheduleGracePeriod)
 }
 
 func TestMissingUnitNamesShouldRaiseError(t *testing.T) {
@@ -406,16 +413,18 @@ unit_names:
 	}, nil)
 	stats.On("UnixNow").Return(int64(1000))
 	stats.On("GetUnitTypeProperties", mock.Anything, "unit1.service", dbusTypeMap[typeService]).Return(getCreatePropertieWithDefaults(map[string]interface{}{
-		"CPUUsageNSec":  uint64(10),
-		"MemoryCurrent": uint64(20),
-		"TasksCurrent":  uint64(30),
-		"NRestarts":     uint64(40),
+		"CPUUsageNSec":   uint64(10),
+		"MemoryCurrent":  uint64(20),
+		"TasksCurrent":   uint64(30),
+		"NRestarts":      uint64(40),
+		"ExecMainStatus": int32(1),
 	}), nil)
 	stats.On("GetUnitTypeProperties", mock.Anything, "unit2.service", dbusTypeMap[typeService]).Return(getCreatePropertieWithDefaults(map[string]interface{}{
-		"CPUUsageNSec":  uint64(110),
-		"MemoryCurrent": uint64(120),
-		"TasksCurrent":  uint64(130),
-		"NRestarts":     uint64(140),
+		"CPUUsageNSec":   uint64(110),
+		"MemoryCurrent":  uint64(120),
+		"TasksCurrent":   uint64(130),
+		"NRestarts":      uint64(140),
+		"ExecMainStatus": int32(0),
 	}), nil)
 	stats.On("GetUnitTypeProperties", mock.Anything, "unit1.service", dbusTypeMap[typeUnit]).Return(map[string]interface{}{
 		"ActiveEnterTimestamp": uint64(100 * 1000 * 1000),
@@ -447,12 +456,14 @@ unit_names:
 	mockSender.AssertCalled(t, "Gauge", "systemd.service.memory_usage", float64(20), "", tags)
 	mockSender.AssertCalled(t, "Gauge", "systemd.service.task_count", float64(30), "", tags)
 	mockSender.AssertCalled(t, "Gauge", "systemd.service.restart_count", float64(40), "", tags)
+	mockSender.AssertCalled(t, "Gauge", "systemd.service.last_exit_status", float64(1), "", tags)
 
 	tags = []string{"unit:unit2.service"}
 	mockSender.AssertCalled(t, "Gauge", "systemd.service.cpu_time_consumed", float64(110), "", tags)
+	mockSender.AssertCalled(t, "Gauge", "systemd.service.last_exit_status", float64(0), "", tags)
 
 	expectedGaugeCalls := 8     /* overall metrics */
-	expectedGaugeCalls += 2 * 8 /* unit/service metrics */
+	expectedGaugeCalls += 2 * 9 /* unit/service metrics */
 	mockSender.AssertNumberOfCalls(t, "Gauge", expectedGaugeCalls)
 	mockSender.AssertNumberOfCalls(t, "Commit", 1)
 	mockSender.AssertNumberOfCalls(t, "ServiceCheck", 4)
@@ -533,6 +544,164 @@ unit_names:
 	mockSender.AssertCalled(t, "Gauge", "systemd.socket.connection_refused_count", mock.Anything, "", tags)
 }
 
+func TestTimerMetrics(t *testing.T) {
+	rawInstanceConfig := []byte(`
+unit_names:
+ - backup.timer
+ - cleanup.timer
+ - refresh.timer
+ - sync.timer
+`)
+
+	stats := createDefaultMockSystemdStats()
+	stats.On("ListUnits", mock.Anything).Return([]dbus.UnitStatus{
+		{Name: "backup.timer", ActiveState: "active", LoadState: "loaded"},
+		{Name: "cleanup.timer", ActiveState: "active", LoadState: "loaded"},
+		{Name: "refresh.timer", ActiveState: "active", LoadState: "loaded"},
+		{Name: "sync.timer", ActiveState: "active", LoadState: "loaded"},
+	}, nil)
+	stats.On("UnixNow").Return(int64(10000))
+	stats.On("MonotonicNow").Return(int64(5000))
+	stats.On("GetUnitTypeProperties", mock.Anything, mock.Anything, dbusTypeMap[typeUnit]).Return(map[string]interface{}{
+		"ActiveEnterTimestamp": uint64(1000 * 1000 * 1000),
+	}, nil)
+	stats.On("GetUnitTypeProperties", mock.Anything, "backup.timer", dbusTypeMap[typeTimer]).Return(map[string]interface{}{
+		"Unit":                    "backup.service",
+		"Result":                  "success",
+		"LastTriggerUSec":         uint64(9400 * 1000 * 1000),
+		"NextElapseUSecRealtime":  uint64(10600 * 1000 * 1000),
+		"NextElapseUSecMonotonic": uint64(0),
+		"AccuracyUSec":            uint64(60 * 1000 * 1000),
+	}, nil)
+	// cleanup.timer should have triggered 1000 seconds ago
+	stats.On("GetUnitTypeProperties", mock.Anything, "cleanup.timer", dbusTypeMap[typeTimer]).Return(map[string]interface{}{
+		"Result":                  "success",
+		"LastTriggerUSec":         uint64(0),
+		"NextElapseUSecRealtime":  uint64(9000 * 1000 * 1000),
+		"NextElapseUSecMonotonic": uint64(0),
+		"AccuracyUSec":            uint64(60 * 1000 * 1000),
+	}, nil)
+	// refresh.timer is scheduled on the monotonic clock only, and should have triggered 500 seconds ago
+	stats.On("GetUnitTypeProperties", mock.Anything, "refresh.timer", dbusTypeMap[typeTimer]).Return(map[string]interface{}{
+		"Result":                  "success",
+		"LastTriggerUSec":         uint64(8000 * 1000 * 1000),
+		"NextElapseUSecRealtime":  uint64(0),
+		"NextElapseUSecMonotonic": uint64(4500 * 1000 * 1000),
+		"AccuracyUSec":            uint64(60 * 1000 * 1000),
+	}, nil)
+	// sync.timer triggered its service, which failed
+	stats.On("GetUnitTypeProperties", mock.Anything, "sync.timer", dbusTypeMap[typeTimer]).Return(map[string]interface{}{
+		"Unit":                    "sync.service",
+		"Result":                  "success",
+		"LastTriggerUSec":         uint64(9400 * 1000 * 1000),
+		"NextElapseUSecRealtime":  uint64(10600 * 1000 * 1000),
+		"NextElapseUSecMonotonic": uint64(0),
+	}, nil)
+	stats.On("GetUnitTypeProperties", mock.Anything, "backup.service", dbusTypeMap[typeService]).Return(map[string]interface{}{
+		"Result":         "success",
+		"ExecMainStatus": int32(0),
+	}, nil)
+	stats.On("GetUnitTypeProperties", mock.Anything, "sync.service", dbusTypeMap[typeService]).Return(map[string]interface{}{
+		"Result":         "exit-code",
+		"ExecMainStatus": int32(2),
+	}, nil)
+	stats.On("GetVersion", mock.Anything).Return(systemdVersion)
+
+	check := SystemdCheck{stats: stats}
+	check.Configure(rawInstanceConfig, nil, "test")
+
+	// setup expectation
+	mockSender := mocksender.NewMockSender(check.ID())
+	mockSender.On("Gauge", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("ServiceCheck", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	mockSender.On("Commit").Return()
+
+	// run
+	check.Run()
+
+	// assertions
+	tags := []string{"unit:backup.timer"}
+	mockSender.AssertCalled(t, "Gauge", "systemd.timer.time_since_last_trigger", float64(600), "", tags)
+	mockSender.AssertCalled(t, "Gauge", "systemd.timer.time_until_next_trigger", float64(600), "", tags)
+	mockSender.AssertCalled(t, "ServiceCheck", timerScheduleServiceCheck, metrics.ServiceCheckOK, "", tags, "")
+
+	tags = []string{"unit:cleanup.timer"}
+	mockSender.AssertNotCalled(t, "Gauge", "systemd.timer.time_since_last_trigger", mock.Anything, "", tags)
+	mockSender.AssertCalled(t, "Gauge", "systemd.timer.time_until_next_trigger", float64(-1000), "", tags)
+	mockSender.AssertCalled(t, "ServiceCheck", timerScheduleServiceCheck, metrics.ServiceCheckCritical, "", tags, "Timer missed its schedule, it was expected to trigger 1000 seconds ago")
+
+	tags = []string{"unit:refresh.timer"}
+	mockSender.AssertCalled(t, "Gauge", "systemd.timer.time_since_last_trigger", float64(2000), "", tags)
+	mockSender.AssertCalled(t, "Gauge", "systemd.timer.time_until_next_trigger", float64(-500), "", tags)
+	mockSender.AssertCalled(t, "ServiceCheck", timerScheduleServiceCheck, metrics.ServiceCheckCritical, "", tags, "Timer missed its schedule, it was expected to trigger 500 seconds ago")
+
+	tags = []string{"unit:sync.timer"}
+	mockSender.AssertCalled(t, "ServiceCheck", timerScheduleServiceCheck, metrics.ServiceCheckCritical, "", tags, "Triggered service failed with result 'exit-code'")
+}
+
+func TestGetTimerScheduleStatus(t *testing.T) {
+	data := map[string]struct {
+		properties     map[string]interface{}
+		gracePeriod    int64
+		expectedStatus metrics.ServiceCheckStatus
+	}{
+		"next trigger in the future": {map[string]interface{}{"Result": "success", "NextElapseUSecRealtime": uint64(1100 * 1000 * 1000)}, 0, metrics.ServiceCheckOK},
+		"not scheduled on realtime":  {map[string]interface{}{"Result": "success", "NextElapseUSecRealtime": uint64(0)}, 0, metrics.ServiceCheckOK},
+		"overdue within grace":       {map[string]interface{}{"Result": "success", "NextElapseUSecRealtime": uint64(950 * 1000 * 1000)}, 60, metrics.ServiceCheckOK},
+		"overdue within accuracy":    {map[string]interface{}{"Result": "success", "NextElapseUSecRealtime": uint64(950 * 1000 * 1000), "AccuracyUSec": uint64(30 * 1000 * 1000), "RandomizedDelayUSec": uint64(30 * 1000 * 1000)}, 0, metrics.ServiceCheckOK},
+		"overdue":                    {map[string]interface{}{"Result": "success", "NextElapseUSecRealtime": uint64(900 * 1000 * 1000)}, 60, metrics.ServiceCheckCritical},
+		"failed":                     {map[string]interface{}{"Result": "start-limit-hit", "NextElapseUSecRealtime": uint64(1100 * 1000 * 1000)}, 0, metrics.ServiceCheckCritical},
+		"missing result":             {map[string]interface{}{}, 0, metrics.ServiceCheckUnknown},
+		"missing next trigger":       {map[string]interface{}{"Result": "success"}, 0, metrics.ServiceCheckUnknown},
+		"monotonic in the future":    {map[string]interface{}{"Result": "success", "NextElapseUSecRealtime": uint64(0), "NextElapseUSecMonotonic": uint64(600 * 1000 * 1000)}, 0, metrics.ServiceCheckOK},
+		"monotonic overdue":          {map[string]interface{}{"Result": "success", "NextElapseUSecRealtime": uint64(0), "NextElapseUSecMonotonic": uint64(400 * 1000 * 1000)}, 60, metrics.ServiceCheckCritical},
+		"monotonic unscheduled":      {map[string]interface{}{"Result": "success", "NextElapseUSecMonotonic": uint64(math.MaxUint64)}, 0, metrics.ServiceCheckOK},
+		"earliest clock overdue":     {map[string]interface{}{"Result": "success", "NextElapseUSecRealtime": uint64(1100 * 1000 * 1000), "NextElapseUSecMonotonic": uint64(400 * 1000 * 1000)}, 60, metrics.ServiceCheckCritical},
+		"triggered within period":    {map[string]interface{}{"Result": "success", "NextElapseUSecMonotonic": uint64(600 * 1000 * 1000), "LastTriggerUSec": uint64(750 * 1000 * 1000), "TimersMonotonic": [][]interface{}{{"OnUnitActiveUSec", uint64(300 * 1000 * 1000), uint64(600 * 1000 * 1000)}}}, 0, metrics.ServiceCheckOK},
+		"not triggered for a period": {map[string]interface{}{"Result": "success", "NextElapseUSecMonotonic": uint64(600 * 1000 * 1000), "LastTriggerUSec": uint64(500 * 1000 * 1000), "TimersMonotonic": [][]interface{}{{"OnUnitActiveUSec", uint64(300 * 1000 * 1000), uint64(600 * 1000 * 1000)}}}, 60, metrics.ServiceCheckCritical},
+		"inactive period ignored":    {map[string]interface{}{"Result": "success", "NextElapseUSecMonotonic": uint64(600 * 1000 * 1000), "LastTriggerUSec": uint64(500 * 1000 * 1000), "TimersMonotonic": [][]interface{}{{"OnUnitInactiveUSec", uint64(300 * 1000 * 1000), uint64(600 * 1000 * 1000)}}}, 0, metrics.ServiceCheckOK},
+	}
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			status, _ := getTimerScheduleStatus(d.properties, nil, 1000, 500, d.gracePeriod)
+			assert.Equal(t, d.expectedStatus, status)
+		})
+	}
+}
+
+func TestGetTimerScheduleStatusTriggeredService(t *testing.T) {
+	timerProperties := map[string]interface{}{"Result": "success", "NextElapseUSecRealtime": uint64(1100 * 1000 * 1000)}
+	data := map[string]struct {
+		serviceProperties map[string]interface{}
+		expectedStatus    metrics.ServiceCheckStatus
+		expectedMessage   string
+	}{
+		"succeeded":        {map[string]interface{}{"Result": "success", "ExecMainStatus": int32(0)}, metrics.ServiceCheckOK, ""},
+		"unknown":          {nil, metrics.ServiceCheckOK, ""},
+		"failed":           {map[string]interface{}{"Result": "timeout", "ExecMainStatus": int32(0)}, metrics.ServiceCheckCritical, "Triggered service failed with result 'timeout'"},
+		"non-zero status":  {map[string]interface{}{"Result": "success", "ExecMainStatus": int32(3)}, metrics.ServiceCheckCritical, "Triggered service exited with status 3"},
+		"missing property": {map[string]interface{}{}, metrics.ServiceCheckOK, ""},
+	}
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			status, message := getTimerScheduleStatus(timerProperties, d.serviceProperties, 1000, 500, 0)
+			assert.Equal(t, d.expectedStatus, status)
+			assert.Equal(t, d.expectedMessage, message)
+		})
+	}
+}
+
+func TestInvalidTimerScheduleGracePeriod(t *testing.T) {
+	check := SystemdCheck{}
+	rawInstanceConfig := []byte(`
+unit_names:
+ - backup.timer
+timer_schedule_grace_period: -1
+`)
+	err := check.Configure(rawInstanceConfig, []byte(``), "test")
+	assert.EqualError(t, err, "instance config `timer_schedule_grace_period` must not be negative")
+}
+
 func TestSubmitMonitoredServiceMetrics(t *testing.T) {
 	rawInstanceConfig := []byte(`
 unit_names:
@@ -917,6 +1086,36 @@ func TestGetPropertyUint64(t *testing.T) {
 	}
 }
 
+func TestGetPropertyNumber(t *testing.T) {
+	properties := map[string]interface{}{
+		"prop_uint":   uint(3),
+		"prop_uint64": uint64(10),
+		"prop_int32":  int32(-15),
+		"prop_int64":  int64(20),
+		"prop_string": "foo bar",
+	}
+
+	data := map[string]struct {
+		propertyName   string
+		expectedNumber float64
+		expectedError  error
+	}{
+		"prop_uint property retrieved": {"prop_uint", 3, nil},
+		"uint64 property retrieved":    {"prop_uint64", 10, nil},
+		"int32 property retrieved":     {"prop_int32", -15, nil},
+		"int64 property retrieved":     {"prop_int64", 20, nil},
+		"error string not valid":       {"prop_string", 0, fmt.Errorf("property prop_string (string) cannot be converted to a number")},
+		"error prop not exist":         {"prop_not_exist", 0, fmt.Errorf("property prop_not_exist not found")},
+	}
+	for name, d := range data {
+		t.Run(name, func(t *testing.T) {
+			num, err := getPropertyNumber(properties, d.propertyName)
+			assert.Equal(t, d.expectedNumber, num)
+			assert.Equal(t, d.expectedError, err)
+		})
+	}
+}
+
 func TestGetPropertyString(t *testing.T) {
 	properties := map[string]interface{}{
 		"prop_uint":   uint(3),
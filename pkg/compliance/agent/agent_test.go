@@ -220,7 +220,7 @@ func TestRunChecks(t *testing.T) {
 	dockerClient.On("Close").Return(nil).Once()
 	defer dockerClient.AssertExpectations(t)
 
-	err := RunChecks(
+	_, err := RunChecks(
 		reporter,
 		e.dir,
 		checks.WithMatchSuite(checks.IsFramework("cis-docker")),
@@ -268,7 +268,7 @@ func TestRunChecksFromFile(t *testing.T) {
 		"node-role.kubernetes.io/worker": "",
 	}
 
-	err := RunChecksFromFile(
+	statuses, err := RunChecksFromFile(
 		reporter,
 		filepath.Join(e.dir, "cis-kubernetes.yaml"),
 		checks.WithHostname("the-host"),
@@ -278,4 +278,8 @@ func TestRunChecksFromFile(t *testing.T) {
 		checks.WithKubernetesClient(kubeClient, "kube_system_uuid"),
 	)
 	assert.NoError(err)
+	assert.NotEmpty(statuses)
+	assert.Equal("cis-kubernetes-1", statuses[0].RuleID)
+	assert.Equal("CIS Kubernetes Generic", statuses[0].Suite)
+	assert.Equal("cis-kubernetes", statuses[0].Framework)
 }
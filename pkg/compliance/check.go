@@ -18,6 +18,7 @@ type CheckStatus struct {
 	RuleID      string
 	Name        string
 	Description string
+	Suite       string
 	Version     string
 	Framework   string
 	Source      string
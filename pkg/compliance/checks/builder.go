@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"strings"
 	"time"
@@ -236,6 +237,14 @@ func WithRegoInputDumpPath(regoInputDumpPath string) BuilderOption {
 	}
 }
 
+// WithRegoTrace configures a builder to write the Rego evaluation trace of every rego check to w
+func WithRegoTrace(w io.Writer) BuilderOption {
+	return func(b *builder) error {
+		b.regoTraceWriter = w
+		return nil
+	}
+}
+
 // IsFramework matches a compliance suite by the name of the framework
 func IsFramework(framework string) SuiteMatcher {
 	return func(s *compliance.SuiteMeta) bool {
@@ -296,6 +305,7 @@ type builder struct {
 
 	regoInputOverride map[string]eval.RegoInputMap
 	regoInputDumpPath string
+	regoTraceWriter   io.Writer
 
 	status *status
 }
@@ -340,6 +350,7 @@ func (b *builder) addCheckAndRun(suite *compliance.Suite, r *compliance.RuleComm
 			RuleID:      r.ID,
 			Description: r.Description,
 			Name:        compliance.CheckName(r.ID, r.Description),
+			Suite:       suite.Meta.Name,
 			Framework:   suite.Meta.Framework,
 			Source:      suite.Meta.Source,
 			Version:     suite.Meta.Version,
@@ -425,6 +436,12 @@ func (b *builder) GetCheckStatus() compliance.CheckStatusList {
 }
 
 func (b *builder) checkFromRule(meta *compliance.SuiteMeta, rule *compliance.ConditionFallbackRule) (compliance.Check, error) {
+	// condition rules are evaluated against the live system, they can't run on a provided rego input
+	if b.regoInputOverride != nil {
+		log.Debugf("rule %s/%s discarded - rego input is overridden", meta.Framework, rule.ID)
+		return nil, ErrRuleDoesNotApply
+	}
+
 	ruleScope, err := getRuleScope(meta, rule.Scope)
 	if err != nil {
 		return nil, err
@@ -451,7 +468,12 @@ func (b *builder) checkFromRegoRule(meta *compliance.SuiteMeta, rule *compliance
 	}
 
 	// skip host match check if rego input is overridden
-	if b.regoInputOverride == nil {
+	if b.regoInputOverride != nil {
+		if _, found := b.regoInputOverride[rule.ID]; !found {
+			log.Debugf("rule %s/%s discarded - no provided rego input", meta.Framework, rule.ID)
+			return nil, ErrRuleDoesNotApply
+		}
+	} else {
 		eligible, err := b.hostMatcher(ruleScope, rule.ID, rule.HostSelector)
 		if err != nil {
 			return nil, err
@@ -734,6 +756,10 @@ func (b *builder) DumpInputPath() string {
 	return b.regoInputDumpPath
 }
 
+func (b *builder) RegoTraceWriter() io.Writer {
+	return b.regoTraceWriter
+}
+
 func (b *builder) Hostname() string {
 	return b.hostname
 }
@@ -10,6 +10,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/DataDog/datadog-agent/pkg/compliance"
 	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
 	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
 	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
@@ -220,3 +221,49 @@ func TestResolveValueFrom(t *testing.T) {
 		})
 	}
 }
+
+func TestProvidedRegoInputSkipsRules(t *testing.T) {
+	assert := assert.New(t)
+
+	b := &builder{
+		regoInputOverride: map[string]eval.RegoInputMap{
+			"rego-rule": {
+				"processes": []interface{}{},
+			},
+		},
+	}
+
+	meta := &compliance.SuiteMeta{Framework: "cis-test"}
+
+	_, err := b.checkFromRule(meta, &compliance.ConditionFallbackRule{
+		RuleCommon: compliance.RuleCommon{
+			ID:    "condition-rule",
+			Scope: compliance.RuleScopeList{compliance.DockerScope},
+		},
+	})
+	assert.Equal(ErrRuleDoesNotApply, err)
+
+	_, err = b.checkFromRegoRule(meta, &compliance.RegoRule{
+		RuleCommon: compliance.RuleCommon{
+			ID:    "missing-rule",
+			Scope: compliance.RuleScopeList{compliance.DockerScope},
+		},
+	})
+	assert.Equal(ErrRuleDoesNotApply, err)
+
+	check, err := b.checkFromRegoRule(meta, &compliance.RegoRule{
+		RuleCommon: compliance.RuleCommon{
+			ID:    "rego-rule",
+			Scope: compliance.RuleScopeList{compliance.DockerScope},
+		},
+		Module: `
+			package datadog
+
+			findings[f] {
+				f := {"status": "passed"}
+			}
+		`,
+	})
+	assert.NoError(err)
+	assert.NotNil(check)
+}
@@ -6,6 +6,8 @@
 package env
 
 import (
+	"io"
+
 	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
 )
@@ -29,6 +31,7 @@ type Clients interface {
 type RegoConfiguration interface {
 	ProvidedInput(ruleID string) eval.RegoInputMap
 	DumpInputPath() string
+	RegoTraceWriter() io.Writer
 }
 
 // Configuration provides an abstraction for various environment methods used by checks
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package checks
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const procModulesPath = "/proc/modules"
+
+var kernelModuleReportedFields = []string{
+	compliance.KernelModuleFieldName,
+	compliance.KernelModuleFieldLoaded,
+	compliance.KernelModuleFieldState,
+}
+
+type kernelModule struct {
+	name  string
+	size  int
+	state string
+}
+
+func resolveKernelModule(_ context.Context, e env.Env, id string, res compliance.ResourceCommon, rego bool) (resolved, error) {
+	if res.KernelModule == nil {
+		return nil, fmt.Errorf("%s: expecting kernel module resource in kernel module check", id)
+	}
+
+	name := res.KernelModule.Name
+	if name == "" {
+		return nil, fmt.Errorf("%s: kernel module resource is missing name", id)
+	}
+
+	log.Debugf("%s: running kernel module check: %s", id, name)
+
+	modules, err := readKernelModules(e.NormalizeToHostRoot(procModulesPath))
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read kernel modules: %w", id, err)
+	}
+
+	// module names are reported with underscores even when loaded with dashes
+	module, loaded := modules[strings.ReplaceAll(name, "-", "_")]
+	if !loaded {
+		module = kernelModule{name: name}
+	}
+
+	instance := eval.NewInstance(
+		eval.VarMap{
+			compliance.KernelModuleFieldName:   module.name,
+			compliance.KernelModuleFieldLoaded: loaded,
+			compliance.KernelModuleFieldSize:   module.size,
+			compliance.KernelModuleFieldState:  module.state,
+		},
+		nil,
+		eval.RegoInputMap{
+			"name":   module.name,
+			"loaded": loaded,
+			"size":   module.size,
+			"state":  module.state,
+		},
+	)
+
+	return newResolvedInstance(instance, module.name, "kernel_module"), nil
+}
+
+// readKernelModules parses /proc/modules where each line is formatted as
+// name size refcount dependencies state address
+func readKernelModules(path string) (map[string]kernelModule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	modules := make(map[string]kernelModule)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+
+		size, err := strconv.Atoi(fields[1])
+		if err != nil {
+			log.Debugf("failed to parse size of kernel module %s: %v", fields[0], err)
+		}
+
+		modules[fields[0]] = kernelModule{
+			name:  fields[0],
+			size:  size,
+			state: fields[4],
+		}
+	}
+
+	return modules, scanner.Err()
+}
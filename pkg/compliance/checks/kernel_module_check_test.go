@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package checks
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
+
+	"github.com/stretchr/testify/mock"
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestKernelModuleCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		resource     compliance.Resource
+		expectReport *compliance.Report
+	}{
+		{
+			name: "module not loaded",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					KernelModule: &compliance.KernelModule{
+						Name: "cramfs",
+					},
+				},
+				Condition: `!kernelModule.loaded`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"kernelModule.name":   "cramfs",
+					"kernelModule.loaded": false,
+					"kernelModule.state":  "",
+				},
+				Resource: compliance.ReportResource{
+					ID:   "cramfs",
+					Type: "kernel_module",
+				},
+			},
+		},
+		{
+			name: "module loaded with dashes in name",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					KernelModule: &compliance.KernelModule{
+						Name: "br-netfilter",
+					},
+				},
+				Condition: `!kernelModule.loaded`,
+			},
+			expectReport: &compliance.Report{
+				Passed: false,
+				Data: event.Data{
+					"kernelModule.name":   "br_netfilter",
+					"kernelModule.loaded": true,
+					"kernelModule.state":  "Live",
+				},
+				Resource: compliance.ReportResource{
+					ID:   "br_netfilter",
+					Type: "kernel_module",
+				},
+			},
+		},
+		{
+			name: "module state",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					KernelModule: &compliance.KernelModule{
+						Name: "usb_storage",
+					},
+				},
+				Condition: `kernelModule.state == "Unloading" && kernelModule.size > 0`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"kernelModule.name":   "usb_storage",
+					"kernelModule.loaded": true,
+					"kernelModule.state":  "Unloading",
+				},
+				Resource: compliance.ReportResource{
+					ID:   "usb_storage",
+					Type: "kernel_module",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			env := &mocks.Env{}
+			env.On("MaxEventsPerRun").Return(30).Maybe()
+			env.On("NormalizeToHostRoot", mock.Anything).Return(hostRootMapper("./testdata/kernel_module"))
+			defer env.AssertExpectations(t)
+
+			moduleCheck, err := newResourceCheck(env, "rule-id", test.resource)
+			assert.NoError(err)
+
+			reports := moduleCheck.check(env)
+			assert.NoError(reports[0].Error)
+			assert.Equal(test.expectReport, reports[0])
+		})
+	}
+}
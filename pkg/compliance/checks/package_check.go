@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package checks
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	dpkgStatusPath = "/var/lib/dpkg/status"
+	rpmDBPath      = "/var/lib/rpm"
+
+	packageManagerDpkg = "dpkg"
+	packageManagerRPM  = "rpm"
+)
+
+var packageReportedFields = []string{
+	compliance.PackageFieldName,
+	compliance.PackageFieldVersion,
+	compliance.PackageFieldInstalled,
+}
+
+// ErrPackageManagerNotFound is returned when neither a dpkg nor a rpm database can be found
+var ErrPackageManagerNotFound = errors.New("no supported package database found")
+
+type packageInfo struct {
+	name      string
+	version   string
+	installed bool
+	manager   string
+}
+
+func resolvePackage(_ context.Context, e env.Env, id string, res compliance.ResourceCommon, rego bool) (resolved, error) {
+	if res.Package == nil {
+		return nil, fmt.Errorf("%s: expecting package resource in package check", id)
+	}
+
+	name := res.Package.Name
+	if name == "" {
+		return nil, fmt.Errorf("%s: package resource is missing name", id)
+	}
+
+	log.Debugf("%s: running package check: %s", id, name)
+
+	var (
+		pkg *packageInfo
+		err error
+	)
+
+	if statusPath := e.NormalizeToHostRoot(dpkgStatusPath); fileExists(statusPath) {
+		pkg, err = findDpkgPackage(statusPath, name)
+	} else if dbPath := e.NormalizeToHostRoot(rpmDBPath); fileExists(dbPath) {
+		pkg, err = findRPMPackage(dbPath, name)
+	} else {
+		err = ErrPackageManagerNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to look up package %s: %w", id, name, err)
+	}
+
+	instance := eval.NewInstance(
+		eval.VarMap{
+			compliance.PackageFieldName:      pkg.name,
+			compliance.PackageFieldVersion:   pkg.version,
+			compliance.PackageFieldInstalled: pkg.installed,
+			compliance.PackageFieldManager:   pkg.manager,
+		},
+		nil,
+		eval.RegoInputMap{
+			"name":      pkg.name,
+			"version":   pkg.version,
+			"installed": pkg.installed,
+			"manager":   pkg.manager,
+		},
+	)
+
+	return newResolvedInstance(instance, pkg.name, "package"), nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// dpkgNativeArch is the dpkg name of the architecture of the host
+var dpkgNativeArch = map[string]string{
+	"386":     "i386",
+	"amd64":   "amd64",
+	"arm":     "armhf",
+	"arm64":   "arm64",
+	"ppc64le": "ppc64el",
+	"s390x":   "s390x",
+}[runtime.GOARCH]
+
+// findDpkgPackage looks up an installed package in a dpkg status file made of
+// stanzas of `Field: value` lines separated by blank lines. The name may be qualified
+// with an architecture like `libc6:i386`, otherwise the package installed for the
+// native architecture is preferred over the ones installed for foreign architectures.
+func findDpkgPackage(statusPath, name string) (*packageInfo, error) {
+	f, err := os.Open(statusPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pkg := &packageInfo{name: name, manager: packageManagerDpkg}
+
+	pkgName, pkgArch := name, ""
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		pkgName, pkgArch = name[:i], name[i+1:]
+	}
+
+	var current, status, version, arch string
+	// flush handles the stanza read and returns whether the lookup is over
+	flush := func() bool {
+		defer func() {
+			current, status, version, arch = "", "", "", ""
+		}()
+
+		if current != pkgName || !isDpkgInstalled(status) || (pkgArch != "" && arch != pkgArch) {
+			return false
+		}
+
+		native := pkgArch != "" || arch == dpkgNativeArch || arch == "all"
+		if !pkg.installed || native {
+			pkg.installed = true
+			pkg.version = version
+		}
+		return native
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if flush() {
+				return pkg, nil
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "Package:"):
+			current = strings.TrimSpace(strings.TrimPrefix(line, "Package:"))
+		case strings.HasPrefix(line, "Status:"):
+			status = strings.TrimSpace(strings.TrimPrefix(line, "Status:"))
+		case strings.HasPrefix(line, "Version:"):
+			version = strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
+		case strings.HasPrefix(line, "Architecture:"):
+			arch = strings.TrimSpace(strings.TrimPrefix(line, "Architecture:"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	flush()
+	return pkg, nil
+}
+
+// isDpkgInstalled returns whether a dpkg status, formatted as `want flag status`,
+// is the one of an installed package, e.g. `install ok installed` or `hold ok installed`
+func isDpkgInstalled(status string) bool {
+	fields := strings.Fields(status)
+	return len(fields) == 3 && (fields[0] == "install" || fields[0] == "hold") && fields[1] == "ok" && fields[2] == "installed"
+}
+
+// findRPMPackage queries the rpm database, which can't be parsed natively, with the rpm binary
+func findRPMPackage(dbPath, name string) (*packageInfo, error) {
+	pkg := &packageInfo{name: name, manager: packageManagerRPM}
+
+	cmd := &compliance.BinaryCmd{
+		Name: "rpm",
+		Args: []string{"--dbpath", dbPath, "-q", "--queryformat", "%{EPOCH}:%{VERSION}-%{RELEASE}", name},
+	}
+	exitCode, stdout, err := runBinaryCmd(cmd, defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// rpm exits with 1 when the package is not installed
+	if exitCode != 0 {
+		return pkg, nil
+	}
+
+	pkg.version = strings.TrimPrefix(strings.TrimSpace(stdout), "(none):")
+	pkg.installed = true
+	return pkg, nil
+}
@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package checks
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
+
+	"github.com/stretchr/testify/mock"
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestPackageCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		resource     compliance.Resource
+		expectReport *compliance.Report
+	}{
+		{
+			name: "installed package",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Package: &compliance.Package{
+						Name: "openssh-server",
+					},
+				},
+				Condition: `package.installed && package.version == "1:8.2p1-4ubuntu0.3"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"package.name":      "openssh-server",
+					"package.version":   "1:8.2p1-4ubuntu0.3",
+					"package.installed": true,
+				},
+				Resource: compliance.ReportResource{
+					ID:   "openssh-server",
+					Type: "package",
+				},
+			},
+		},
+		{
+			name: "removed package",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Package: &compliance.Package{
+						Name: "telnet",
+					},
+				},
+				Condition: `!package.installed`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"package.name":      "telnet",
+					"package.version":   "",
+					"package.installed": false,
+				},
+				Resource: compliance.ReportResource{
+					ID:   "telnet",
+					Type: "package",
+				},
+			},
+		},
+		{
+			name: "last package of the database",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Package: &compliance.Package{
+						Name: "rsync",
+					},
+				},
+				Condition: `!package.installed`,
+			},
+			expectReport: &compliance.Report{
+				Passed: false,
+				Data: event.Data{
+					"package.name":      "rsync",
+					"package.version":   "3.1.3-8ubuntu0.1",
+					"package.installed": true,
+				},
+				Resource: compliance.ReportResource{
+					ID:   "rsync",
+					Type: "package",
+				},
+			},
+		},
+		{
+			name: "package removed for a foreign architecture",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Package: &compliance.Package{
+						Name: "libssl1.1",
+					},
+				},
+				Condition: `package.installed`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"package.name":      "libssl1.1",
+					"package.version":   "1.1.1f-1ubuntu2.16",
+					"package.installed": true,
+				},
+				Resource: compliance.ReportResource{
+					ID:   "libssl1.1",
+					Type: "package",
+				},
+			},
+		},
+		{
+			name: "package installed for several architectures",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Package: &compliance.Package{
+						Name: "libc6",
+					},
+				},
+				Condition: `package.installed`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"package.name":      "libc6",
+					"package.version":   "2.31-0ubuntu9.7",
+					"package.installed": true,
+				},
+				Resource: compliance.ReportResource{
+					ID:   "libc6",
+					Type: "package",
+				},
+			},
+		},
+		{
+			name: "package qualified with an architecture",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Package: &compliance.Package{
+						Name: "libc6:i386",
+					},
+				},
+				Condition: `package.installed`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"package.name":      "libc6:i386",
+					"package.version":   "2.31-0ubuntu9.2",
+					"package.installed": true,
+				},
+				Resource: compliance.ReportResource{
+					ID:   "libc6:i386",
+					Type: "package",
+				},
+			},
+		},
+		{
+			name: "unknown package",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Package: &compliance.Package{
+						Name: "xinetd",
+					},
+				},
+				Condition: `!package.installed`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"package.name":      "xinetd",
+					"package.version":   "",
+					"package.installed": false,
+				},
+				Resource: compliance.ReportResource{
+					ID:   "xinetd",
+					Type: "package",
+				},
+			},
+		},
+	}
+
+	// the packages of the test database are installed for amd64 and i386
+	defer func(arch string) { dpkgNativeArch = arch }(dpkgNativeArch)
+	dpkgNativeArch = "amd64"
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			env := &mocks.Env{}
+			env.On("MaxEventsPerRun").Return(30).Maybe()
+			env.On("NormalizeToHostRoot", mock.Anything).Return(hostRootMapper("./testdata/package"))
+			defer env.AssertExpectations(t)
+
+			packageCheck, err := newResourceCheck(env, "rule-id", test.resource)
+			assert.NoError(err)
+
+			reports := packageCheck.check(env)
+			assert.NoError(reports[0].Error)
+			assert.Equal(test.expectReport, reports[0])
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/topdown"
 	"github.com/open-policy-agent/opa/topdown/print"
 	"gopkg.in/yaml.v3"
 
@@ -383,8 +384,23 @@ func (r *regoCheck) check(env env.Env) []*compliance.Report {
 		_ = dumpInputToFile(r.ruleID, path, input)
 	}
 
+	evalOptions := []rego.EvalOption{rego.EvalInput(input)}
+
+	var tracer *topdown.BufferTracer
+	traceWriter := env.RegoTraceWriter()
+	if traceWriter != nil {
+		tracer = topdown.NewBufferTracer()
+		evalOptions = append(evalOptions, rego.EvalQueryTracer(tracer))
+	}
+
 	ctx := context.TODO()
-	results, err := r.preparedEvalQuery.Eval(ctx, rego.EvalInput(input))
+	results, err := r.preparedEvalQuery.Eval(ctx, evalOptions...)
+
+	if tracer != nil {
+		fmt.Fprintf(traceWriter, "%s: rego evaluation trace:\n", r.ruleID)
+		topdown.PrettyTraceWithLocation(traceWriter, *tracer)
+	}
+
 	if err != nil {
 		return buildErrorReports(err)
 	} else if len(results) == 0 {
@@ -20,6 +20,7 @@ type regoInputFixture struct {
 	name          string
 	inputs        []compliance.RegoInput
 	processes     processes
+	hostRoot      string
 	expectedInput string
 }
 
@@ -67,6 +68,10 @@ func (f *regoInputFixture) run(t *testing.T) {
 	env.On("ProvidedInput", mock.Anything).Return(nil).Once()
 	env.On("Hostname").Return("hostname_test").Once()
 	env.On("DumpInputPath").Return(tf.Name()).Once()
+	env.On("RegoTraceWriter").Return(nil).Once()
+	if f.hostRoot != "" {
+		env.On("NormalizeToHostRoot", mock.Anything).Return(hostRootMapper(f.hostRoot))
+	}
 
 	defer env.AssertExpectations(t)
 
@@ -147,6 +152,140 @@ func TestRegoInputCheck(t *testing.T) {
 				}
 			`,
 		},
+		{
+			name: "sysctl",
+			inputs: []compliance.RegoInput{
+				{
+					ResourceCommon: compliance.ResourceCommon{
+						Sysctl: &compliance.Sysctl{
+							Name: "net.ipv4.ip_forward",
+						},
+					},
+					TagName: "sysctl",
+					Type:    "object",
+				},
+			},
+			hostRoot: "./testdata/sysctl",
+			expectedInput: `
+				{
+					"context": {
+						"hostname": "hostname_test",
+						"ruleID": "rule-id",
+						"input": {
+							"sysctl": {
+								"sysctl": {
+									"name": "net.ipv4.ip_forward"
+								},
+								"tag": "sysctl",
+								"type": "object"
+							}
+						}
+					},
+					"sysctl": {
+						"name": "net.ipv4.ip_forward",
+						"value": "1"
+					}
+				}
+			`,
+		},
+		{
+			name: "kernel modules",
+			inputs: []compliance.RegoInput{
+				{
+					ResourceCommon: compliance.ResourceCommon{
+						KernelModule: &compliance.KernelModule{
+							Name: "br-netfilter",
+						},
+					},
+					TagName: "netfilter",
+					Type:    "object",
+				},
+				{
+					ResourceCommon: compliance.ResourceCommon{
+						KernelModule: &compliance.KernelModule{
+							Name: "cramfs",
+						},
+					},
+					TagName: "cramfs",
+					Type:    "object",
+				},
+			},
+			hostRoot: "./testdata/kernel_module",
+			expectedInput: `
+				{
+					"context": {
+						"hostname": "hostname_test",
+						"ruleID": "rule-id",
+						"input": {
+							"netfilter": {
+								"kernelModule": {
+									"name": "br-netfilter"
+								},
+								"tag": "netfilter",
+								"type": "object"
+							},
+							"cramfs": {
+								"kernelModule": {
+									"name": "cramfs"
+								},
+								"tag": "cramfs",
+								"type": "object"
+							}
+						}
+					},
+					"netfilter": {
+						"name": "br_netfilter",
+						"loaded": true,
+						"size": 28672,
+						"state": "Live"
+					},
+					"cramfs": {
+						"name": "cramfs",
+						"loaded": false,
+						"size": 0,
+						"state": ""
+					}
+				}
+			`,
+		},
+		{
+			name: "package",
+			inputs: []compliance.RegoInput{
+				{
+					ResourceCommon: compliance.ResourceCommon{
+						Package: &compliance.Package{
+							Name: "libc6:i386",
+						},
+					},
+					TagName: "package",
+					Type:    "object",
+				},
+			},
+			hostRoot: "./testdata/package",
+			expectedInput: `
+				{
+					"context": {
+						"hostname": "hostname_test",
+						"ruleID": "rule-id",
+						"input": {
+							"package": {
+								"package": {
+									"name": "libc6:i386"
+								},
+								"tag": "package",
+								"type": "object"
+							}
+						}
+					},
+					"package": {
+						"name": "libc6:i386",
+						"version": "2.31-0ubuntu9.2",
+						"installed": true,
+						"manager": "dpkg"
+					}
+				}
+			`,
+		},
 	}
 
 	for _, tt := range tests {
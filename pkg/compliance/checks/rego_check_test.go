@@ -5,6 +5,7 @@
 package checks
 
 import (
+	"bytes"
 	"errors"
 	"testing"
 
@@ -66,6 +67,7 @@ func (f *regoFixture) run(t *testing.T) {
 	env.On("ProvidedInput", mock.Anything).Return(nil).Once()
 	env.On("Hostname").Return("hostname_test").Once()
 	env.On("DumpInputPath").Return("").Once()
+	env.On("RegoTraceWriter").Return(nil).Once()
 
 	defer env.AssertExpectations(t)
 
@@ -263,3 +265,57 @@ func TestRegoCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestRegoCheckTrace(t *testing.T) {
+	assert := assert.New(t)
+
+	fixture := regoFixture{
+		inputs: []compliance.RegoInput{
+			{
+				ResourceCommon: compliance.ResourceCommon{
+					Process: &compliance.Process{
+						Name: "proc1",
+					},
+				},
+				TagName: "processes",
+				Type:    "array",
+			},
+		},
+		module: `
+			package test
+
+			import data.datadog as dd
+
+			findings[f] {
+				p := input.processes[_]
+				f := dd.passed_finding("process", "42", dd.process_data(p))
+			}
+		`,
+		findings: "data.test.findings",
+	}
+
+	cache.Cache.Delete(processCacheKey)
+	processFetcher = func() (processes, error) {
+		return processes{42: {Pid: 42, Name: "proc1"}}, nil
+	}
+
+	var trace bytes.Buffer
+	env := &mocks.Env{}
+	env.On("MaxEventsPerRun").Return(30).Maybe()
+	env.On("ProvidedInput", mock.Anything).Return(nil).Once()
+	env.On("Hostname").Return("hostname_test").Once()
+	env.On("DumpInputPath").Return("").Once()
+	env.On("RegoTraceWriter").Return(&trace).Once()
+	defer env.AssertExpectations(t)
+
+	regoCheck, err := fixture.newRegoCheck()
+	assert.NoError(err)
+
+	reports := regoCheck.check(env)
+	assert.Len(reports, 1)
+	assert.True(reports[0].Passed)
+
+	assert.Contains(trace.String(), "rule-id: rego evaluation trace:\n")
+	assert.Contains(trace.String(), "Enter data.test.findings")
+	assert.Contains(trace.String(), "Exit data.test.findings")
+}
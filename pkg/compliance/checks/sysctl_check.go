@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package checks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const procSysPath = "/proc/sys"
+
+var sysctlReportedFields = []string{
+	compliance.SysctlFieldName,
+	compliance.SysctlFieldValue,
+}
+
+func resolveSysctl(_ context.Context, e env.Env, id string, res compliance.ResourceCommon, rego bool) (resolved, error) {
+	if res.Sysctl == nil {
+		return nil, fmt.Errorf("%s: expecting sysctl resource in sysctl check", id)
+	}
+
+	sysctl := res.Sysctl
+	if sysctl.Name == "" {
+		return nil, fmt.Errorf("%s: sysctl resource is missing name", id)
+	}
+
+	log.Debugf("%s: running sysctl check: %s", id, sysctl.Name)
+
+	name, relPath := sysctlNameAndPath(sysctl.Name)
+	path := e.NormalizeToHostRoot(filepath.Join(procSysPath, relPath))
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && rego {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: failed to read sysctl %s: %w", id, name, err)
+	}
+
+	// multi-valued parameters are tab separated, report them the way the sysctl command does
+	value := strings.Join(strings.Fields(string(content)), " ")
+
+	instance := eval.NewInstance(
+		eval.VarMap{
+			compliance.SysctlFieldName:  name,
+			compliance.SysctlFieldValue: value,
+		},
+		nil,
+		eval.RegoInputMap{
+			"name":  name,
+			"value": value,
+		},
+	)
+
+	return newResolvedInstance(instance, name, "sysctl"), nil
+}
+
+// sysctlNameAndPath returns the dotted name and the /proc/sys relative path of a sysctl.
+// Like the sysctl command, both the dotted form and the path form are accepted: the first
+// separator gives the form and, in the dotted form, slashes stand for the dots of a
+// component, like in net.ipv4.conf.eth0/100.rp_filter for the eth0.100 interface.
+func sysctlNameAndPath(sysctl string) (string, string) {
+	sysctl = strings.Trim(sysctl, "/")
+
+	separator, literal := ".", "/"
+	if i := strings.IndexAny(sysctl, "./"); i >= 0 && sysctl[i] == '/' {
+		separator, literal = "/", "."
+	}
+
+	components := strings.Split(sysctl, separator)
+	names := make([]string, 0, len(components))
+	paths := make([]string, 0, len(components))
+	for _, component := range components {
+		// a component is a file or directory name in /proc/sys
+		component = strings.ReplaceAll(component, literal, ".")
+		names = append(names, strings.ReplaceAll(component, ".", "/"))
+		paths = append(paths, component)
+	}
+
+	return strings.Join(names, "."), strings.Join(paths, "/")
+}
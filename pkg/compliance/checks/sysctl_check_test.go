@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package checks
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
+
+	"github.com/stretchr/testify/mock"
+	assert "github.com/stretchr/testify/require"
+)
+
+// hostRootMapper maps paths to a test host root the way WithHostRootMount does
+func hostRootMapper(hostRoot string) func(string) string {
+	return func(path string) string {
+		return filepath.Join(hostRoot, path)
+	}
+}
+
+func TestSysctlCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource compliance.Resource
+
+		expectReport *compliance.Report
+		expectError  bool
+	}{
+		{
+			name: "ip forwarding enabled",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Sysctl: &compliance.Sysctl{
+						Name: "net.ipv4.ip_forward",
+					},
+				},
+				Condition: `sysctl.value == "0"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: false,
+				Data: event.Data{
+					"sysctl.name":  "net.ipv4.ip_forward",
+					"sysctl.value": "1",
+				},
+				Resource: compliance.ReportResource{
+					ID:   "net.ipv4.ip_forward",
+					Type: "sysctl",
+				},
+			},
+		},
+		{
+			name: "multi-valued parameter in path form",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Sysctl: &compliance.Sysctl{
+						Name: "net/ipv4/ip_local_port_range",
+					},
+				},
+				Condition: `sysctl.value == "32768 60999"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"sysctl.name":  "net.ipv4.ip_local_port_range",
+					"sysctl.value": "32768 60999",
+				},
+				Resource: compliance.ReportResource{
+					ID:   "net.ipv4.ip_local_port_range",
+					Type: "sysctl",
+				},
+			},
+		},
+		{
+			name: "interface name with a dot in dotted form",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Sysctl: &compliance.Sysctl{
+						Name: "net.ipv4.conf.eth0/100.rp_filter",
+					},
+				},
+				Condition: `sysctl.value == "1"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: false,
+				Data: event.Data{
+					"sysctl.name":  "net.ipv4.conf.eth0/100.rp_filter",
+					"sysctl.value": "2",
+				},
+				Resource: compliance.ReportResource{
+					ID:   "net.ipv4.conf.eth0/100.rp_filter",
+					Type: "sysctl",
+				},
+			},
+		},
+		{
+			name: "interface name with a dot in path form",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Sysctl: &compliance.Sysctl{
+						Name: "net/ipv4/conf/eth0.100/rp_filter",
+					},
+				},
+				Condition: `sysctl.value == "1"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: false,
+				Data: event.Data{
+					"sysctl.name":  "net.ipv4.conf.eth0/100.rp_filter",
+					"sysctl.value": "2",
+				},
+				Resource: compliance.ReportResource{
+					ID:   "net.ipv4.conf.eth0/100.rp_filter",
+					Type: "sysctl",
+				},
+			},
+		},
+		{
+			name: "unknown parameter",
+			resource: compliance.Resource{
+				ResourceCommon: compliance.ResourceCommon{
+					Sysctl: &compliance.Sysctl{
+						Name: "net.ipv4.unknown",
+					},
+				},
+				Condition: `sysctl.value == "0"`,
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			env := &mocks.Env{}
+			env.On("MaxEventsPerRun").Return(30).Maybe()
+			env.On("NormalizeToHostRoot", mock.Anything).Return(hostRootMapper("./testdata/sysctl"))
+			defer env.AssertExpectations(t)
+
+			sysctlCheck, err := newResourceCheck(env, "rule-id", test.resource)
+			assert.NoError(err)
+
+			reports := sysctlCheck.check(env)
+			if test.expectError {
+				assert.Error(reports[0].Error)
+				return
+			}
+			assert.NoError(reports[0].Error)
+			assert.Equal(test.expectReport, reports[0])
+		})
+	}
+}
+
+func TestSysctlNameAndPath(t *testing.T) {
+	tests := []struct {
+		sysctl string
+		name   string
+		path   string
+	}{
+		{"kernel.randomize_va_space", "kernel.randomize_va_space", "kernel/randomize_va_space"},
+		{"kernel/randomize_va_space", "kernel.randomize_va_space", "kernel/randomize_va_space"},
+		{"/kernel/randomize_va_space", "kernel.randomize_va_space", "kernel/randomize_va_space"},
+		{"net.ipv4.conf.eth0/100.rp_filter", "net.ipv4.conf.eth0/100.rp_filter", "net/ipv4/conf/eth0.100/rp_filter"},
+		{"net/ipv4/conf/eth0.100/rp_filter", "net.ipv4.conf.eth0/100.rp_filter", "net/ipv4/conf/eth0.100/rp_filter"},
+		{"fs", "fs", "fs"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sysctl, func(t *testing.T) {
+			name, path := sysctlNameAndPath(test.sysctl)
+			assert.Equal(t, test.name, name)
+			assert.Equal(t, test.path, path)
+		})
+	}
+}
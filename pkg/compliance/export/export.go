@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package export implements local reports of compliance check results
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// FormatSARIF is the format of SARIF 2.1.0 reports
+	FormatSARIF = "sarif"
+	// FormatOCSF is the format of OCSF compliance finding reports
+	FormatOCSF = "ocsf"
+	// FormatHTML is the format of the HTML summary
+	FormatHTML = "html"
+)
+
+// Formats lists all the supported report formats
+var Formats = []string{FormatSARIF, FormatOCSF, FormatHTML}
+
+var formatFiles = map[string]string{
+	FormatSARIF: "compliance.sarif.json",
+	FormatOCSF:  "compliance.ocsf.json",
+	FormatHTML:  "compliance.html",
+}
+
+type writerFunc func(w io.Writer, results *Results) error
+
+var formatWriters = map[string]writerFunc{
+	FormatSARIF: WriteSARIF,
+	FormatOCSF:  WriteOCSF,
+	FormatHTML:  WriteHTML,
+}
+
+// RuleResult holds the events reported for a rule along with its benchmark metadata
+type RuleResult struct {
+	RuleID      string
+	Name        string
+	Description string
+	Suite       string
+	Framework   string
+	Version     string
+	Source      string
+	Events      []*event.Event
+}
+
+// Count returns the number of events reported with the given result
+func (r *RuleResult) Count(result string) int {
+	count := 0
+	for _, e := range r.Events {
+		if e.Result == result {
+			count++
+		}
+	}
+	return count
+}
+
+// Results holds the results of a compliance checks run
+type Results struct {
+	Hostname     string
+	AgentVersion string
+	Time         time.Time
+	Rules        []*RuleResult
+}
+
+// NewResults builds the results of a run from the checks status and the events reported by rule ID.
+// Rules and events are sorted so that reports of different runs can be diffed.
+func NewResults(hostname, agentVersion string, now time.Time, statuses compliance.CheckStatusList, events map[string][]*event.Event) *Results {
+	results := &Results{
+		Hostname:     hostname,
+		AgentVersion: agentVersion,
+		Time:         now.UTC(),
+	}
+
+	for _, status := range statuses {
+		ruleEvents := events[status.RuleID]
+		if len(ruleEvents) == 0 {
+			continue
+		}
+
+		sorted := make([]*event.Event, len(ruleEvents))
+		copy(sorted, ruleEvents)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if sorted[i].ResourceType != sorted[j].ResourceType {
+				return sorted[i].ResourceType < sorted[j].ResourceType
+			}
+			return sorted[i].ResourceID < sorted[j].ResourceID
+		})
+
+		results.Rules = append(results.Rules, &RuleResult{
+			RuleID:      status.RuleID,
+			Name:        status.Name,
+			Description: status.Description,
+			Suite:       status.Suite,
+			Framework:   status.Framework,
+			Version:     status.Version,
+			Source:      status.Source,
+			Events:      sorted,
+		})
+	}
+
+	sort.SliceStable(results.Rules, func(i, j int) bool {
+		if results.Rules[i].Framework != results.Rules[j].Framework {
+			return results.Rules[i].Framework < results.Rules[j].Framework
+		}
+		return results.Rules[i].RuleID < results.Rules[j].RuleID
+	})
+
+	return results
+}
+
+// ParseFormats parses a comma separated list of report formats
+func ParseFormats(formats string) ([]string, error) {
+	var res []string
+	for _, format := range strings.Split(formats, ",") {
+		format = strings.TrimSpace(strings.ToLower(format))
+		if format == "" {
+			continue
+		}
+		if _, ok := formatWriters[format]; !ok {
+			return nil, fmt.Errorf("unsupported report format `%s`, expecting one of %s", format, strings.Join(Formats, ", "))
+		}
+		res = append(res, format)
+	}
+	return res, nil
+}
+
+// WriteReports writes a report file for each of the requested formats in the given directory
+func WriteReports(dir string, results *Results, formats []string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, format := range formats {
+		write, ok := formatWriters[format]
+		if !ok {
+			return fmt.Errorf("unsupported report format `%s`", format)
+		}
+
+		path := filepath.Join(dir, formatFiles[format])
+		if err := writeReport(path, results, write); err != nil {
+			return fmt.Errorf("failed to write %s report: %w", format, err)
+		}
+		log.Infof("Wrote %s report to %s", format, path)
+	}
+
+	return nil
+}
+
+func writeReport(path string, results *Results, write writerFunc) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func eventError(e *event.Event) string {
+	if data, ok := e.Data.(event.Data); ok {
+		if msg, ok := data["error"].(string); ok {
+			return msg
+		}
+	}
+	return ""
+}
+
+func resultMessage(rule *RuleResult, e *event.Event) string {
+	switch e.Result {
+	case event.Passed:
+		return fmt.Sprintf("%s passed on %s %s", rule.RuleID, e.ResourceType, e.ResourceID)
+	case event.Failed:
+		return fmt.Sprintf("%s failed on %s %s", rule.RuleID, e.ResourceType, e.ResourceID)
+	default:
+		return fmt.Sprintf("%s could not be evaluated on %s %s: %s", rule.RuleID, e.ResourceType, e.ResourceID, eventError(e))
+	}
+}
@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func testResults() *Results {
+	statuses := compliance.CheckStatusList{
+		{
+			RuleID:      "cis-docker-2",
+			Name:        "cis-docker-2: Ensure TLS",
+			Description: "Ensure TLS",
+			Suite:       "CIS Docker Generic",
+			Framework:   "cis-docker",
+			Version:     "1.2.0",
+		},
+		{
+			RuleID:      "cis-docker-1",
+			Name:        "cis-docker-1: Ensure permissions",
+			Description: "Ensure permissions",
+			Suite:       "CIS Docker Generic",
+			Framework:   "cis-docker",
+			Version:     "1.2.0",
+		},
+		{
+			RuleID:    "cis-docker-3",
+			Framework: "cis-docker",
+		},
+	}
+
+	events := map[string][]*event.Event{
+		"cis-docker-1": {
+			{
+				AgentRuleID:  "cis-docker-1",
+				ResourceID:   "host_daemon",
+				ResourceType: "docker_daemon",
+				Result:       event.Failed,
+				Data:         event.Data{"file.permissions": 0644},
+			},
+			{
+				AgentRuleID:  "cis-docker-1",
+				ResourceID:   "host_container",
+				ResourceType: "docker_container",
+				Result:       event.Passed,
+			},
+		},
+		"cis-docker-2": {
+			{
+				AgentRuleID:  "cis-docker-2",
+				ResourceID:   "host_daemon",
+				ResourceType: "docker_daemon",
+				Result:       event.Error,
+				Data:         event.Data{"error": "no such file"},
+			},
+		},
+	}
+
+	return NewResults("host", "7.33.0", time.Unix(1600000000, 0), statuses, events)
+}
+
+func TestNewResults(t *testing.T) {
+	assert := assert.New(t)
+
+	results := testResults()
+	assert.Len(results.Rules, 2)
+	assert.Equal("cis-docker-1", results.Rules[0].RuleID)
+	assert.Equal("docker_container", results.Rules[0].Events[0].ResourceType)
+	assert.Equal("docker_daemon", results.Rules[0].Events[1].ResourceType)
+	assert.Equal("cis-docker-2", results.Rules[1].RuleID)
+	assert.Equal(1, results.Rules[0].Count(event.Passed))
+	assert.Equal(1, results.Rules[0].Count(event.Failed))
+}
+
+func TestWriteSARIF(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.NoError(WriteSARIF(&buf, testResults()))
+
+	var log sarifLog
+	assert.NoError(json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal("2.1.0", log.Version)
+	assert.Len(log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Len(run.Tool.Driver.Rules, 2)
+	assert.Equal("CIS Docker Generic", run.Tool.Driver.Rules[0].Properties["benchmark"])
+	assert.Len(run.Results, 3)
+
+	assert.Equal("pass", run.Results[0].Kind)
+	assert.Equal("none", run.Results[0].Level)
+	assert.Equal("fail", run.Results[1].Kind)
+	assert.Equal("error", run.Results[1].Level)
+	assert.Equal("docker_daemon/host_daemon", run.Results[1].Locations[0].LogicalLocations[0].FullyQualifiedName)
+	assert.Equal("review", run.Results[2].Kind)
+	assert.Equal(1, run.Results[2].RuleIndex)
+	assert.Contains(run.Results[2].Message.Text, "no such file")
+}
+
+func TestWriteOCSF(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.NoError(WriteOCSF(&buf, testResults()))
+
+	var findings []ocsfFinding
+	assert.NoError(json.Unmarshal(buf.Bytes(), &findings))
+	assert.Len(findings, 3)
+
+	finding := findings[1]
+	assert.Equal(2003, finding.ClassUID)
+	assert.Equal(200301, finding.TypeUID)
+	assert.Equal(int64(1600000000000), finding.Time)
+	assert.Equal("Fail", finding.Compliance.Status)
+	assert.Equal([]string{"cis-docker 1.2.0"}, finding.Compliance.Standards)
+	assert.Equal("cis-docker/cis-docker-1/docker_daemon/host_daemon", finding.Finding.UID)
+	assert.Equal("host_daemon", finding.Resources[0].UID)
+}
+
+func TestWriteReports(t *testing.T) {
+	assert := assert.New(t)
+
+	formats, err := ParseFormats("sarif, HTML")
+	assert.NoError(err)
+	assert.Equal([]string{FormatSARIF, FormatHTML}, formats)
+
+	_, err = ParseFormats("sarif,pdf")
+	assert.Error(err)
+
+	dir := t.TempDir()
+	assert.NoError(WriteReports(dir, testResults(), formats))
+
+	_, err = os.Stat(filepath.Join(dir, "compliance.sarif.json"))
+	assert.NoError(err)
+	_, err = os.Stat(filepath.Join(dir, "compliance.ocsf.json"))
+	assert.True(os.IsNotExist(err))
+
+	html, err := os.ReadFile(filepath.Join(dir, "compliance.html"))
+	assert.NoError(err)
+	assert.Contains(string(html), "CIS Docker Generic")
+	assert.Contains(string(html), "host_container")
+}
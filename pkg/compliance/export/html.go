@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package export
+
+import (
+	"html/template"
+	"io"
+)
+
+const summaryTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Compliance summary - {{ .Hostname }}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.passed { color: #2e7d32; }
+.failed { color: #c62828; }
+.error { color: #ef6c00; }
+</style>
+</head>
+<body>
+<h1>Compliance summary</h1>
+<p>Host <b>{{ .Hostname }}</b>, agent version {{ .AgentVersion }}, generated at {{ .Time.Format "2006-01-02T15:04:05Z07:00" }}</p>
+<table>
+<tr><th>Passed</th><th>Failed</th><th>Error</th></tr>
+<tr><td class="passed">{{ total .Rules "passed" }}</td><td class="failed">{{ total .Rules "failed" }}</td><td class="error">{{ total .Rules "error" }}</td></tr>
+</table>
+<h2>Rules</h2>
+<table>
+<tr><th>Benchmark</th><th>Framework</th><th>Version</th><th>Rule</th><th>Name</th><th>Passed</th><th>Failed</th><th>Error</th></tr>
+{{- range .Rules }}
+<tr><td>{{ .Suite }}</td><td>{{ .Framework }}</td><td>{{ .Version }}</td><td>{{ .RuleID }}</td><td>{{ .Name }}</td><td class="passed">{{ .Count "passed" }}</td><td class="failed">{{ .Count "failed" }}</td><td class="error">{{ .Count "error" }}</td></tr>
+{{- end }}
+</table>
+<h2>Resources</h2>
+<table>
+<tr><th>Rule</th><th>Resource type</th><th>Resource</th><th>Result</th><th>Evaluator</th></tr>
+{{- range $rule := .Rules }}
+{{- range .Events }}
+<tr><td>{{ $rule.RuleID }}</td><td>{{ .ResourceType }}</td><td>{{ .ResourceID }}</td><td class="{{ .Result }}">{{ .Result }}</td><td>{{ .Evaluator }}</td></tr>
+{{- end }}
+{{- end }}
+</table>
+</body>
+</html>
+`
+
+var summary = template.Must(template.New("summary").Funcs(template.FuncMap{
+	"total": totalCount,
+}).Parse(summaryTemplate))
+
+func totalCount(rules []*RuleResult, result string) int {
+	count := 0
+	for _, rule := range rules {
+		count += rule.Count(result)
+	}
+	return count
+}
+
+// WriteHTML writes an HTML summary of the results
+func WriteHTML(w io.Writer, results *Results) error {
+	return summary.Execute(w, results)
+}
@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+)
+
+// OCSF 1.0.0 Compliance Finding class, see https://schema.ocsf.io/1.0.0/classes/compliance_finding
+const (
+	ocsfVersion          = "1.0.0"
+	ocsfCategoryFindings = 2
+	ocsfClassCompliance  = 2003
+	ocsfActivityCreate   = 1
+	ocsfStatusNew        = 1
+
+	ocsfSeverityUnknown       = 0
+	ocsfSeverityInformational = 1
+
+	ocsfCompliancePass  = 1
+	ocsfComplianceFail  = 3
+	ocsfComplianceOther = 99
+)
+
+type ocsfFinding struct {
+	ActivityID  int                    `json:"activity_id"`
+	CategoryUID int                    `json:"category_uid"`
+	ClassUID    int                    `json:"class_uid"`
+	TypeUID     int                    `json:"type_uid"`
+	SeverityID  int                    `json:"severity_id"`
+	StatusID    int                    `json:"status_id"`
+	Time        int64                  `json:"time"`
+	Message     string                 `json:"message"`
+	Metadata    ocsfMetadata           `json:"metadata"`
+	Finding     ocsfFindingDetails     `json:"finding"`
+	Compliance  ocsfCompliance         `json:"compliance"`
+	Resources   []ocsfResource         `json:"resources"`
+	Unmapped    map[string]interface{} `json:"unmapped,omitempty"`
+}
+
+type ocsfMetadata struct {
+	Version string      `json:"version"`
+	Product ocsfProduct `json:"product"`
+}
+
+type ocsfProduct struct {
+	Name       string `json:"name"`
+	VendorName string `json:"vendor_name"`
+	Version    string `json:"version,omitempty"`
+}
+
+type ocsfFindingDetails struct {
+	UID   string `json:"uid"`
+	Title string `json:"title"`
+	Desc  string `json:"desc,omitempty"`
+}
+
+type ocsfCompliance struct {
+	Control      string   `json:"control"`
+	Requirements []string `json:"requirements"`
+	Standards    []string `json:"standards"`
+	Status       string   `json:"status"`
+	StatusID     int      `json:"status_id"`
+}
+
+type ocsfResource struct {
+	UID  string `json:"uid"`
+	Type string `json:"type"`
+}
+
+func ocsfComplianceStatus(result string) (string, int) {
+	switch result {
+	case event.Passed:
+		return "Pass", ocsfCompliancePass
+	case event.Failed:
+		return "Fail", ocsfComplianceFail
+	default:
+		return "Error", ocsfComplianceOther
+	}
+}
+
+// WriteOCSF writes the results as a JSON array of OCSF compliance findings, one per rule and resource
+func WriteOCSF(w io.Writer, results *Results) error {
+	findings := []ocsfFinding{}
+	timestamp := results.Time.UnixNano() / 1e6
+
+	for _, rule := range results.Rules {
+		standard := rule.Framework
+		if rule.Version != "" {
+			standard += " " + rule.Version
+		}
+
+		for _, e := range rule.Events {
+			status, statusID := ocsfComplianceStatus(e.Result)
+
+			severityID := ocsfSeverityUnknown
+			if e.Result == event.Passed {
+				severityID = ocsfSeverityInformational
+			}
+
+			finding := ocsfFinding{
+				ActivityID:  ocsfActivityCreate,
+				CategoryUID: ocsfCategoryFindings,
+				ClassUID:    ocsfClassCompliance,
+				TypeUID:     ocsfClassCompliance*100 + ocsfActivityCreate,
+				SeverityID:  severityID,
+				StatusID:    ocsfStatusNew,
+				Time:        timestamp,
+				Message:     resultMessage(rule, e),
+				Metadata: ocsfMetadata{
+					Version: ocsfVersion,
+					Product: ocsfProduct{
+						Name:       toolName,
+						VendorName: "Datadog",
+						Version:    results.AgentVersion,
+					},
+				},
+				Finding: ocsfFindingDetails{
+					UID:   rule.Framework + "/" + rule.RuleID + "/" + e.ResourceType + "/" + e.ResourceID,
+					Title: rule.Name,
+					Desc:  rule.Description,
+				},
+				Compliance: ocsfCompliance{
+					Control:      rule.RuleID,
+					Requirements: []string{rule.RuleID},
+					Standards:    []string{standard},
+					Status:       status,
+					StatusID:     statusID,
+				},
+				Resources: []ocsfResource{
+					{
+						UID:  e.ResourceID,
+						Type: e.ResourceType,
+					},
+				},
+				Unmapped: map[string]interface{}{
+					"benchmark": rule.Suite,
+					"hostname":  results.Hostname,
+					"evaluator": e.Evaluator,
+				},
+			}
+			if e.Data != nil {
+				finding.Unmapped["data"] = e.Data
+			}
+
+			findings = append(findings, finding)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(findings)
+}
@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "datadog-security-agent"
+	toolURI      = "https://docs.datadoghq.com/security_platform/cspm/"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool              `json:"tool"`
+	Results    []sarifResult          `json:"results"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID               string                 `json:"id"`
+	Name             string                 `json:"name,omitempty"`
+	ShortDescription *sarifMessage          `json:"shortDescription,omitempty"`
+	Properties       map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Kind       string                 `json:"kind"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifKindAndLevel maps a compliance event result to a SARIF result kind and level.
+// SARIF requires the level to be `none` for any result that is not a failure.
+func sarifKindAndLevel(result string) (string, string) {
+	switch result {
+	case event.Passed:
+		return "pass", "none"
+	case event.Failed:
+		return "fail", "error"
+	default:
+		return "review", "none"
+	}
+}
+
+// WriteSARIF writes the results as a SARIF 2.1.0 log with one result per rule and resource
+func WriteSARIF(w io.Writer, results *Results) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           toolName,
+				Version:        results.AgentVersion,
+				InformationURI: toolURI,
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+		Properties: map[string]interface{}{
+			"hostname": results.Hostname,
+		},
+	}
+
+	for ruleIndex, rule := range results.Rules {
+		sRule := sarifRule{
+			ID:   rule.RuleID,
+			Name: rule.Name,
+			Properties: map[string]interface{}{
+				"benchmark": rule.Suite,
+				"framework": rule.Framework,
+				"version":   rule.Version,
+			},
+		}
+		if rule.Description != "" {
+			sRule.ShortDescription = &sarifMessage{Text: rule.Description}
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sRule)
+
+		for _, e := range rule.Events {
+			kind, level := sarifKindAndLevel(e.Result)
+
+			result := sarifResult{
+				RuleID:    rule.RuleID,
+				RuleIndex: ruleIndex,
+				Kind:      kind,
+				Level:     level,
+				Message:   sarifMessage{Text: resultMessage(rule, e)},
+				Locations: []sarifLocation{
+					{
+						LogicalLocations: []sarifLogicalLocation{
+							{
+								Name:               e.ResourceID,
+								FullyQualifiedName: e.ResourceType + "/" + e.ResourceID,
+								Kind:               e.ResourceType,
+							},
+						},
+					},
+				},
+				Properties: map[string]interface{}{
+					"result":    e.Result,
+					"evaluator": e.Evaluator,
+				},
+			}
+			if e.Data != nil {
+				result.Properties["data"] = e.Data
+			}
+
+			run.Results = append(run.Results, result)
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
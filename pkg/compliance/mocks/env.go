@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	io "io"
+
 	env "github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
 	eval "github.com/DataDog/datadog-agent/pkg/compliance/eval"
 
@@ -232,3 +234,19 @@ func (_m *Env) Reporter() event.Reporter {
 
 	return r0
 }
+
+// RegoTraceWriter provides a mock function with given fields:
+func (_m *Env) RegoTraceWriter() io.Writer {
+	ret := _m.Called()
+
+	var r0 io.Writer
+	if rf, ok := ret.Get(0).(func() io.Writer); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.Writer)
+		}
+	}
+
+	return r0
+}
@@ -3,6 +3,8 @@
 package mocks
 
 import (
+	io "io"
+
 	eval "github.com/DataDog/datadog-agent/pkg/compliance/eval"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -41,3 +43,19 @@ func (_m *RegoConfiguration) ProvidedInput(ruleID string) eval.RegoInputMap {
 
 	return r0
 }
+
+// RegoTraceWriter provides a mock function with given fields:
+func (_m *RegoConfiguration) RegoTraceWriter() io.Writer {
+	ret := _m.Called()
+
+	var r0 io.Writer
+	if rf, ok := ret.Get(0).(func() io.Writer); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(io.Writer)
+		}
+	}
+
+	return r0
+}
@@ -34,6 +34,12 @@ const (
 	KindConstants = ResourceKind("constants")
 	// KindCustom is used for a Custom check
 	KindCustom = ResourceKind("custom")
+	// KindSysctl is used for a Sysctl resource
+	KindSysctl = ResourceKind("sysctl")
+	// KindKernelModule is used for a KernelModule resource
+	KindKernelModule = ResourceKind("kernelModule")
+	// KindPackage is used for a Package resource
+	KindPackage = ResourceKind("package")
 )
 
 // ResourceCommon describes the base fields of resource types
@@ -47,6 +53,9 @@ type ResourceCommon struct {
 	KubeApiserver *KubernetesResource `yaml:"kubeApiserver,omitempty"`
 	Constants     *ConstantsResource  `yaml:"constants,omitempty"`
 	Custom        *Custom             `yaml:"custom,omitempty"`
+	Sysctl        *Sysctl             `yaml:"sysctl,omitempty"`
+	KernelModule  *KernelModule       `yaml:"kernelModule,omitempty"`
+	Package       *Package            `yaml:"package,omitempty"`
 }
 
 // Resource describes supported resource types observed by a Rule
@@ -96,6 +105,12 @@ func (r *ResourceCommon) Kind() ResourceKind {
 		return KindConstants
 	case r.Custom != nil:
 		return KindCustom
+	case r.Sysctl != nil:
+		return KindSysctl
+	case r.KernelModule != nil:
+		return KindKernelModule
+	case r.Package != nil:
+		return KindPackage
 	default:
 		return KindInvalid
 	}
@@ -301,3 +316,40 @@ type Custom struct {
 	Name      string            `yaml:"name"`
 	Variables map[string]string `yaml:"variables,omitempty"`
 }
+
+// Fields available for Sysctl
+const (
+	SysctlFieldName  = "sysctl.name"
+	SysctlFieldValue = "sysctl.value"
+)
+
+// Sysctl describes a kernel parameter read from /proc/sys
+type Sysctl struct {
+	Name string `yaml:"name"`
+}
+
+// Fields available for KernelModule
+const (
+	KernelModuleFieldName   = "kernelModule.name"
+	KernelModuleFieldLoaded = "kernelModule.loaded"
+	KernelModuleFieldSize   = "kernelModule.size"
+	KernelModuleFieldState  = "kernelModule.state"
+)
+
+// KernelModule describes a kernel module looked up in /proc/modules
+type KernelModule struct {
+	Name string `yaml:"name"`
+}
+
+// Fields available for Package
+const (
+	PackageFieldName      = "package.name"
+	PackageFieldVersion   = "package.version"
+	PackageFieldInstalled = "package.installed"
+	PackageFieldManager   = "package.manager"
+)
+
+// Package describes a package looked up in the dpkg or rpm database
+type Package struct {
+	Name string `yaml:"name"`
+}
@@ -62,6 +62,24 @@ docker:
 condition: docker.template("{{ $.Config.Healthcheck }}") != ""
 `
 
+const testResourceSysctl = `
+sysctl:
+  name: net.ipv4.ip_forward
+condition: sysctl.value == "0"
+`
+
+const testResourceKernelModule = `
+kernelModule:
+  name: cramfs
+condition: "!kernelModule.loaded"
+`
+
+const testResourcePackage = `
+package:
+  name: telnet
+condition: "!package.installed"
+`
+
 func TestResources(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -166,6 +184,42 @@ func TestResources(t *testing.T) {
 				Condition: `docker.template("{{ $.Config.Healthcheck }}") != ""`,
 			},
 		},
+		{
+			name:  "sysctl",
+			input: testResourceSysctl,
+			expected: Resource{
+				ResourceCommon: ResourceCommon{
+					Sysctl: &Sysctl{
+						Name: "net.ipv4.ip_forward",
+					},
+				},
+				Condition: `sysctl.value == "0"`,
+			},
+		},
+		{
+			name:  "kernel module",
+			input: testResourceKernelModule,
+			expected: Resource{
+				ResourceCommon: ResourceCommon{
+					KernelModule: &KernelModule{
+						Name: "cramfs",
+					},
+				},
+				Condition: `!kernelModule.loaded`,
+			},
+		},
+		{
+			name:  "package",
+			input: testResourcePackage,
+			expected: Resource{
+				ResourceCommon: ResourceCommon{
+					Package: &Package{
+						Name: "telnet",
+					},
+				},
+				Condition: `!package.installed`,
+			},
+		},
 	}
 
 	for _, test := range tests {
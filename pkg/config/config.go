@@ -818,6 +818,16 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("cluster_checks.extra_tags", []string{})
 	config.BindEnvAndSetDefault("cluster_checks.advanced_dispatching_enabled", false)
 	config.BindEnvAndSetDefault("cluster_checks.clc_runners_port", 5005)
+	config.BindEnvAndSetDefault("cluster_checks.rebalance_node_capacity", 0) // maximum busyness of a node receiving checks when rebalancing, unlimited when 0
+	// rules pinning checks to nodes when rebalancing, e.g. [{"check_name": "postgres", "node_labels": {"topology.kubernetes.io/zone": "us-east-1a"}}]
+	config.BindEnv("cluster_checks.rebalance_affinity_rules")
+	config.SetEnvKeyTransformer("cluster_checks.rebalance_affinity_rules", func(in string) interface{} {
+		var rules []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"cluster_checks.rebalance_affinity_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
 	// Cluster check runner
 	config.BindEnvAndSetDefault("clc_runner_enabled", false)
 	config.BindEnvAndSetDefault("clc_runner_id", "")
@@ -839,6 +849,25 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("admission_controller.inject_config.endpoint", "/injectconfig")
 	config.BindEnvAndSetDefault("admission_controller.inject_tags.enabled", true)
 	config.BindEnvAndSetDefault("admission_controller.inject_tags.endpoint", "/injecttags")
+	config.BindEnvAndSetDefault("admission_controller.auto_instrumentation.enabled", false)
+	config.BindEnvAndSetDefault("admission_controller.auto_instrumentation.endpoint", "/injectlib")
+	config.BindEnvAndSetDefault("admission_controller.auto_instrumentation.container_registry", "gcr.io/datadoghq")
+	// namespaces allowed to get the tracing libraries injected, none of them when empty
+	config.BindEnvAndSetDefault("admission_controller.auto_instrumentation.enabled_namespaces", []string{})
+	config.BindEnvAndSetDefault("admission_controller.agent_sidecar.enabled", false)
+	config.BindEnvAndSetDefault("admission_controller.agent_sidecar.endpoint", "/agentsidecar")
+	config.BindEnvAndSetDefault("admission_controller.agent_sidecar.container_registry", "gcr.io/datadoghq")
+	config.BindEnvAndSetDefault("admission_controller.agent_sidecar.image_name", "agent")
+	config.BindEnvAndSetDefault("admission_controller.agent_sidecar.image_tag", "latest")
+	// the API key secret is referenced from the namespace of the pod, the sidecar isn't injected into the pods of the
+	// namespaces where it doesn't exist. Checking it requires the cluster agent to be allowed to get the secret.
+	config.BindEnvAndSetDefault("admission_controller.agent_sidecar.api_key_secret.name", "datadog-secret")
+	config.BindEnvAndSetDefault("admission_controller.agent_sidecar.api_key_secret.key", "api-key")
+	// JSON object with the objectSelector and namespaceSelector of the pods getting the agent sidecar, required to register the webhook
+	config.BindEnvAndSetDefault("admission_controller.agent_sidecar.selectors", "")
+	// name of the ConfigMap of the cluster agent namespace holding the profiles overriding the env vars and resources of the
+	// agent sidecar, as a YAML list under the profiles.yaml key, e.g. [{"name": "default", "env": [...], "resources": {...}}]
+	config.BindEnvAndSetDefault("admission_controller.agent_sidecar.profiles_configmap", "")
 	config.BindEnvAndSetDefault("admission_controller.pod_owners_cache_validity", 10) // in minutes
 	config.BindEnvAndSetDefault("admission_controller.namespace_selector_fallback", false)
 
@@ -872,6 +901,7 @@ func InitConfig(config Config) {
 	// this option will potentially impact the CPU usage of the agent
 	config.BindEnvAndSetDefault("orchestrator_explorer.container_scrubbing.enabled", true)
 	config.BindEnvAndSetDefault("orchestrator_explorer.custom_sensitive_words", []string{})
+	config.SetKnown("orchestrator_explorer.custom_scrub_rules")
 	config.BindEnv("orchestrator_explorer.max_per_message")
 	config.BindEnv("orchestrator_explorer.orchestrator_dd_url")
 	config.BindEnv("orchestrator_explorer.orchestrator_additional_endpoints")
@@ -899,6 +929,7 @@ func InitConfig(config Config) {
 	config.SetKnown("process_config.intervals.container_realtime")
 	config.SetKnown("process_config.dd_agent_bin")
 	config.SetKnown("process_config.custom_sensitive_words")
+	config.SetKnown("process_config.custom_scrub_rules")
 	config.SetKnown("process_config.scrub_args")
 	config.SetKnown("process_config.strip_proc_arguments")
 	config.SetKnown("process_config.windows.args_refresh_interval")
@@ -912,6 +943,7 @@ func InitConfig(config Config) {
 	config.SetKnown("process_config.internal_profiling.enabled")
 
 	config.BindEnvAndSetDefault("process_config.remote_tagger", true)
+	config.BindEnvAndSetDefault("process_config.extended_stats.enabled", false)
 
 	// Process Discovery Check
 	config.BindEnvAndSetDefault("process_config.process_discovery.enabled", false)
@@ -16,11 +16,18 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apiwatch "k8s.io/apimachinery/pkg/watch"
 	vpaclientset "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/client/clientset/versioned"
+	"k8s.io/client-go/dynamic"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	ksmbuild "k8s.io/kube-state-metrics/v2/pkg/builder"
 	ksmtypes "k8s.io/kube-state-metrics/v2/pkg/builder/types"
+	"k8s.io/kube-state-metrics/v2/pkg/metric"
 	generator "k8s.io/kube-state-metrics/v2/pkg/metric_generator"
 	metricsstore "k8s.io/kube-state-metrics/v2/pkg/metrics_store"
 	"k8s.io/kube-state-metrics/v2/pkg/options"
@@ -34,6 +41,7 @@ type Builder struct {
 
 	kubeClient    clientset.Interface
 	vpaClient     vpaclientset.Interface
+	dynamicClient dynamic.Interface
 	namespaces    options.NamespaceList
 	ctx           context.Context
 	allowDenyList ksmtypes.AllowDenyLister
@@ -83,6 +91,11 @@ func (b *Builder) WithVPAClient(c vpaclientset.Interface) {
 	b.ksmBuilder.WithVPAClient(c)
 }
 
+// WithDynamicClient sets the dynamicClient property of a Builder so that custom resources can be watched.
+func (b *Builder) WithDynamicClient(c dynamic.Interface) {
+	b.dynamicClient = c
+}
+
 // WithMetrics sets the metrics property of a Builder.
 func (b *Builder) WithMetrics(r prometheus.Registerer) {
 	b.ksmBuilder.WithMetrics(r)
@@ -169,3 +182,35 @@ func (b *Builder) startReflector(
 	reflector := cache.NewReflector(listWatcher, expectedType, store, b.resync*time.Second)
 	go reflector.Run(b.ctx.Done())
 }
+
+// BuildCustomResourceStores initializes and registers the stores of the metrics generated
+// by generateFunc for the custom resources identified by gvr.
+// It must be called after WithDynamicClient, WithNamespaces and WithContext.
+func (b *Builder) BuildCustomResourceStores(gvr schema.GroupVersionResource, generateFunc func(interface{}) []metric.FamilyInterface) []cache.Store {
+	expectedType := &unstructured.Unstructured{}
+	listWatchFunc := func(ns string) cache.ListerWatcher {
+		return &cache.ListWatch{
+			ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+				return b.dynamicClient.Resource(gvr).Namespace(ns).List(b.ctx, opts)
+			},
+			WatchFunc: func(opts metav1.ListOptions) (apiwatch.Interface, error) {
+				return b.dynamicClient.Resource(gvr).Namespace(ns).Watch(b.ctx, opts)
+			},
+		}
+	}
+
+	if b.namespaces.IsAllNamespaces() {
+		store := store.NewMetricsStore(generateFunc, gvr.String())
+		b.startReflector(expectedType, store, listWatchFunc(corev1.NamespaceAll))
+		return []cache.Store{store}
+	}
+
+	stores := make([]cache.Store, 0, len(b.namespaces))
+	for _, ns := range b.namespaces {
+		store := store.NewMetricsStore(generateFunc, gvr.String())
+		b.startReflector(expectedType, store, listWatchFunc(ns))
+		stores = append(stores, store)
+	}
+
+	return stores
+}
@@ -67,6 +67,11 @@ type Config struct {
 	// Supported libraries: OpenSSL
 	EnableHTTPSMonitoring bool
 
+	// EnableProtocolClassification specifies whether the tracer should classify the application protocol
+	// of connections and aggregate Kafka and PostgreSQL requests. Payloads are captured by HTTP monitoring,
+	// which has to be enabled too.
+	EnableProtocolClassification bool
+
 	// UDPConnTimeout determines the length of traffic inactivity between two
 	// (IP, port)-pairs before declaring a UDP connection as inactive. This is
 	// set to /proc/sys/net/netfilter/nf_conntrack_udp_timeout on Linux by
@@ -126,6 +131,10 @@ type Config struct {
 	// ConntrackInitTimeout specifies how long we wait for conntrack to initialize before failing
 	ConntrackInitTimeout time.Duration
 
+	// ConntrackTranslationHistoryTTL specifies how long the translations evicted from the conntrack cache
+	// are kept to resolve the connections closed afterwards. Setting it to 0 disables the history.
+	ConntrackTranslationHistoryTTL time.Duration
+
 	// EnableConntrackAllNamespaces enables network address translation via netlink for all namespaces that are peers of the root namespace.
 	// default is true
 	EnableConntrackAllNamespaces bool
@@ -203,6 +212,8 @@ func New() *Config {
 		EnableHTTPSMonitoring: cfg.GetBool(join(netNS, "enable_https_monitoring")),
 		MaxHTTPStatsBuffered:  100000,
 
+		EnableProtocolClassification: cfg.GetBool(join(netNS, "enable_protocol_classification")),
+
 		EnableConntrack:              cfg.GetBool(join(spNS, "enable_conntrack")),
 		ConntrackMaxStateSize:        cfg.GetInt(join(spNS, "conntrack_max_state_size")),
 		ConntrackRateLimit:           cfg.GetInt(join(spNS, "conntrack_rate_limit")),
@@ -210,6 +221,8 @@ func New() *Config {
 		IgnoreConntrackInitFailure:   cfg.GetBool(join(netNS, "ignore_conntrack_init_failure")),
 		ConntrackInitTimeout:         cfg.GetDuration(join(netNS, "conntrack_init_timeout")),
 
+		ConntrackTranslationHistoryTTL: cfg.GetDuration(join(netNS, "conntrack_translation_history_ttl")),
+
 		EnableGatewayLookup: cfg.GetBool(join(netNS, "enable_gateway_lookup")),
 
 		EnableMonotonicCount: cfg.GetBool(join(spNS, "windows.enable_monotonic_count")),
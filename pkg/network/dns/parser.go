@@ -196,7 +196,9 @@ func (p *dnsParser) parseAnswerInto(
 		return nil
 	}
 
+	// the response code includes the extended response code of EDNS replies
 	pktInfo.rCode = uint8(dns.ResponseCode)
+	pktInfo.truncated = dns.TC
 	if dns.ResponseCode != 0 {
 		pktInfo.pktType = failedResponse
 		return nil
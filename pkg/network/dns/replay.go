@@ -0,0 +1,84 @@
+//+build windows linux_bpf
+
+package dns
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/google/gopacket/layers"
+)
+
+// ReplayCapture replays the packets of a pcap or pcapng capture file through the DNS parser
+// and stat keeper used by the snooper, and returns the resulting DNS stats along with the
+// snooper telemetry. This allows reproducing DNS stats computed from real traffic offline.
+func ReplayCapture(cfg *config.Config, path string) (StatsByKeyByNameByType, map[string]int64, error) {
+	source, err := newPcapPacketSource(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// stats are always collected from replayed captures
+	replayCfg := *cfg
+	replayCfg.CollectDNSStats = true
+	cfg = &replayCfg
+
+	// the snooper is driven synchronously instead of polling its packet source in the background
+	snooper := &socketFilterSnooper{
+		source:          source,
+		parser:          newDNSParser(source.PacketType(), cfg),
+		cache:           newReverseDNSCache(dnsCacheSize, dnsCacheExpirationPeriod),
+		statKeeper:      newDNSStatkeeper(cfg.DNSTimeout, cfg.MaxDNSStats),
+		translation:     new(translation),
+		exit:            make(chan struct{}),
+		collectLocalDNS: cfg.CollectLocalDNS,
+	}
+	defer snooper.Close()
+
+	if err := source.VisitPackets(snooper.exit, snooper.processPacket); err != nil {
+		return nil, nil, fmt.Errorf("error replaying capture %s: %w", path, err)
+	}
+
+	stats := snooper.GetDNSStats()
+	return stats, snooper.GetStats(), nil
+}
+
+// WriteStats writes a human readable summary of DNS stats, sorted by client, server and domain
+func WriteStats(w io.Writer, stats StatsByKeyByNameByType) error {
+	var lines []string
+	for key, byDomain := range stats {
+		protocol := "udp"
+		if key.Protocol == syscall.IPPROTO_TCP {
+			protocol = "tcp"
+		}
+
+		for domain, byType := range byDomain {
+			name, _ := domain.Get().(string)
+			for qtype, s := range byType {
+				rcodes := make([]string, 0, len(s.CountByRcode))
+				for rcode, count := range s.CountByRcode {
+					rcodes = append(rcodes, fmt.Sprintf("%d:%d", rcode, count))
+				}
+				sort.Strings(rcodes)
+
+				lines = append(lines, fmt.Sprintf(
+					"%s:%d -> %s (%s) %s %s rcodes=[%s] timeouts=%d truncated=%d success_latency_sum=%dµs failure_latency_sum=%dµs",
+					key.ClientIP, key.ClientPort, key.ServerIP, protocol, name, layers.DNSType(qtype),
+					strings.Join(rcodes, " "), s.Timeouts, s.Truncated, s.SuccessLatencySum, s.FailureLatencySum,
+				))
+			}
+		}
+	}
+
+	sort.Strings(lines)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+//+build windows linux_bpf
+
+package dns
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go4.org/intern"
+)
+
+func replayConfig() *config.Config {
+	cfg := config.New()
+	cfg.CollectDNSStats = true
+	cfg.CollectDNSDomains = true
+	cfg.DNSTimeout = 15 * time.Second
+	cfg.MaxDNSStats = 100
+	cfg.RecordedQueryTypes = []string{"A", "AAAA"}
+	return cfg
+}
+
+func TestReplayCapture(t *testing.T) {
+	// the captures contain the same packets: an ethernet pcap and a raw IP pcapng
+	for _, capture := range []string{"./testdata/dns.pcap", "./testdata/dns_raw.pcapng"} {
+		t.Run(capture, func(t *testing.T) {
+			stats, telemetry, err := ReplayCapture(replayConfig(), capture)
+			require.NoError(t, err)
+
+			key := Key{
+				ClientIP:   util.AddressFromString("10.0.0.1"),
+				ClientPort: 53000,
+				ServerIP:   util.AddressFromString("8.8.8.8"),
+				Protocol:   syscall.IPPROTO_UDP,
+			}
+			require.Contains(t, stats, key)
+
+			byDomain := stats[key]
+			require.Len(t, byDomain, 2)
+
+			found := byDomain[intern.GetByString("example.com")][TypeA]
+			assert.Equal(t, map[uint32]uint32{0: 2}, found.CountByRcode)
+			assert.Equal(t, uint64(6000), found.SuccessLatencySum)
+
+			missing := byDomain[intern.GetByString("missing.example.com")][TypeAAAA]
+			assert.Equal(t, map[uint32]uint32{3: 1}, missing.CountByRcode)
+			assert.Equal(t, uint64(5000), missing.FailureLatencySum)
+
+			assert.Equal(t, int64(6), telemetry["pcap_packets_read"])
+			assert.Equal(t, int64(3), telemetry["queries"])
+			assert.Equal(t, int64(2), telemetry["successes"])
+			assert.Equal(t, int64(1), telemetry["errors"])
+
+			var out bytes.Buffer
+			require.NoError(t, WriteStats(&out, stats))
+			assert.Equal(t,
+				"10.0.0.1:53000 -> 8.8.8.8 (udp) example.com A rcodes=[0:2] timeouts=0 truncated=0 success_latency_sum=6000µs failure_latency_sum=0µs\n"+
+					"10.0.0.1:53000 -> 8.8.8.8 (udp) missing.example.com AAAA rcodes=[3:1] timeouts=0 truncated=0 success_latency_sum=0µs failure_latency_sum=5000µs\n",
+				out.String(),
+			)
+		})
+	}
+}
+
+func TestReplayCaptureMissingFile(t *testing.T) {
+	_, _, err := ReplayCapture(replayConfig(), "./testdata/missing.pcap")
+	assert.Error(t, err)
+}
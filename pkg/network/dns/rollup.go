@@ -0,0 +1,52 @@
+package dns
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// ResolverStats holds the DNS statistics of all the clients of a resolver
+type ResolverStats struct {
+	Timeouts     uint32
+	Truncated    uint32
+	CountByRcode map[uint32]uint32
+	// FailuresByDomain is the number of replies with a non zero response code, by domain and response code.
+	// This makes it possible to spot a client repeatedly querying a domain which does not exist.
+	FailuresByDomain map[string]map[uint32]uint32
+}
+
+// RollupByResolver aggregates DNS stats by resolver IP, across clients and query types
+func RollupByResolver(stats StatsByKeyByNameByType) map[util.Address]*ResolverStats {
+	rollups := make(map[util.Address]*ResolverStats)
+	for key, byDomain := range stats {
+		rollup, ok := rollups[key.ServerIP]
+		if !ok {
+			rollup = &ResolverStats{
+				CountByRcode:     make(map[uint32]uint32),
+				FailuresByDomain: make(map[string]map[uint32]uint32),
+			}
+			rollups[key.ServerIP] = rollup
+		}
+
+		for domain, byType := range byDomain {
+			name, _ := domain.Get().(string)
+			for _, s := range byType {
+				rollup.Timeouts += s.Timeouts
+				rollup.Truncated += s.Truncated
+				for rcode, count := range s.CountByRcode {
+					rollup.CountByRcode[rcode] += count
+					if rcode == RcodeNoError {
+						continue
+					}
+
+					failures, ok := rollup.FailuresByDomain[name]
+					if !ok {
+						failures = make(map[uint32]uint32)
+						rollup.FailuresByDomain[name] = failures
+					}
+					failures[rcode] += count
+				}
+			}
+		}
+	}
+	return rollups
+}
@@ -0,0 +1,66 @@
+package dns
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go4.org/intern"
+)
+
+func TestRollupByResolver(t *testing.T) {
+	resolver := util.AddressFromString("8.8.8.8")
+	other := util.AddressFromString("1.1.1.1")
+	key := func(client string, server util.Address) Key {
+		return Key{
+			ServerIP:   server,
+			ClientIP:   util.AddressFromString(client),
+			ClientPort: 1000,
+			Protocol:   syscall.IPPROTO_UDP,
+		}
+	}
+	found := intern.GetByString("example.com")
+	missing := intern.GetByString("missing.example.com")
+
+	stats := StatsByKeyByNameByType{
+		key("10.0.0.1", resolver): {
+			found: {
+				TypeA:    {CountByRcode: map[uint32]uint32{RcodeNoError: 3}, Truncated: 1},
+				TypeAAAA: {CountByRcode: map[uint32]uint32{RcodeServFail: 1}, Timeouts: 2},
+			},
+			missing: {
+				TypeA: {CountByRcode: map[uint32]uint32{RcodeNXDomain: 10}},
+			},
+		},
+		key("10.0.0.2", resolver): {
+			missing: {
+				TypeA: {CountByRcode: map[uint32]uint32{RcodeNXDomain: 5, RcodeRefused: 1}},
+			},
+		},
+		key("10.0.0.1", other): {
+			found: {
+				TypeA: {CountByRcode: map[uint32]uint32{RcodeNoError: 1}},
+			},
+		},
+	}
+
+	rollups := RollupByResolver(stats)
+	require.Len(t, rollups, 2)
+
+	r := rollups[resolver]
+	require.NotNil(t, r)
+	assert.Equal(t, uint32(2), r.Timeouts)
+	assert.Equal(t, uint32(1), r.Truncated)
+	assert.Equal(t, map[uint32]uint32{RcodeNoError: 3, RcodeServFail: 1, RcodeNXDomain: 15, RcodeRefused: 1}, r.CountByRcode)
+	assert.Equal(t, map[string]map[uint32]uint32{
+		"example.com":         {RcodeServFail: 1},
+		"missing.example.com": {RcodeNXDomain: 15, RcodeRefused: 1},
+	}, r.FailuresByDomain)
+
+	o := rollups[other]
+	require.NotNil(t, o)
+	assert.Equal(t, map[uint32]uint32{RcodeNoError: 1}, o.CountByRcode)
+	assert.Empty(t, o.FailuresByDomain)
+}
@@ -34,6 +34,7 @@ type dnsPacketInfo struct {
 	key           Key
 	pktType       packetType
 	rCode         uint8         // responseCode
+	truncated     bool          // only relevant for response packets
 	question      *intern.Value // only relevant for query packets
 	queryType     QueryType
 }
@@ -149,6 +150,9 @@ func (d *dnsStatKeeper) ProcessPacketInfo(info dnsPacketInfo, ts time.Time) {
 		byqtype.Timeouts++
 	} else {
 		byqtype.CountByRcode[uint32(info.rCode)]++
+		if info.truncated {
+			byqtype.Truncated++
+		}
 		if info.pktType == successfulResponse {
 			byqtype.SuccessLatencySum += latency
 		} else if info.pktType == failedResponse {
@@ -102,6 +102,29 @@ func TestExpiredStateRemoval(t *testing.T) {
 	assert.Equal(t, uint32(1), stats[key][d][TypeA].Timeouts)
 }
 
+func TestTruncatedResponses(t *testing.T) {
+	sk := newDNSStatkeeper(DNSTimeoutSecs*time.Second, 10000)
+	key := getSampleDNSKey()
+	var d = intern.GetByString("abc.com")
+	qPkt1 := dnsPacketInfo{transactionID: 1, pktType: query, key: key, question: d, queryType: TypeA}
+	rPkt1 := dnsPacketInfo{transactionID: 1, key: key, pktType: successfulResponse, queryType: TypeA, truncated: true}
+	qPkt2 := dnsPacketInfo{transactionID: 2, pktType: query, key: key, question: d, queryType: TypeA}
+	rPkt2 := dnsPacketInfo{transactionID: 2, key: key, pktType: failedResponse, queryType: TypeA, rCode: uint8(RcodeNXDomain)}
+
+	now := time.Now()
+	sk.ProcessPacketInfo(qPkt1, now)
+	sk.ProcessPacketInfo(rPkt1, now)
+	sk.ProcessPacketInfo(qPkt2, now)
+	sk.ProcessPacketInfo(rPkt2, now)
+
+	stats := sk.GetAndResetAllStats()
+	require.Contains(t, stats, key)
+	require.Contains(t, stats[key], d)
+
+	assert.Equal(t, uint32(1), stats[key][d][TypeA].Truncated)
+	assert.Equal(t, map[uint32]uint32{RcodeNoError: 1, RcodeNXDomain: 1}, stats[key][d][TypeA].CountByRcode)
+}
+
 func BenchmarkStats(b *testing.B) {
 	key := getSampleDNSKey()
 
@@ -33,6 +33,17 @@ const (
 	TypeURI   QueryType = 256 // URI RR [RFC7553]
 )
 
+// Response codes of DNS replies counted in Stats.CountByRcode. Extended response codes
+// carried by EDNS OPT records are folded into the response code when parsing replies.
+const (
+	RcodeNoError  uint32 = 0 // no error condition
+	RcodeFormErr  uint32 = 1 // the server was unable to interpret the query
+	RcodeServFail uint32 = 2 // the server was unable to process the query
+	RcodeNXDomain uint32 = 3 // the domain name referenced in the query does not exist
+	RcodeNotImp   uint32 = 4 // the server does not support the requested kind of query
+	RcodeRefused  uint32 = 5 // the server refuses to perform the specified operation
+)
+
 // StatsByKeyByNameByType provides a type name for the map of
 // DNS stats based on the host key->the lookup name->querytype
 type StatsByKeyByNameByType map[Key]map[*intern.Value]map[QueryType]Stats
@@ -60,4 +71,6 @@ type Stats struct {
 	SuccessLatencySum uint64
 	FailureLatencySum uint64
 	CountByRcode      map[uint32]uint32
+	// Truncated is the number of replies with the TC flag set, which clients typically retry over TCP
+	Truncated uint32
 }
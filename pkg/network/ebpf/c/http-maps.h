@@ -45,6 +45,56 @@ struct bpf_map_def SEC("maps/http_batch_state") http_batch_state = {
     .namespace = "",
 };
 
+/* This map used for notifying userspace that a batch of payload fragments is ready to be consumed */
+struct bpf_map_def SEC("maps/payload_notifications") payload_notifications = {
+    .type = BPF_MAP_TYPE_PERF_EVENT_ARRAY,
+    .key_size = sizeof(__u32),
+    .value_size = sizeof(__u32),
+    .max_entries = 0, // This will get overridden at runtime
+    .pinning = 0,
+    .namespace = "",
+};
+
+/* This map stores captured payload fragments in batches so they can be consumed by userspace */
+struct bpf_map_def SEC("maps/payload_batches") payload_batches = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(http_batch_key_t),
+    .value_size = sizeof(payload_batch_t),
+    .max_entries = 1024,
+    .pinning = 0,
+    .namespace = "",
+};
+
+/* This map holds one entry per CPU storing state associated to current payload batch */
+struct bpf_map_def SEC("maps/payload_batch_state") payload_batch_state = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(__u32),
+    .value_size = sizeof(http_batch_state_t),
+    .max_entries = 1024,
+    .pinning = 0,
+    .namespace = "",
+};
+
+/* This map holds one payload_fragment_t per CPU, used as scratch space since it doesn't fit in the eBPF stack */
+struct bpf_map_def SEC("maps/payload_heap") payload_heap = {
+    .type = BPF_MAP_TYPE_HASH,
+    .key_size = sizeof(__u32),
+    .value_size = sizeof(payload_fragment_t),
+    .max_entries = 1024,
+    .pinning = 0,
+    .namespace = "",
+};
+
+/* This map holds the connections whose payloads userspace isn't interested in */
+struct bpf_map_def SEC("maps/payload_ignored") payload_ignored = {
+    .type = BPF_MAP_TYPE_LRU_HASH,
+    .key_size = sizeof(conn_tuple_t),
+    .value_size = sizeof(__u8),
+    .max_entries = 1, // This will get overridden at runtime using max_tracked_connections
+    .pinning = 0,
+    .namespace = "",
+};
+
 struct bpf_map_def SEC("maps/ssl_sock_by_ctx") ssl_sock_by_ctx = {
     .type = BPF_MAP_TYPE_HASH,
     .key_size = sizeof(void *),
@@ -10,6 +10,13 @@
 // The greater this number is the less likely are colisions/data-races between the flushes
 #define HTTP_BATCH_PAGES 10
 
+// This determines the size of the fragment captured at the beginning of each TCP segment
+// for the protocols decoded in userspace (HTTP/2, Kafka, PostgreSQL...)
+#define PAYLOAD_BUFFER_SIZE 128
+// This controls the number of payload fragments read from userspace at a time
+#define PAYLOAD_BATCH_SIZE 8
+#define PAYLOAD_BATCH_PAGES 10
+
 typedef enum
 {
     HTTP_PACKET_UNKNOWN,
@@ -85,6 +92,27 @@ typedef struct {
     __u64 batch_idx;
 } http_batch_notification_t;
 
+// Beginning of a TCP segment captured for the protocols decoded in userspace.
+// Payload batches are handled exactly like HTTP batches, so they share the
+// http_batch_state_t, http_batch_key_t and http_batch_notification_t types.
+typedef struct {
+    // tup is normalized to always be (client, server)
+    conn_tuple_t tup;
+    __u64 timestamp;
+    __u32 seq;
+    // len is the size of the segment payload, only its first PAYLOAD_BUFFER_SIZE bytes are captured
+    __u32 len;
+    __u8 from_client;
+    __u8 tcp_flags;
+    char data[PAYLOAD_BUFFER_SIZE];
+} payload_fragment_t;
+
+typedef struct {
+    __u64 idx;
+    __u8 pos;
+    payload_fragment_t fragments[PAYLOAD_BATCH_SIZE];
+} payload_batch_t;
+
 // OpenSSL types
 typedef struct {
     void *ctx;
@@ -215,4 +215,109 @@ static __always_inline int http_process(char *buffer, skb_info_t *skb_info, u16
     return 0;
 }
 
+static __always_inline void payload_notify_batch(struct pt_regs *ctx) {
+    u32 cpu = bpf_get_smp_processor_id();
+
+    http_batch_state_t *batch_state = bpf_map_lookup_elem(&payload_batch_state, &cpu);
+    if (batch_state == NULL || batch_state->idx_to_notify == batch_state->idx) {
+        // batch is not ready to be flushed
+        return;
+    }
+
+    http_batch_notification_t notification = { 0 };
+    notification.cpu = cpu;
+    notification.batch_idx = batch_state->idx_to_notify;
+
+    bpf_perf_event_output(ctx, &payload_notifications, cpu, &notification, sizeof(http_batch_notification_t));
+    log_debug("payload batch notification flushed: cpu: %d idx: %d\n", notification.cpu, notification.batch_idx);
+    batch_state->idx_to_notify++;
+}
+
+static __always_inline void payload_enqueue(payload_fragment_t *fragment) {
+    // Retrieve the active batch number for this CPU
+    u32 cpu = bpf_get_smp_processor_id();
+    http_batch_state_t *batch_state = bpf_map_lookup_elem(&payload_batch_state, &cpu);
+    if (batch_state == NULL) {
+        return;
+    }
+
+    http_batch_key_t key;
+    __builtin_memset(&key, 0, sizeof(http_batch_key_t));
+    key.cpu = cpu;
+    key.page_num = batch_state->idx % PAYLOAD_BATCH_PAGES;
+
+    // Retrieve the batch object
+    payload_batch_t *batch = bpf_map_lookup_elem(&payload_batches, &key);
+    if (batch == NULL) {
+        return;
+    }
+
+    // This loop is unrolled for the same reason as the one in http_enqueue
+#pragma unroll
+    for (int i = 0; i < PAYLOAD_BATCH_SIZE; i++) {
+        if (i == batch_state->pos) {
+            __builtin_memcpy(&batch->fragments[i], fragment, sizeof(payload_fragment_t));
+        }
+    }
+
+    batch_state->pos++;
+
+    // Copy batch state information for user-space
+    batch->idx = batch_state->idx;
+    batch->pos = batch_state->pos;
+
+    // If we have filled the batch we move to the next one
+    if (batch_state->pos == PAYLOAD_BATCH_SIZE) {
+        batch_state->idx++;
+        batch_state->pos = 0;
+    }
+}
+
+// payload_capture sends the beginning of TCP segments to userspace, where the protocols which can't
+// be parsed by eBPF programs (HTTP/2, Kafka, PostgreSQL...) are decoded. Segments are reassembled in
+// userspace using their sequence numbers, which also discards the copies of segments seen several times.
+// Userspace stops the capture of the connections it isn't interested in through the payload_ignored map.
+static __always_inline void payload_capture(struct __sk_buff *skb, skb_info_t *skb_info, u16 src_port) {
+    u8 closing = skb_info->tcp_flags & (TCPHDR_FIN | TCPHDR_RST);
+    if (bpf_map_lookup_elem(&payload_ignored, &skb_info->tup) != NULL) {
+        if (closing) {
+            bpf_map_delete_elem(&payload_ignored, &skb_info->tup);
+        }
+        return;
+    }
+
+    u32 len = 0;
+    if (skb->len > skb_info->data_off) {
+        len = skb->len - skb_info->data_off;
+    }
+
+    // segments without payload are only relevant when they terminate the connection
+    if (len == 0 && !closing) {
+        return;
+    }
+
+    u32 cpu = bpf_get_smp_processor_id();
+    payload_fragment_t *fragment = bpf_map_lookup_elem(&payload_heap, &cpu);
+    if (fragment == NULL) {
+        return;
+    }
+
+    __builtin_memcpy(&fragment->tup, &skb_info->tup, sizeof(conn_tuple_t));
+    fragment->timestamp = bpf_ktime_get_ns();
+    fragment->seq = skb_info->tcp_seq;
+    fragment->len = len;
+    fragment->from_client = src_port == skb_info->tup.sport;
+    fragment->tcp_flags = skb_info->tcp_flags;
+
+#pragma unroll
+    for (int i = 0; i < PAYLOAD_BUFFER_SIZE; i++) {
+        if (i >= len) {
+            break;
+        }
+        fragment->data[i] = load_byte(skb, skb_info->data_off + i);
+    }
+
+    payload_enqueue(fragment);
+}
+
 #endif
@@ -71,6 +71,7 @@ static __always_inline __u64 read_conn_tuple_skb(struct __sk_buff *skb, skb_info
         info->tup.sport = load_half(skb, info->data_off + offsetof(struct tcphdr, source));
         info->tup.dport = load_half(skb, info->data_off + offsetof(struct tcphdr, dest));
 
+        info->tcp_seq = load_word(skb, info->data_off + offsetof(struct tcphdr, seq));
         info->tcp_flags = load_byte(skb, info->data_off + TCP_FLAGS_OFFSET);
         // TODO: Improve readability and explain the bit twiddling below
         info->data_off += ((load_byte(skb, info->data_off + offsetof(struct tcphdr, ack_seq) + 4) & 0xF0) >> 4) * 4;
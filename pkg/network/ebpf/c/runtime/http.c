@@ -56,6 +56,7 @@ int socket__http_filter(struct __sk_buff* skb) {
     __builtin_memset(buffer, 0, sizeof(buffer));
     read_skb_data(skb, skb_info.data_off, buffer);
     http_process(buffer, &skb_info, src_port);
+    payload_capture(skb, &skb_info, src_port);
     return 0;
 }
 
@@ -64,6 +65,7 @@ int socket__http_filter(struct __sk_buff* skb) {
 SEC("kretprobe/tcp_sendmsg")
 int kretprobe__tcp_sendmsg(struct pt_regs* ctx) {
     http_notify_batch(ctx);
+    payload_notify_batch(ctx);
     return 0;
 }
 
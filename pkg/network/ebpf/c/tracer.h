@@ -90,15 +90,17 @@ typedef struct {
 // tcp_flag_byte(th) (((u_int8_t *)th)[13])
 #define TCP_FLAGS_OFFSET 13
 #define TCPHDR_FIN 0x01
+#define TCPHDR_RST 0x04
 
 // skb_info_t embeds a conn_tuple_t extracted from the skb object as well as
 // some ancillary data such as the data offset (the byte offset pointing to
-// where the application payload begins) and the TCP flags if applicable.
+// where the application payload begins) and the TCP flags and sequence number if applicable.
 // This struct is populated by calling `read_conn_tuple_skb` from a program type
 // that manipulates a `__sk_buff` object.
 typedef struct {
     conn_tuple_t tup;
     __u32 data_off;
+    __u32 tcp_seq;
     __u8 tcp_flags;
 } skb_info_t;
 
@@ -155,7 +155,11 @@ func formatDNSStatsByDomain(stats map[*intern.Value]map[dns.QueryType]dns.Stats,
 
 			} else {
 				var ms model.DNSStats
-				ms.DnsCountByRcode = stat.CountByRcode
+				// the rcode counts of the other query types get merged in this map, so it can't be shared with stat
+				ms.DnsCountByRcode = make(map[uint32]uint32, len(stat.CountByRcode))
+				for rcode, count := range stat.CountByRcode {
+					ms.DnsCountByRcode[rcode] = count
+				}
 				ms.DnsFailureLatencySum = stat.FailureLatencySum
 				ms.DnsSuccessLatencySum = stat.SuccessLatencySum
 				ms.DnsTimeouts = stat.Timeouts
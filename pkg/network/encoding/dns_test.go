@@ -166,3 +166,20 @@ func TestDNSPIDCollision(t *testing.T) {
 	assert.NotNil(t, out1.DnsStatsByDomain)
 	assert.Nil(t, out2.DnsStatsByDomain)
 }
+
+func TestFormatDNSStatsByDomainRcodes(t *testing.T) {
+	stats := map[*intern.Value]map[dns.QueryType]dns.Stats{
+		intern.GetByString("missing.com"): {
+			dns.TypeA:    {CountByRcode: map[uint32]uint32{dns.RcodeNXDomain: 3}},
+			dns.TypeAAAA: {CountByRcode: map[uint32]uint32{dns.RcodeNXDomain: 2, dns.RcodeServFail: 1}},
+		},
+	}
+
+	out := formatDNSStatsByDomain(stats, make(map[string]int))
+	assert.Equal(t, map[uint32]uint32{dns.RcodeNXDomain: 5, dns.RcodeServFail: 1}, out[0].DnsCountByRcode)
+
+	// the rcode counts of the connection stats are left untouched
+	byType := stats[intern.GetByString("missing.com")]
+	assert.Equal(t, map[uint32]uint32{dns.RcodeNXDomain: 3}, byType[dns.TypeA].CountByRcode)
+	assert.Equal(t, map[uint32]uint32{dns.RcodeNXDomain: 2, dns.RcodeServFail: 1}, byType[dns.TypeAAAA].CountByRcode)
+}
@@ -0,0 +1,230 @@
+package encoding
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	netconfig "github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/http"
+	"github.com/DataDog/datadog-agent/pkg/network/http/debugging"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	protocoldebugging "github.com/DataDog/datadog-agent/pkg/network/protocols/debugging"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// captureSize is the number of bytes captured at the beginning of each segment by the eBPF socket filter
+const captureSize = 128
+
+// capturedConn generates the segments captured on a connection by the eBPF socket filter
+type capturedConn struct {
+	client, server         util.Address
+	clientPort, serverPort uint16
+
+	clientSeq, serverSeq uint32
+	ts                   uint64
+}
+
+func (c *capturedConn) segment(fromClient bool, payload []byte) http.PayloadSegment {
+	seq := &c.serverSeq
+	if fromClient {
+		seq = &c.clientSeq
+	}
+	c.ts += uint64(time.Millisecond)
+
+	seg := http.PayloadSegment{
+		ClientAddr: c.client,
+		ServerAddr: c.server,
+		ClientPort: c.clientPort,
+		ServerPort: c.serverPort,
+		FromClient: fromClient,
+		Seq:        *seq,
+		Len:        len(payload),
+		Data:       payload,
+		Timestamp:  c.ts,
+	}
+	if len(seg.Data) > captureSize {
+		seg.Data = seg.Data[:captureSize]
+	}
+	*seq += uint32(len(payload))
+	return seg
+}
+
+func (c *capturedConn) stats(protocol protocols.ProtocolType) network.ConnectionStats {
+	return network.ConnectionStats{
+		Source:   c.client,
+		Dest:     c.server,
+		SPort:    c.clientPort,
+		DPort:    c.serverPort,
+		Type:     network.TCP,
+		Family:   network.AFINET,
+		Protocol: protocol,
+	}
+}
+
+// http2Frames encodes HTTP/2 frames, using a single HPACK context
+type http2Frames struct {
+	t       *testing.T
+	buf     bytes.Buffer
+	framer  *http2.Framer
+	block   bytes.Buffer
+	encoder *hpack.Encoder
+}
+
+func newHTTP2Frames(t *testing.T) *http2Frames {
+	f := &http2Frames{t: t}
+	f.framer = http2.NewFramer(&f.buf, nil)
+	f.encoder = hpack.NewEncoder(&f.block)
+	return f
+}
+
+func (f *http2Frames) headers(streamID uint32, endStream bool, fields ...string) *http2Frames {
+	f.block.Reset()
+	for i := 0; i < len(fields); i += 2 {
+		require.NoError(f.t, f.encoder.WriteField(hpack.HeaderField{Name: fields[i], Value: fields[i+1]}))
+	}
+	require.NoError(f.t, f.framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: f.block.Bytes(),
+		EndStream:     endStream,
+		EndHeaders:    true,
+	}))
+	return f
+}
+
+func (f *http2Frames) data(streamID uint32, endStream bool, size int) *http2Frames {
+	require.NoError(f.t, f.framer.WriteData(streamID, endStream, make([]byte, size)))
+	return f
+}
+
+func (f *http2Frames) flush() []byte {
+	out := append([]byte(nil), f.buf.Bytes()...)
+	f.buf.Reset()
+	return out
+}
+
+func kafkaProduceRequest(topic string, recordSize int) []byte {
+	// produce v2 request with a null client id, acks=1, timeout=1000 and a single partition
+	body := []byte{0, 0, 0, 2, 0, 0, 0, 7, 0xff, 0xff, 0, 1, 0, 0, 0x03, 0xe8, 0, 0, 0, 1}
+	body = append(body, 0, byte(len(topic)))
+	body = append(body, topic...)
+	body = append(body, 0, 0, 0, 1, 0, 0, 0, 0)
+	body = append(body, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(body[len(body)-4:], uint32(recordSize))
+	body = append(body, make([]byte, recordSize)...)
+
+	out := make([]byte, 4)
+	binary.BigEndian.PutUint32(out, uint32(len(body)))
+	return append(out, body...)
+}
+
+func postgresMessage(msgType byte, body string) []byte {
+	out := []byte{msgType, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(out[1:], uint32(4+len(body)))
+	return append(out, body...)
+}
+
+// TestEncodeCapturedPayloads decodes the payloads captured on connections up to the connections payload and the debug endpoints
+func TestEncodeCapturedPayloads(t *testing.T) {
+	client := util.AddressFromString("10.0.0.1")
+	grpcConn := &capturedConn{client: client, server: util.AddressFromString("10.0.0.2"), clientPort: 40000, serverPort: 50051}
+	kafkaConn := &capturedConn{client: client, server: util.AddressFromString("10.0.0.3"), clientPort: 40001, serverPort: 9092}
+	postgresConn := &capturedConn{client: client, server: util.AddressFromString("10.0.0.4"), clientPort: 40002, serverPort: 5432}
+	otherConn := &capturedConn{client: client, server: util.AddressFromString("10.0.0.5"), clientPort: 40003, serverPort: 22}
+
+	http2Keeper := http.NewHTTP2StatKeeper(netconfig.New())
+	protocolMonitor := protocols.NewMonitor(protocols.DefaultClassifiers(), 10, 10)
+	dispatcher := http.NewPayloadDispatcher([]http.DataConsumer{http2Keeper, protocolMonitor}, 10, uint64(time.Minute))
+
+	clientFrames, serverFrames := newHTTP2Frames(t), newHTTP2Frames(t)
+	clientFrames.buf.WriteString(http2.ClientPreface)
+	clientFrames.headers(1, false, ":method", "POST", ":path", "/pkg.Service/Get", "content-type", "application/grpc")
+	serverFrames.headers(1, false, ":status", "200", "content-type", "application/grpc")
+	segments := []http.PayloadSegment{
+		grpcConn.segment(true, clientFrames.flush()),
+		// only the beginning of the request message is captured
+		grpcConn.segment(true, clientFrames.data(1, true, 1000).flush()),
+		grpcConn.segment(false, serverFrames.flush()),
+		grpcConn.segment(false, serverFrames.headers(1, true, "grpc-status", "5").flush()),
+
+		kafkaConn.segment(true, kafkaProduceRequest("orders", 10)),
+		kafkaConn.segment(true, kafkaProduceRequest("orders", 500)),
+
+		postgresConn.segment(true, postgresMessage('Q', "SELECT 1\x00")),
+		postgresConn.segment(false, bytes.Join([][]byte{
+			postgresMessage('T', "row description"),
+			postgresMessage('D', string(make([]byte, 300))),
+			postgresMessage('C', "SELECT 1\x00"),
+			postgresMessage('Z', "I"),
+		}, nil)),
+		postgresConn.segment(true, postgresMessage('Q', "SELECT 2\x00")),
+		postgresConn.segment(false, append(postgresMessage('C', "SELECT 1\x00"), postgresMessage('Z', "I")...)),
+
+		otherConn.segment(false, []byte("SSH-2.0-OpenSSH_8.9\r\n")),
+		otherConn.segment(true, []byte("SSH-2.0-OpenSSH_8.9\r\n")),
+	}
+
+	for i, seg := range segments {
+		ignored := dispatcher.Dispatch(seg)
+		// nobody is interested in connections which aren't classified
+		assert.Equal(t, seg.ServerPort == otherConn.serverPort && seg.FromClient, ignored, "segment %d", i)
+	}
+	dispatcher.Flush()
+
+	httpStats := http2Keeper.GetAndResetAllStats()
+	kafkaStats, postgresStats := protocolMonitor.GetAndResetAllStats()
+	in := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: []network.ConnectionStats{
+				grpcConn.stats(protocolMonitor.Protocol(grpcConn.client, grpcConn.server, grpcConn.clientPort, grpcConn.serverPort)),
+				kafkaConn.stats(protocolMonitor.Protocol(kafkaConn.client, kafkaConn.server, kafkaConn.clientPort, kafkaConn.serverPort)),
+				postgresConn.stats(protocolMonitor.Protocol(postgresConn.client, postgresConn.server, postgresConn.clientPort, postgresConn.serverPort)),
+				otherConn.stats(protocolMonitor.Protocol(otherConn.client, otherConn.server, otherConn.clientPort, otherConn.serverPort)),
+			},
+		},
+		HTTP:     httpStats,
+		Kafka:    kafkaStats,
+		Postgres: postgresStats,
+	}
+
+	blob, err := GetMarshaler(ContentTypeProtobuf).Marshal(in)
+	require.NoError(t, err)
+
+	unmarshaler := GetUnmarshaler(ContentTypeProtobuf)
+	payload, err := unmarshaler.Unmarshal(blob)
+	require.NoError(t, err)
+	require.Len(t, payload.Conns, 4)
+	assert.NotNil(t, payload.Conns[0].HttpAggregations)
+
+	// the gRPC status codes, the protocols and their stats are only exposed by the debug endpoints
+	summaries := debugging.HTTP(httpStats, nil)
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "/pkg.Service/Get", summaries[0].Path)
+	assert.Equal(t, map[int]int{5: 1}, summaries[0].GRPCStatuses)
+
+	protocolSummary := protocoldebugging.Protocols(in.Conns, in.Kafka, in.Postgres)
+	assert.Equal(t, []protocoldebugging.ConnectionSummary{
+		{Source: protocoldebugging.Address{IP: "10.0.0.1", Port: 40000}, Dest: protocoldebugging.Address{IP: "10.0.0.2", Port: 50051}, Protocol: "http2"},
+		{Source: protocoldebugging.Address{IP: "10.0.0.1", Port: 40001}, Dest: protocoldebugging.Address{IP: "10.0.0.3", Port: 9092}, Protocol: "kafka"},
+		{Source: protocoldebugging.Address{IP: "10.0.0.1", Port: 40002}, Dest: protocoldebugging.Address{IP: "10.0.0.4", Port: 5432}, Protocol: "postgres"},
+	}, protocolSummary.Connections)
+	assert.Equal(t, []protocoldebugging.KafkaSummary{
+		{
+			Client:  protocoldebugging.Address{IP: "10.0.0.1", Port: 40001},
+			Server:  protocoldebugging.Address{IP: "10.0.0.3", Port: 9092},
+			Topic:   "orders",
+			Produce: 2,
+		},
+	}, protocolSummary.Kafka)
+	require.Len(t, protocolSummary.Postgres, 1)
+	assert.Equal(t, "SELECT", protocolSummary.Postgres[0].QueryType)
+	// the completion of the first query wasn't captured
+	assert.Equal(t, 1, protocolSummary.Postgres[0].Count)
+	assert.NotZero(t, protocolSummary.Postgres[0].LatencyP50)
+}
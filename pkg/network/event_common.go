@@ -8,6 +8,9 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/dustin/go-humanize"
 )
@@ -116,6 +119,8 @@ type Connections struct {
 	ConnTelemetry               *ConnectionsTelemetry
 	CompilationTelemetryByAsset map[string]RuntimeCompilationTelemetry
 	HTTP                        map[http.Key]http.RequestStats
+	Kafka                       map[kafka.Key]kafka.RequestStats
+	Postgres                    map[postgres.Key]*postgres.RequestStats
 	DNSStats                    dns.StatsByKeyByNameByType
 }
 
@@ -194,6 +199,9 @@ type ConnectionStats struct {
 	Via              *Via
 
 	IsAssured bool
+
+	// Protocol is the application protocol classified from the payloads of the connection
+	Protocol protocols.ProtocolType
 }
 
 // Via has info about the routing decision for a flow
@@ -311,6 +319,10 @@ func ConnectionSummary(c *ConnectionStats, names map[util.Address][]string) stri
 		)
 	}
 
+	if c.Protocol != protocols.Unknown {
+		str += fmt.Sprintf("[%s] ", c.Protocol)
+	}
+
 	str += fmt.Sprintf("(%s) %s sent (+%s), %s received (+%s)",
 		c.Direction,
 		humanize.Bytes(c.MonotonicSentBytes), humanize.Bytes(c.LastSentBytes),
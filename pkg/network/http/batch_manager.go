@@ -16,7 +16,10 @@ import (
 */
 import "C"
 
-var errLostBatch = errors.New("http batch lost (not consumed fast enough)")
+var (
+	errLostBatch        = errors.New("http batch lost (not consumed fast enough)")
+	errLostPayloadBatch = errors.New("payload batch lost (not consumed fast enough)")
+)
 
 const maxLookupsPerCPU = 2
 
@@ -125,3 +128,103 @@ func (m *batchManager) GetPendingTransactions() []httpTX {
 
 	return transactions
 }
+
+// payloadBatchManager reads the payload fragments batched by the kernel the same way batchManager reads HTTP transactions
+type payloadBatchManager struct {
+	batchMap   *ebpf.Map
+	stateByCPU []usrBatchState
+	numCPUs    int
+}
+
+func newPayloadBatchManager(batchMap, batchStateMap, heapMap *ebpf.Map, numCPUs int) *payloadBatchManager {
+	batch := new(payloadBatch)
+	fragment := new(payloadFragment)
+	state := new(C.http_batch_state_t)
+	stateByCPU := make([]usrBatchState, numCPUs)
+
+	for i := 0; i < numCPUs; i++ {
+		// Initialize eBPF maps
+		batchStateMap.Put(unsafe.Pointer(&i), unsafe.Pointer(state))
+		heapMap.Put(unsafe.Pointer(&i), unsafe.Pointer(fragment))
+		for j := 0; j < PayloadBatchPages; j++ {
+			key := &httpBatchKey{cpu: C.uint(i), page_num: C.uint(j)}
+			batchMap.Put(unsafe.Pointer(key), unsafe.Pointer(batch))
+		}
+	}
+
+	return &payloadBatchManager{
+		batchMap:   batchMap,
+		stateByCPU: stateByCPU,
+		numCPUs:    numCPUs,
+	}
+}
+
+func (m *payloadBatchManager) GetFragmentsFrom(notification httpNotification) ([]payloadFragment, error) {
+	var (
+		state    = &m.stateByCPU[notification.cpu]
+		batch    = new(payloadBatch)
+		batchKey = &httpBatchKey{cpu: notification.cpu, page_num: C.uint(int(notification.batch_idx) % PayloadBatchPages)}
+	)
+
+	err := m.batchMap.Lookup(unsafe.Pointer(batchKey), unsafe.Pointer(batch))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving payload batch for cpu=%d", notification.cpu)
+	}
+
+	if int(batch.idx) < state.idx {
+		// This means this batch was processed via GetPendingFragments
+		return nil, nil
+	}
+
+	if batch.IsDirty(notification) {
+		// This means the batch was overridden before we a got chance to read it
+		return nil, errLostPayloadBatch
+	}
+
+	offset := state.pos
+	state.idx = int(notification.batch_idx) + 1
+	state.pos = 0
+
+	return batch.Fragments()[offset:], nil
+}
+
+func (m *payloadBatchManager) GetPendingFragments() []payloadFragment {
+	fragments := make([]payloadFragment, 0, PayloadBatchSize*PayloadBatchPages/2)
+	for i := 0; i < m.numCPUs; i++ {
+		for lookup := 0; lookup < maxLookupsPerCPU; lookup++ {
+			var (
+				usrState = &m.stateByCPU[i]
+				pageNum  = usrState.idx % PayloadBatchPages
+				batchKey = &httpBatchKey{cpu: C.uint(i), page_num: C.uint(pageNum)}
+				batch    = new(payloadBatch)
+			)
+
+			err := m.batchMap.Lookup(unsafe.Pointer(batchKey), unsafe.Pointer(batch))
+			if err != nil {
+				break
+			}
+
+			krnStateIDX := int(batch.idx)
+			krnStatePos := int(batch.pos)
+			if krnStateIDX != usrState.idx || krnStatePos <= usrState.pos {
+				break
+			}
+
+			all := batch.Fragments()
+			fragments = append(fragments, all[usrState.pos:krnStatePos]...)
+
+			if krnStatePos == PayloadBatchSize {
+				// The batch is full: move on to the next one, as done for HTTP transactions
+				usrState.idx++
+				usrState.pos = 0
+				continue
+			}
+
+			usrState.pos = krnStatePos
+			// Move on to the next CPU core
+			break
+		}
+	}
+
+	return fragments
+}
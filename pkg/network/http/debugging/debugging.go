@@ -15,6 +15,8 @@ type RequestSummary struct {
 	Path     string
 	Method   string
 	ByStatus map[int]Stats
+	// GRPCStatuses counts the gRPC status codes returned by the endpoint, if it serves gRPC calls
+	GRPCStatuses map[int]int
 }
 
 // Address represents represents a IP:Port
@@ -63,6 +65,13 @@ func HTTP(stats map[http.Key]http.RequestStats, dns map[util.Address][]string) [
 				FirstLatencySample: stat.FirstLatencySample,
 				LatencyP50:         getSketchQuantile(stat.Latencies, 0.5),
 			}
+
+			for grpcStatus, count := range stat.GRPCStatuses {
+				if debug.GRPCStatuses == nil {
+					debug.GRPCStatuses = make(map[int]int)
+				}
+				debug.GRPCStatuses[int(grpcStatus)] += count
+			}
 		}
 
 		all = append(all, debug)
@@ -49,6 +49,15 @@ func dumpMapsHandler(managerMap *manager.Map, manager *manager.Manager) string {
 			output.WriteString(spew.Sdump(key, value))
 		}
 
+	case payloadIgnoredMap: // maps/payload_ignored (BPF_MAP_TYPE_LRU_HASH), key ConnTuple, value C.__u8
+		output.WriteString("Map: '" + mapName + "', key: 'ConnTuple', value: 'C.__u8'\n")
+		iter := currentMap.Iterate()
+		var key ebpf.ConnTuple
+		var value uint8
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			output.WriteString(spew.Sdump(key, value))
+		}
+
 	case sslSockByCtxMap: // maps/ssl_sock_by_ctx (BPF_MAP_TYPE_HASH), key uintptr // C.void *, value C.ssl_sock_t
 		output.WriteString("Map: '" + mapName + "', key: 'uintptr // C.void *', value: 'C.ssl_sock_t'\n")
 		iter := currentMap.Iterate()
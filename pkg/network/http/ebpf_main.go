@@ -24,6 +24,12 @@ const (
 	httpBatchStateMap        = "http_batch_state"
 	httpNotificationsPerfMap = "http_notifications"
 
+	payloadBatchesMap           = "payload_batches"
+	payloadBatchStateMap        = "payload_batch_state"
+	payloadHeapMap              = "payload_heap"
+	payloadIgnoredMap           = "payload_ignored"
+	payloadNotificationsPerfMap = "payload_notifications"
+
 	// ELF section of the BPF_PROG_TYPE_SOCKET_FILTER program used
 	// to inspect plain HTTP traffic
 	httpSocketFilter = "socket/http_filter"
@@ -45,7 +51,8 @@ type ebpfProgram struct {
 	offsets     []manager.ConstantEditor
 	subprograms []subprogram
 
-	batchCompletionHandler *ddebpf.PerfHandler
+	batchCompletionHandler   *ddebpf.PerfHandler
+	payloadCompletionHandler *ddebpf.PerfHandler
 }
 
 type subprogram interface {
@@ -76,11 +83,16 @@ func newEBPFProgram(c *config.Config, offsets []manager.ConstantEditor, sockFD *
 	}
 
 	batchCompletionHandler := ddebpf.NewPerfHandler(batchNotificationsChanSize)
+	payloadCompletionHandler := ddebpf.NewPerfHandler(batchNotificationsChanSize)
 	mgr := &manager.Manager{
 		Maps: []*manager.Map{
 			{Name: httpInFlightMap},
 			{Name: httpBatchesMap},
 			{Name: httpBatchStateMap},
+			{Name: payloadBatchesMap},
+			{Name: payloadBatchStateMap},
+			{Name: payloadHeapMap},
+			{Name: payloadIgnoredMap},
 			{Name: sslSockByCtxMap},
 			{Name: "ssl_read_args"},
 			{Name: "bio_new_socket_args"},
@@ -96,6 +108,15 @@ func newEBPFProgram(c *config.Config, offsets []manager.ConstantEditor, sockFD *
 					LostHandler:        batchCompletionHandler.LostHandler,
 				},
 			},
+			{
+				Map: manager.Map{Name: payloadNotificationsPerfMap},
+				PerfMapOptions: manager.PerfMapOptions{
+					PerfRingBufferSize: 8 * os.Getpagesize(),
+					Watermark:          1,
+					DataHandler:        payloadCompletionHandler.DataHandler,
+					LostHandler:        payloadCompletionHandler.LostHandler,
+				},
+			},
 		},
 		Probes: []*manager.Probe{
 			{Section: httpSocketFilter},
@@ -105,12 +126,13 @@ func newEBPFProgram(c *config.Config, offsets []manager.ConstantEditor, sockFD *
 
 	openSSLProgram, _ := newOpenSSLProgram(c, sockFD)
 	program := &ebpfProgram{
-		Manager:                mgr,
-		bytecode:               bytecode,
-		cfg:                    c,
-		offsets:                offsets,
-		batchCompletionHandler: batchCompletionHandler,
-		subprograms:            []subprogram{openSSLProgram},
+		Manager:                  mgr,
+		bytecode:                 bytecode,
+		cfg:                      c,
+		offsets:                  offsets,
+		batchCompletionHandler:   batchCompletionHandler,
+		payloadCompletionHandler: payloadCompletionHandler,
+		subprograms:              []subprogram{openSSLProgram},
 	}
 
 	return program, nil
@@ -135,6 +157,11 @@ func (e *ebpfProgram) Init() error {
 				MaxEntries: uint32(e.cfg.MaxTrackedConnections),
 				EditorFlag: manager.EditMaxEntries,
 			},
+			payloadIgnoredMap: {
+				Type:       ebpf.LRUHash,
+				MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+				EditorFlag: manager.EditMaxEntries,
+			},
 		},
 		ActivatedProbes: []manager.ProbesSelector{
 			&manager.ProbeSelector{
@@ -179,6 +206,7 @@ func (e *ebpfProgram) Start() error {
 func (e *ebpfProgram) Close() error {
 	err := e.Manager.Stop(manager.CleanAll)
 	e.batchCompletionHandler.Stop()
+	e.payloadCompletionHandler.Stop()
 	for _, s := range e.subprograms {
 		s.Stop()
 	}
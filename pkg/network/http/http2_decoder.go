@@ -0,0 +1,586 @@
+package http
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"golang.org/x/net/http2/hpack"
+)
+
+// http2ClientPreface is sent by the client at the beginning of every HTTP/2 connection
+var http2ClientPreface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+const (
+	http2FrameHeaderLength = 9
+	// http2MaxFrameLength bounds the frames we're willing to buffer. Peers can advertise
+	// frames up to 2^24-1 bytes but the default maximum size is 2^14 bytes.
+	http2MaxFrameLength = 1 << 20
+	// http2MaxStreams bounds the number of in-flight streams tracked per connection
+	http2MaxStreams = 1024
+	// http2HeaderTableSize is the default size of the HPACK dynamic table
+	http2HeaderTableSize = 4096
+
+	http2FrameData         = 0x0
+	http2FrameHeaders      = 0x1
+	http2FramePriority     = 0x2
+	http2FrameRSTStream    = 0x3
+	http2FrameSettings     = 0x4
+	http2FramePing         = 0x6
+	http2FrameGoAway       = 0x7
+	http2FrameWindowUpdate = 0x8
+	http2FrameContinuation = 0x9
+
+	http2FlagEndStream  = 0x1
+	http2FlagAck        = 0x1
+	http2FlagEndHeaders = 0x4
+	http2FlagPadded     = 0x8
+	http2FlagPriority   = 0x20
+)
+
+var (
+	errHTTP2FrameTooLarge  = errors.New("http2 frame exceeds maximum length")
+	errHTTP2InvalidPadding = errors.New("http2 frame has invalid padding")
+	errHTTP2Continuation   = errors.New("http2 continuation frame without headers")
+)
+
+// HTTP2Transaction is a request/response exchange decoded from an HTTP/2 stream
+type HTTP2Transaction struct {
+	Key              Key
+	StatusCode       int
+	GRPC             bool
+	GRPCStatus       GRPCStatus
+	RequestStarted   uint64
+	ResponseLastSeen uint64
+}
+
+// StatusClass returns an integer representing the status code class
+// Example: a 404 would return 400
+func (tx *HTTP2Transaction) StatusClass() int {
+	return (tx.StatusCode / 100) * 100
+}
+
+// RequestLatency returns the latency of the request in nanoseconds
+func (tx *HTTP2Transaction) RequestLatency() float64 {
+	return nsTimestampToFloat(tx.ResponseLastSeen - tx.RequestStarted)
+}
+
+type http2Stream struct {
+	method         Method
+	path           string
+	grpc           bool
+	requestStarted uint64
+
+	statusCode    int
+	grpcStatus    GRPCStatus
+	hasGRPCStatus bool
+}
+
+// http2Direction holds the decoding state of one side of an HTTP/2 connection.
+// HPACK dynamic tables are maintained per direction, so every header block
+// has to be decoded in order, even for streams that aren't tracked.
+//
+// Only the beginning of each TCP segment is captured, so the bytes of a frame which
+// weren't captured are skipped using the length of the frame. When the frame boundaries
+// themselves are lost, the frames are ignored until a captured buffer starts with a frame.
+type http2Direction struct {
+	pending []byte
+	// discard is the number of bytes of the current frame not seen yet which aren't needed:
+	// DATA frames payloads aren't decoded and the end of truncated frames was lost.
+	discard int
+	// desynced is set once the frame boundaries were lost
+	desynced bool
+	// lastSeen is the timestamp of the last buffer captured
+	lastSeen uint64
+
+	hpack  *hpack.Decoder
+	fields []hpack.HeaderField
+
+	headerBlock  []byte
+	headerStream uint32
+	headerFlags  byte
+	inHeaders    bool
+	// headerTruncated is set once a part of the header block being received was lost
+	headerTruncated bool
+
+	broken bool
+}
+
+func newHTTP2Direction() *http2Direction {
+	dir := &http2Direction{}
+	dir.resetHPACK()
+	return dir
+}
+
+// resetHPACK starts over with an empty HPACK dynamic table, once entries may have been inserted
+// by header blocks which weren't fully decoded. The entries inserted from then on are the most
+// recent ones of the table of the peer, so they are still referenced by the same indexes, and
+// the references to older entries fail to be decoded instead of returning wrong values.
+func (dir *http2Direction) resetHPACK() {
+	dir.hpack = hpack.NewDecoder(http2HeaderTableSize, func(field hpack.HeaderField) {
+		dir.fields = append(dir.fields, field)
+	})
+}
+
+// HTTP2Decoder decodes the frames of a single HTTP/2 connection into transactions.
+// It is fed with the buffers captured on each direction of the connection, in order,
+// along with the timestamp (in nanoseconds) at which they were captured.
+type HTTP2Decoder struct {
+	key Key
+
+	client *http2Direction
+	server *http2Direction
+
+	prefaceChecked bool
+	streams        map[uint32]*http2Stream
+	completed      []HTTP2Transaction
+	dropped        int
+}
+
+// NewHTTP2Decoder returns a decoder for the connection between the given client and server
+func NewHTTP2Decoder(clientAddr, serverAddr util.Address, clientPort, serverPort uint16) *HTTP2Decoder {
+	return &HTTP2Decoder{
+		key:     NewKey(clientAddr, serverAddr, clientPort, serverPort, "", MethodUnknown),
+		client:  newHTTP2Direction(),
+		server:  newHTTP2Direction(),
+		streams: make(map[uint32]*http2Stream),
+	}
+}
+
+// OnClientData processes bytes sent by the client to the server
+func (d *HTTP2Decoder) OnClientData(data []byte, ts uint64) error {
+	if !d.prefaceChecked {
+		d.client.pending = append(d.client.pending, data...)
+		if len(d.client.pending) < len(http2ClientPreface) && bytes.HasPrefix(http2ClientPreface, d.client.pending) {
+			return nil
+		}
+
+		// the capture can start in the middle of a connection, in which case there is no preface
+		d.client.pending = bytes.TrimPrefix(d.client.pending, http2ClientPreface)
+		d.prefaceChecked = true
+		data = nil
+	}
+	return d.decode(d.client, data, ts, true)
+}
+
+// OnServerData processes bytes sent by the server to the client
+func (d *HTTP2Decoder) OnServerData(data []byte, ts uint64) error {
+	return d.decode(d.server, data, ts, false)
+}
+
+// OnClientDataLost accounts for n bytes sent by the client which weren't captured
+func (d *HTTP2Decoder) OnClientDataLost(n int) {
+	if !d.prefaceChecked {
+		d.prefaceChecked = true
+		d.desync(d.client, true)
+		return
+	}
+	d.lose(d.client, n, true)
+}
+
+// OnServerDataLost accounts for n bytes sent by the server which weren't captured
+func (d *HTTP2Decoder) OnServerDataLost(n int) {
+	d.lose(d.server, n, false)
+}
+
+// Broken returns whether the decoder stopped decoding the connection
+func (d *HTTP2Decoder) Broken() bool {
+	return d.client.broken || d.server.broken
+}
+
+// Transactions returns the transactions completed since the last call
+func (d *HTTP2Decoder) Transactions() []HTTP2Transaction {
+	completed := d.completed
+	d.completed = nil
+	return completed
+}
+
+// Dropped returns the number of streams that were dropped since the decoder was created
+func (d *HTTP2Decoder) Dropped() int {
+	return d.dropped
+}
+
+func (d *HTTP2Decoder) decode(dir *http2Direction, data []byte, ts uint64, fromClient bool) error {
+	if dir.broken {
+		return nil
+	}
+	dir.lastSeen = ts
+
+	if dir.desynced {
+		// the frames are decoded again from the first buffer starting with a frame
+		if !isHTTP2FrameHeader(data) {
+			return nil
+		}
+		dir.desynced = false
+	}
+
+	if dir.discard > 0 {
+		n := dir.discard
+		if n > len(data) {
+			n = len(data)
+		}
+		dir.discard -= n
+		data = data[n:]
+	}
+	dir.pending = append(dir.pending, data...)
+
+	for len(dir.pending) >= http2FrameHeaderLength {
+		length, frameType, flags, streamID := parseHTTP2FrameHeader(dir.pending)
+		if length > http2MaxFrameLength {
+			d.fail(dir)
+			return errHTTP2FrameTooLarge
+		}
+
+		if frameType == http2FrameData && len(dir.pending) < http2FrameHeaderLength+length {
+			// the payload of DATA frames isn't needed, so the frame is handled without waiting for it
+			if err := d.handleFrame(dir, frameType, flags, streamID, nil, 0, fromClient); err != nil {
+				d.fail(dir)
+				return err
+			}
+			dir.discard = http2FrameHeaderLength + length - len(dir.pending)
+			dir.pending = nil
+			break
+		}
+		if len(dir.pending) < http2FrameHeaderLength+length {
+			break
+		}
+
+		payload := dir.pending[http2FrameHeaderLength : http2FrameHeaderLength+length]
+
+		if err := d.handleFrame(dir, frameType, flags, streamID, payload, length, fromClient); err != nil {
+			d.fail(dir)
+			return err
+		}
+
+		dir.pending = dir.pending[http2FrameHeaderLength+length:]
+	}
+
+	// avoid holding on to large backing arrays between captures
+	if len(dir.pending) == 0 {
+		dir.pending = nil
+	}
+
+	return nil
+}
+
+// lose skips n bytes which weren't captured. The bytes lost within the frame being received are
+// skipped using its length, the captured beginning of the frame being handled as a truncated frame.
+// Losing the header of a frame loses the frame boundaries, which are recovered by desync.
+func (d *HTTP2Decoder) lose(dir *http2Direction, n int, fromClient bool) {
+	if dir.broken || dir.desynced {
+		return
+	}
+
+	if len(dir.pending) >= http2FrameHeaderLength {
+		length, frameType, flags, streamID := parseHTTP2FrameHeader(dir.pending)
+		payload := dir.pending[http2FrameHeaderLength:]
+		dir.discard = http2FrameHeaderLength + length - len(dir.pending)
+		dir.pending = nil
+
+		if err := d.handleFrame(dir, frameType, flags, streamID, payload, length, fromClient); err != nil {
+			d.fail(dir)
+			return
+		}
+	}
+
+	if len(dir.pending) > 0 || n > dir.discard {
+		d.desync(dir, fromClient)
+		return
+	}
+	dir.discard -= n
+}
+
+// desync stops decoding a direction whose frame boundaries were lost, until a captured buffer starts
+// with a frame. The HPACK dynamic table is reset as the lost header blocks may have updated it, and the
+// streams which may have ended with the lost bytes sent by the server are dropped.
+func (d *HTTP2Decoder) desync(dir *http2Direction, fromClient bool) {
+	dir.desynced = true
+	dir.pending = nil
+	dir.discard = 0
+	dir.inHeaders = false
+	dir.headerTruncated = false
+	dir.headerBlock = nil
+	dir.resetHPACK()
+
+	if !fromClient {
+		d.dropped += len(d.streams)
+		d.streams = make(map[uint32]*http2Stream)
+	}
+}
+
+// fail stops decoding a direction once it doesn't follow the HTTP/2 framing
+func (d *HTTP2Decoder) fail(dir *http2Direction) {
+	dir.broken = true
+	dir.pending = nil
+	dir.discard = 0
+	d.dropped += len(d.streams)
+	d.streams = make(map[uint32]*http2Stream)
+}
+
+// handleFrame handles a frame of the given length, of which payload holds the beginning when the end of the frame was lost
+func (d *HTTP2Decoder) handleFrame(dir *http2Direction, frameType, flags byte, streamID uint32, payload []byte, length int, fromClient bool) error {
+	if dir.inHeaders && frameType != http2FrameContinuation {
+		return errHTTP2Continuation
+	}
+
+	truncated := len(payload) < length
+
+	switch frameType {
+	case http2FrameHeaders:
+		fragment, err := headersFragment(flags, payload, length)
+		if err != nil {
+			return err
+		}
+
+		dir.headerBlock = append(dir.headerBlock[:0], fragment...)
+		dir.headerStream = streamID
+		dir.headerFlags = flags
+		dir.headerTruncated = false
+		return d.handleHeaderBlockFragment(dir, flags, truncated, fromClient)
+	case http2FrameContinuation:
+		if !dir.inHeaders || streamID != dir.headerStream {
+			return errHTTP2Continuation
+		}
+
+		if !dir.headerTruncated {
+			dir.headerBlock = append(dir.headerBlock, payload...)
+		}
+		return d.handleHeaderBlockFragment(dir, flags, truncated, fromClient)
+	case http2FrameData:
+		if flags&http2FlagEndStream != 0 {
+			d.endStream(streamID, dir.lastSeen, fromClient)
+		}
+	case http2FrameRSTStream:
+		if _, ok := d.streams[streamID]; ok {
+			delete(d.streams, streamID)
+			d.dropped++
+		}
+	}
+
+	return nil
+}
+
+// handleHeaderBlockFragment decodes the header block once complete. When a part of it was lost, the
+// fields of its captured beginning are decoded right away, and the rest of the block is skipped.
+func (d *HTTP2Decoder) handleHeaderBlockFragment(dir *http2Direction, flags byte, truncated bool, fromClient bool) error {
+	if truncated && !dir.headerTruncated {
+		d.handleHeaderBlock(dir, false, fromClient)
+		dir.headerTruncated = true
+	}
+
+	if flags&http2FlagEndHeaders == 0 {
+		dir.inHeaders = true
+		return nil
+	}
+	dir.inHeaders = false
+
+	if !dir.headerTruncated {
+		d.handleHeaderBlock(dir, true, fromClient)
+	}
+	dir.headerTruncated = false
+
+	if dir.headerFlags&http2FlagEndStream != 0 {
+		d.endStream(dir.headerStream, dir.lastSeen, fromClient)
+	}
+	return nil
+}
+
+// headersFragment strips the padding and priority fields of a HEADERS frame payload
+// of the given length, of which payload may only hold the beginning
+func headersFragment(flags byte, payload []byte, length int) ([]byte, error) {
+	offset, padding := 0, 0
+	if flags&http2FlagPadded != 0 {
+		if length < 1 {
+			return nil, errHTTP2InvalidPadding
+		}
+		if len(payload) > 0 {
+			padding = int(payload[0])
+		}
+		offset++
+	}
+
+	if flags&http2FlagPriority != 0 {
+		offset += 5
+	}
+
+	if offset+padding > length {
+		return nil, errHTTP2InvalidPadding
+	}
+
+	end := length - padding
+	if end > len(payload) {
+		end = len(payload)
+	}
+	if offset >= end {
+		return nil, nil
+	}
+	return payload[offset:end], nil
+}
+
+// handleHeaderBlock decodes the header block received, complete or not. The HPACK dynamic table
+// is reset when the block can't be fully decoded, the fields decoded until then being used anyway.
+func (d *HTTP2Decoder) handleHeaderBlock(dir *http2Direction, complete bool, fromClient bool) {
+	dir.fields = dir.fields[:0]
+	_, err := dir.hpack.Write(dir.headerBlock)
+	if err == nil && complete {
+		err = dir.hpack.Close()
+	}
+	if err != nil || !complete {
+		dir.resetHPACK()
+	}
+
+	if fromClient {
+		d.handleRequestHeaders(dir.headerStream, dir.fields, dir.lastSeen)
+	} else {
+		d.handleResponseHeaders(dir.headerStream, dir.fields)
+	}
+}
+
+func (d *HTTP2Decoder) handleRequestHeaders(streamID uint32, fields []hpack.HeaderField, ts uint64) {
+	stream, ok := d.streams[streamID]
+	if !ok {
+		if len(d.streams) >= http2MaxStreams {
+			d.dropped++
+			return
+		}
+		stream = &http2Stream{requestStarted: ts}
+		d.streams[streamID] = stream
+	}
+
+	for _, field := range fields {
+		switch field.Name {
+		case ":method":
+			stream.method = methodFromString(field.Value)
+		case ":path":
+			stream.path = requestPath(field.Value)
+		case "content-type":
+			stream.grpc = strings.HasPrefix(field.Value, "application/grpc")
+		}
+	}
+}
+
+func (d *HTTP2Decoder) handleResponseHeaders(streamID uint32, fields []hpack.HeaderField) {
+	stream, ok := d.streams[streamID]
+	if !ok {
+		return
+	}
+
+	for _, field := range fields {
+		switch field.Name {
+		case ":status":
+			// informational responses are followed by the final response headers
+			if code, err := strconv.Atoi(field.Value); err == nil && code >= 200 {
+				stream.statusCode = code
+			}
+		case "grpc-status":
+			if code, err := strconv.ParseUint(field.Value, 10, 8); err == nil {
+				stream.grpcStatus = GRPCStatus(code)
+				stream.hasGRPCStatus = true
+			}
+		}
+	}
+}
+
+// endStream completes a transaction once the server closed its side of the stream
+func (d *HTTP2Decoder) endStream(streamID uint32, ts uint64, fromClient bool) {
+	if fromClient {
+		return
+	}
+
+	stream, ok := d.streams[streamID]
+	if !ok {
+		return
+	}
+	delete(d.streams, streamID)
+
+	if stream.statusCode == 0 || stream.path == "" {
+		d.dropped++
+		return
+	}
+
+	key := d.key
+	key.Path = stream.path
+	key.Method = stream.method
+
+	d.completed = append(d.completed, HTTP2Transaction{
+		Key:              key,
+		StatusCode:       stream.statusCode,
+		GRPC:             stream.grpc && stream.hasGRPCStatus,
+		GRPCStatus:       stream.grpcStatus,
+		RequestStarted:   stream.requestStarted,
+		ResponseLastSeen: ts,
+	})
+}
+
+// parseHTTP2FrameHeader returns the length, type, flags and stream of the frame starting the given buffer
+func parseHTTP2FrameHeader(b []byte) (int, byte, byte, uint32) {
+	length := int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	return length, b[3], b[4], binary.BigEndian.Uint32(b[5:9]) & 0x7fffffff
+}
+
+// isHTTP2FrameHeader returns whether a buffer starts with a frame header which is valid for its type.
+// It's used to find the frame boundaries again, so the frames which can't be decoded from there, like
+// CONTINUATION frames or PUSH_PROMISE frames which aren't supported, aren't accepted.
+func isHTTP2FrameHeader(b []byte) bool {
+	if len(b) < http2FrameHeaderLength || b[5]&0x80 != 0 {
+		return false
+	}
+
+	length, frameType, flags, streamID := parseHTTP2FrameHeader(b)
+	if length > http2MaxFrameLength {
+		return false
+	}
+
+	switch frameType {
+	case http2FrameData:
+		return streamID != 0 && flags&^(http2FlagEndStream|http2FlagPadded) == 0
+	case http2FrameHeaders:
+		return streamID != 0 && flags&^(http2FlagEndStream|http2FlagEndHeaders|http2FlagPadded|http2FlagPriority) == 0
+	case http2FramePriority:
+		return streamID != 0 && length == 5 && flags == 0
+	case http2FrameRSTStream:
+		return streamID != 0 && length == 4 && flags == 0
+	case http2FrameSettings:
+		return streamID == 0 && length%6 == 0 && flags&^http2FlagAck == 0
+	case http2FramePing:
+		return streamID == 0 && length == 8 && flags&^http2FlagAck == 0
+	case http2FrameGoAway:
+		return streamID == 0 && length >= 8 && flags == 0
+	case http2FrameWindowUpdate:
+		return length == 4 && flags == 0
+	default:
+		return false
+	}
+}
+
+// requestPath returns the path of a request with its query string excluded
+func requestPath(path string) string {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+func methodFromString(method string) Method {
+	switch method {
+	case "GET":
+		return MethodGet
+	case "POST":
+		return MethodPost
+	case "PUT":
+		return MethodPut
+	case "DELETE":
+		return MethodDelete
+	case "HEAD":
+		return MethodHead
+	case "OPTIONS":
+		return MethodOptions
+	case "PATCH":
+		return MethodPatch
+	default:
+		return MethodUnknown
+	}
+}
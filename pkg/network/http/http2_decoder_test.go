@@ -0,0 +1,442 @@
+package http
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+type http2Conn struct {
+	t *testing.T
+
+	clientBuf, serverBuf         bytes.Buffer
+	clientFramer, serverFramer   *http2.Framer
+	clientEncoder, serverEncoder *hpack.Encoder
+	clientBlock, serverBlock     bytes.Buffer
+}
+
+func newHTTP2Conn(t *testing.T) *http2Conn {
+	c := &http2Conn{t: t}
+	c.clientFramer = http2.NewFramer(&c.clientBuf, nil)
+	c.serverFramer = http2.NewFramer(&c.serverBuf, nil)
+	c.clientEncoder = hpack.NewEncoder(&c.clientBlock)
+	c.serverEncoder = hpack.NewEncoder(&c.serverBlock)
+	return c
+}
+
+func (c *http2Conn) headers(fromClient bool, streamID uint32, endStream bool, fields ...string) {
+	framer, encoder, block := c.serverFramer, c.serverEncoder, &c.serverBlock
+	if fromClient {
+		framer, encoder, block = c.clientFramer, c.clientEncoder, &c.clientBlock
+	}
+
+	block.Reset()
+	for i := 0; i < len(fields); i += 2 {
+		require.NoError(c.t, encoder.WriteField(hpack.HeaderField{Name: fields[i], Value: fields[i+1]}))
+	}
+
+	require.NoError(c.t, framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: block.Bytes(),
+		EndStream:     endStream,
+		EndHeaders:    true,
+	}))
+}
+
+func (c *http2Conn) data(fromClient bool, streamID uint32, endStream bool, payload string) {
+	framer := c.serverFramer
+	if fromClient {
+		framer = c.clientFramer
+	}
+	require.NoError(c.t, framer.WriteData(streamID, endStream, []byte(payload)))
+}
+
+// flush returns the bytes written on each side of the connection since the last call
+func (c *http2Conn) flush() (client []byte, server []byte) {
+	client = append([]byte(nil), c.clientBuf.Bytes()...)
+	server = append([]byte(nil), c.serverBuf.Bytes()...)
+	c.clientBuf.Reset()
+	c.serverBuf.Reset()
+	return
+}
+
+func newTestHTTP2Decoder() *HTTP2Decoder {
+	return NewHTTP2Decoder(util.AddressFromString("1.1.1.1"), util.AddressFromString("2.2.2.2"), 1234, 50051)
+}
+
+func TestHTTP2DecoderGRPC(t *testing.T) {
+	conn := newHTTP2Conn(t)
+	decoder := newTestHTTP2Decoder()
+
+	conn.clientBuf.Write(http2ClientPreface)
+	conn.headers(true, 1, false,
+		":method", "POST",
+		":path", "/helloworld.Greeter/SayHello",
+		"content-type", "application/grpc+proto",
+	)
+	conn.data(true, 1, true, "request")
+	conn.headers(false, 1, false, ":status", "200", "content-type", "application/grpc")
+	conn.data(false, 1, false, "response")
+	conn.headers(false, 1, true, "grpc-status", "5", "grpc-message", "not found")
+
+	client, server := conn.flush()
+	require.NoError(t, decoder.OnClientData(client, 1000))
+	require.NoError(t, decoder.OnServerData(server, 3000))
+
+	txs := decoder.Transactions()
+	require.Len(t, txs, 1)
+	assert.Equal(t, "/helloworld.Greeter/SayHello", txs[0].Key.Path)
+	assert.Equal(t, MethodPost, txs[0].Key.Method)
+	assert.Equal(t, uint16(50051), txs[0].Key.DstPort)
+	assert.Equal(t, 200, txs[0].StatusCode)
+	assert.True(t, txs[0].GRPC)
+	assert.Equal(t, GRPCStatus(5), txs[0].GRPCStatus)
+	assert.Equal(t, float64(2000), txs[0].RequestLatency())
+	assert.Empty(t, decoder.Transactions())
+}
+
+func TestHTTP2DecoderPartialFrames(t *testing.T) {
+	conn := newHTTP2Conn(t)
+	decoder := newTestHTTP2Decoder()
+
+	conn.clientBuf.Write(http2ClientPreface)
+	conn.headers(true, 1, true, ":method", "GET", ":path", "/foo?bar=baz")
+	conn.headers(true, 3, true, ":method", "DELETE", ":path", "/foo")
+	conn.headers(false, 3, true, ":status", "404")
+	conn.headers(false, 1, true, ":status", "200")
+
+	// feed the captured buffers one byte at a time to exercise frame reassembly
+	client, server := conn.flush()
+	for _, b := range client {
+		require.NoError(t, decoder.OnClientData([]byte{b}, 10))
+	}
+	for _, b := range server {
+		require.NoError(t, decoder.OnServerData([]byte{b}, 20))
+	}
+
+	txs := decoder.Transactions()
+	require.Len(t, txs, 2)
+	assert.Equal(t, "/foo", txs[0].Key.Path)
+	assert.Equal(t, MethodDelete, txs[0].Key.Method)
+	assert.Equal(t, 400, txs[0].StatusClass())
+	assert.Equal(t, "/foo", txs[1].Key.Path)
+	assert.Equal(t, MethodGet, txs[1].Key.Method)
+	assert.Equal(t, 200, txs[1].StatusClass())
+	assert.False(t, txs[1].GRPC)
+}
+
+func TestHTTP2DecoderWithoutPreface(t *testing.T) {
+	conn := newHTTP2Conn(t)
+	decoder := newTestHTTP2Decoder()
+
+	// the first request uses the dynamic table, so later requests can only be decoded
+	// if all the header blocks of the connection have been decoded in order
+	conn.headers(true, 1, true, ":method", "GET", ":path", "/a", "user-agent", "test")
+	conn.headers(true, 3, true, ":method", "GET", ":path", "/b", "user-agent", "test")
+	conn.headers(false, 1, true, ":status", "200", "server", "test")
+	conn.headers(false, 3, true, ":status", "500", "server", "test")
+
+	client, server := conn.flush()
+	require.NoError(t, decoder.OnClientData(client, 1))
+	require.NoError(t, decoder.OnServerData(server, 2))
+
+	txs := decoder.Transactions()
+	require.Len(t, txs, 2)
+	assert.Equal(t, "/a", txs[0].Key.Path)
+	assert.Equal(t, "/b", txs[1].Key.Path)
+	assert.Equal(t, 500, txs[1].StatusCode)
+}
+
+func TestHTTP2DecoderResetStream(t *testing.T) {
+	conn := newHTTP2Conn(t)
+	decoder := newTestHTTP2Decoder()
+
+	conn.headers(true, 1, true, ":method", "GET", ":path", "/a")
+	require.NoError(t, conn.clientFramer.WriteRSTStream(1, http2.ErrCodeCancel))
+	conn.headers(false, 1, true, ":status", "200")
+
+	client, server := conn.flush()
+	require.NoError(t, decoder.OnClientData(client, 1))
+	require.NoError(t, decoder.OnServerData(server, 2))
+
+	assert.Empty(t, decoder.Transactions())
+	assert.Equal(t, 1, decoder.Dropped())
+}
+
+func TestHTTP2DecoderFrameTooLarge(t *testing.T) {
+	decoder := newTestHTTP2Decoder()
+
+	frame := []byte{0xff, 0xff, 0xff, http2FrameData, 0, 0, 0, 0, 1}
+	assert.Equal(t, errHTTP2FrameTooLarge, decoder.OnServerData(frame, 1))
+
+	// the direction is no longer decoded once its state is lost
+	assert.NoError(t, decoder.OnServerData(frame, 1))
+}
+
+func TestHTTP2StatKeeper(t *testing.T) {
+	cfg := config.New()
+	cfg.HTTPReplaceRules = []*config.ReplaceRule{
+		{
+			Re:   regexp.MustCompile("/users/.*"),
+			Repl: "/users/?",
+		},
+	}
+	keeper := NewHTTP2StatKeeper(cfg)
+
+	clientAddr, serverAddr := util.AddressFromString("1.1.1.1"), util.AddressFromString("2.2.2.2")
+	conn := newHTTP2Conn(t)
+	conn.clientBuf.Write(http2ClientPreface)
+	conn.headers(true, 1, true, ":method", "GET", ":path", "/users/1", "content-type", "application/grpc")
+	conn.headers(true, 3, true, ":method", "GET", ":path", "/users/2", "content-type", "application/grpc")
+	conn.headers(false, 1, true, ":status", "200", "grpc-status", "0")
+	conn.headers(false, 3, true, ":status", "200", "grpc-status", "14")
+
+	client, server := conn.flush()
+	assert.True(t, keeper.OnData(clientAddr, serverAddr, 1234, 50051, true, client, 1))
+	assert.True(t, keeper.OnData(clientAddr, serverAddr, 1234, 50051, false, server, 2))
+
+	stats := keeper.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+
+	key := NewKey(clientAddr, serverAddr, 1234, 50051, "/users/?", MethodGet)
+	require.Contains(t, stats, key)
+	assert.Equal(t, 2, stats[key][1].Count)
+	assert.Equal(t, map[GRPCStatus]int{0: 1, 14: 1}, stats[key][1].GRPCStatuses)
+
+	assert.Empty(t, keeper.GetAndResetAllStats())
+
+	keeper.CloseConnection(clientAddr, serverAddr, 1234, 50051)
+	assert.Empty(t, keeper.decoders)
+}
+
+func TestHTTP2StatKeeperNotHTTP2(t *testing.T) {
+	keeper := NewHTTP2StatKeeper(config.New())
+	clientAddr, serverAddr := util.AddressFromString("1.1.1.1"), util.AddressFromString("2.2.2.2")
+
+	// server data is ignored until the client speaks
+	assert.True(t, keeper.OnData(clientAddr, serverAddr, 1234, 80, false, []byte("220 ready\r\n"), 1))
+	assert.False(t, keeper.OnData(clientAddr, serverAddr, 1234, 80, true, []byte("GET / HTTP/1.1\r\n"), 2))
+	assert.Empty(t, keeper.decoders)
+
+	// the beginning of the connection wasn't captured
+	assert.False(t, keeper.OnDataLost(clientAddr, serverAddr, 1234, 80, true, 10))
+}
+
+func TestHTTP2StatKeeperBrokenConnection(t *testing.T) {
+	keeper := NewHTTP2StatKeeper(config.New())
+	clientAddr, serverAddr := util.AddressFromString("1.1.1.1"), util.AddressFromString("2.2.2.2")
+
+	conn := newHTTP2Conn(t)
+	conn.clientBuf.Write(http2ClientPreface)
+	conn.headers(true, 1, false, ":method", "POST", ":path", "/upload")
+	client, _ := conn.flush()
+	assert.True(t, keeper.OnData(clientAddr, serverAddr, 1234, 80, true, client, 1))
+
+	// losing bytes doesn't stop the decoding
+	assert.True(t, keeper.OnDataLost(clientAddr, serverAddr, 1234, 80, true, 10))
+
+	// a frame which isn't valid HTTP/2 does
+	frame := []byte{0xff, 0xff, 0xff, http2FrameData, 0, 0, 0, 0, 1}
+	assert.False(t, keeper.OnData(clientAddr, serverAddr, 1234, 80, false, frame, 2))
+	assert.Empty(t, keeper.decoders)
+}
+
+func TestHTTP2DecoderDataLost(t *testing.T) {
+	conn := newHTTP2Conn(t)
+	decoder := newTestHTTP2Decoder()
+
+	conn.clientBuf.Write(http2ClientPreface)
+	conn.headers(true, 1, false, ":method", "POST", ":path", "/upload")
+	conn.data(true, 1, true, "0123456789")
+	conn.headers(false, 1, true, ":status", "200")
+
+	// only the beginning of the DATA frame is captured
+	client, server := conn.flush()
+	require.NoError(t, decoder.OnClientData(client[:len(client)-6], 1))
+	decoder.OnClientDataLost(6)
+	require.NoError(t, decoder.OnServerData(server, 2))
+
+	assert.False(t, decoder.Broken())
+	txs := decoder.Transactions()
+	require.Len(t, txs, 1)
+	assert.Equal(t, "/upload", txs[0].Key.Path)
+	assert.Equal(t, 200, txs[0].StatusCode)
+
+	// more bytes than the remaining of the DATA frame are lost, the frame boundaries are lost with them
+	conn.data(true, 3, false, "0123456789")
+	client, _ = conn.flush()
+	require.NoError(t, decoder.OnClientData(client[:http2FrameHeaderLength], 3))
+	decoder.OnClientDataLost(11)
+	assert.False(t, decoder.Broken())
+	assert.True(t, decoder.client.desynced)
+
+	// the frames are decoded again from the next buffer starting with a frame
+	require.NoError(t, decoder.OnClientData([]byte("not a frame"), 4))
+	assert.True(t, decoder.client.desynced)
+
+	conn.headers(true, 5, true, ":method", "GET", ":path", "/download")
+	conn.headers(false, 5, true, ":status", "200")
+	client, server = conn.flush()
+	require.NoError(t, decoder.OnClientData(client, 5))
+	require.NoError(t, decoder.OnServerData(server, 6))
+	assert.False(t, decoder.client.desynced)
+
+	txs = decoder.Transactions()
+	require.Len(t, txs, 1)
+	assert.Equal(t, "/download", txs[0].Key.Path)
+}
+
+// capture feeds the decoder with the segments sent on a direction the way they're captured:
+// only their first payloadCaptureSize bytes are captured, the rest of them being lost
+func capture(t *testing.T, decoder *HTTP2Decoder, fromClient bool, ts uint64, segments ...[]byte) {
+	const payloadCaptureSize = 128
+
+	for _, segment := range segments {
+		captured, lost := segment, 0
+		if len(segment) > payloadCaptureSize {
+			captured, lost = segment[:payloadCaptureSize], len(segment)-payloadCaptureSize
+		}
+
+		if fromClient {
+			require.NoError(t, decoder.OnClientData(captured, ts))
+			if lost > 0 {
+				decoder.OnClientDataLost(lost)
+			}
+		} else {
+			require.NoError(t, decoder.OnServerData(captured, ts))
+			if lost > 0 {
+				decoder.OnServerDataLost(lost)
+			}
+		}
+	}
+}
+
+func TestHTTP2DecoderLargeFrames(t *testing.T) {
+	conn := newHTTP2Conn(t)
+	decoder := newTestHTTP2Decoder()
+	large := strings.Repeat("x", 1000)
+
+	// every frame is sent in its own segment, the DATA frames spanning several segments
+	conn.clientBuf.Write(http2ClientPreface)
+	conn.headers(true, 1, false, ":method", "POST", ":path", "/helloworld.Greeter/SayHello", "content-type", "application/grpc")
+	client1, _ := conn.flush()
+	conn.data(true, 1, true, large)
+	client2, _ := conn.flush()
+	capture(t, decoder, true, 1, client1, client2[:600], client2[600:])
+
+	conn.headers(false, 1, false, ":status", "200", "content-type", "application/grpc")
+	_, server1 := conn.flush()
+	conn.data(false, 1, false, large)
+	_, server2 := conn.flush()
+	conn.headers(false, 1, true, "grpc-status", "7")
+	_, server3 := conn.flush()
+	capture(t, decoder, false, 2, server1, server2, server3)
+
+	assert.False(t, decoder.client.desynced)
+	assert.False(t, decoder.server.desynced)
+	txs := decoder.Transactions()
+	require.Len(t, txs, 1)
+	assert.Equal(t, "/helloworld.Greeter/SayHello", txs[0].Key.Path)
+	assert.Equal(t, 200, txs[0].StatusCode)
+	assert.True(t, txs[0].GRPC)
+	assert.Equal(t, GRPCStatus(7), txs[0].GRPCStatus)
+
+	// the trailers are sent in the same segment as a large DATA frame, so their frame header is lost
+	conn.headers(true, 3, true, ":method", "POST", ":path", "/helloworld.Greeter/SayHello", "content-type", "application/grpc")
+	client, _ := conn.flush()
+	capture(t, decoder, true, 3, client)
+
+	conn.headers(false, 3, false, ":status", "200", "content-type", "application/grpc")
+	conn.data(false, 3, false, large)
+	conn.headers(false, 3, true, "grpc-status", "0")
+	_, server := conn.flush()
+	capture(t, decoder, false, 4, server)
+
+	assert.False(t, decoder.Broken())
+	assert.True(t, decoder.server.desynced)
+	assert.Empty(t, decoder.Transactions())
+	assert.Equal(t, 1, decoder.Dropped())
+
+	// the next response, starting its own segment, is decoded with the HPACK dynamic table reset
+	conn.headers(true, 5, true, ":method", "POST", ":path", "/helloworld.Greeter/SayGoodbye", "content-type", "application/grpc")
+	client, _ = conn.flush()
+	capture(t, decoder, true, 5, client)
+
+	conn.headers(false, 5, true, ":status", "503")
+	_, server = conn.flush()
+	capture(t, decoder, false, 6, server)
+
+	assert.False(t, decoder.server.desynced)
+	txs = decoder.Transactions()
+	require.Len(t, txs, 1)
+	assert.Equal(t, "/helloworld.Greeter/SayGoodbye", txs[0].Key.Path)
+	assert.Equal(t, 503, txs[0].StatusCode)
+
+	// the entries inserted since the reset are decoded, but not the ones inserted before the loss
+	conn.headers(true, 7, true, ":method", "POST", ":path", "/helloworld.Greeter/SayGoodbye", "content-type", "application/grpc")
+	client, _ = conn.flush()
+	capture(t, decoder, true, 7, client)
+
+	conn.headers(false, 7, true, ":status", "503", "grpc-status", "0")
+	_, server = conn.flush()
+	capture(t, decoder, false, 8, server)
+
+	txs = decoder.Transactions()
+	require.Len(t, txs, 1)
+	assert.Equal(t, 503, txs[0].StatusCode)
+}
+
+func TestHTTP2DecoderLargeHeaders(t *testing.T) {
+	conn := newHTTP2Conn(t)
+	decoder := newTestHTTP2Decoder()
+
+	// the end of the request header block isn't captured
+	conn.clientBuf.Write(http2ClientPreface)
+	conn.headers(true, 1, true, ":method", "GET", ":path", "/a", "x-large", strings.Repeat("x", 300))
+	client, _ := conn.flush()
+	capture(t, decoder, true, 1, client)
+	conn.headers(false, 1, true, ":status", "200")
+	_, server := conn.flush()
+	capture(t, decoder, false, 2, server)
+
+	// the fields of the captured beginning of the block are decoded, and the end of the stream is still seen
+	txs := decoder.Transactions()
+	require.Len(t, txs, 1)
+	assert.Equal(t, "/a", txs[0].Key.Path)
+	assert.Equal(t, MethodGet, txs[0].Key.Method)
+	assert.Equal(t, 200, txs[0].StatusCode)
+
+	// the fields inserted in the HPACK dynamic table after the truncated block are decoded
+	conn.headers(true, 3, true, ":method", "GET", ":path", "/b")
+	conn.headers(true, 5, true, ":method", "GET", ":path", "/b")
+	client, _ = conn.flush()
+	capture(t, decoder, true, 3, client)
+	conn.headers(false, 3, true, ":status", "200")
+	conn.headers(false, 5, true, ":status", "200")
+	_, server = conn.flush()
+	capture(t, decoder, false, 4, server)
+
+	assert.False(t, decoder.Broken())
+	txs = decoder.Transactions()
+	require.Len(t, txs, 2)
+	assert.Equal(t, "/b", txs[0].Key.Path)
+	assert.Equal(t, "/b", txs[1].Key.Path)
+}
+
+func TestIsHTTP2FrameHeader(t *testing.T) {
+	assert.True(t, isHTTP2FrameHeader([]byte{0, 0, 4, http2FrameData, http2FlagEndStream, 0, 0, 0, 1}))
+	assert.True(t, isHTTP2FrameHeader([]byte{0, 0, 0, http2FrameSettings, http2FlagAck, 0, 0, 0, 0}))
+	assert.False(t, isHTTP2FrameHeader([]byte{0, 0, 4, http2FrameData, 0, 0, 0, 0}))
+	assert.False(t, isHTTP2FrameHeader([]byte{0, 0, 4, http2FrameData, 0, 0, 0, 0, 0}))
+	assert.False(t, isHTTP2FrameHeader([]byte{0, 0, 4, http2FrameContinuation, 0, 0, 0, 0, 1}))
+	assert.False(t, isHTTP2FrameHeader([]byte{0, 0, 3, http2FrameRSTStream, 0, 0, 0, 0, 1}))
+	assert.False(t, isHTTP2FrameHeader([]byte("GET / HTTP/1.1\r\n")))
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// HTTP2StatKeeper aggregates the HTTP/2 (and gRPC) transactions decoded from the buffers
+// captured on each connection into RequestStats keyed like HTTP/1.x stats
+type HTTP2StatKeeper struct {
+	mux sync.Mutex
+
+	decoders   map[Key]*HTTP2Decoder
+	stats      map[Key]RequestStats
+	maxEntries int
+
+	// replace rules for HTTP path
+	replaceRules []*config.ReplaceRule
+
+	// map containing interned path strings
+	// this is rotated  with the stats map
+	interned map[string]string
+
+	dropped  int64
+	rejected int64
+}
+
+// NewHTTP2StatKeeper returns a new HTTP2StatKeeper
+func NewHTTP2StatKeeper(c *config.Config) *HTTP2StatKeeper {
+	return &HTTP2StatKeeper{
+		decoders:     make(map[Key]*HTTP2Decoder),
+		stats:        make(map[Key]RequestStats),
+		maxEntries:   c.MaxHTTPStatsBuffered,
+		replaceRules: c.HTTPReplaceRules,
+		interned:     make(map[string]string),
+	}
+}
+
+// OnData feeds a buffer captured on the connection between the given client and server.
+// fromClient indicates the direction of the buffer and ts is its capture timestamp in nanoseconds.
+// It returns false once the connection turned out not to be decodable as HTTP/2.
+func (h *HTTP2StatKeeper) OnData(clientAddr, serverAddr util.Address, clientPort, serverPort uint16, fromClient bool, data []byte, ts uint64) bool {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	connKey := NewKey(clientAddr, serverAddr, clientPort, serverPort, "", MethodUnknown)
+	decoder, ok := h.decoders[connKey]
+	if !ok {
+		if !fromClient {
+			// wait for the client preface
+			return true
+		}
+		// HPACK state can only be tracked from the beginning of connections, which start with the client preface
+		if !bytes.HasPrefix(data, http2ClientPreface) && !bytes.HasPrefix(http2ClientPreface, data) {
+			return false
+		}
+		if len(h.decoders) >= h.maxEntries {
+			h.dropped++
+			return false
+		}
+		decoder = NewHTTP2Decoder(clientAddr, serverAddr, clientPort, serverPort)
+		h.decoders[connKey] = decoder
+	}
+
+	var err error
+	if fromClient {
+		err = decoder.OnClientData(data, ts)
+	} else {
+		err = decoder.OnServerData(data, ts)
+	}
+	if err != nil {
+		log.Debugf("error decoding http2 traffic between %s:%d and %s:%d: %s", clientAddr, clientPort, serverAddr, serverPort, err)
+	}
+
+	return h.collect(connKey, decoder)
+}
+
+// OnDataLost accounts for n bytes sent on the connection which weren't captured.
+// It returns false once the connection can't be decoded anymore.
+func (h *HTTP2StatKeeper) OnDataLost(clientAddr, serverAddr util.Address, clientPort, serverPort uint16, fromClient bool, n int) bool {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	connKey := NewKey(clientAddr, serverAddr, clientPort, serverPort, "", MethodUnknown)
+	decoder, ok := h.decoders[connKey]
+	if !ok {
+		// the beginning of the connection, and hence its HPACK state, is lost
+		return !fromClient
+	}
+
+	if fromClient {
+		decoder.OnClientDataLost(n)
+	} else {
+		decoder.OnServerDataLost(n)
+	}
+	return h.collect(connKey, decoder)
+}
+
+// CloseConnection discards the decoding state of a connection
+func (h *HTTP2StatKeeper) CloseConnection(clientAddr, serverAddr util.Address, clientPort, serverPort uint16) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	connKey := NewKey(clientAddr, serverAddr, clientPort, serverPort, "", MethodUnknown)
+	if decoder, ok := h.decoders[connKey]; ok {
+		h.remove(connKey, decoder)
+	}
+}
+
+// collect aggregates the transactions completed by a decoder, which is removed once broken
+func (h *HTTP2StatKeeper) collect(connKey Key, decoder *HTTP2Decoder) bool {
+	for _, tx := range decoder.Transactions() {
+		h.add(tx)
+	}
+
+	if decoder.Broken() {
+		h.remove(connKey, decoder)
+		return false
+	}
+	return true
+}
+
+func (h *HTTP2StatKeeper) remove(connKey Key, decoder *HTTP2Decoder) {
+	h.dropped += int64(decoder.Dropped())
+	delete(h.decoders, connKey)
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call
+func (h *HTTP2StatKeeper) GetAndResetAllStats() map[Key]RequestStats {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if h.dropped > 0 || h.rejected > 0 {
+		log.Debugf("http2 stats summary: aggregations=%d decoders=%d dropped=%d rejected=%d", len(h.stats), len(h.decoders), h.dropped, h.rejected)
+	}
+
+	ret := h.stats // No deep copy needed since `h.stats` gets reset
+	h.stats = make(map[Key]RequestStats)
+	h.interned = make(map[string]string)
+	h.dropped, h.rejected = 0, 0
+	return ret
+}
+
+func (h *HTTP2StatKeeper) add(tx HTTP2Transaction) {
+	path, rejected := h.processPath(tx.Key.Path)
+	if rejected {
+		h.rejected++
+		return
+	}
+
+	key := tx.Key
+	key.Path = path
+	stats, ok := h.stats[key]
+	if !ok && len(h.stats) >= h.maxEntries {
+		h.dropped++
+		return
+	}
+
+	stats.AddRequest(tx.StatusClass(), tx.RequestLatency())
+	if tx.GRPC {
+		stats.AddGRPCStatus(tx.StatusClass(), tx.GRPCStatus)
+	}
+	h.stats[key] = stats
+}
+
+func (h *HTTP2StatKeeper) processPath(path string) (pathStr string, rejected bool) {
+	for _, r := range h.replaceRules {
+		if r.Re.MatchString(path) {
+			if r.Repl == "" {
+				// this is a "drop" rule
+				return "", true
+			}
+
+			path = r.Re.ReplaceAllString(path, r.Repl)
+		}
+	}
+
+	v, ok := h.interned[path]
+	if !ok {
+		v = path
+		h.interned[v] = v
+	}
+	return v, false
+}
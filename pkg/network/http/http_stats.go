@@ -85,6 +85,9 @@ func NewKey(saddr, daddr util.Address, sport, dport uint16, path string, method
 	}
 }
 
+// GRPCStatus is the status code of a gRPC call, as reported by the grpc-status trailer
+type GRPCStatus uint8
+
 // NumStatusClasses represents the number of HTTP status classes (1XX, 2XX, 3XX, 4XX, 5XX)
 const NumStatusClasses = 5
 
@@ -102,6 +105,10 @@ type RequestStats [NumStatusClasses]struct {
 	// a single value. This is quite common in the context of HTTP requests without
 	// keep-alives where a short-lived TCP connection is used for a single request.
 	FirstLatencySample float64
+
+	// GRPCStatuses counts the gRPC status codes of the requests in this bucket.
+	// It is only allocated for gRPC calls, which are answered with a 2XX status.
+	GRPCStatuses map[GRPCStatus]int
 }
 
 // CombineWith merges the data in 2 RequestStats objects
@@ -115,6 +122,10 @@ func (r *RequestStats) CombineWith(newStats RequestStats) {
 			continue
 		}
 
+		for status, count := range newStats[i].GRPCStatuses {
+			r.addGRPCStatuses(i, status, count)
+		}
+
 		if newStats[i].Count == 1 {
 			// The other bucket has a single latency sample, so we "manually" add it
 			r.AddRequest(statusClass, newStats[i].FirstLatencySample)
@@ -179,6 +190,23 @@ func (r *RequestStats) AddRequest(statusClass int, latency float64) {
 	}
 }
 
+// AddGRPCStatus records the gRPC status of a request previously added with AddRequest
+func (r *RequestStats) AddGRPCStatus(statusClass int, status GRPCStatus) {
+	i := statusClass/100 - 1
+	if i < 0 || i >= len(r) {
+		return
+	}
+
+	r.addGRPCStatuses(i, status, 1)
+}
+
+func (r *RequestStats) addGRPCStatuses(i int, status GRPCStatus, count int) {
+	if r[i].GRPCStatuses == nil {
+		r[i].GRPCStatuses = make(map[GRPCStatus]int)
+	}
+	r[i].GRPCStatuses[status] += count
+}
+
 func (r *RequestStats) initSketch(i int) (err error) {
 	r[i].Latencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy)
 	if err != nil {
@@ -186,3 +214,17 @@ func (r *RequestStats) initSketch(i int) (err error) {
 	}
 	return
 }
+
+// below is copied from pkg/trace/stats/statsraw.go
+// 10 bits precision (any value will be +/- 1/1024)
+const roundMask uint64 = 1 << 10
+
+// nsTimestampToFloat converts a nanosec timestamp into a float nanosecond timestamp truncated to a fixed precision
+func nsTimestampToFloat(ns uint64) float64 {
+	var shift uint
+	for ns > roundMask {
+		ns = ns >> 1
+		shift++
+	}
+	return float64(ns << shift)
+}
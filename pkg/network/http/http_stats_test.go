@@ -59,6 +59,27 @@ func TestCombineWith(t *testing.T) {
 	}
 }
 
+func TestCombineWithGRPCStatuses(t *testing.T) {
+	var stats, stats2, stats3 RequestStats
+	stats2.AddRequest(200, 10.0)
+	stats2.AddGRPCStatus(200, 0)
+	stats3.AddRequest(200, 15.0)
+	stats3.AddGRPCStatus(200, 5)
+	stats3.AddRequest(200, 20.0)
+	stats3.AddGRPCStatus(200, 5)
+
+	stats.CombineWith(stats2)
+	stats.CombineWith(stats3)
+
+	assert.Equal(t, 3, stats[1].Count)
+	assert.Equal(t, map[GRPCStatus]int{0: 1, 5: 2}, stats[1].GRPCStatuses)
+	for i := 0; i < 5; i++ {
+		if i != 1 {
+			assert.Nil(t, stats[i].GRPCStatuses)
+		}
+	}
+}
+
 func verifyQuantile(t *testing.T, sketch *ddsketch.DDSketch, q float64, expectedValue float64) {
 	val, err := sketch.GetValueAtQuantile(q)
 	assert.Nil(t, err)
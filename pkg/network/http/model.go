@@ -4,6 +4,8 @@ package http
 
 import (
 	"unsafe"
+
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
 )
 
 /*
@@ -15,12 +17,21 @@ const (
 	HTTPBatchSize  = int(C.HTTP_BATCH_SIZE)
 	HTTPBatchPages = int(C.HTTP_BATCH_PAGES)
 	HTTPBufferSize = int(C.HTTP_BUFFER_SIZE)
+
+	PayloadBatchSize  = int(C.PAYLOAD_BATCH_SIZE)
+	PayloadBatchPages = int(C.PAYLOAD_BATCH_PAGES)
+	PayloadBufferSize = int(C.PAYLOAD_BUFFER_SIZE)
+
+	tcpFlagFIN = 0x01
+	tcpFlagRST = 0x04
 )
 
 type httpTX C.http_transaction_t
 type httpNotification C.http_batch_notification_t
 type httpBatch C.http_batch_t
 type httpBatchKey C.http_batch_key_t
+type payloadFragment C.payload_fragment_t
+type payloadBatch C.payload_batch_t
 
 func toHTTPNotification(data []byte) httpNotification {
 	return *(*httpNotification)(unsafe.Pointer(&data[0]))
@@ -86,16 +97,40 @@ func (batch *httpBatch) Transactions() []httpTX {
 	return (*(*[HTTPBatchSize]httpTX)(unsafe.Pointer(&batch.txs)))[:]
 }
 
-// below is copied from pkg/trace/stats/statsraw.go
-// 10 bits precision (any value will be +/- 1/1024)
-const roundMask uint64 = 1 << 10
+// IsDirty detects whether the payload batch page we're supposed to read from is still valid
+func (batch *payloadBatch) IsDirty(notification httpNotification) bool {
+	return batch.idx != notification.batch_idx
+}
+
+// Fragments returns the slice of payload fragments embedded in the batch
+func (batch *payloadBatch) Fragments() []payloadFragment {
+	return (*(*[PayloadBatchSize]payloadFragment)(unsafe.Pointer(&batch.fragments)))[:]
+}
 
-// nsTimestampToFloat converts a nanosec timestamp into a float nanosecond timestamp truncated to a fixed precision
-func nsTimestampToFloat(ns uint64) float64 {
-	var shift uint
-	for ns > roundMask {
-		ns = ns >> 1
-		shift++
+// Tuple returns the (client, server) tuple of the connection the fragment was captured on
+func (f *payloadFragment) Tuple() *netebpf.ConnTuple {
+	return (*netebpf.ConnTuple)(unsafe.Pointer(&f.tup))
+}
+
+// Segment returns the captured segment. Its data references the fragment's buffer.
+func (f *payloadFragment) Segment() PayloadSegment {
+	tup := f.Tuple()
+	captured := int(f.len)
+	if captured > PayloadBufferSize {
+		captured = PayloadBufferSize
+	}
+	data := (*(*[PayloadBufferSize]byte)(unsafe.Pointer(&f.data)))[:captured]
+
+	return PayloadSegment{
+		ClientAddr: tup.SourceAddress(),
+		ServerAddr: tup.DestAddress(),
+		ClientPort: tup.Sport,
+		ServerPort: tup.Dport,
+		FromClient: f.from_client != 0,
+		Seq:        uint32(f.seq),
+		Len:        int(f.len),
+		Data:       data,
+		Closing:    f.tcp_flags&(tcpFlagFIN|tcpFlagRST) != 0,
+		Timestamp:  uint64(f.timestamp),
 	}
-	return float64(ns << shift)
 }
@@ -7,10 +7,12 @@ import (
 
 	"sync"
 	"time"
+	"unsafe"
 
 	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	filterpkg "github.com/DataDog/datadog-agent/pkg/network/filter"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/ebpf"
 	"github.com/DataDog/ebpf/manager"
 )
@@ -20,6 +22,8 @@ import (
 // * Polling a perf buffer that contains notifications about HTTP transaction batches ready to be read;
 // * Querying these batches by doing a map lookup;
 // * Aggregating and emitting metrics based on the received HTTP transactions;
+// * Reassembling the payload fragments captured on TCP connections and feeding them to the
+// decoders of the protocols which can't be parsed by eBPF programs (HTTP/2 and the extra consumers);
 type Monitor struct {
 	handler func([]httpTX)
 
@@ -29,6 +33,13 @@ type Monitor struct {
 	telemetry              *telemetry
 	pollRequests           chan chan map[Key]RequestStats
 	statkeeper             *httpStatKeeper
+	http2StatKeeper        *HTTP2StatKeeper
+
+	payloadBatchManager      *payloadBatchManager
+	payloadCompletionHandler *ddebpf.PerfHandler
+	payloadDispatcher        *PayloadDispatcher
+	payloadIgnoredMap        *ebpf.Map
+	lostPayloadBatches       int
 
 	// termination
 	mux           sync.Mutex
@@ -37,8 +48,12 @@ type Monitor struct {
 	stopped       bool
 }
 
-// NewMonitor returns a new Monitor instance
-func NewMonitor(c *config.Config, offsets []manager.ConstantEditor, sockFD *ebpf.Map) (*Monitor, error) {
+// payloadIdleTimeout is the time after which the connections without traffic are forgotten by the payload dispatcher
+const payloadIdleTimeout = 2 * time.Minute
+
+// NewMonitor returns a new Monitor instance. The payloads captured on TCP connections are fed to
+// the HTTP/2 decoder as well as to the given consumers.
+func NewMonitor(c *config.Config, offsets []manager.ConstantEditor, sockFD *ebpf.Map, consumers ...DataConsumer) (*Monitor, error) {
 	mgr, err := newEBPFProgram(c, offsets, sockFD)
 	if err != nil {
 		return nil, fmt.Errorf("error setting up http ebpf program: %s", err)
@@ -68,6 +83,26 @@ func NewMonitor(c *config.Config, offsets []manager.ConstantEditor, sockFD *ebpf
 		return nil, err
 	}
 
+	payloadBatchMap, _, err := mgr.GetMap(payloadBatchesMap)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadBatchStateMap, _, err := mgr.GetMap(payloadBatchStateMap)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadHeap, _, err := mgr.GetMap(payloadHeapMap)
+	if err != nil {
+		return nil, err
+	}
+
+	payloadIgnored, _, err := mgr.GetMap(payloadIgnoredMap)
+	if err != nil {
+		return nil, err
+	}
+
 	notificationMap, _, _ := mgr.GetMap(httpNotificationsPerfMap)
 	numCPUs := int(notificationMap.ABI().MaxEntries)
 
@@ -80,15 +115,27 @@ func NewMonitor(c *config.Config, offsets []manager.ConstantEditor, sockFD *ebpf
 		}
 	}
 
+	http2StatKeeper := NewHTTP2StatKeeper(c)
+	dispatcher := NewPayloadDispatcher(
+		append([]DataConsumer{http2StatKeeper}, consumers...),
+		int(c.MaxTrackedConnections),
+		uint64(payloadIdleTimeout.Nanoseconds()),
+	)
+
 	return &Monitor{
-		handler:                handler,
-		ebpfProgram:            mgr,
-		batchManager:           newBatchManager(batchMap, batchStateMap, numCPUs),
-		batchCompletionHandler: mgr.batchCompletionHandler,
-		telemetry:              telemetry,
-		pollRequests:           make(chan chan map[Key]RequestStats),
-		closeFilterFn:          closeFilterFn,
-		statkeeper:             statkeeper,
+		handler:                  handler,
+		ebpfProgram:              mgr,
+		batchManager:             newBatchManager(batchMap, batchStateMap, numCPUs),
+		batchCompletionHandler:   mgr.batchCompletionHandler,
+		telemetry:                telemetry,
+		pollRequests:             make(chan chan map[Key]RequestStats),
+		closeFilterFn:            closeFilterFn,
+		statkeeper:               statkeeper,
+		http2StatKeeper:          http2StatKeeper,
+		payloadBatchManager:      newPayloadBatchManager(payloadBatchMap, payloadBatchStateMap, payloadHeap, numCPUs),
+		payloadCompletionHandler: mgr.payloadCompletionHandler,
+		payloadDispatcher:        dispatcher,
+		payloadIgnoredMap:        payloadIgnored,
 	}, nil
 }
 
@@ -124,6 +171,20 @@ func (m *Monitor) Start() error {
 				}
 
 				m.process(nil, errLostBatch)
+			case dataEvent, ok := <-m.payloadCompletionHandler.DataChannel:
+				if !ok {
+					return
+				}
+
+				notification := toHTTPNotification(dataEvent.Data)
+				fragments, err := m.payloadBatchManager.GetFragmentsFrom(notification)
+				m.dispatch(fragments, err)
+			case _, ok := <-m.payloadCompletionHandler.LostChannel:
+				if !ok {
+					return
+				}
+
+				m.dispatch(nil, errLostPayloadBatch)
 			case reply, ok := <-m.pollRequests:
 				if !ok {
 					return
@@ -131,14 +192,22 @@ func (m *Monitor) Start() error {
 
 				transactions := m.batchManager.GetPendingTransactions()
 				m.process(transactions, nil)
+				m.flushPayloads()
 
 				delta := m.telemetry.reset()
 				delta.report()
 
-				reply <- m.statkeeper.GetAndResetAllStats()
+				stats := m.statkeeper.GetAndResetAllStats()
+				for key, http2Stats := range m.http2StatKeeper.GetAndResetAllStats() {
+					merged := stats[key]
+					merged.CombineWith(http2Stats)
+					stats[key] = merged
+				}
+				reply <- stats
 			case <-report.C:
 				transactions := m.batchManager.GetPendingTransactions()
 				m.process(transactions, nil)
+				m.flushPayloads()
 			}
 		}
 	}()
@@ -192,6 +261,39 @@ func (m *Monitor) process(transactions []httpTX, err error) {
 	}
 }
 
+// dispatch feeds the captured payload fragments to the consumers, and stops the capture
+// of the connections none of them is interested in anymore
+func (m *Monitor) dispatch(fragments []payloadFragment, err error) {
+	if err != nil {
+		m.lostPayloadBatches++
+	}
+
+	for i := range fragments {
+		if m.payloadDispatcher.Dispatch(fragments[i].Segment()) {
+			ignored := uint8(1)
+			m.payloadIgnoredMap.Put(unsafe.Pointer(fragments[i].Tuple()), unsafe.Pointer(&ignored))
+		}
+	}
+}
+
+// flushPayloads dispatches the fragments not notified yet, and forgets about the idle connections
+func (m *Monitor) flushPayloads() {
+	m.dispatch(m.payloadBatchManager.GetPendingFragments(), nil)
+	m.payloadDispatcher.Flush()
+
+	now, err := ddebpf.NowNanoseconds()
+	if err != nil {
+		log.Debugf("error retrieving the current time: %s", err)
+		return
+	}
+	m.payloadDispatcher.Expire(uint64(now))
+
+	if m.lostPayloadBatches > 0 {
+		log.Debugf("lost %d payload batches", m.lostPayloadBatches)
+		m.lostPayloadBatches = 0
+	}
+}
+
 func (m *Monitor) DumpMaps(maps ...string) (string, error) {
 	return m.ebpfProgram.Manager.DumpMaps(maps...)
 }
@@ -0,0 +1,285 @@
+package http
+
+import (
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// maxPendingSegments bounds the number of out-of-order segments buffered on each direction of a connection
+const maxPendingSegments = 16
+
+// DataConsumer decodes the payloads captured on TCP connections. Connections are identified from
+// their client to their server, and the bytes sent on each direction are fed in order.
+type DataConsumer interface {
+	// OnData processes bytes sent on the connection at the given time in nanoseconds.
+	// It returns false once the consumer isn't interested in the connection anymore.
+	OnData(clientAddr, serverAddr util.Address, clientPort, serverPort uint16, fromClient bool, data []byte, ts uint64) bool
+	// OnDataLost accounts for n bytes sent on the connection which weren't captured.
+	// It returns false once the consumer isn't interested in the connection anymore.
+	OnDataLost(clientAddr, serverAddr util.Address, clientPort, serverPort uint16, fromClient bool, n int) bool
+	// CloseConnection discards the state of the connection
+	CloseConnection(clientAddr, serverAddr util.Address, clientPort, serverPort uint16)
+}
+
+// PayloadSegment is the beginning of a TCP segment captured on a connection
+type PayloadSegment struct {
+	ClientAddr util.Address
+	ServerAddr util.Address
+	ClientPort uint16
+	ServerPort uint16
+	FromClient bool
+	// Seq is the sequence number of the first byte of the segment
+	Seq uint32
+	// Len is the size of the segment payload, of which Data only holds the beginning
+	Len  int
+	Data []byte
+	// Closing is set for segments terminating the connection (FIN or RST)
+	Closing   bool
+	Timestamp uint64
+}
+
+type payloadConn struct {
+	clientAddr util.Address
+	serverAddr util.Address
+	clientPort uint16
+	serverPort uint16
+
+	client payloadStream
+	server payloadStream
+
+	// consumers are the consumers still interested in the connection
+	consumers []DataConsumer
+	lastSeen  uint64
+	// done is set once the connection is closed or nobody is interested in it anymore.
+	// Its entry is kept until the next call to Expire so that late or duplicated segments are discarded.
+	done bool
+}
+
+// PayloadDispatcher reassembles the TCP segments captured on each connection and feeds
+// their payloads to the consumers interested in them. Segments seen several times (for
+// instance on both ends of a loopback or veth interface) or retransmitted are discarded,
+// and the bytes which couldn't be captured are reported as lost.
+type PayloadDispatcher struct {
+	consumers   []DataConsumer
+	conns       map[Key]*payloadConn
+	maxConns    int
+	idleTimeout uint64
+
+	dropped int
+}
+
+// NewPayloadDispatcher returns a PayloadDispatcher tracking up to maxConns connections, which are
+// forgotten after idleTimeout nanoseconds without traffic
+func NewPayloadDispatcher(consumers []DataConsumer, maxConns int, idleTimeout uint64) *PayloadDispatcher {
+	return &PayloadDispatcher{
+		consumers:   consumers,
+		conns:       make(map[Key]*payloadConn),
+		maxConns:    maxConns,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Dispatch processes a captured segment. It returns true when no consumer is interested in the
+// connection anymore, in which case its capture should be stopped.
+func (d *PayloadDispatcher) Dispatch(seg PayloadSegment) bool {
+	key := NewKey(seg.ClientAddr, seg.ServerAddr, seg.ClientPort, seg.ServerPort, "", MethodUnknown)
+	conn, ok := d.conns[key]
+	if !ok {
+		if seg.Len == 0 {
+			// termination of a connection we know nothing about
+			return false
+		}
+		if len(d.conns) >= d.maxConns {
+			d.dropped++
+			return false
+		}
+
+		conn = &payloadConn{
+			clientAddr: seg.ClientAddr,
+			serverAddr: seg.ServerAddr,
+			clientPort: seg.ClientPort,
+			serverPort: seg.ServerPort,
+			consumers:  append([]DataConsumer(nil), d.consumers...),
+		}
+		d.conns[key] = conn
+	}
+
+	conn.lastSeen = seg.Timestamp
+	if conn.done {
+		return false
+	}
+
+	stream := &conn.server
+	if seg.FromClient {
+		stream = &conn.client
+	}
+	stream.push(seg, conn.deliver)
+
+	if seg.Closing {
+		conn.close()
+		return false
+	}
+
+	if len(conn.consumers) == 0 {
+		conn.done = true
+		return true
+	}
+	return false
+}
+
+// Flush delivers the segments waiting for missing ones, which are then reported as lost.
+// It should be called once all the segments captured so far were dispatched.
+func (d *PayloadDispatcher) Flush() {
+	for _, conn := range d.conns {
+		if conn.done {
+			continue
+		}
+
+		conn.client.flush(conn.deliver)
+		conn.server.flush(conn.deliver)
+		if len(conn.consumers) == 0 {
+			conn.done = true
+		}
+	}
+}
+
+// Expire forgets about the connections which are done or without traffic since idleTimeout nanoseconds before now
+func (d *PayloadDispatcher) Expire(now uint64) {
+	for key, conn := range d.conns {
+		if !conn.done && conn.lastSeen+d.idleTimeout > now {
+			continue
+		}
+
+		conn.close()
+		delete(d.conns, key)
+	}
+
+	if d.dropped > 0 {
+		log.Debugf("payload dispatcher summary: tracked_conns=%d dropped_conns=%d", len(d.conns), d.dropped)
+		d.dropped = 0
+	}
+}
+
+// close flushes the segments waiting for missing ones and discards the state of the consumers
+func (c *payloadConn) close() {
+	if c.done {
+		return
+	}
+
+	c.client.flush(c.deliver)
+	c.server.flush(c.deliver)
+	for _, consumer := range c.consumers {
+		consumer.CloseConnection(c.clientAddr, c.serverAddr, c.clientPort, c.serverPort)
+	}
+	c.consumers = nil
+	c.done = true
+}
+
+// deliver feeds the consumers with the lost bytes preceding a segment,
+// then with the part of the segment starting at offset skip
+func (c *payloadConn) deliver(seg PayloadSegment, lostBefore, skip int) {
+	var data []byte
+	lost := seg.Len - skip
+	if skip < len(seg.Data) {
+		data = seg.Data[skip:]
+		lost = seg.Len - len(seg.Data)
+	}
+
+	interested := c.consumers[:0]
+	for _, consumer := range c.consumers {
+		ok := true
+		if lostBefore > 0 {
+			ok = consumer.OnDataLost(c.clientAddr, c.serverAddr, c.clientPort, c.serverPort, seg.FromClient, lostBefore)
+		}
+		if ok && len(data) > 0 {
+			ok = consumer.OnData(c.clientAddr, c.serverAddr, c.clientPort, c.serverPort, seg.FromClient, data, seg.Timestamp)
+		}
+		if ok && lost > 0 {
+			ok = consumer.OnDataLost(c.clientAddr, c.serverAddr, c.clientPort, c.serverPort, seg.FromClient, lost)
+		}
+		if ok {
+			interested = append(interested, consumer)
+		}
+	}
+	c.consumers = interested
+}
+
+// payloadStream orders the segments sent on one direction of a connection
+type payloadStream struct {
+	started bool
+	nextSeq uint32
+	// pending holds the segments received ahead of a missing one, ordered by sequence number
+	pending []PayloadSegment
+}
+
+type deliverFunc func(seg PayloadSegment, lostBefore, skip int)
+
+func (s *payloadStream) push(seg PayloadSegment, deliver deliverFunc) {
+	if seg.Len == 0 {
+		return
+	}
+
+	if !s.started {
+		s.started = true
+		s.nextSeq = seg.Seq
+	}
+
+	if int32(seg.Seq-s.nextSeq) > 0 {
+		// a segment is missing, wait for it unless too many segments are waiting already
+		s.queue(seg)
+		if len(s.pending) > maxPendingSegments {
+			s.flush(deliver)
+		}
+		return
+	}
+
+	s.deliver(seg, 0, deliver)
+	for len(s.pending) > 0 && int32(s.pending[0].Seq-s.nextSeq) <= 0 {
+		next := s.pending[0]
+		s.pending = s.pending[1:]
+		s.deliver(next, 0, deliver)
+	}
+	if len(s.pending) == 0 {
+		s.pending = nil
+	}
+}
+
+// flush delivers the pending segments, reporting the bytes missing between them as lost
+func (s *payloadStream) flush(deliver deliverFunc) {
+	pending := s.pending
+	s.pending = nil
+	for _, seg := range pending {
+		lostBefore := 0
+		if gap := int32(seg.Seq - s.nextSeq); gap > 0 {
+			lostBefore = int(gap)
+			s.nextSeq = seg.Seq
+		}
+		s.deliver(seg, lostBefore, deliver)
+	}
+}
+
+// deliver delivers the part of a segment which wasn't delivered yet, if any.
+// Duplicated and retransmitted segments are discarded here.
+func (s *payloadStream) deliver(seg PayloadSegment, lostBefore int, deliver deliverFunc) {
+	skip := int(int32(s.nextSeq - seg.Seq))
+	if skip >= seg.Len {
+		return
+	}
+
+	deliver(seg, lostBefore, skip)
+	s.nextSeq = seg.Seq + uint32(seg.Len)
+}
+
+func (s *payloadStream) queue(seg PayloadSegment) {
+	// the captured data references memory reused for the next captures
+	seg.Data = append([]byte(nil), seg.Data...)
+
+	i := sort.Search(len(s.pending), func(i int) bool {
+		return int32(s.pending[i].Seq-seg.Seq) > 0
+	})
+	s.pending = append(s.pending, PayloadSegment{})
+	copy(s.pending[i+1:], s.pending[i:])
+	s.pending[i] = seg
+}
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingConsumer records the events it receives as strings
+type recordingConsumer struct {
+	events []string
+	// interested is returned by OnData and OnDataLost
+	interested bool
+}
+
+func (r *recordingConsumer) OnData(_, _ util.Address, _, _ uint16, fromClient bool, data []byte, _ uint64) bool {
+	r.events = append(r.events, fmt.Sprintf("%s data %s", direction(fromClient), data))
+	return r.interested
+}
+
+func (r *recordingConsumer) OnDataLost(_, _ util.Address, _, _ uint16, fromClient bool, n int) bool {
+	r.events = append(r.events, fmt.Sprintf("%s lost %d", direction(fromClient), n))
+	return r.interested
+}
+
+func (r *recordingConsumer) CloseConnection(_, _ util.Address, _, _ uint16) {
+	r.events = append(r.events, "close")
+}
+
+func direction(fromClient bool) string {
+	if fromClient {
+		return "client"
+	}
+	return "server"
+}
+
+func segment(fromClient bool, seq uint32, data string, ts uint64) PayloadSegment {
+	return PayloadSegment{
+		ClientAddr: util.AddressFromString("1.1.1.1"),
+		ServerAddr: util.AddressFromString("2.2.2.2"),
+		ClientPort: 1234,
+		ServerPort: 80,
+		FromClient: fromClient,
+		Seq:        seq,
+		Len:        len(data),
+		Data:       []byte(data),
+		Timestamp:  ts,
+	}
+}
+
+func TestPayloadDispatcherReassembly(t *testing.T) {
+	consumer := &recordingConsumer{interested: true}
+	d := NewPayloadDispatcher([]DataConsumer{consumer}, 10, 100)
+
+	assert.False(t, d.Dispatch(segment(true, 100, "abc", 1)))
+	// duplicate, as seen on both ends of a veth pair
+	assert.False(t, d.Dispatch(segment(true, 100, "abc", 1)))
+	// out of order
+	assert.False(t, d.Dispatch(segment(true, 106, "ghi", 2)))
+	assert.False(t, d.Dispatch(segment(true, 103, "def", 3)))
+	// partial retransmission
+	assert.False(t, d.Dispatch(segment(true, 107, "hijk", 4)))
+	assert.False(t, d.Dispatch(segment(false, 5000, "ok", 5)))
+
+	assert.Equal(t, []string{
+		"client data abc",
+		"client data def",
+		"client data ghi",
+		"client data jk",
+		"server data ok",
+	}, consumer.events)
+}
+
+func TestPayloadDispatcherLostData(t *testing.T) {
+	consumer := &recordingConsumer{interested: true}
+	d := NewPayloadDispatcher([]DataConsumer{consumer}, 10, 100)
+
+	// only the beginning of the segment is captured
+	truncated := segment(true, 100, "abc", 1)
+	truncated.Len = 10
+	d.Dispatch(truncated)
+
+	// the segment at 110 is missing
+	d.Dispatch(segment(true, 115, "xyz", 2))
+	assert.Equal(t, []string{"client data abc", "client lost 7"}, consumer.events)
+
+	d.Flush()
+	assert.Equal(t, []string{"client data abc", "client lost 7", "client lost 5", "client data xyz"}, consumer.events)
+}
+
+func TestPayloadDispatcherTooManyPendingSegments(t *testing.T) {
+	consumer := &recordingConsumer{interested: true}
+	d := NewPayloadDispatcher([]DataConsumer{consumer}, 10, 100)
+
+	d.Dispatch(segment(true, 0, "a", 1))
+	for i := 0; i <= maxPendingSegments; i++ {
+		d.Dispatch(segment(true, uint32(2+i), "b", 1))
+	}
+
+	assert.Equal(t, "client lost 1", consumer.events[1])
+	assert.Len(t, consumer.events, maxPendingSegments+3)
+}
+
+func TestPayloadDispatcherInterest(t *testing.T) {
+	interested := &recordingConsumer{interested: true}
+	uninterested := &recordingConsumer{}
+	d := NewPayloadDispatcher([]DataConsumer{interested, uninterested}, 10, 100)
+
+	assert.False(t, d.Dispatch(segment(true, 0, "a", 1)))
+	assert.False(t, d.Dispatch(segment(true, 1, "b", 2)))
+	assert.Equal(t, []string{"client data a"}, uninterested.events)
+	assert.Equal(t, []string{"client data a", "client data b"}, interested.events)
+
+	interested.interested = false
+	assert.True(t, d.Dispatch(segment(true, 2, "c", 3)))
+	// the connection is ignored from now on
+	assert.False(t, d.Dispatch(segment(true, 3, "d", 4)))
+	assert.Equal(t, []string{"client data a", "client data b", "client data c"}, interested.events)
+}
+
+func TestPayloadDispatcherClose(t *testing.T) {
+	consumer := &recordingConsumer{interested: true}
+	d := NewPayloadDispatcher([]DataConsumer{consumer}, 10, 100)
+
+	d.Dispatch(segment(true, 0, "a", 1))
+	d.Dispatch(segment(true, 5, "b", 2))
+
+	fin := segment(false, 100, "", 3)
+	fin.Closing = true
+	d.Dispatch(fin)
+	assert.Equal(t, []string{"client data a", "client lost 4", "client data b", "close"}, consumer.events)
+
+	// late segments are discarded until the connection is expired
+	d.Dispatch(segment(true, 6, "c", 4))
+	assert.Len(t, consumer.events, 4)
+	d.Expire(5)
+	assert.Empty(t, d.conns)
+}
+
+func TestPayloadDispatcherExpire(t *testing.T) {
+	consumer := &recordingConsumer{interested: true}
+	d := NewPayloadDispatcher([]DataConsumer{consumer}, 1, 100)
+
+	d.Dispatch(segment(true, 0, "a", 10))
+	// the dispatcher is full
+	other := segment(true, 0, "b", 20)
+	other.ClientPort = 4321
+	d.Dispatch(other)
+	assert.Equal(t, []string{"client data a"}, consumer.events)
+
+	d.Expire(100)
+	assert.Len(t, d.conns, 1)
+	d.Expire(110)
+	assert.Empty(t, d.conns)
+	assert.Equal(t, []string{"client data a", "close"}, consumer.events)
+}
@@ -73,6 +73,7 @@ type realConntracker struct {
 		unregisters          int64
 		unregistersTotalTime int64
 		evicts               int64
+		historyHits          int64
 	}
 }
 
@@ -86,7 +87,7 @@ func NewConntracker(config *config.Config) (Conntracker, error) {
 	done := make(chan struct{})
 
 	go func() {
-		conntracker, err = newConntrackerOnce(config.ProcRoot, config.ConntrackMaxStateSize, config.ConntrackRateLimit, config.EnableConntrackAllNamespaces)
+		conntracker, err = newConntrackerOnce(config.ProcRoot, config.ConntrackMaxStateSize, config.ConntrackRateLimit, config.EnableConntrackAllNamespaces, config.ConntrackTranslationHistoryTTL)
 		done <- struct{}{}
 	}()
 
@@ -98,7 +99,7 @@ func NewConntracker(config *config.Config) (Conntracker, error) {
 	}
 }
 
-func newConntrackerOnce(procRoot string, maxStateSize, targetRateLimit int, listenAllNamespaces bool) (Conntracker, error) {
+func newConntrackerOnce(procRoot string, maxStateSize, targetRateLimit int, listenAllNamespaces bool, historyTTL time.Duration) (Conntracker, error) {
 	consumer := NewConsumer(procRoot, targetRateLimit, listenAllNamespaces)
 	ctr := &realConntracker{
 		consumer:      consumer,
@@ -107,6 +108,7 @@ func newConntrackerOnce(procRoot string, maxStateSize, targetRateLimit int, list
 		compactTicker: time.NewTicker(compactInterval),
 		decoder:       NewDecoder(),
 	}
+	ctr.cache.history = newTranslationHistory(maxStateSize, historyTTL)
 
 	for _, family := range []uint8{unix.AF_INET, unix.AF_INET6} {
 		events, err := consumer.DumpTable(family)
@@ -144,6 +146,11 @@ func (ctr *realConntracker) GetTranslationForConn(c network.ConnectionStats) *ne
 
 	t, ok := ctr.cache.Get(k)
 	if !ok {
+		// the translation may have left the cache before the connection got closed
+		if translation := ctr.cache.history.Get(k, time.Now()); translation != nil {
+			atomic.AddInt64(&ctr.stats.historyHits, 1)
+			return translation
+		}
 		return nil
 	}
 
@@ -155,11 +162,13 @@ func (ctr *realConntracker) GetStats() map[string]int64 {
 	ctr.RLock()
 	size := ctr.cache.cache.Len()
 	orphanSize := ctr.cache.orphans.Len()
+	historySize := ctr.cache.history.Len()
 	ctr.RUnlock()
 
 	m := map[string]int64{
-		"state_size":  int64(size),
-		"orphan_size": int64(orphanSize),
+		"state_size":   int64(size),
+		"orphan_size":  int64(orphanSize),
+		"history_size": int64(historySize),
 	}
 
 	gets := atomic.LoadInt64(&ctr.stats.gets)
@@ -184,6 +193,7 @@ func (ctr *realConntracker) GetStats() map[string]int64 {
 		m["nanoseconds_per_unregister"] = unregisterTotalTime / unregisters
 	}
 	m["evicts_total"] = atomic.LoadInt64(&ctr.stats.evicts)
+	m["history_hits_total"] = atomic.LoadInt64(&ctr.stats.historyHits)
 
 	// Merge telemetry from the consumer
 	for k, v := range ctr.consumer.GetStats() {
@@ -213,6 +223,7 @@ func (ctr *realConntracker) DeleteTranslation(c network.ConnectionStats) {
 	if ctr.cache.Remove(k) {
 		atomic.AddInt64(&ctr.stats.unregisters, 1)
 	}
+	ctr.cache.history.Remove(k)
 }
 
 func (ctr *realConntracker) IsSampling() bool {
@@ -298,13 +309,21 @@ func (ctr *realConntracker) compact() {
 	ctr.Lock()
 	defer ctr.Unlock()
 
-	removed = ctr.cache.removeOrphans(time.Now())
+	now := time.Now()
+	removed = ctr.cache.removeOrphans(now)
+	ctr.cache.history.removeExpired(now)
 }
 
 type conntrackCache struct {
 	cache         *simplelru.LRU
 	orphans       *list.List
 	orphanTimeout time.Duration
+
+	// history receives the translations evicted from the cache, or removed because they were orphaned
+	history *translationHistory
+	// removing is set while translations are explicitly removed from the cache, which
+	// happens once their connection is closed so they don't need to be kept in the history
+	removing bool
 }
 
 func newConntrackCache(maxSize int, orphanTimeout time.Duration) *conntrackCache {
@@ -318,6 +337,9 @@ func newConntrackCache(maxSize int, orphanTimeout time.Duration) *conntrackCache
 		if t.orphan != nil {
 			c.orphans.Remove(t.orphan)
 		}
+		if !c.removing {
+			c.history.Add(key.(connKey), t.IPTranslation, time.Now())
+		}
 	})
 
 	return c
@@ -339,6 +361,8 @@ func (cc *conntrackCache) Get(k connKey) (*translationEntry, bool) {
 }
 
 func (cc *conntrackCache) Remove(k connKey) bool {
+	cc.removing = true
+	defer func() { cc.removing = false }()
 	return cc.cache.Remove(k)
 }
 
@@ -0,0 +1,105 @@
+// +build linux
+// +build !android
+
+package netlink
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+type historyEntry struct {
+	*network.IPTranslation
+	expires time.Time
+}
+
+// translationHistory keeps the IP translations that left the conntrack cache before the connections
+// they belong to were closed, either because the cache was full or because the translation was orphaned
+// for too long. This allows short-lived connections to still be resolved to their pre-NAT addresses
+// (typically Kubernetes service VIPs) when they get closed.
+//
+// Entries are only ever added, never refreshed on reads, so the oldest entry of the LRU
+// is always the first to expire.
+type translationHistory struct {
+	cache *simplelru.LRU
+	ttl   time.Duration
+}
+
+// newTranslationHistory returns a translation history bounded to maxSize entries, or nil if
+// either maxSize or ttl is not positive. All the methods of a nil history are no-ops.
+func newTranslationHistory(maxSize int, ttl time.Duration) *translationHistory {
+	if maxSize <= 0 || ttl <= 0 {
+		return nil
+	}
+
+	cache, _ := simplelru.NewLRU(maxSize, nil)
+	return &translationHistory{
+		cache: cache,
+		ttl:   ttl,
+	}
+}
+
+// Add records the translation of the given connection, expiring after the history TTL
+func (h *translationHistory) Add(k connKey, t *network.IPTranslation, now time.Time) {
+	if h == nil || t == nil {
+		return
+	}
+
+	h.cache.Add(k, &historyEntry{
+		IPTranslation: t,
+		expires:       now.Add(h.ttl),
+	})
+}
+
+// Get returns the translation recorded for the given connection, if it hasn't expired
+func (h *translationHistory) Get(k connKey, now time.Time) *network.IPTranslation {
+	if h == nil {
+		return nil
+	}
+
+	v, ok := h.cache.Peek(k)
+	if !ok {
+		return nil
+	}
+
+	e := v.(*historyEntry)
+	if e.expires.Before(now) {
+		h.cache.Remove(k)
+		return nil
+	}
+	return e.IPTranslation
+}
+
+// Remove deletes the translation recorded for the given connection
+func (h *translationHistory) Remove(k connKey) {
+	if h == nil {
+		return
+	}
+	h.cache.Remove(k)
+}
+
+// Len returns the number of translations in the history
+func (h *translationHistory) Len() int {
+	if h == nil {
+		return 0
+	}
+	return h.cache.Len()
+}
+
+// removeExpired deletes the translations that expired before now
+func (h *translationHistory) removeExpired(now time.Time) (removed int64) {
+	if h == nil {
+		return 0
+	}
+
+	for {
+		_, v, ok := h.cache.GetOldest()
+		if !ok || !v.(*historyEntry).expires.Before(now) {
+			return removed
+		}
+		h.cache.RemoveOldest()
+		removed++
+	}
+}
@@ -0,0 +1,116 @@
+// +build linux
+// +build !android
+
+package netlink
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslationHistory(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		h := newTranslationHistory(10, 0)
+		require.Nil(t, h)
+
+		k := connKey{srcIP: util.AddressFromString("10.0.0.1"), srcPort: 1234, dstIP: util.AddressFromString("10.96.0.1"), dstPort: 80}
+		h.Add(k, &network.IPTranslation{}, time.Now())
+		assert.Nil(t, h.Get(k, time.Now()))
+		assert.Zero(t, h.Len())
+	})
+
+	t.Run("expiration", func(t *testing.T) {
+		h := newTranslationHistory(10, time.Minute)
+		now := time.Now()
+
+		k1 := connKey{srcIP: util.AddressFromString("10.0.0.1"), srcPort: 1234, dstIP: util.AddressFromString("10.96.0.1"), dstPort: 80}
+		k2 := connKey{srcIP: util.AddressFromString("10.0.0.1"), srcPort: 1235, dstIP: util.AddressFromString("10.96.0.1"), dstPort: 80}
+		t1 := &network.IPTranslation{ReplSrcIP: util.AddressFromString("10.244.0.5"), ReplSrcPort: 8080}
+		t2 := &network.IPTranslation{ReplSrcIP: util.AddressFromString("10.244.0.6"), ReplSrcPort: 8080}
+		h.Add(k1, t1, now)
+		h.Add(k2, t2, now.Add(30*time.Second))
+
+		assert.Equal(t, t1, h.Get(k1, now.Add(time.Minute)))
+		assert.Equal(t, int64(1), h.removeExpired(now.Add(61*time.Second)))
+		assert.Nil(t, h.Get(k1, now.Add(61*time.Second)))
+		assert.Equal(t, t2, h.Get(k2, now.Add(61*time.Second)))
+
+		// expired entries are dropped when looked up
+		assert.Nil(t, h.Get(k2, now.Add(91*time.Second)))
+		assert.Zero(t, h.Len())
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		h := newTranslationHistory(2, time.Minute)
+		now := time.Now()
+		for port := uint16(1); port <= 3; port++ {
+			h.Add(connKey{srcPort: port}, &network.IPTranslation{ReplDstPort: port}, now)
+		}
+
+		assert.Equal(t, 2, h.Len())
+		assert.Nil(t, h.Get(connKey{srcPort: 1}, now))
+		assert.NotNil(t, h.Get(connKey{srcPort: 3}, now))
+	})
+}
+
+func TestConntrackerTranslationHistory(t *testing.T) {
+	conn := func(src string) network.ConnectionStats {
+		return network.ConnectionStats{
+			Source: util.AddressFromString(src),
+			SPort:  12345,
+			Dest:   util.AddressFromString("50.30.40.10"),
+			DPort:  80,
+			Type:   network.TCP,
+		}
+	}
+
+	t.Run("evicted", func(t *testing.T) {
+		rt := newConntracker(2)
+		rt.cache.history = newTranslationHistory(10, time.Minute)
+
+		rt.register(makeTranslatedConn(net.ParseIP("10.0.0.0"), net.ParseIP("20.0.0.0"), net.ParseIP("50.30.40.10"), 6, 12345, 80, 80))
+		rt.register(makeTranslatedConn(net.ParseIP("10.0.0.1"), net.ParseIP("20.0.0.1"), net.ParseIP("50.30.40.10"), 6, 12345, 80, 80))
+		require.Equal(t, 2, rt.cache.Len())
+
+		// the translation of the first connection was evicted from the cache, but is still in the history
+		tr := rt.GetTranslationForConn(conn("10.0.0.0"))
+		require.NotNil(t, tr)
+		assert.Equal(t, "20.0.0.0", tr.ReplSrcIP.String())
+		assert.Equal(t, int64(1), rt.stats.historyHits)
+
+		// once the connection is closed, the translation isn't kept anymore
+		rt.DeleteTranslation(conn("10.0.0.0"))
+		assert.Nil(t, rt.GetTranslationForConn(conn("10.0.0.0")))
+	})
+
+	t.Run("orphaned", func(t *testing.T) {
+		rt := newConntracker(10)
+		rt.cache.history = newTranslationHistory(10, time.Minute)
+
+		rt.register(makeTranslatedConn(net.ParseIP("10.0.0.0"), net.ParseIP("20.0.0.0"), net.ParseIP("50.30.40.10"), 6, 12345, 80, 80))
+		assert.Equal(t, int64(2), rt.cache.removeOrphans(time.Now().Add(defaultOrphanTimeout+time.Second)))
+		assert.Zero(t, rt.cache.Len())
+
+		tr := rt.GetTranslationForConn(conn("10.0.0.0"))
+		require.NotNil(t, tr)
+		assert.Equal(t, "20.0.0.0", tr.ReplSrcIP.String())
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		rt := newConntracker(10)
+		rt.cache.history = newTranslationHistory(10, time.Minute)
+
+		rt.register(makeTranslatedConn(net.ParseIP("10.0.0.0"), net.ParseIP("20.0.0.0"), net.ParseIP("50.30.40.10"), 6, 12345, 80, 80))
+		rt.DeleteTranslation(conn("10.0.0.0"))
+
+		// translations of closed connections don't go to the history
+		assert.Nil(t, rt.GetTranslationForConn(conn("10.0.0.0")))
+		assert.Zero(t, rt.cache.history.Len())
+	})
+}
@@ -0,0 +1,100 @@
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// Summary represents a (debug-friendly) view of the protocols classified by the network tracer
+type Summary struct {
+	Connections []ConnectionSummary
+	Kafka       []KafkaSummary
+	Postgres    []PostgresSummary
+}
+
+// ConnectionSummary represents a connection and its classified protocol
+type ConnectionSummary struct {
+	Source   Address
+	Dest     Address
+	Protocol string
+}
+
+// KafkaSummary represents the Kafka requests sent for a (client, server, topic) tuple
+type KafkaSummary struct {
+	Client  Address
+	Server  Address
+	Topic   string
+	Produce int
+	Fetch   int
+}
+
+// PostgresSummary represents the PostgreSQL queries sent for a (client, server, query type) tuple
+type PostgresSummary struct {
+	Client     Address
+	Server     Address
+	QueryType  string
+	Count      int
+	Errors     int
+	LatencyP50 float64
+}
+
+// Address represents an IP:Port
+type Address struct {
+	IP   string
+	Port uint16
+}
+
+// Protocols returns a debug-friendly representation of the classified connections and of the stats of their protocols
+func Protocols(conns []network.ConnectionStats, kafkaStats map[kafka.Key]kafka.RequestStats, postgresStats map[postgres.Key]*postgres.RequestStats) Summary {
+	var summary Summary
+	for _, c := range conns {
+		if c.Protocol == protocols.Unknown {
+			continue
+		}
+
+		summary.Connections = append(summary.Connections, ConnectionSummary{
+			Source:   Address{IP: c.Source.String(), Port: c.SPort},
+			Dest:     Address{IP: c.Dest.String(), Port: c.DPort},
+			Protocol: c.Protocol.String(),
+		})
+	}
+
+	for k, v := range kafkaStats {
+		summary.Kafka = append(summary.Kafka, KafkaSummary{
+			Client:  Address{IP: formatIP(k.SrcIPLow, k.SrcIPHigh).String(), Port: k.SrcPort},
+			Server:  Address{IP: formatIP(k.DstIPLow, k.DstIPHigh).String(), Port: k.DstPort},
+			Topic:   k.Topic,
+			Produce: v.Produce,
+			Fetch:   v.Fetch,
+		})
+	}
+
+	for k, v := range postgresStats {
+		debug := PostgresSummary{
+			Client:    Address{IP: formatIP(k.SrcIPLow, k.SrcIPHigh).String(), Port: k.SrcPort},
+			Server:    Address{IP: formatIP(k.DstIPLow, k.DstIPHigh).String(), Port: k.DstPort},
+			QueryType: k.QueryType,
+			Count:     v.Count,
+			Errors:    v.Errors,
+		}
+		if v.Latencies != nil {
+			debug.LatencyP50, _ = v.Latencies.GetValueAtQuantile(0.5)
+		}
+		summary.Postgres = append(summary.Postgres, debug)
+	}
+
+	return summary
+}
+
+func formatIP(low, high uint64) util.Address {
+	// like for HTTP, the stats have no socket family information, so the address is assumed to
+	// be IPv6 only if higher order bits are set
+	if high > 0 || (low>>32) > 0 {
+		return util.V6Address(low, high)
+	}
+
+	return util.V4Address(uint32(low))
+}
@@ -0,0 +1,261 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// APIKey identifies the type of a Kafka request
+type APIKey int16
+
+const (
+	// ProduceAPIKey is the API key of produce requests
+	ProduceAPIKey APIKey = 0
+	// FetchAPIKey is the API key of fetch requests
+	FetchAPIKey APIKey = 1
+
+	// maxAPIKey is the highest API key defined by the protocol
+	maxAPIKey = 67
+	// maxAPIVersion is an upper bound on the versions of any API
+	maxAPIVersion = 15
+	// maxProduceVersion and maxFetchVersion are the last versions not using
+	// the flexible (tagged fields) encoding, which isn't decoded
+	maxProduceVersion = 8
+	maxFetchVersion   = 11
+	// maxMessageSize bounds the size of valid messages, brokers default to 100MB
+	maxMessageSize = 100 * 1024 * 1024
+
+	// requestHeaderLength is the size of the length prefix, api key, api version and correlation id
+	requestHeaderLength = 4 + 2 + 2 + 4
+)
+
+var errTruncated = errors.New("kafka request is truncated")
+
+// Request is the header of a Kafka request along with the topics it targets
+type Request struct {
+	// Length is the size of the request, its 4 bytes length prefix excluded
+	Length        int32
+	APIKey        APIKey
+	APIVersion    int16
+	CorrelationID int32
+	ClientID      string
+	// Topics is only decoded for produce and fetch requests
+	Topics []string
+}
+
+// IsKafka returns whether the payload starts with a Kafka request
+func IsKafka(payload []byte) bool {
+	_, err := parseHeader(payload)
+	return err == nil
+}
+
+// ParseRequest decodes the Kafka request at the beginning of the payload. The payload
+// may be truncated, in which case only the topics fully contained in it are returned.
+func ParseRequest(payload []byte) (*Request, error) {
+	req, err := parseHeader(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	r := reader{buf: payload, off: requestHeaderLength + 2 + len(req.ClientID)}
+	switch {
+	case req.APIKey == ProduceAPIKey && req.APIVersion <= maxProduceVersion:
+		req.Topics = parseProduceTopics(&r, req.APIVersion)
+	case req.APIKey == FetchAPIKey && req.APIVersion <= maxFetchVersion:
+		req.Topics = parseFetchTopics(&r, req.APIVersion)
+	}
+
+	return req, nil
+}
+
+func parseHeader(payload []byte) (*Request, error) {
+	r := reader{buf: payload}
+	length, ok := r.int32()
+	if !ok {
+		return nil, errTruncated
+	}
+	apiKey, _ := r.int16()
+	apiVersion, _ := r.int16()
+	correlationID, ok := r.int32()
+	if !ok {
+		return nil, errTruncated
+	}
+
+	if length < requestHeaderLength-4+2 || length > maxMessageSize {
+		return nil, errors.New("invalid kafka request length")
+	}
+	if apiKey < 0 || apiKey > maxAPIKey || apiVersion < 0 || apiVersion > maxAPIVersion {
+		return nil, errors.New("invalid kafka api key or version")
+	}
+	if correlationID < 0 {
+		return nil, errors.New("invalid kafka correlation id")
+	}
+
+	clientID, ok := r.nullableString()
+	if !ok || !isPrintable(clientID) {
+		return nil, errors.New("invalid kafka client id")
+	}
+
+	return &Request{
+		Length:        length,
+		APIKey:        APIKey(apiKey),
+		APIVersion:    apiVersion,
+		CorrelationID: correlationID,
+		ClientID:      clientID,
+	}, nil
+}
+
+func parseProduceTopics(r *reader, version int16) []string {
+	if version >= 3 {
+		// transactional_id
+		if _, ok := r.nullableString(); !ok {
+			return nil
+		}
+	}
+	// acks and timeout
+	if !r.skip(2 + 4) {
+		return nil
+	}
+
+	topicCount, ok := r.int32()
+	if !ok {
+		return nil
+	}
+
+	var topics []string
+	for i := int32(0); i < topicCount; i++ {
+		topic, ok := r.string()
+		if !ok {
+			return topics
+		}
+		topics = append(topics, topic)
+
+		partitionCount, ok := r.int32()
+		if !ok {
+			return topics
+		}
+		for j := int32(0); j < partitionCount; j++ {
+			// partition index, followed by the record set
+			if !r.skip(4) {
+				return topics
+			}
+			size, ok := r.int32()
+			if !ok || (size > 0 && !r.skip(int(size))) {
+				return topics
+			}
+		}
+	}
+	return topics
+}
+
+func parseFetchTopics(r *reader, version int16) []string {
+	// replica_id, max_wait_ms and min_bytes
+	skip := 4 + 4 + 4
+	if version >= 3 {
+		// max_bytes
+		skip += 4
+	}
+	if version >= 4 {
+		// isolation_level
+		skip++
+	}
+	if version >= 7 {
+		// session_id and session_epoch
+		skip += 4 + 4
+	}
+	if !r.skip(skip) {
+		return nil
+	}
+
+	// partition, fetch_offset and partition_max_bytes
+	partitionSize := 4 + 8 + 4
+	if version >= 5 {
+		// log_start_offset
+		partitionSize += 8
+	}
+	if version >= 9 {
+		// current_leader_epoch
+		partitionSize += 4
+	}
+
+	topicCount, ok := r.int32()
+	if !ok {
+		return nil
+	}
+
+	var topics []string
+	for i := int32(0); i < topicCount; i++ {
+		topic, ok := r.string()
+		if !ok {
+			return topics
+		}
+		topics = append(topics, topic)
+
+		partitionCount, ok := r.int32()
+		if !ok || partitionCount < 0 || !r.skip(int(partitionCount)*partitionSize) {
+			return topics
+		}
+	}
+	return topics
+}
+
+func isPrintable(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+type reader struct {
+	buf []byte
+	off int
+}
+
+func (r *reader) skip(n int) bool {
+	if n < 0 || r.off+n > len(r.buf) {
+		return false
+	}
+	r.off += n
+	return true
+}
+
+func (r *reader) int16() (int16, bool) {
+	if r.off+2 > len(r.buf) {
+		return 0, false
+	}
+	v := int16(binary.BigEndian.Uint16(r.buf[r.off:]))
+	r.off += 2
+	return v, true
+}
+
+func (r *reader) int32() (int32, bool) {
+	if r.off+4 > len(r.buf) {
+		return 0, false
+	}
+	v := int32(binary.BigEndian.Uint32(r.buf[r.off:]))
+	r.off += 4
+	return v, true
+}
+
+func (r *reader) nullableString() (string, bool) {
+	length, ok := r.int16()
+	if !ok {
+		return "", false
+	}
+	if length == -1 {
+		return "", true
+	}
+	if length < 0 || r.off+int(length) > len(r.buf) {
+		return "", false
+	}
+	s := string(r.buf[r.off : r.off+int(length)])
+	r.off += int(length)
+	return s, true
+}
+
+func (r *reader) string() (string, bool) {
+	s, ok := r.nullableString()
+	return s, ok && s != ""
+}
@@ -0,0 +1,195 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type requestBuilder struct {
+	buf []byte
+}
+
+func (b *requestBuilder) int8(v int8) *requestBuilder {
+	b.buf = append(b.buf, byte(v))
+	return b
+}
+
+func (b *requestBuilder) int16(v int16) *requestBuilder {
+	b.buf = append(b.buf, 0, 0)
+	binary.BigEndian.PutUint16(b.buf[len(b.buf)-2:], uint16(v))
+	return b
+}
+
+func (b *requestBuilder) int32(v int32) *requestBuilder {
+	b.buf = append(b.buf, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(b.buf[len(b.buf)-4:], uint32(v))
+	return b
+}
+
+func (b *requestBuilder) int64(v int64) *requestBuilder {
+	return b.int32(int32(v >> 32)).int32(int32(v))
+}
+
+func (b *requestBuilder) string(s string) *requestBuilder {
+	b.int16(int16(len(s)))
+	b.buf = append(b.buf, s...)
+	return b
+}
+
+func (b *requestBuilder) bytes(p []byte) *requestBuilder {
+	b.int32(int32(len(p)))
+	b.buf = append(b.buf, p...)
+	return b
+}
+
+// request prefixes the request with its length
+func (b *requestBuilder) request() []byte {
+	out := make([]byte, 4, 4+len(b.buf))
+	binary.BigEndian.PutUint32(out, uint32(len(b.buf)))
+	return append(out, b.buf...)
+}
+
+func header(apiKey APIKey, version int16, correlationID int32) *requestBuilder {
+	b := &requestBuilder{}
+	return b.int16(int16(apiKey)).int16(version).int32(correlationID).string("client-1")
+}
+
+func produceRequest(topics ...string) []byte {
+	b := header(ProduceAPIKey, 7, 1).int16(-1).int16(1).int32(1000).int32(int32(len(topics)))
+	for _, topic := range topics {
+		b.string(topic).int32(2)
+		b.int32(0).bytes([]byte("record batch 0"))
+		b.int32(1).bytes([]byte("record batch 1"))
+	}
+	return b.request()
+}
+
+func fetchRequest(topics ...string) []byte {
+	b := header(FetchAPIKey, 11, 2).int32(-1).int32(500).int32(1).int32(1 << 20).int8(0).int32(0).int32(-1)
+	b.int32(int32(len(topics)))
+	for _, topic := range topics {
+		b.string(topic).int32(1)
+		b.int32(0).int32(-1).int64(42).int64(0).int32(1 << 20)
+	}
+	// forgotten topics and rack id
+	return b.int32(0).string("").request()
+}
+
+func TestParseRequest(t *testing.T) {
+	req, err := ParseRequest(produceRequest("orders", "payments"))
+	require.NoError(t, err)
+	assert.Equal(t, ProduceAPIKey, req.APIKey)
+	assert.Equal(t, int16(7), req.APIVersion)
+	assert.Equal(t, int32(1), req.CorrelationID)
+	assert.Equal(t, "client-1", req.ClientID)
+	assert.Equal(t, []string{"orders", "payments"}, req.Topics)
+
+	req, err = ParseRequest(fetchRequest("orders", "payments"))
+	require.NoError(t, err)
+	assert.Equal(t, FetchAPIKey, req.APIKey)
+	assert.Equal(t, []string{"orders", "payments"}, req.Topics)
+
+	// metadata requests are recognized but their topics aren't decoded
+	req, err = ParseRequest(header(3, 9, 3).int32(0).request())
+	require.NoError(t, err)
+	assert.Equal(t, APIKey(3), req.APIKey)
+	assert.Empty(t, req.Topics)
+}
+
+func TestParseTruncatedRequest(t *testing.T) {
+	payload := produceRequest("orders", "payments")
+
+	// the records of the second topic are missing
+	req, err := ParseRequest(payload[:len(payload)-20])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders", "payments"}, req.Topics)
+
+	_, err = ParseRequest(payload[:10])
+	assert.Equal(t, errTruncated, err)
+}
+
+func TestIsKafka(t *testing.T) {
+	assert.True(t, IsKafka(produceRequest("orders")))
+	assert.True(t, IsKafka(fetchRequest("orders")))
+	assert.False(t, IsKafka([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")))
+	assert.False(t, IsKafka(header(1000, 0, 1).request()))
+	assert.False(t, IsKafka(header(ProduceAPIKey, 0, -1).request()))
+	assert.False(t, IsKafka((&requestBuilder{}).int16(0).int16(0).int32(1).string("\x00\x01").request()))
+}
+
+func TestDecoder(t *testing.T) {
+	var payload []byte
+	payload = append(payload, produceRequest("orders")...)
+	payload = append(payload, fetchRequest("orders", "payments")...)
+	payload = append(payload, produceRequest("payments")...)
+
+	// requests are split across buffers at arbitrary boundaries
+	var (
+		decoder  Decoder
+		requests []*Request
+	)
+	for len(payload) > 0 {
+		n := 7
+		if n > len(payload) {
+			n = len(payload)
+		}
+		requests = append(requests, decoder.Feed(payload[:n])...)
+		payload = payload[n:]
+	}
+
+	require.Len(t, requests, 3)
+	assert.Equal(t, ProduceAPIKey, requests[0].APIKey)
+	assert.Equal(t, FetchAPIKey, requests[1].APIKey)
+	assert.Equal(t, ProduceAPIKey, requests[2].APIKey)
+	assert.Equal(t, []string{"payments"}, requests[2].Topics)
+}
+
+func TestDecoderSkip(t *testing.T) {
+	var decoder Decoder
+
+	// only the beginning of the requests is captured
+	request := fetchRequest("orders", "payments")
+	captured := len(request) - 10
+	assert.Empty(t, decoder.Feed(request[:captured]))
+	requests := decoder.Skip(10)
+	require.Len(t, requests, 1)
+	assert.Equal(t, FetchAPIKey, requests[0].APIKey)
+
+	request = produceRequest("orders")
+	assert.Empty(t, decoder.Feed(request[:len(request)-4]))
+	assert.Len(t, decoder.Skip(4), 1)
+
+	// message boundaries are lost, decoding resumes with the next request
+	assert.Empty(t, decoder.Skip(100))
+	assert.Empty(t, decoder.Feed([]byte("end of a record batch")))
+	requests = decoder.Feed(request)
+	require.Len(t, requests, 1)
+	assert.Equal(t, []string{"orders"}, requests[0].Topics)
+	assert.False(t, decoder.Broken())
+}
+
+func TestStatKeeper(t *testing.T) {
+	saddr, daddr := util.AddressFromString("1.1.1.1"), util.AddressFromString("2.2.2.2")
+	keeper := NewStatKeeper(2)
+
+	for _, payload := range [][]byte{produceRequest("orders"), produceRequest("orders"), fetchRequest("orders", "payments"), fetchRequest("audit")} {
+		req, err := ParseRequest(payload)
+		require.NoError(t, err)
+		keeper.Add(saddr, daddr, 1234, 9092, req)
+	}
+
+	stats, dropped := keeper.GetAndResetAllStats()
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, map[Key]RequestStats{
+		NewKey(saddr, daddr, 1234, 9092, "orders"):   {Produce: 2, Fetch: 1},
+		NewKey(saddr, daddr, 1234, 9092, "payments"): {Fetch: 1},
+	}, stats)
+
+	stats, dropped = keeper.GetAndResetAllStats()
+	assert.Empty(t, stats)
+	assert.Zero(t, dropped)
+}
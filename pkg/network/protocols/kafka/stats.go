@@ -0,0 +1,230 @@
+package kafka
+
+import (
+	"encoding/binary"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// maxParsedPrefix is the number of bytes buffered at the beginning of each request to decode its topics
+const maxParsedPrefix = 1024
+
+// Key is an identifier for a group of Kafka requests
+type Key struct {
+	SrcIPHigh uint64
+	SrcIPLow  uint64
+	SrcPort   uint16
+
+	DstIPHigh uint64
+	DstIPLow  uint64
+	DstPort   uint16
+
+	Topic string
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, topic string) Key {
+	saddrl, saddrh := util.ToLowHigh(saddr)
+	daddrl, daddrh := util.ToLowHigh(daddr)
+	return Key{
+		SrcIPHigh: saddrh,
+		SrcIPLow:  saddrl,
+		SrcPort:   sport,
+		DstIPHigh: daddrh,
+		DstIPLow:  daddrl,
+		DstPort:   dport,
+		Topic:     topic,
+	}
+}
+
+// RequestStats counts the requests sent for a topic
+type RequestStats struct {
+	Produce int
+	Fetch   int
+}
+
+// CombineWith merges the data in 2 RequestStats objects
+func (r *RequestStats) CombineWith(other RequestStats) {
+	r.Produce += other.Produce
+	r.Fetch += other.Fetch
+}
+
+// Decoder extracts the requests sent by the client of a Kafka connection.
+// Requests can span several captured buffers, so the decoder keeps track of
+// message boundaries and only parses the beginning of each request.
+type Decoder struct {
+	// remaining is the number of bytes of the current request not seen yet
+	remaining int
+	// partial holds the beginning of a request too short to be parsed
+	partial []byte
+	broken  bool
+	// desynced is set once message boundaries are lost with uncaptured bytes. Clients
+	// usually write requests at the beginning of segments, so decoding resumes with
+	// the next buffer starting with a request.
+	desynced bool
+}
+
+// Feed processes bytes sent by the client and returns the requests starting in them
+func (d *Decoder) Feed(data []byte) []*Request {
+	if d.broken {
+		return nil
+	}
+	if d.desynced {
+		if !IsKafka(data) {
+			return nil
+		}
+		d.desynced = false
+	}
+
+	var requests []*Request
+	for len(data) > 0 {
+		if d.remaining > 0 {
+			n := d.remaining
+			if n > len(data) {
+				n = len(data)
+			}
+			d.remaining -= n
+			data = data[n:]
+			continue
+		}
+
+		if len(d.partial) > 0 {
+			data = append(d.partial, data...)
+			d.partial = nil
+		}
+
+		if len(data) < 4 {
+			d.partial = append([]byte(nil), data...)
+			return requests
+		}
+
+		length := int(binary.BigEndian.Uint32(data))
+		if length > maxMessageSize {
+			// message boundaries are lost, stop decoding the connection
+			d.broken = true
+			return requests
+		}
+
+		// wait for the beginning of the request, where its topics are, to be complete
+		size := 4 + length
+		prefix := size
+		if prefix > maxParsedPrefix {
+			prefix = maxParsedPrefix
+		}
+		if len(data) < prefix {
+			d.partial = append([]byte(nil), data...)
+			return requests
+		}
+
+		end := size
+		if end > len(data) {
+			end = len(data)
+		}
+		req, err := ParseRequest(data[:end])
+		if err != nil {
+			d.broken = true
+			return requests
+		}
+
+		requests = append(requests, req)
+		if size > len(data) {
+			d.remaining = size - len(data)
+			return requests
+		}
+		data = data[size:]
+	}
+	return requests
+}
+
+// Skip accounts for n bytes sent by the client which weren't captured. The request whose
+// beginning is buffered is decoded from the captured bytes and returned.
+func (d *Decoder) Skip(n int) []*Request {
+	if d.broken || d.desynced {
+		return nil
+	}
+
+	if len(d.partial) == 0 {
+		if n <= d.remaining {
+			d.remaining -= n
+		} else {
+			d.desync()
+		}
+		return nil
+	}
+
+	partial := d.partial
+	d.partial = nil
+	if len(partial) < 4 {
+		d.desync()
+		return nil
+	}
+
+	missing := 4 + int(binary.BigEndian.Uint32(partial)) - len(partial)
+	if n <= missing {
+		d.remaining = missing - n
+	} else {
+		// the lost bytes span the beginning of the next request
+		d.desync()
+	}
+
+	req, err := ParseRequest(partial)
+	if err != nil {
+		return nil
+	}
+	return []*Request{req}
+}
+
+// Broken returns whether the decoder stopped decoding the connection
+func (d *Decoder) Broken() bool {
+	return d.broken
+}
+
+func (d *Decoder) desync() {
+	d.desynced = true
+	d.remaining = 0
+	d.partial = nil
+}
+
+// StatKeeper aggregates Kafka requests by connection and topic
+type StatKeeper struct {
+	stats      map[Key]RequestStats
+	maxEntries int
+	dropped    int
+}
+
+// NewStatKeeper returns a new StatKeeper
+func NewStatKeeper(maxEntries int) *StatKeeper {
+	return &StatKeeper{
+		stats:      make(map[Key]RequestStats),
+		maxEntries: maxEntries,
+	}
+}
+
+// Add records a request sent on the given connection
+func (s *StatKeeper) Add(saddr, daddr util.Address, sport, dport uint16, req *Request) {
+	for _, topic := range req.Topics {
+		key := NewKey(saddr, daddr, sport, dport, topic)
+		stats, ok := s.stats[key]
+		if !ok && len(s.stats) >= s.maxEntries {
+			s.dropped++
+			continue
+		}
+
+		switch req.APIKey {
+		case ProduceAPIKey:
+			stats.Produce++
+		case FetchAPIKey:
+			stats.Fetch++
+		}
+		s.stats[key] = stats
+	}
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call along with
+// the number of requests dropped because the keeper was full
+func (s *StatKeeper) GetAndResetAllStats() (map[Key]RequestStats, int) {
+	ret, dropped := s.stats, s.dropped
+	s.stats = make(map[Key]RequestStats)
+	s.dropped = 0
+	return ret, dropped
+}
@@ -0,0 +1,276 @@
+package protocols
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// connKey identifies a connection, oriented from its client to its server
+type connKey struct {
+	srcIPHigh, srcIPLow uint64
+	dstIPHigh, dstIPLow uint64
+	srcPort, dstPort    uint16
+}
+
+func newConnKey(saddr, daddr util.Address, sport, dport uint16) connKey {
+	saddrl, saddrh := util.ToLowHigh(saddr)
+	daddrl, daddrh := util.ToLowHigh(daddr)
+	return connKey{
+		srcIPHigh: saddrh,
+		srcIPLow:  saddrl,
+		dstIPHigh: daddrh,
+		dstIPLow:  daddrl,
+		srcPort:   sport,
+		dstPort:   dport,
+	}
+}
+
+type connState struct {
+	protocol ProtocolType
+	kafka    *kafka.Decoder
+	postgres *postgres.Decoder
+
+	// closed is set once the payloads of the connection stopped being captured. Its protocol
+	// is kept until the tracer reports the connection, or until the next stats collection.
+	closed  bool
+	expired bool
+	// reported is set once the protocol of the connection was looked up by the tracer
+	reported bool
+}
+
+// decoding returns whether the requests of the connection are still being decoded
+func (c *connState) decoding() bool {
+	switch {
+	case c.kafka != nil:
+		return !c.kafka.Broken()
+	case c.postgres != nil:
+		return !c.postgres.Broken()
+	default:
+		return false
+	}
+}
+
+// Monitor classifies connections from the first payload sent by their client and
+// aggregates the requests of the protocols it knows how to decode.
+// It consumes the payloads captured by the HTTP monitor.
+type Monitor struct {
+	mux sync.Mutex
+
+	classifiers []Classifier
+	conns       map[connKey]*connState
+	maxConns    int
+
+	kafka    *kafka.StatKeeper
+	postgres *postgres.StatKeeper
+
+	droppedConns int
+	evictedConns int
+}
+
+// NewMonitor returns a new Monitor tracking up to maxConns connections and maxStats
+// aggregations of each protocol between two calls to GetAndResetAllStats
+func NewMonitor(classifiers []Classifier, maxConns, maxStats int) *Monitor {
+	return &Monitor{
+		classifiers: classifiers,
+		conns:       make(map[connKey]*connState),
+		maxConns:    maxConns,
+		kafka:       kafka.NewStatKeeper(maxStats),
+		postgres:    postgres.NewStatKeeper(maxStats),
+	}
+}
+
+// OnData processes a buffer captured on the connection between the given client and server.
+// fromClient indicates the direction of the buffer and ts is its capture timestamp in nanoseconds.
+// It returns false once the connection is classified and its requests aren't decoded.
+func (m *Monitor) OnData(clientAddr, serverAddr util.Address, clientPort, serverPort uint16, fromClient bool, data []byte, ts uint64) bool {
+	if m == nil {
+		return false
+	}
+	if len(data) == 0 {
+		return true
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	key := newConnKey(clientAddr, serverAddr, clientPort, serverPort)
+	conn, ok := m.conns[key]
+	if !ok {
+		// connections are classified from the first payload sent by their client
+		if !fromClient {
+			return true
+		}
+
+		protocol := Classify(m.classifiers, data)
+		if protocol == Unknown {
+			return false
+		}
+		if len(m.conns) >= m.maxConns && !m.evict() {
+			m.droppedConns++
+			return false
+		}
+
+		conn = &connState{protocol: protocol}
+		switch conn.protocol {
+		case Kafka:
+			conn.kafka = &kafka.Decoder{}
+		case Postgres:
+			conn.postgres = &postgres.Decoder{}
+		}
+		m.conns[key] = conn
+	}
+
+	switch {
+	case conn.kafka != nil && fromClient:
+		for _, req := range conn.kafka.Feed(data) {
+			m.kafka.Add(clientAddr, serverAddr, clientPort, serverPort, req)
+		}
+	case conn.postgres != nil && fromClient:
+		conn.postgres.OnClientData(data, ts)
+	case conn.postgres != nil:
+		for _, q := range conn.postgres.OnServerData(data, ts) {
+			m.postgres.Add(clientAddr, serverAddr, clientPort, serverPort, q)
+		}
+	}
+
+	return m.stopDecoding(conn)
+}
+
+// OnDataLost accounts for n bytes sent on the connection which weren't captured.
+// It returns false once the requests of the connection can't be decoded anymore.
+func (m *Monitor) OnDataLost(clientAddr, serverAddr util.Address, clientPort, serverPort uint16, fromClient bool, n int) bool {
+	if m == nil {
+		return false
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	conn, ok := m.conns[newConnKey(clientAddr, serverAddr, clientPort, serverPort)]
+	if !ok {
+		// the first payload of the client, used to classify the connection, is lost
+		return !fromClient
+	}
+
+	switch {
+	case conn.kafka != nil && fromClient:
+		for _, req := range conn.kafka.Skip(n) {
+			m.kafka.Add(clientAddr, serverAddr, clientPort, serverPort, req)
+		}
+	case conn.postgres != nil && fromClient:
+		conn.postgres.OnClientDataLost(n)
+	case conn.postgres != nil:
+		conn.postgres.OnServerDataLost(n)
+	}
+
+	return m.stopDecoding(conn)
+}
+
+// stopDecoding releases the decoders of a connection which can't be decoded anymore,
+// and returns whether its payloads are still needed
+func (m *Monitor) stopDecoding(conn *connState) bool {
+	if conn.decoding() {
+		return true
+	}
+
+	conn.kafka, conn.postgres = nil, nil
+	return false
+}
+
+// evict makes room for new connections by forgetting the connections which aren't decoded
+// anymore, like the HTTP ones, once their protocol was reported by the tracer at least once.
+// It returns whether there is room for a new connection.
+func (m *Monitor) evict() bool {
+	for key, conn := range m.conns {
+		if conn.reported && !conn.decoding() {
+			delete(m.conns, key)
+			m.evictedConns++
+		}
+	}
+	return len(m.conns) < m.maxConns
+}
+
+// CloseConnection stops decoding a connection. Its protocol is kept for the tracer to report it.
+func (m *Monitor) CloseConnection(clientAddr, serverAddr util.Address, clientPort, serverPort uint16) {
+	if m == nil {
+		return
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	if conn, ok := m.conns[newConnKey(clientAddr, serverAddr, clientPort, serverPort)]; ok {
+		conn.kafka, conn.postgres = nil, nil
+		conn.closed = true
+	}
+}
+
+// Forget discards the state of a connection reported as closed by the tracer, in whichever direction it is seen
+func (m *Monitor) Forget(saddr, daddr util.Address, sport, dport uint16) {
+	if m == nil {
+		return
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	delete(m.conns, newConnKey(saddr, daddr, sport, dport))
+	delete(m.conns, newConnKey(daddr, saddr, dport, sport))
+}
+
+// Protocol returns the protocol of a connection, in whichever direction it is seen.
+// The connection can then be evicted if it isn't decoded anymore.
+func (m *Monitor) Protocol(saddr, daddr util.Address, sport, dport uint16) ProtocolType {
+	if m == nil {
+		return Unknown
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	conn, ok := m.conns[newConnKey(saddr, daddr, sport, dport)]
+	if !ok {
+		conn, ok = m.conns[newConnKey(daddr, saddr, dport, sport)]
+	}
+	if !ok {
+		return Unknown
+	}
+
+	conn.reported = true
+	return conn.protocol
+}
+
+// GetAndResetAllStats returns the Kafka and PostgreSQL stats aggregated since the last call
+func (m *Monitor) GetAndResetAllStats() (map[kafka.Key]kafka.RequestStats, map[postgres.Key]*postgres.RequestStats) {
+	if m == nil {
+		return nil, nil
+	}
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	// the connections closed before the previous collection and not reported since are forgotten
+	for key, conn := range m.conns {
+		if conn.expired {
+			delete(m.conns, key)
+		} else if conn.closed {
+			conn.expired = true
+		}
+	}
+
+	kafkaStats, kafkaDropped := m.kafka.GetAndResetAllStats()
+	postgresStats, postgresDropped := m.postgres.GetAndResetAllStats()
+	if m.droppedConns > 0 || m.evictedConns > 0 || kafkaDropped > 0 || postgresDropped > 0 {
+		log.Debugf(
+			"protocol classification summary: tracked_conns=%d dropped_conns=%d evicted_conns=%d kafka_dropped=%d postgres_dropped=%d",
+			len(m.conns), m.droppedConns, m.evictedConns, kafkaDropped, postgresDropped,
+		)
+	}
+	m.droppedConns = 0
+	m.evictedConns = 0
+
+	return kafkaStats, postgresStats
+}
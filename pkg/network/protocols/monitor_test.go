@@ -0,0 +1,136 @@
+package protocols
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pgMessage(msgType byte, body string) []byte {
+	out := []byte{msgType, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(out[1:], uint32(4+len(body)))
+	return append(out, body...)
+}
+
+func kafkaProduce(topic string) []byte {
+	// produce v2 request with a null client id, acks=1, timeout=1000 and a single topic
+	body := []byte{0, 0, 0, 2, 0, 0, 0, 7, 0xff, 0xff, 0, 1, 0, 0, 0x03, 0xe8, 0, 0, 0, 1}
+	body = append(body, 0, byte(len(topic)))
+	body = append(body, topic...)
+	body = append(body, 0, 0, 0, 0)
+
+	out := make([]byte, 4)
+	binary.BigEndian.PutUint32(out, uint32(len(body)))
+	return append(out, body...)
+}
+
+func TestClassify(t *testing.T) {
+	classifiers := DefaultClassifiers()
+	assert.Equal(t, HTTP, Classify(classifiers, []byte("GET /foo HTTP/1.1\r\n\r\n")))
+	assert.Equal(t, HTTP2, Classify(classifiers, []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")))
+	assert.Equal(t, Postgres, Classify(classifiers, pgMessage('Q', "SELECT 1\x00")))
+	assert.Equal(t, Kafka, Classify(classifiers, kafkaProduce("orders")))
+	assert.Equal(t, Unknown, Classify(classifiers, []byte("\x16\x03\x01\x02\x00\x01\x00\x01\xfc\x03\x03")))
+}
+
+func TestMonitor(t *testing.T) {
+	client, kafkaServer, pgServer := util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2"), util.AddressFromString("10.0.0.3")
+	m := NewMonitor(DefaultClassifiers(), 10, 10)
+
+	assert.True(t, m.OnData(client, kafkaServer, 40000, 9092, true, kafkaProduce("orders"), 1))
+	assert.True(t, m.OnData(client, kafkaServer, 40000, 9092, true, kafkaProduce("orders"), 2))
+
+	// responses from servers can't be used to classify connections
+	assert.True(t, m.OnData(client, pgServer, 40001, 5432, false, pgMessage('C', "SELECT 1\x00"), 3))
+	assert.True(t, m.OnData(client, pgServer, 40001, 5432, true, pgMessage('Q', "SELECT 1\x00"), 10))
+	assert.True(t, m.OnData(client, pgServer, 40001, 5432, false, pgMessage('C', "SELECT 1\x00"), 30))
+
+	// the requests of HTTP connections aren't decoded by the monitor
+	assert.False(t, m.OnData(client, kafkaServer, 40002, 80, true, []byte("GET / HTTP/1.1\r\n\r\n"), 40))
+	assert.Equal(t, HTTP, m.Protocol(client, kafkaServer, 40002, 80))
+
+	assert.Equal(t, Kafka, m.Protocol(client, kafkaServer, 40000, 9092))
+	assert.Equal(t, Kafka, m.Protocol(kafkaServer, client, 9092, 40000))
+	assert.Equal(t, Postgres, m.Protocol(pgServer, client, 5432, 40001))
+	assert.Equal(t, Unknown, m.Protocol(client, pgServer, 40002, 5432))
+
+	kafkaStats, postgresStats := m.GetAndResetAllStats()
+	assert.Equal(t, map[kafka.Key]kafka.RequestStats{
+		kafka.NewKey(client, kafkaServer, 40000, 9092, "orders"): {Produce: 2},
+	}, kafkaStats)
+	require.Len(t, postgresStats, 1)
+	selects := postgresStats[postgres.NewKey(client, pgServer, 40001, 5432, "SELECT")]
+	require.NotNil(t, selects)
+	assert.Equal(t, 1, selects.Count)
+
+	// the protocol of closed connections is kept until the tracer reports them
+	m.CloseConnection(client, kafkaServer, 40000, 9092)
+	assert.Equal(t, Kafka, m.Protocol(client, kafkaServer, 40000, 9092))
+	m.Forget(kafkaServer, client, 9092, 40000)
+	assert.Equal(t, Unknown, m.Protocol(client, kafkaServer, 40000, 9092))
+
+	// or until they were closed for a whole collection interval
+	m.CloseConnection(client, pgServer, 40001, 5432)
+	m.GetAndResetAllStats()
+	assert.Equal(t, Postgres, m.Protocol(client, pgServer, 40001, 5432))
+	m.GetAndResetAllStats()
+	assert.Equal(t, Unknown, m.Protocol(client, pgServer, 40001, 5432))
+}
+
+func TestMonitorDataLost(t *testing.T) {
+	client, server := util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2")
+	m := NewMonitor(DefaultClassifiers(), 10, 10)
+
+	// the beginning of the connection is lost
+	assert.False(t, m.OnDataLost(client, server, 40000, 9092, true, 10))
+
+	// only the beginning of the request is captured
+	request := kafkaProduce("orders")
+	assert.True(t, m.OnData(client, server, 40001, 9092, true, request[:33], 1))
+	assert.True(t, m.OnDataLost(client, server, 40001, 9092, true, len(request)-33))
+	assert.True(t, m.OnData(client, server, 40001, 9092, true, request, 2))
+
+	kafkaStats, _ := m.GetAndResetAllStats()
+	assert.Equal(t, map[kafka.Key]kafka.RequestStats{
+		kafka.NewKey(client, server, 40001, 9092, "orders"): {Produce: 2},
+	}, kafkaStats)
+}
+
+func TestMonitorEviction(t *testing.T) {
+	client, server := util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2")
+	m := NewMonitor(DefaultClassifiers(), 2, 10)
+
+	assert.False(t, m.OnData(client, server, 40000, 80, true, []byte("GET / HTTP/1.1\r\n\r\n"), 1))
+	assert.True(t, m.OnData(client, server, 40001, 9092, true, kafkaProduce("orders"), 2))
+
+	// the HTTP connection can't be evicted until its protocol is reported
+	assert.False(t, m.OnData(client, server, 40002, 80, true, []byte("GET / HTTP/1.1\r\n\r\n"), 3))
+	assert.Equal(t, Unknown, m.Protocol(client, server, 40002, 80))
+	assert.Equal(t, HTTP, m.Protocol(client, server, 40000, 80))
+
+	// the connections still decoded are kept
+	assert.False(t, m.OnData(client, server, 40002, 80, true, []byte("GET / HTTP/1.1\r\n\r\n"), 4))
+	assert.Equal(t, HTTP, m.Protocol(client, server, 40002, 80))
+	assert.Equal(t, Unknown, m.Protocol(client, server, 40000, 80))
+	assert.Equal(t, Kafka, m.Protocol(client, server, 40001, 9092))
+
+	assert.True(t, m.OnData(client, server, 40001, 9092, true, kafkaProduce("orders"), 5))
+	kafkaStats, _ := m.GetAndResetAllStats()
+	assert.Equal(t, map[kafka.Key]kafka.RequestStats{
+		kafka.NewKey(client, server, 40001, 9092, "orders"): {Produce: 2},
+	}, kafkaStats)
+}
+
+func TestNilMonitor(t *testing.T) {
+	var m *Monitor
+	m.OnData(util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2"), 1, 2, true, []byte("GET /"), 1)
+	assert.Equal(t, Unknown, m.Protocol(util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2"), 1, 2))
+	kafkaStats, postgresStats := m.GetAndResetAllStats()
+	assert.Nil(t, kafkaStats)
+	assert.Nil(t, postgresStats)
+}
@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// maxPendingQueries bounds the number of queries awaiting a response on a connection
+const maxPendingQueries = 64
+
+// Query is a query which received a response from the server
+type Query struct {
+	QueryType string
+	// Latency is the time, in nanoseconds, between the query and its completion
+	Latency uint64
+	Error   bool
+}
+
+type pendingQuery struct {
+	queryType string
+	started   uint64
+}
+
+// stream tracks message boundaries on one direction of a connection
+type stream struct {
+	// remaining is the number of bytes of the current message not seen yet
+	remaining int
+	// partial holds the beginning of a message too short to be parsed
+	partial []byte
+	// desynced is set once message boundaries are lost with uncaptured bytes. Messages are
+	// usually written at the beginning of segments, so decoding resumes with the next
+	// buffer starting with a message.
+	desynced bool
+}
+
+// Decoder matches the queries sent on a PostgreSQL connection with their completion.
+// It supports both the simple and extended query protocols and stops decoding
+// connections upgraded to TLS.
+type Decoder struct {
+	client stream
+	server stream
+
+	sslRequested bool
+	lastParsed   string
+	pending      []pendingQuery
+	broken       bool
+}
+
+// OnClientData processes bytes sent by the client at the given time in nanoseconds
+func (d *Decoder) OnClientData(data []byte, ts uint64) {
+	if d.broken {
+		return
+	}
+
+	d.walk(&d.client, data, true, func(msgType byte, body []byte) {
+		switch msgType {
+		case 0:
+			// untyped startup, SSL or cancel request
+			if len(body) >= 4 {
+				switch binary.BigEndian.Uint32(body) {
+				case sslRequestCode, gssEncRequestCode:
+					d.sslRequested = true
+				}
+			}
+		case queryMessage:
+			d.push(QueryType(body), ts)
+		case parseMessage:
+			// the statement name precedes the query
+			if i := bytes.IndexByte(body, 0); i >= 0 {
+				d.lastParsed = QueryType(body[i+1:])
+			}
+		case executeMessage:
+			queryType := d.lastParsed
+			if queryType == "" {
+				queryType = defaultQueryType
+			}
+			d.push(queryType, ts)
+		}
+	})
+}
+
+// OnServerData processes bytes sent by the server at the given time in nanoseconds
+// and returns the queries completed by them
+func (d *Decoder) OnServerData(data []byte, ts uint64) []Query {
+	if d.broken {
+		return nil
+	}
+
+	if d.sslRequested && len(data) > 0 && d.server.remaining == 0 && len(d.server.partial) == 0 {
+		d.sslRequested = false
+		switch data[0] {
+		case sslAccepted:
+			// the rest of the connection is encrypted
+			d.broken = true
+			d.pending = nil
+			return nil
+		case sslRejected:
+			data = data[1:]
+		}
+	}
+
+	var completed []Query
+	d.walk(&d.server, data, false, func(msgType byte, _ []byte) {
+		switch msgType {
+		case commandComplete, emptyQueryResponse, errorResponse:
+			if len(d.pending) == 0 {
+				return
+			}
+			query := d.pending[0]
+			d.pending = d.pending[1:]
+
+			var latency uint64
+			if ts > query.started {
+				latency = ts - query.started
+			}
+			completed = append(completed, Query{
+				QueryType: query.queryType,
+				Latency:   latency,
+				Error:     msgType == errorResponse,
+			})
+		case readyForQuery:
+			// the queries of the batch which didn't complete were skipped by the server
+			d.pending = nil
+		}
+	})
+	return completed
+}
+
+// OnClientDataLost accounts for n bytes sent by the client which weren't captured
+func (d *Decoder) OnClientDataLost(n int) {
+	d.lose(&d.client, n)
+}
+
+// OnServerDataLost accounts for n bytes sent by the server which weren't captured
+func (d *Decoder) OnServerDataLost(n int) {
+	if d.lose(&d.server, n) {
+		// the completion of pending queries may have been lost
+		d.pending = nil
+	}
+}
+
+// Broken returns whether the decoder stopped decoding the connection
+func (d *Decoder) Broken() bool {
+	return d.broken
+}
+
+// lose skips n bytes which weren't captured, and returns whether message boundaries were lost.
+// Skipping bytes is only possible within the body of a message whose header was decoded.
+func (d *Decoder) lose(s *stream, n int) bool {
+	if d.broken || s.desynced {
+		return false
+	}
+
+	if len(s.partial) == 0 && n <= s.remaining {
+		s.remaining -= n
+		return false
+	}
+
+	s.desynced = true
+	s.remaining = 0
+	s.partial = nil
+	return true
+}
+
+// isMessageStart returns whether data plausibly starts with a typed message.
+// Message types are letters, except for the completions of the extended query protocol steps.
+func isMessageStart(data []byte) bool {
+	if len(data) < messageHeaderLength || !(isLetter(data[0]) || (data[0] >= '1' && data[0] <= '3')) {
+		return false
+	}
+	length := int(binary.BigEndian.Uint32(data[1:]))
+	return length >= 4 && length <= maxMessageLength
+}
+
+func (d *Decoder) push(queryType string, ts uint64) {
+	if len(d.pending) >= maxPendingQueries {
+		return
+	}
+	d.pending = append(d.pending, pendingQuery{queryType: queryType, started: ts})
+}
+
+// walk calls handle with the type and the available part of the body of each message
+// starting in data. Untyped messages, which are only sent by clients, are reported with a 0 type.
+func (d *Decoder) walk(s *stream, data []byte, fromClient bool, handle func(msgType byte, body []byte)) {
+	if s.desynced {
+		if !isMessageStart(data) {
+			return
+		}
+		s.desynced = false
+	}
+
+	for len(data) > 0 {
+		if s.remaining > 0 {
+			n := s.remaining
+			if n > len(data) {
+				n = len(data)
+			}
+			s.remaining -= n
+			data = data[n:]
+			continue
+		}
+
+		if len(s.partial) > 0 {
+			data = append(s.partial, data...)
+			s.partial = nil
+		}
+
+		var msgType byte
+		header := messageHeaderLength
+		// message types are letters, while the length of untyped messages starts with a 0 byte
+		if fromClient && data[0] == 0 {
+			header = 4
+		} else {
+			msgType = data[0]
+		}
+
+		if len(data) < header {
+			s.partial = append([]byte(nil), data...)
+			return
+		}
+
+		length := int(binary.BigEndian.Uint32(data[header-4:]))
+		if length < 4 || length > maxMessageLength {
+			// message boundaries are lost, stop decoding the connection
+			d.broken = true
+			d.pending = nil
+			return
+		}
+
+		size := header - 4 + length
+		body := data[header:]
+		if size <= len(data) {
+			body = data[header:size]
+		}
+		handle(msgType, body)
+
+		if size > len(data) {
+			s.remaining = size - len(data)
+			return
+		}
+		data = data[size:]
+	}
+}
@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func message(msgType byte, body string) []byte {
+	out := []byte{msgType, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(out[1:], uint32(4+len(body)))
+	return append(out, body...)
+}
+
+func untypedMessage(code uint32, body string) []byte {
+	out := make([]byte, 8)
+	binary.BigEndian.PutUint32(out, uint32(8+len(body)))
+	binary.BigEndian.PutUint32(out[4:], code)
+	return append(out, body...)
+}
+
+func concat(messages ...[]byte) []byte {
+	var out []byte
+	for _, m := range messages {
+		out = append(out, m...)
+	}
+	return out
+}
+
+func TestQueryType(t *testing.T) {
+	assert.Equal(t, "SELECT", QueryType([]byte("select * from users")))
+	assert.Equal(t, "INSERT", QueryType([]byte("\n  INSERT INTO users VALUES ($1)")))
+	assert.Equal(t, "SELECT", QueryType([]byte("(SELECT 1) UNION (SELECT 2)")))
+	assert.Equal(t, "OTHER", QueryType([]byte("VACUUM users")))
+	assert.Equal(t, "OTHER", QueryType([]byte("")))
+}
+
+func TestIsPostgres(t *testing.T) {
+	assert.True(t, IsPostgres(untypedMessage(protocolVersion3, "user\x00postgres\x00\x00")))
+	assert.True(t, IsPostgres(untypedMessage(sslRequestCode, "")))
+	assert.True(t, IsPostgres(message(queryMessage, "SELECT 1\x00")))
+	assert.False(t, IsPostgres(message(queryMessage, "not a query\x00")))
+	assert.False(t, IsPostgres([]byte("GET / HTTP/1.1\r\n\r\n")))
+	assert.False(t, IsPostgres(untypedMessage(12345, "")))
+}
+
+func TestDecoderSimpleQueries(t *testing.T) {
+	var d Decoder
+
+	d.OnClientData(untypedMessage(protocolVersion3, "user\x00postgres\x00\x00"), 10)
+	assert.Empty(t, d.OnServerData(concat(message('R', "\x00\x00\x00\x00"), message(readyForQuery, "I")), 20))
+
+	d.OnClientData(message(queryMessage, "SELECT * FROM users\x00"), 100)
+	queries := d.OnServerData(concat(
+		message('T', "row description"),
+		message('D', "data row"),
+		message(commandComplete, "SELECT 1\x00"),
+		message(readyForQuery, "I"),
+	), 350)
+	require.Len(t, queries, 1)
+	assert.Equal(t, Query{QueryType: "SELECT", Latency: 250}, queries[0])
+
+	d.OnClientData(message(queryMessage, "DELETE FROM nope\x00"), 400)
+	queries = d.OnServerData(concat(message(errorResponse, "SERROR\x00\x00"), message(readyForQuery, "I")), 500)
+	require.Len(t, queries, 1)
+	assert.Equal(t, Query{QueryType: "DELETE", Latency: 100, Error: true}, queries[0])
+}
+
+func TestDecoderExtendedQueries(t *testing.T) {
+	var d Decoder
+
+	d.OnClientData(concat(
+		message(parseMessage, "\x00UPDATE users SET name = $1\x00\x00\x00"),
+		message('B', "bind"),
+		message(executeMessage, "\x00\x00\x00\x00\x00"),
+		message('S', ""),
+	), 1000)
+
+	// the response is split in the middle of a message header
+	response := concat(
+		message('1', ""),
+		message('2', ""),
+		message(commandComplete, "UPDATE 1\x00"),
+		message(readyForQuery, "I"),
+	)
+	assert.Empty(t, d.OnServerData(response[:12], 1500))
+	queries := d.OnServerData(response[12:], 2000)
+	require.Len(t, queries, 1)
+	assert.Equal(t, Query{QueryType: "UPDATE", Latency: 1000}, queries[0])
+}
+
+func TestDecoderSSL(t *testing.T) {
+	var d Decoder
+	d.OnClientData(untypedMessage(sslRequestCode, ""), 1)
+	d.OnServerData([]byte{sslAccepted}, 2)
+	d.OnClientData(message(queryMessage, "SELECT 1\x00"), 3)
+	assert.Empty(t, d.OnServerData(message(commandComplete, "SELECT 1\x00"), 4))
+
+	var plain Decoder
+	plain.OnClientData(untypedMessage(sslRequestCode, ""), 1)
+	plain.OnServerData([]byte{sslRejected}, 2)
+	plain.OnClientData(message(queryMessage, "SELECT 1\x00"), 3)
+	assert.Len(t, plain.OnServerData(message(commandComplete, "SELECT 1\x00"), 4), 1)
+}
+
+func TestDecoderDataLost(t *testing.T) {
+	var d Decoder
+
+	// only the beginning of the query and of its response are captured
+	query := message(queryMessage, "SELECT * FROM users WHERE name = 'a very long name'\x00")
+	d.OnClientData(query[:20], 100)
+	d.OnClientDataLost(len(query) - 20)
+
+	response := concat(message('D', "a large data row"), message(commandComplete, "SELECT 1\x00"), message(readyForQuery, "I"))
+	assert.Empty(t, d.OnServerData(response[:10], 200))
+	d.OnServerDataLost(len(response) - 10)
+	assert.False(t, d.Broken())
+
+	// message boundaries were lost, decoding resumes with the next message
+	d.OnClientData(message(queryMessage, "INSERT INTO users VALUES ('b')\x00"), 300)
+	assert.Empty(t, d.OnServerData([]byte("\x00 end of a data row"), 350))
+	queries := d.OnServerData(concat(message(commandComplete, "INSERT 0 1\x00"), message(readyForQuery, "I")), 400)
+	require.Len(t, queries, 1)
+	assert.Equal(t, Query{QueryType: "INSERT", Latency: 100}, queries[0])
+}
+
+func TestStatKeeper(t *testing.T) {
+	saddr, daddr := util.AddressFromString("1.1.1.1"), util.AddressFromString("2.2.2.2")
+	keeper := NewStatKeeper(10)
+	keeper.Add(saddr, daddr, 1234, 5432, Query{QueryType: "SELECT", Latency: 100})
+	keeper.Add(saddr, daddr, 1234, 5432, Query{QueryType: "SELECT", Latency: 300, Error: true})
+	keeper.Add(saddr, daddr, 1234, 5432, Query{QueryType: "INSERT", Latency: 50})
+
+	stats, dropped := keeper.GetAndResetAllStats()
+	assert.Zero(t, dropped)
+	require.Len(t, stats, 2)
+
+	selects := stats[NewKey(saddr, daddr, 1234, 5432, "SELECT")]
+	require.NotNil(t, selects)
+	assert.Equal(t, 2, selects.Count)
+	assert.Equal(t, 1, selects.Errors)
+	assert.Equal(t, 2.0, selects.Latencies.GetCount())
+
+	inserts := stats[NewKey(saddr, daddr, 1234, 5432, "INSERT")]
+	inserts.CombineWith(selects)
+	assert.Equal(t, 3, inserts.Count)
+	assert.Equal(t, 3.0, inserts.Latencies.GetCount())
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+)
+
+const (
+	// protocolVersion3 is the version sent in startup messages by all the supported clients
+	protocolVersion3 = 196608
+	// sslRequestCode, gssEncRequestCode and cancelRequestCode replace the protocol version
+	// in the corresponding untyped messages
+	sslRequestCode    = 80877103
+	gssEncRequestCode = 80877104
+	cancelRequestCode = 80877102
+
+	// maxStartupLength is the limit enforced by servers on startup packets
+	maxStartupLength = 10000
+	// maxMessageLength bounds the size of regular messages considered valid
+	maxMessageLength = 1 << 30
+
+	// messageHeaderLength is the size of the type and length of regular messages
+	messageHeaderLength = 5
+
+	queryMessage         = 'Q'
+	parseMessage         = 'P'
+	executeMessage       = 'E'
+	terminateMessage     = 'X'
+	commandComplete      = 'C'
+	errorResponse        = 'E'
+	emptyQueryResponse   = 'I'
+	readyForQuery        = 'Z'
+	sslAccepted          = 'S'
+	sslRejected          = 'N'
+	defaultQueryType     = "OTHER"
+	maxQueryKeywordBytes = 16
+)
+
+// queryTypes are the leading keywords used to group queries
+var queryTypes = map[string]struct{}{
+	"SELECT":   {},
+	"INSERT":   {},
+	"UPDATE":   {},
+	"DELETE":   {},
+	"BEGIN":    {},
+	"COMMIT":   {},
+	"ROLLBACK": {},
+	"CREATE":   {},
+	"DROP":     {},
+	"ALTER":    {},
+	"TRUNCATE": {},
+	"WITH":     {},
+	"COPY":     {},
+	"SET":      {},
+	"SHOW":     {},
+}
+
+// IsPostgres returns whether the payload starts with a message sent by a PostgreSQL client
+func IsPostgres(payload []byte) bool {
+	if isStartupMessage(payload) {
+		return true
+	}
+
+	if len(payload) < messageHeaderLength+1 {
+		return false
+	}
+
+	// connections established before the capture started are recognized by their queries
+	length := int(binary.BigEndian.Uint32(payload[1:]))
+	if payload[0] != queryMessage || length < 5 || length > maxMessageLength {
+		return false
+	}
+
+	query := payload[messageHeaderLength:]
+	if len(query) > length-4 {
+		query = query[:length-4]
+	}
+	if i := bytes.IndexByte(query, 0); i >= 0 {
+		if i != length-5 {
+			return false
+		}
+		query = query[:i]
+	}
+	return QueryType(query) != defaultQueryType
+}
+
+func isStartupMessage(payload []byte) bool {
+	if len(payload) < 8 {
+		return false
+	}
+
+	length := binary.BigEndian.Uint32(payload)
+	if length < 8 || length > maxStartupLength {
+		return false
+	}
+
+	switch binary.BigEndian.Uint32(payload[4:]) {
+	case protocolVersion3, sslRequestCode, gssEncRequestCode, cancelRequestCode:
+		return true
+	}
+	return false
+}
+
+// QueryType returns the upper-cased leading keyword of a query, or OTHER when
+// it isn't one of the keywords queries are grouped by
+func QueryType(query []byte) string {
+	query = bytes.TrimLeft(query, " \t\r\n(")
+
+	end := 0
+	for end < len(query) && end < maxQueryKeywordBytes && isLetter(query[end]) {
+		end++
+	}
+
+	keyword := strings.ToUpper(string(query[:end]))
+	if _, ok := queryTypes[keyword]; !ok {
+		return defaultQueryType
+	}
+	return keyword
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/sketches-go/ddsketch"
+)
+
+// RelativeAccuracy defines the acceptable error in quantile values calculated by DDSketch.
+// For example, if the actual value at p50 is 100, with a relative accuracy of 0.01 the value calculated
+// will be between 99 and 101
+const RelativeAccuracy = 0.01
+
+// Key is an identifier for a group of PostgreSQL queries
+type Key struct {
+	SrcIPHigh uint64
+	SrcIPLow  uint64
+	SrcPort   uint16
+
+	DstIPHigh uint64
+	DstIPLow  uint64
+	DstPort   uint16
+
+	QueryType string
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, queryType string) Key {
+	saddrl, saddrh := util.ToLowHigh(saddr)
+	daddrl, daddrh := util.ToLowHigh(daddr)
+	return Key{
+		SrcIPHigh: saddrh,
+		SrcIPLow:  saddrl,
+		SrcPort:   sport,
+		DstIPHigh: daddrh,
+		DstIPLow:  daddrl,
+		DstPort:   dport,
+		QueryType: queryType,
+	}
+}
+
+// RequestStats stores the count and latencies of the queries of a given type
+type RequestStats struct {
+	Count  int
+	Errors int
+	// Latencies is a sketch of the latencies of the queries, in nanoseconds
+	Latencies *ddsketch.DDSketch
+}
+
+// AddQuery records a completed query
+func (r *RequestStats) AddQuery(q Query) {
+	r.Count++
+	if q.Error {
+		r.Errors++
+	}
+
+	if r.Latencies == nil {
+		var err error
+		if r.Latencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy); err != nil {
+			log.Debugf("could not create new ddsketch for postgres stats: %v", err)
+			return
+		}
+	}
+
+	if err := r.Latencies.Add(float64(q.Latency)); err != nil {
+		log.Debugf("could not add postgres query latency to ddsketch: %v", err)
+	}
+}
+
+// CombineWith merges the data in 2 RequestStats objects
+func (r *RequestStats) CombineWith(other *RequestStats) {
+	r.Count += other.Count
+	r.Errors += other.Errors
+
+	if other.Latencies == nil {
+		return
+	}
+	if r.Latencies == nil {
+		r.Latencies = other.Latencies.Copy()
+		return
+	}
+	if err := r.Latencies.MergeWith(other.Latencies); err != nil {
+		log.Debugf("error merging postgres stats: %v", err)
+	}
+}
+
+// StatKeeper aggregates PostgreSQL queries by connection and query type
+type StatKeeper struct {
+	stats      map[Key]*RequestStats
+	maxEntries int
+	dropped    int
+}
+
+// NewStatKeeper returns a new StatKeeper
+func NewStatKeeper(maxEntries int) *StatKeeper {
+	return &StatKeeper{
+		stats:      make(map[Key]*RequestStats),
+		maxEntries: maxEntries,
+	}
+}
+
+// Add records a query completed on the given connection
+func (s *StatKeeper) Add(saddr, daddr util.Address, sport, dport uint16, q Query) {
+	key := NewKey(saddr, daddr, sport, dport, q.QueryType)
+	stats, ok := s.stats[key]
+	if !ok {
+		if len(s.stats) >= s.maxEntries {
+			s.dropped++
+			return
+		}
+		stats = &RequestStats{}
+		s.stats[key] = stats
+	}
+	stats.AddQuery(q)
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call along with
+// the number of queries dropped because the keeper was full
+func (s *StatKeeper) GetAndResetAllStats() (map[Key]*RequestStats, int) {
+	ret, dropped := s.stats, s.dropped
+	s.stats = make(map[Key]*RequestStats)
+	s.dropped = 0
+	return ret, dropped
+}
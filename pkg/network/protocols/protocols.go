@@ -0,0 +1,110 @@
+package protocols
+
+import (
+	"bytes"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+)
+
+// ProtocolType is the application protocol spoken over a connection
+type ProtocolType uint8
+
+const (
+	// Unknown is used for connections that haven't been, or couldn't be, classified
+	Unknown ProtocolType = iota
+	// HTTP is used for HTTP/1.x connections
+	HTTP
+	// HTTP2 is used for HTTP/2 connections, including gRPC
+	HTTP2
+	// Kafka is used for connections to Kafka brokers
+	Kafka
+	// Postgres is used for connections to PostgreSQL servers
+	Postgres
+)
+
+func (p ProtocolType) String() string {
+	switch p {
+	case HTTP:
+		return "http"
+	case HTTP2:
+		return "http2"
+	case Kafka:
+		return "kafka"
+	case Postgres:
+		return "postgres"
+	default:
+		return "unknown"
+	}
+}
+
+// Classifier recognizes a protocol from the first payload sent by the client of a connection
+type Classifier interface {
+	Protocol() ProtocolType
+	Match(payload []byte) bool
+}
+
+type classifierFunc struct {
+	protocol ProtocolType
+	match    func([]byte) bool
+}
+
+func (c classifierFunc) Protocol() ProtocolType {
+	return c.protocol
+}
+
+func (c classifierFunc) Match(payload []byte) bool {
+	return c.match(payload)
+}
+
+// NewClassifier returns a Classifier recognizing the given protocol with the match function
+func NewClassifier(protocol ProtocolType, match func(payload []byte) bool) Classifier {
+	return classifierFunc{protocol: protocol, match: match}
+}
+
+// DefaultClassifiers returns the classifiers used by the tracer, ordered from the
+// most to the least specific: the first classifier matching a payload wins.
+func DefaultClassifiers() []Classifier {
+	return []Classifier{
+		NewClassifier(HTTP2, isHTTP2),
+		NewClassifier(HTTP, isHTTP),
+		NewClassifier(Postgres, postgres.IsPostgres),
+		NewClassifier(Kafka, kafka.IsKafka),
+	}
+}
+
+// Classify returns the protocol of the first classifier matching the payload
+func Classify(classifiers []Classifier, payload []byte) ProtocolType {
+	for _, c := range classifiers {
+		if c.Match(payload) {
+			return c.Protocol()
+		}
+	}
+	return Unknown
+}
+
+var (
+	http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+	httpMethods  = [][]byte{
+		[]byte("GET "),
+		[]byte("POST "),
+		[]byte("PUT "),
+		[]byte("DELETE "),
+		[]byte("HEAD "),
+		[]byte("OPTIONS "),
+		[]byte("PATCH "),
+	}
+)
+
+func isHTTP2(payload []byte) bool {
+	return bytes.HasPrefix(payload, http2Preface)
+}
+
+func isHTTP(payload []byte) bool {
+	for _, method := range httpMethods {
+		if bytes.HasPrefix(payload, method) {
+			return true
+		}
+	}
+	return false
+}
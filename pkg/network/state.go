@@ -6,6 +6,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"go4.org/intern"
@@ -39,6 +41,8 @@ type State interface {
 		active []ConnectionStats,
 		dns dns.StatsByKeyByNameByType,
 		http map[http.Key]http.RequestStats,
+		kafka map[kafka.Key]kafka.RequestStats,
+		postgres map[postgres.Key]*postgres.RequestStats,
 	) Delta
 
 	// RemoveClient stops tracking stateful data for a given client
@@ -64,6 +68,8 @@ type State interface {
 type Delta struct {
 	BufferedData
 	HTTP     map[http.Key]http.RequestStats
+	Kafka    map[kafka.Key]kafka.RequestStats
+	Postgres map[postgres.Key]*postgres.RequestStats
 	DNSStats dns.StatsByKeyByNameByType
 }
 
@@ -74,6 +80,8 @@ type telemetry struct {
 	timeSyncCollisions int64
 	dnsStatsDropped    int64
 	httpStatsDropped   int64
+	kafkaStatsDropped  int64
+	pgStatsDropped     int64
 	dnsPidCollisions   int64
 }
 
@@ -96,8 +104,10 @@ type client struct {
 	closedConnections     []ConnectionStats
 	stats                 map[string]*stats
 	// maps by dns key the domain (string) to stats structure
-	dnsStats       dns.StatsByKeyByNameByType
-	httpStatsDelta map[http.Key]http.RequestStats
+	dnsStats           dns.StatsByKeyByNameByType
+	httpStatsDelta     map[http.Key]http.RequestStats
+	kafkaStatsDelta    map[kafka.Key]kafka.RequestStats
+	postgresStatsDelta map[postgres.Key]*postgres.RequestStats
 }
 
 func (c *client) Reset(active map[string]*ConnectionStats) {
@@ -110,6 +120,8 @@ func (c *client) Reset(active map[string]*ConnectionStats) {
 	c.closedConnectionsKeys = make(map[string]int)
 	c.dnsStats = make(dns.StatsByKeyByNameByType)
 	c.httpStatsDelta = make(map[http.Key]http.RequestStats)
+	c.kafkaStatsDelta = make(map[kafka.Key]kafka.RequestStats)
+	c.postgresStatsDelta = make(map[postgres.Key]*postgres.RequestStats)
 
 	// XXX: we should change the way we clean this map once
 	// https://github.com/golang/go/issues/20135 is solved
@@ -176,6 +188,8 @@ func (ns *networkState) GetDelta(
 	active []ConnectionStats,
 	dnsStats dns.StatsByKeyByNameByType,
 	httpStats map[http.Key]http.RequestStats,
+	kafkaStats map[kafka.Key]kafka.RequestStats,
+	postgresStats map[postgres.Key]*postgres.RequestStats,
 ) Delta {
 	ns.Lock()
 	defer ns.Unlock()
@@ -216,6 +230,12 @@ func (ns *networkState) GetDelta(
 	if len(httpStats) > 0 {
 		ns.storeHTTPStats(httpStats)
 	}
+	if len(kafkaStats) > 0 {
+		ns.storeKafkaStats(kafkaStats)
+	}
+	if len(postgresStats) > 0 {
+		ns.storePostgresStats(postgresStats)
+	}
 
 	return Delta{
 		BufferedData: BufferedData{
@@ -223,6 +243,8 @@ func (ns *networkState) GetDelta(
 			buffer: clientBuffer,
 		},
 		HTTP:     client.httpStatsDelta,
+		Kafka:    client.kafkaStatsDelta,
+		Postgres: client.postgresStatsDelta,
 		DNSStats: client.dnsStats,
 	}
 }
@@ -322,6 +344,7 @@ func (ns *networkState) storeDNSStats(stats dns.StatsByKeyByNameByType) {
 						prev.Timeouts += dnsStats.Timeouts
 						prev.SuccessLatencySum += dnsStats.SuccessLatencySum
 						prev.FailureLatencySum += dnsStats.FailureLatencySum
+						prev.Truncated += dnsStats.Truncated
 						for rcode, count := range dnsStats.CountByRcode {
 							prev.CountByRcode[rcode] += count
 						}
@@ -356,17 +379,55 @@ func (ns *networkState) storeHTTPStats(allStats map[http.Key]http.RequestStats)
 	}
 }
 
+// storeKafkaStats stores latest Kafka stats for all clients
+func (ns *networkState) storeKafkaStats(allStats map[kafka.Key]kafka.RequestStats) {
+	for key, stats := range allStats {
+		for _, client := range ns.clients {
+			prevStats, ok := client.kafkaStatsDelta[key]
+			if !ok && len(client.kafkaStatsDelta) >= ns.maxHTTPStats {
+				ns.telemetry.kafkaStatsDropped++
+				continue
+			}
+
+			prevStats.CombineWith(stats)
+			client.kafkaStatsDelta[key] = prevStats
+		}
+	}
+}
+
+// storePostgresStats stores latest PostgreSQL stats for all clients
+func (ns *networkState) storePostgresStats(allStats map[postgres.Key]*postgres.RequestStats) {
+	for key, stats := range allStats {
+		for _, client := range ns.clients {
+			prevStats, ok := client.postgresStatsDelta[key]
+			if !ok {
+				if len(client.postgresStatsDelta) >= ns.maxHTTPStats {
+					ns.telemetry.pgStatsDropped++
+					continue
+				}
+				// the sketch of the stats is copied, as it is merged with the next stats of each client
+				prevStats = new(postgres.RequestStats)
+				client.postgresStatsDelta[key] = prevStats
+			}
+
+			prevStats.CombineWith(stats)
+		}
+	}
+}
+
 func (ns *networkState) getClient(clientID string) (*client, bool) {
 	if c, ok := ns.clients[clientID]; ok {
 		return c, true
 	}
 
 	c := &client{
-		lastFetch:         time.Now(),
-		stats:             map[string]*stats{},
-		closedConnections: make([]ConnectionStats, 0, minClosedCapacity),
-		dnsStats:          dns.StatsByKeyByNameByType{},
-		httpStatsDelta:    map[http.Key]http.RequestStats{},
+		lastFetch:          time.Now(),
+		stats:              map[string]*stats{},
+		closedConnections:  make([]ConnectionStats, 0, minClosedCapacity),
+		dnsStats:           dns.StatsByKeyByNameByType{},
+		httpStatsDelta:     map[http.Key]http.RequestStats{},
+		kafkaStatsDelta:    map[kafka.Key]kafka.RequestStats{},
+		postgresStatsDelta: map[postgres.Key]*postgres.RequestStats{},
 	}
 	ns.clients[clientID] = c
 	return c, false
@@ -564,6 +625,8 @@ func (ns *networkState) RemoveConnections(keys []string) {
 		s += " [%d closed connections dropped]"
 		s += " [%d dns stats dropped]"
 		s += " [%d HTTP stats dropped]"
+		s += " [%d Kafka stats dropped]"
+		s += " [%d PostgreSQL stats dropped]"
 		s += " [%d DNS pid collisions]"
 		s += " [%d time sync collisions]"
 		log.Warnf(s,
@@ -572,6 +635,8 @@ func (ns *networkState) RemoveConnections(keys []string) {
 			ns.telemetry.closedConnDropped,
 			ns.telemetry.dnsStatsDropped,
 			ns.telemetry.httpStatsDropped,
+			ns.telemetry.kafkaStatsDropped,
+			ns.telemetry.pgStatsDropped,
 			ns.telemetry.dnsPidCollisions,
 			ns.telemetry.timeSyncCollisions)
 	}
@@ -602,6 +667,8 @@ func (ns *networkState) GetStats() map[string]interface{} {
 			"time_sync_collisions": ns.telemetry.timeSyncCollisions,
 			"dns_stats_dropped":    ns.telemetry.dnsStatsDropped,
 			"http_stats_dropped":   ns.telemetry.httpStatsDropped,
+			"kafka_stats_dropped":  ns.telemetry.kafkaStatsDropped,
+			"pg_stats_dropped":     ns.telemetry.pgStatsDropped,
 			"dns_pid_collisions":   ns.telemetry.dnsPidCollisions,
 		},
 		"current_time":       time.Now().Unix(),
@@ -12,6 +12,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/network/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"go4.org/intern"
 
@@ -80,7 +82,7 @@ func BenchmarkConnectionsGet(b *testing.B) {
 			ns := newDefaultState()
 
 			// Initial fetch to set up client
-			ns.GetDelta(DEBUGCLIENT, latestTime, nil, nil, nil)
+			ns.GetDelta(DEBUGCLIENT, latestTime, nil, nil, nil, nil, nil)
 
 			for _, c := range closed[:bench.closedCount] {
 				ns.StoreClosedConnections([]ConnectionStats{c})
@@ -90,7 +92,7 @@ func BenchmarkConnectionsGet(b *testing.B) {
 			b.ReportAllocs()
 
 			for n := 0; n < b.N; n++ {
-				ns.GetDelta(DEBUGCLIENT, latestTime, conns[:bench.connCount], nil, nil)
+				ns.GetDelta(DEBUGCLIENT, latestTime, conns[:bench.connCount], nil, nil, nil, nil)
 			}
 		})
 	}
@@ -120,10 +122,10 @@ func TestRemoveConnections(t *testing.T) {
 
 	clientID := "1"
 	state := newDefaultState().(*networkState)
-	conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+	conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 	assert.Equal(t, 0, len(conns))
 
-	conns = state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns = state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, conn, conns[0])
 
@@ -157,7 +159,7 @@ func TestRetrieveClosedConnection(t *testing.T) {
 	t.Run("without prior registration", func(t *testing.T) {
 		state := newDefaultState()
 		state.StoreClosedConnections([]ConnectionStats{conn})
-		conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 
 		assert.Equal(t, 0, len(conns))
 	})
@@ -165,21 +167,21 @@ func TestRetrieveClosedConnection(t *testing.T) {
 	t.Run("with registration", func(t *testing.T) {
 		state := newDefaultState()
 
-		conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		state.StoreClosedConnections([]ConnectionStats{conn})
 
-		conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, conn, conns[0])
 
 		// An other client that is not registered should not have the closed connection
-		conns = state.GetDelta("2", latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta("2", latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// It should no more have connections stored
-		conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 	})
 }
@@ -191,7 +193,7 @@ func TestCleanupClient(t *testing.T) {
 	clients := state.(*networkState).getClients()
 	assert.Equal(t, 0, len(clients))
 
-	conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+	conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 	assert.Equal(t, 0, len(conns))
 
 	// Should be a no op
@@ -241,15 +243,15 @@ func TestLastStats(t *testing.T) {
 	conn3.MonotonicRetransmits += dRetransmits
 
 	// First get, we should not have any connections stored
-	conns := state.GetDelta(client1, latestEpochTime(), nil, nil, nil).Conns
+	conns := state.GetDelta(client1, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 	assert.Equal(t, 0, len(conns))
 
 	// Same for an other client
-	conns = state.GetDelta(client2, latestEpochTime(), nil, nil, nil).Conns
+	conns = state.GetDelta(client2, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 	assert.Equal(t, 0, len(conns))
 
 	// We should have only one connection but with last stats equal to monotonic
-	conns = state.GetDelta(client1, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns = state.GetDelta(client1, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, conn.MonotonicSentBytes, conns[0].LastSentBytes)
 	assert.Equal(t, conn.MonotonicRecvBytes, conns[0].LastRecvBytes)
@@ -259,7 +261,7 @@ func TestLastStats(t *testing.T) {
 	assert.Equal(t, conn.MonotonicRetransmits, conns[0].MonotonicRetransmits)
 
 	// This client didn't collect the first connection so last stats = monotonic
-	conns = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{conn2}, nil, nil).Conns
+	conns = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{conn2}, nil, nil, nil, nil).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, conn2.MonotonicSentBytes, conns[0].LastSentBytes)
 	assert.Equal(t, conn2.MonotonicRecvBytes, conns[0].LastRecvBytes)
@@ -269,7 +271,7 @@ func TestLastStats(t *testing.T) {
 	assert.Equal(t, conn2.MonotonicRetransmits, conns[0].MonotonicRetransmits)
 
 	// client 1 should have conn3 - conn1 since it did not collected conn2
-	conns = state.GetDelta(client1, latestEpochTime(), []ConnectionStats{conn3}, nil, nil).Conns
+	conns = state.GetDelta(client1, latestEpochTime(), []ConnectionStats{conn3}, nil, nil, nil, nil).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, 2*dSent, conns[0].LastSentBytes)
 	assert.Equal(t, 2*dRecv, conns[0].LastRecvBytes)
@@ -279,7 +281,7 @@ func TestLastStats(t *testing.T) {
 	assert.Equal(t, conn3.MonotonicRetransmits, conns[0].MonotonicRetransmits)
 
 	// client 2 should have conn3 - conn2
-	conns = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{conn3}, nil, nil).Conns
+	conns = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{conn3}, nil, nil, nil, nil).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, dSent, conns[0].LastSentBytes)
 	assert.Equal(t, dRecv, conns[0].LastRecvBytes)
@@ -316,11 +318,11 @@ func TestLastStatsForClosedConnection(t *testing.T) {
 	conn2.MonotonicRetransmits += dRetransmits
 
 	// First get, we should not have any connections stored
-	conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+	conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 	assert.Equal(t, 0, len(conns))
 
 	// We should have one connection with last stats equal to monotonic stats
-	conns = state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns = state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, conn.MonotonicSentBytes, conns[0].LastSentBytes)
 	assert.Equal(t, conn.MonotonicRecvBytes, conns[0].LastRecvBytes)
@@ -332,7 +334,7 @@ func TestLastStatsForClosedConnection(t *testing.T) {
 	state.StoreClosedConnections([]ConnectionStats{conn2})
 
 	// We should have one connection with last stats
-	conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+	conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, dSent, conns[0].LastSentBytes)
@@ -383,7 +385,7 @@ func TestRaceConditions(t *testing.T) {
 				case <-timer.C:
 					return
 				default:
-					state.GetDelta(c, latestEpochTime(), genConns(nConns), nil, nil)
+					state.GetDelta(c, latestEpochTime(), genConns(nConns), nil, nil, nil, nil)
 				}
 			}
 		}(fmt.Sprintf("%d", i))
@@ -422,14 +424,14 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state := newDefaultState()
 
 		// First get, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection as closed
 		state.StoreClosedConnections([]ConnectionStats{conn})
 
 		// Second get, we should have monotonic and last stats = 3
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 3, int(conns[0].LastSentBytes))
@@ -451,7 +453,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state := newDefaultState()
 
 		// First get, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection as closed
@@ -464,7 +466,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn2})
 
 		// Second get, we should have monotonic and last stats = 8
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 8, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 8, int(conns[0].LastSentBytes))
@@ -488,7 +490,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state := newDefaultState()
 
 		// First get for client c, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Len(t, conns, 0)
 
 		conn := ConnectionStats{
@@ -503,7 +505,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		}
 
 		// Simulate this connection starting
-		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
 		require.Len(t, conns, 1)
 		assert.EqualValues(t, 1, conns[0].LastSentBytes)
 		assert.EqualValues(t, 1, conns[0].MonotonicSentBytes)
@@ -516,7 +518,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		conn.MonotonicSentBytes = 1
 		conn.LastUpdateEpoch = latestEpochTime()
 		// Retrieve the connections
-		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
 		require.Len(t, conns, 1)
 		assert.EqualValues(t, 2, conns[0].LastSentBytes)
 		assert.EqualValues(t, 3, conns[0].MonotonicSentBytes)
@@ -526,7 +528,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		// Store the connection as closed
 		state.StoreClosedConnections([]ConnectionStats{conn})
 
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		require.Len(t, conns, 1)
 		assert.EqualValues(t, 1, conns[0].LastSentBytes)
 		assert.EqualValues(t, 2, conns[0].MonotonicSentBytes)
@@ -550,7 +552,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state := newDefaultState()
 
 		// First get, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection as closed
@@ -563,7 +565,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs := []ConnectionStats{conn2}
 
 		// Second get, we should have monotonic and last stats = 5
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil, nil, nil).Conns
 		require.Equal(t, 1, len(conns))
 		assert.Equal(t, 5, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 5, int(conns[0].LastSentBytes))
@@ -580,7 +582,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn3}
 
 		// Third get, we should have monotonic = 6 and last stats = 4
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 6, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 4, int(conns[0].LastSentBytes))
@@ -590,7 +592,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn3})
 
 		// 4th get, we should have monotonic = 3 and last stats = 2
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 2, int(conns[0].LastSentBytes))
@@ -612,14 +614,14 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state := newDefaultState()
 
 		// this is to register we should not have anything
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection as opened
 		cs := []ConnectionStats{conn}
 
 		// First get, we should have monotonic = 3 and last seen = 3
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 3, int(conns[0].LastSentBytes))
@@ -630,7 +632,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn2})
 
 		// Second get, we should have monotonic = 8 and last stats = 5
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 8, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 5, int(conns[0].LastSentBytes))
@@ -670,18 +672,18 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state := newDefaultState()
 
 		// First get for client c, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// First get for client d, we should have nothing
-		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection as closed
 		state.StoreClosedConnections([]ConnectionStats{conn})
 
 		// Second get for client d we should have monotonic and last stats = 3
-		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 3, int(conns[0].LastSentBytes))
@@ -693,7 +695,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs := []ConnectionStats{conn2}
 
 		// Second get, for client c we should have monotonic and last stats = 5
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 5, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 5, int(conns[0].LastSentBytes))
@@ -704,7 +706,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn2}
 
 		// Third get, for client d we should have monotonic = 3 and last stats = 3
-		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 3, int(conns[0].LastSentBytes))
@@ -721,7 +723,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn3}
 
 		// Third get, for client c, we should have monotonic = 6 and last stats = 4
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 6, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 4, int(conns[0].LastSentBytes))
@@ -732,7 +734,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn3}
 
 		// 4th get, for client d, we should have monotonic = 7 and last stats = 4
-		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 7, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 4, int(conns[0].LastSentBytes))
@@ -743,13 +745,13 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn3})
 
 		// 4th get, for client c we should have monotonic = 3 and last stats = 2
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 2, int(conns[0].LastSentBytes))
 
 		// 5th get, for client d we should have monotonic = 3 and last stats = 1
-		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 1, int(conns[0].LastSentBytes))
@@ -797,15 +799,15 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state := newDefaultState()
 
 		// First get for client c, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// First get for client d, we should have nothing
-		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// First get for client e, we should have nothing
-		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection
@@ -814,7 +816,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs := []ConnectionStats{conn}
 
 		// Second get for client e we should have monotonic and last stats = 2
-		conns = state.GetDelta(clientE, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(clientE, latestEpochTime(), cs, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 2, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 2, int(conns[0].LastSentBytes))
@@ -825,13 +827,13 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn})
 
 		// Second get for client d we should have monotonic and last stats = 3
-		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 3, int(conns[0].LastSentBytes))
 
 		// Third get for client e we should have monotonic = 3and last stats = 1
-		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 1, int(conns[0].LastSentBytes))
@@ -843,7 +845,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn2}
 
 		// Second get, for client c we should have monotonic and last stats = 5
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 5, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 5, int(conns[0].LastSentBytes))
@@ -854,7 +856,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn2}
 
 		// Third get, for client d we should have monotonic = 3 and last stats = 3
-		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 3, int(conns[0].LastSentBytes))
@@ -865,7 +867,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn2})
 
 		// 4th get, for client e we should have monotonic = 5 and last stats = 5
-		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 5, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 5, int(conns[0].LastSentBytes))
@@ -901,11 +903,11 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state := newDefaultState()
 
 		// First get for client c, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Second get for client c we should have monotonic and last stats = 3
-		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
 		assert.Len(t, conns, 1)
 		assert.Equal(t, 3, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 3, int(conns[0].LastSentBytes))
@@ -915,7 +917,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		conn2.LastUpdateEpoch++
 
 		// First get for client d we should have monotonic = 4 and last bytes = 4
-		conns = state.GetDelta(clientD, latestEpochTime(), []ConnectionStats{conn2}, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), []ConnectionStats{conn2}, nil, nil, nil, nil).Conns
 		assert.Len(t, conns, 1)
 		assert.Equal(t, 4, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 0, int(conns[0].LastSentBytes))
@@ -925,7 +927,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		conn3.LastUpdateEpoch++
 
 		// Third get for client c we should have monotonic = 7 and last bytes = 4
-		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn3}, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn3}, nil, nil, nil, nil).Conns
 		assert.Len(t, conns, 1)
 		assert.Equal(t, 7, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 4, int(conns[0].LastSentBytes))
@@ -935,7 +937,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		conn4.LastUpdateEpoch++
 
 		// Second get for client d we should have monotonic = 9 and last bytes = 5
-		conns = state.GetDelta(clientD, latestEpochTime(), []ConnectionStats{conn4}, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), []ConnectionStats{conn4}, nil, nil, nil, nil).Conns
 		assert.Len(t, conns, 1)
 		assert.Equal(t, 9, int(conns[0].MonotonicSentBytes))
 		assert.Equal(t, 5, int(conns[0].LastSentBytes))
@@ -958,10 +960,10 @@ func TestStatsResetOnUnderflow(t *testing.T) {
 	state := newDefaultState()
 
 	// Register the client
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
 
 	// Get the connections once to register stats
-	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
 	require.Len(t, conns, 1)
 
 	// Expect LastStats to be 3
@@ -971,7 +973,7 @@ func TestStatsResetOnUnderflow(t *testing.T) {
 	// Get the connections again but by simulating an underflow
 	conn.MonotonicSentBytes--
 
-	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
 	require.Len(t, conns, 1)
 	expected := conn
 	expected.LastSentBytes = 2
@@ -1001,8 +1003,8 @@ func TestDoubleCloseOnTwoClients(t *testing.T) {
 	state := newDefaultState()
 
 	// Register the clients
-	assert.Len(t, state.GetDelta(client1, latestEpochTime(), nil, nil, nil).Conns, 0)
-	assert.Len(t, state.GetDelta(client2, latestEpochTime(), nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client1, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client2, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
 
 	// Store the closed connection twice
 	state.StoreClosedConnections([]ConnectionStats{conn})
@@ -1011,12 +1013,12 @@ func TestDoubleCloseOnTwoClients(t *testing.T) {
 
 	expectedConn.LastUpdateEpoch = conn.LastUpdateEpoch
 	// Get the connections for client1 we should have only one with stats = 2*conn
-	conns := state.GetDelta(client1, latestEpochTime(), nil, nil, nil).Conns
+	conns := state.GetDelta(client1, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 	require.Len(t, conns, 1)
 	assert.Equal(t, expectedConn, conns[0])
 
 	// Same for client2
-	conns = state.GetDelta(client2, latestEpochTime(), nil, nil, nil).Conns
+	conns = state.GetDelta(client2, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 	require.Len(t, conns, 1)
 	assert.Equal(t, expectedConn, conns[0])
 }
@@ -1035,7 +1037,7 @@ func TestUnorderedCloseEvent(t *testing.T) {
 	state := newDefaultState()
 
 	// Register the client
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
 
 	// Simulate storing a closed connection while we were reading from the eBPF map
 	// in this case the closed conn will have an earlier epoch
@@ -1047,22 +1049,22 @@ func TestUnorderedCloseEvent(t *testing.T) {
 	conn.LastUpdateEpoch--
 	conn.MonotonicSentBytes--
 	conn.MonotonicRecvBytes = 0
-	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil, nil, nil).Conns
 	require.Len(t, conns, 1)
 	assert.EqualValues(t, 4, conns[0].LastSentBytes)
 	assert.EqualValues(t, 1, conns[0].LastRecvBytes)
 
 	// Simulate some other gets
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
 
 	// Simulate having the connection getting active again
 	conn.LastUpdateEpoch = latestEpochTime()
 	conn.MonotonicSentBytes--
 	state.StoreClosedConnections([]ConnectionStats{conn})
 
-	conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+	conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns
 	require.Len(t, conns, 1)
 	assert.EqualValues(t, 2, conns[0].LastSentBytes)
 	assert.EqualValues(t, 0, conns[0].LastRecvBytes)
@@ -1070,7 +1072,7 @@ func TestUnorderedCloseEvent(t *testing.T) {
 	// Ensure we don't have underflows / unordered conns
 	assert.Zero(t, state.(*networkState).telemetry.statsResets)
 
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
 }
 
 func TestAggregateClosedConnectionsTimestamp(t *testing.T) {
@@ -1087,7 +1089,7 @@ func TestAggregateClosedConnectionsTimestamp(t *testing.T) {
 	state := newDefaultState()
 
 	// Register the client
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
 
 	conn.LastUpdateEpoch = latestEpochTime()
 	state.StoreClosedConnections([]ConnectionStats{conn})
@@ -1099,7 +1101,7 @@ func TestAggregateClosedConnectionsTimestamp(t *testing.T) {
 	state.StoreClosedConnections([]ConnectionStats{conn})
 
 	// Make sure the connections we get has the latest timestamp
-	delta := state.GetDelta(client, latestEpochTime(), nil, nil, nil)
+	delta := state.GetDelta(client, latestEpochTime(), nil, nil, nil, nil, nil)
 	assert.Equal(t, conn.LastUpdateEpoch, delta.Conns[0].LastUpdateEpoch)
 }
 
@@ -1148,26 +1150,26 @@ func TestDNSStatsWithMultipleClients(t *testing.T) {
 	}
 
 	// Register the first two clients
-	assert.Len(t, state.GetDelta(client1, latestEpochTime(), nil, nil, nil).Conns, 0)
-	assert.Len(t, state.GetDelta(client2, latestEpochTime(), nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client1, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client2, latestEpochTime(), nil, nil, nil, nil, nil).Conns, 0)
 
 	c.LastUpdateEpoch = latestEpochTime()
 
-	delta := state.GetDelta(client1, latestEpochTime(), []ConnectionStats{c}, getStats(), nil)
+	delta := state.GetDelta(client1, latestEpochTime(), []ConnectionStats{c}, getStats(), nil, nil, nil)
 	require.Len(t, delta.Conns, 1)
 
 	rcode := getRCodeFrom(delta, delta.Conns[0], "foo.com", dns.TypeA, DNSResponseCodeNoError)
 	assert.EqualValues(t, 1, rcode)
 
 	// Register the third client but also pass in dns stats
-	delta = state.GetDelta(client3, latestEpochTime(), []ConnectionStats{c}, getStats(), nil)
+	delta = state.GetDelta(client3, latestEpochTime(), []ConnectionStats{c}, getStats(), nil, nil, nil)
 	require.Len(t, delta.Conns, 1)
 
 	// DNS stats should be available for the new client
 	rcode = getRCodeFrom(delta, delta.Conns[0], "foo.com", dns.TypeA, DNSResponseCodeNoError)
 	assert.EqualValues(t, 1, rcode)
 
-	delta = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{c}, getStats(), nil)
+	delta = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{c}, getStats(), nil, nil, nil)
 	require.Len(t, delta.Conns, 1)
 
 	// 2nd client should get accumulated stats
@@ -1191,13 +1193,13 @@ func TestHTTPStats(t *testing.T) {
 
 	// Register client & pass in HTTP stats
 	state := newDefaultState()
-	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, httpStats)
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, httpStats, nil, nil)
 
 	// Verify connection has HTTP data embedded in it
 	assert.Len(t, delta.HTTP, 1)
 
 	// Verify HTTP data has been flushed
-	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, nil)
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, nil, nil, nil)
 	assert.Len(t, delta.HTTP, 0)
 }
 
@@ -1223,40 +1225,97 @@ func TestHTTPStatsWithMultipleClients(t *testing.T) {
 	state := newDefaultState()
 
 	// Register the first two clients
-	assert.Len(t, state.GetDelta(client1, latestEpochTime(), nil, nil, nil).HTTP, 0)
-	assert.Len(t, state.GetDelta(client2, latestEpochTime(), nil, nil, nil).HTTP, 0)
+	assert.Len(t, state.GetDelta(client1, latestEpochTime(), nil, nil, nil, nil, nil).HTTP, 0)
+	assert.Len(t, state.GetDelta(client2, latestEpochTime(), nil, nil, nil, nil, nil).HTTP, 0)
 
 	// Store the connection to both clients & pass HTTP stats to the first client
 	c.LastUpdateEpoch = latestEpochTime()
 	state.StoreClosedConnections([]ConnectionStats{c})
 
-	delta := state.GetDelta(client1, latestEpochTime(), nil, nil, getStats("/testpath"))
+	delta := state.GetDelta(client1, latestEpochTime(), nil, nil, getStats("/testpath"), nil, nil)
 	assert.Len(t, delta.HTTP, 1)
 
 	// Verify that the HTTP stats were also stored in the second client
-	delta = state.GetDelta(client2, latestEpochTime(), nil, nil, nil)
+	delta = state.GetDelta(client2, latestEpochTime(), nil, nil, nil, nil, nil)
 	assert.Len(t, delta.HTTP, 1)
 
 	// Register a third client & verify that it does not have the HTTP stats
-	delta = state.GetDelta(client3, latestEpochTime(), []ConnectionStats{c}, nil, nil)
+	delta = state.GetDelta(client3, latestEpochTime(), []ConnectionStats{c}, nil, nil, nil, nil)
 	assert.Len(t, delta.HTTP, 0)
 
 	c.LastUpdateEpoch = latestEpochTime()
 	state.StoreClosedConnections([]ConnectionStats{c})
 
 	// Pass in new HTTP stats to the first client
-	delta = state.GetDelta(client1, latestEpochTime(), nil, nil, getStats("/testpath2"))
+	delta = state.GetDelta(client1, latestEpochTime(), nil, nil, getStats("/testpath2"), nil, nil)
 	assert.Len(t, delta.HTTP, 1)
 
 	// And the second client
-	delta = state.GetDelta(client2, latestEpochTime(), nil, nil, getStats("/testpath3"))
+	delta = state.GetDelta(client2, latestEpochTime(), nil, nil, getStats("/testpath3"), nil, nil)
 	assert.Len(t, delta.HTTP, 2)
 
 	// Verify that the third client also accumulated both new HTTP stats
-	delta = state.GetDelta(client3, latestEpochTime(), nil, nil, nil)
+	delta = state.GetDelta(client3, latestEpochTime(), nil, nil, nil, nil, nil)
 	assert.Len(t, delta.HTTP, 2)
 }
 
+func TestProtocolStatsWithMultipleClients(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  5432,
+	}
+
+	getKafkaStats := func(topic string) map[kafka.Key]kafka.RequestStats {
+		return map[kafka.Key]kafka.RequestStats{
+			kafka.NewKey(c.Source, c.Dest, c.SPort, 9092, topic): {Produce: 1},
+		}
+	}
+	getPostgresStats := func() map[postgres.Key]*postgres.RequestStats {
+		stats := new(postgres.RequestStats)
+		stats.AddQuery(postgres.Query{Latency: 1000})
+		return map[postgres.Key]*postgres.RequestStats{
+			postgres.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "SELECT"): stats,
+		}
+	}
+
+	client1 := "client1"
+	client2 := "client2"
+	state := newDefaultState()
+
+	// Register both clients
+	state.GetDelta(client1, latestEpochTime(), nil, nil, nil, nil, nil)
+	state.GetDelta(client2, latestEpochTime(), nil, nil, nil, nil, nil)
+
+	// The stats passed when collecting the first client are also kept for the second one
+	delta := state.GetDelta(client1, latestEpochTime(), nil, nil, nil, getKafkaStats("orders"), getPostgresStats())
+	assert.Len(t, delta.Kafka, 1)
+	require.Len(t, delta.Postgres, 1)
+
+	delta = state.GetDelta(client1, latestEpochTime(), nil, nil, nil, nil, nil)
+	assert.Len(t, delta.Kafka, 0)
+	assert.Len(t, delta.Postgres, 0)
+
+	delta = state.GetDelta(client2, latestEpochTime(), nil, nil, nil, getKafkaStats("orders"), getPostgresStats())
+	assert.Equal(t, map[kafka.Key]kafka.RequestStats{
+		kafka.NewKey(c.Source, c.Dest, c.SPort, 9092, "orders"): {Produce: 2},
+	}, delta.Kafka)
+	require.Len(t, delta.Postgres, 1)
+	for _, stats := range delta.Postgres {
+		assert.Equal(t, 2, stats.Count)
+		assert.Equal(t, float64(2), stats.Latencies.GetCount())
+	}
+
+	// The stats of the second client don't share their latencies with the ones of the first client
+	delta = state.GetDelta(client1, latestEpochTime(), nil, nil, nil, nil, nil)
+	require.Len(t, delta.Postgres, 1)
+	for _, stats := range delta.Postgres {
+		assert.Equal(t, 1, stats.Count)
+		assert.Equal(t, float64(1), stats.Latencies.GetCount())
+	}
+}
+
 func TestDetermineConnectionIntraHost(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -22,6 +22,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
 	"github.com/DataDog/datadog-agent/pkg/network/http"
 	"github.com/DataDog/datadog-agent/pkg/network/netlink"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection/kprobe"
 	"github.com/DataDog/datadog-agent/pkg/process/procutil"
@@ -43,6 +44,9 @@ type Tracer struct {
 	httpMonitor *http.Monitor
 	ebpfTracer  connection.Tracer
 
+	// protocolMonitor classifies connections from their payloads, it is nil when disabled
+	protocolMonitor *protocols.Monitor
+
 	// Telemetry
 	skippedConns int64
 	// Will track the count of expired TCP connections
@@ -136,11 +140,19 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 		config.MaxHTTPStatsBuffered,
 	)
 
+	protocolMonitor := newProtocolMonitor(config)
+	httpMonitor := newHTTPMonitor(!pre410Kernel, config, ebpfTracer, constantEditors, protocolMonitor)
+	if protocolMonitor != nil && httpMonitor == nil {
+		// the payloads classified are captured by the socket filter of the http monitor
+		log.Warn("protocol classification requires http monitoring, which isn't enabled")
+		protocolMonitor = nil
+	}
+
 	tr := &Tracer{
 		config:                     config,
 		state:                      state,
 		reverseDNS:                 newReverseDNS(!pre410Kernel, config),
-		httpMonitor:                newHTTPMonitor(!pre410Kernel, config, ebpfTracer, constantEditors),
+		httpMonitor:                httpMonitor,
 		activeBuffer:               network.NewConnectionBuffer(512, 256),
 		conntracker:                conntracker,
 		sourceExcludes:             network.ParseConnectionFilters(config.ExcludedSourceConnections),
@@ -150,6 +162,7 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 		sysctlUDPConnStreamTimeout: sysctl.NewInt(config.ProcRoot, "net/netfilter/nf_conntrack_udp_timeout_stream", time.Minute),
 		gwLookup:                   newGatewayLookup(config),
 		ebpfTracer:                 ebpfTracer,
+		protocolMonitor:            protocolMonitor,
 	}
 
 	err = ebpfTracer.Start(tr.storeClosedConnections)
@@ -307,10 +320,12 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 	}
 	active := t.activeBuffer.Connections()
 
-	delta := t.state.GetDelta(clientID, latestTime, active, t.reverseDNS.GetDNSStats(), t.httpMonitor.GetHTTPStats())
+	kafkaStats, postgresStats := t.protocolMonitor.GetAndResetAllStats()
+	delta := t.state.GetDelta(clientID, latestTime, active, t.reverseDNS.GetDNSStats(), t.httpMonitor.GetHTTPStats(), kafkaStats, postgresStats)
 	t.activeBuffer.Reset()
 
 	t.retryConntrack(delta.Conns)
+	t.classifyConnections(delta.Conns)
 
 	ips := make([]util.Address, 0, len(delta.Conns)*2)
 	for _, conn := range delta.Conns {
@@ -325,6 +340,8 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 		DNS:                         names,
 		DNSStats:                    delta.DNSStats,
 		HTTP:                        delta.HTTP,
+		Kafka:                       delta.Kafka,
+		Postgres:                    delta.Postgres,
 		ConnTelemetry:               ctm,
 		CompilationTelemetryByAsset: rctm,
 	}, nil
@@ -653,7 +670,33 @@ func (t *Tracer) retryConntrack(connections []network.ConnectionStats) {
 	}
 }
 
-func newHTTPMonitor(supported bool, c *config.Config, tracer connection.Tracer, offsets []manager.ConstantEditor) *http.Monitor {
+func newProtocolMonitor(c *config.Config) *protocols.Monitor {
+	if !c.EnableProtocolClassification {
+		return nil
+	}
+
+	log.Info("protocol classification enabled")
+	return protocols.NewMonitor(protocols.DefaultClassifiers(), int(c.MaxTrackedConnections), c.MaxHTTPStatsBuffered)
+}
+
+// classifyConnections attaches the protocol classified from their payloads to the connections,
+// and forgets about the connections which were closed
+func (t *Tracer) classifyConnections(connections []network.ConnectionStats) {
+	if t.protocolMonitor == nil {
+		return
+	}
+
+	for i := range connections {
+		conn := &connections[i]
+		conn.Protocol = t.protocolMonitor.Protocol(conn.Source, conn.Dest, conn.SPort, conn.DPort)
+		if conn.Type == network.TCP && conn.LastTCPClosed > 0 {
+			t.protocolMonitor.Forget(conn.Source, conn.Dest, conn.SPort, conn.DPort)
+		}
+	}
+}
+
+// newHTTPMonitor returns the http monitor, which feeds the payloads it captures to the protocol monitor
+func newHTTPMonitor(supported bool, c *config.Config, tracer connection.Tracer, offsets []manager.ConstantEditor, protocolMonitor *protocols.Monitor) *http.Monitor {
 	if !c.EnableHTTPMonitoring {
 		return nil
 	}
@@ -664,7 +707,11 @@ func newHTTPMonitor(supported bool, c *config.Config, tracer connection.Tracer,
 	}
 	// Shared with the HTTP program
 	sockFDMap := tracer.GetMap(string(probes.SockByPidFDMap))
-	monitor, err := http.NewMonitor(c, offsets, sockFDMap)
+	var consumers []http.DataConsumer
+	if protocolMonitor != nil {
+		consumers = append(consumers, protocolMonitor)
+	}
+	monitor, err := http.NewMonitor(c, offsets, sockFDMap, consumers...)
 	if err != nil {
 		log.Errorf("could not instantiate http monitor: %s", err)
 		return nil
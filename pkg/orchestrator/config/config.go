@@ -17,6 +17,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/orchestrator/redact"
 	apicfg "github.com/DataDog/datadog-agent/pkg/process/util/api/config"
 	coreutil "github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/cmdlinescrubber"
 	"github.com/DataDog/datadog-agent/pkg/util/flavor"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/clustername"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -85,6 +86,17 @@ func (oc *OrchestratorConfig) Load() error {
 		oc.Scrubber.AddCustomSensitiveWords(config.Datadog.GetStringSlice(k))
 	}
 
+	// Structured rules scrubbing the arguments of specific executables
+	if k := key(orchestratorNS, "custom_scrub_rules"); config.Datadog.IsSet(k) {
+		var rules []cmdlinescrubber.Rule
+		if err := config.Datadog.UnmarshalKey(k, &rules); err != nil {
+			return fmt.Errorf("invalid %s: %w", k, err)
+		}
+		if err := oc.Scrubber.AddScrubRules(rules); err != nil {
+			return fmt.Errorf("invalid %s: %w", k, err)
+		}
+	}
+
 	// The maximum number of pods, nodes, replicaSets, deployments and services per message. Note: Only change if the defaults are causing issues.
 	if k := key(orchestratorNS, "max_per_message"); config.Datadog.IsSet(k) {
 		if maxPerMessage := config.Datadog.GetInt(k); maxPerMessage <= 0 {
@@ -11,6 +11,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/DataDog/datadog-agent/pkg/util/cmdlinescrubber"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -30,6 +31,7 @@ type DataScrubber struct {
 	RegexSensitivePatterns []*regexp.Regexp
 	// LiteralSensitivePatterns are custom words which use to match against
 	LiteralSensitivePatterns []string
+	Rules                    *cmdlinescrubber.RuleSet // structured rules scrubbing the arguments of specific executables
 	scrubbedCmdLines         map[string][]string
 }
 
@@ -56,7 +58,8 @@ func (ds *DataScrubber) ContainsSensitiveWord(word string) bool {
 	return false
 }
 
-// ScrubSimpleCommand hides the argument value for any key which matches a "sensitive word" pattern.
+// ScrubSimpleCommand hides the argument value for any key which matches a "sensitive word" pattern,
+// or targeted by one of the scrubbing rules.
 // It returns the updated cmdline, as well as a boolean representing whether it was scrubbed.
 func (ds *DataScrubber) ScrubSimpleCommand(cmdline []string) ([]string, bool) {
 	changed := false
@@ -67,8 +70,10 @@ func (ds *DataScrubber) ScrubSimpleCommand(cmdline []string) ([]string, bool) {
 		return cmdline, false
 	}
 
+	ruleScrubbed, rulesChanged := ds.Rules.Scrub(cmdline)
+
 	// in case we have custom regexes we have to join them and perform regex find and replace
-	rawCmdline := strings.Join(cmdline, " ")
+	rawCmdline := strings.Join(ruleScrubbed, " ")
 	for _, pattern := range ds.RegexSensitivePatterns {
 		if pattern.MatchString(rawCmdline) {
 			regexChanged = true
@@ -133,11 +138,11 @@ func (ds *DataScrubber) ScrubSimpleCommand(cmdline []string) ([]string, bool) {
 	}
 
 	// if nothing changed, just return the input
-	if !(changed || regexChanged) {
+	if !(changed || regexChanged || rulesChanged) {
 		return cmdline, false
 	}
 
-	return newCmdline, changed || regexChanged
+	return newCmdline, true
 }
 
 // AddCustomSensitiveWords adds custom sensitive words on the DataScrubber object
@@ -145,6 +150,14 @@ func (ds *DataScrubber) AddCustomSensitiveWords(words []string) {
 	ds.LiteralSensitivePatterns = append(ds.LiteralSensitivePatterns, words...)
 }
 
+// AddScrubRules adds structured scrubbing rules on the DataScrubber object
+func (ds *DataScrubber) AddScrubRules(rules []cmdlinescrubber.Rule) error {
+	if ds.Rules == nil {
+		ds.Rules = &cmdlinescrubber.RuleSet{}
+	}
+	return ds.Rules.Add(rules...)
+}
+
 // AddCustomSensitiveRegex adds custom sensitive regex on the DataScrubber object
 func (ds *DataScrubber) AddCustomSensitiveRegex(words []string) {
 	r := compileStringsToRegex(words)
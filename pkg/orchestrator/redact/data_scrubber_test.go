@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/DataDog/datadog-agent/pkg/util/cmdlinescrubber"
 	scrubberpkg "github.com/DataDog/datadog-agent/pkg/util/scrubber"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -311,3 +312,48 @@ func setupInsensitiveCmdLines() []testCase {
 		{[]string{"agent 1_pword:1234"}, []string{"agent 1_pword:1234"}},
 	}
 }
+
+func TestScrubSimpleCommandRules(t *testing.T) {
+	scrubber := NewDefaultDataScrubber()
+	err := scrubber.AddScrubRules([]cmdlinescrubber.Rule{
+		{Executable: "mysql", Flags: []string{"-p", "--password"}},
+		{Executable: "java", Flags: []string{"-Djavax.net.ssl.keyStorePassword"}},
+	})
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		cmdline  []string
+		expected []string
+		changed  bool
+	}{
+		{
+			cmdline:  []string{"mysql", "-u", "root", "-psecret"},
+			expected: []string{"mysql", "-u", "root", "-p********"},
+			changed:  true,
+		},
+		{
+			cmdline:  []string{"/usr/bin/mysql", "--password", "secret", "db"},
+			expected: []string{"/usr/bin/mysql", "--password", "********", "db"},
+			changed:  true,
+		},
+		{
+			cmdline:  []string{"java", "-Djavax.net.ssl.keyStorePassword=secret", "-jar", "app.jar"},
+			expected: []string{"java", "-Djavax.net.ssl.keyStorePassword=********", "-jar", "app.jar"},
+			changed:  true,
+		},
+		{
+			cmdline:  []string{"psql", "-phunter2"},
+			expected: []string{"psql", "-phunter2"},
+			changed:  false,
+		},
+	} {
+		cmdline, changed := scrubber.ScrubSimpleCommand(tc.cmdline)
+		assert.Equal(t, tc.expected, cmdline)
+		assert.Equal(t, tc.changed, changed)
+	}
+}
+
+func TestAddScrubRulesInvalid(t *testing.T) {
+	scrubber := NewDefaultDataScrubber()
+	assert.Error(t, scrubber.AddScrubRules([]cmdlinescrubber.Rule{{Executable: "[java"}}))
+}
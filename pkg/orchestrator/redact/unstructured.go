@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redact
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ScrubUnstructured scrubs sensitive information in a Kubernetes object of any kind,
+// like a custom resource, given as its unstructured content.
+// Following the same rules as ScrubContainer, it walks the whole object and scrubs:
+// - the values of the name/value pairs (e.g. env vars) whose name contains a sensitive word
+// - the values of the keys containing a sensitive word, the maps and lists being walked instead
+// - the command lines given as command/args string lists
+func ScrubUnstructured(obj map[string]interface{}, scrubber *DataScrubber) {
+	for k, v := range obj {
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			scrubUnstructuredValue(v, scrubber)
+		case nil:
+		default:
+			if scrubber.ContainsSensitiveWord(k) {
+				obj[k] = redactedValue
+			}
+		}
+	}
+
+	scrubNameValuePair(obj, scrubber)
+	scrubCommand(obj, scrubber)
+}
+
+func scrubUnstructuredValue(v interface{}, scrubber *DataScrubber) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		ScrubUnstructured(value, scrubber)
+	case []interface{}:
+		for _, item := range value {
+			scrubUnstructuredValue(item, scrubber)
+		}
+	}
+}
+
+// scrubNameValuePair redacts the value of an object like {"name": "PASSWORD", "value": "1234"}
+func scrubNameValuePair(obj map[string]interface{}, scrubber *DataScrubber) {
+	name, ok := obj["name"].(string)
+	if !ok {
+		return
+	}
+
+	if _, ok := obj["value"].(string); ok && scrubber.ContainsSensitiveWord(name) {
+		obj["value"] = redactedValue
+	}
+}
+
+// scrubCommand scrubs an object like {"command": ["mysql", "--password"], "args": ["1234"]}
+func scrubCommand(obj map[string]interface{}, scrubber *DataScrubber) {
+	command, hasCommand := toStringSlice(obj["command"])
+	args, hasArgs := toStringSlice(obj["args"])
+	if !hasCommand && !hasArgs {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Failed to parse cmd from unstructured object, obscuring whole command")
+			// we still want to obscure to be safe
+			obj["command"] = []interface{}{redactedValue}
+		}
+	}()
+
+	merged := append(command, args...)
+	words := 0
+	for _, cmd := range command {
+		words += len(strings.Split(cmd, " "))
+	}
+
+	scrubbedMergedCommand, changed := scrubber.ScrubSimpleCommand(merged) // return value is split if has been changed
+	if !changed {
+		return
+	}
+
+	if len(command) > 0 {
+		obj["command"] = toInterfaceSlice(scrubbedMergedCommand[:words])
+	}
+	if len(args) > 0 {
+		obj["args"] = toInterfaceSlice(scrubbedMergedCommand[words:])
+	}
+}
+
+// toStringSlice returns the given unstructured value as a string slice if it's a list of strings
+func toStringSlice(v interface{}) ([]string, bool) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	strs := make([]string, 0, len(list))
+	for _, item := range list {
+		str, ok := item.(string)
+		if !ok {
+			return nil, false
+		}
+		strs = append(strs, str)
+	}
+
+	return strs, true
+}
+
+func toInterfaceSlice(strs []string) []interface{} {
+	list := make([]interface{}, 0, len(strs))
+	for _, str := range strs {
+		list = append(list, str)
+	}
+
+	return list
+}
@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redact
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrubUnstructured(t *testing.T) {
+	raw := `{
+  "apiVersion": "example.com/v1",
+  "kind": "Database",
+  "metadata": {
+    "name": "db",
+    "namespace": "default"
+  },
+  "spec": {
+    "replicas": 2,
+    "credentials": [
+      {"name": "password", "value": "1234"},
+      {"name": "user", "value": "admin"},
+      {"name": "api_key", "valueFrom": {"secretKeyRef": {"name": "db-secret", "key": "api_key"}}}
+    ],
+    "template": {
+      "spec": {
+        "containers": [
+          {
+            "name": "db",
+            "command": ["mysql", "--password", "1234"],
+            "args": ["--user", "admin"],
+            "env": [{"name": "DB_SECRET", "value": "s3cr3t"}]
+          },
+          {
+            "name": "sidecar",
+            "command": ["agent"],
+            "args": ["--apikey=1234"]
+          },
+          {
+            "name": "invalid",
+            "command": ["sh", 1]
+          }
+        ]
+      }
+    }
+  }
+}`
+
+	expected := `{
+  "apiVersion": "example.com/v1",
+  "kind": "Database",
+  "metadata": {
+    "name": "db",
+    "namespace": "default"
+  },
+  "spec": {
+    "replicas": 2,
+    "credentials": [
+      {"name": "password", "value": "********"},
+      {"name": "user", "value": "admin"},
+      {"name": "api_key", "valueFrom": {"secretKeyRef": {"name": "db-secret", "key": "api_key"}}}
+    ],
+    "template": {
+      "spec": {
+        "containers": [
+          {
+            "name": "db",
+            "command": ["mysql", "--password", "********"],
+            "args": ["--user", "admin"],
+            "env": [{"name": "DB_SECRET", "value": "********"}]
+          },
+          {
+            "name": "sidecar",
+            "command": ["agent"],
+            "args": ["--apikey=********"]
+          },
+          {
+            "name": "invalid",
+            "command": ["sh", 1]
+          }
+        ]
+      }
+    }
+  }
+}`
+
+	var obj, expectedObj map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(raw), &obj))
+	require.NoError(t, json.Unmarshal([]byte(expected), &expectedObj))
+
+	ScrubUnstructured(obj, NewDefaultDataScrubber())
+	assert.Equal(t, expectedObj, obj)
+}
+
+func TestScrubUnstructuredSensitiveKeys(t *testing.T) {
+	raw := `{
+  "spec": {
+    "password": "1234",
+    "pwd": 1234,
+    "user": "admin",
+    "tls": null,
+    "connection": {
+      "host": "db.example.com",
+      "access_token": "abcd",
+      "options": [
+        {"timeout": 10, "apiKey": "abcd"},
+        [{"secret": "s3cr3t"}, "value"]
+      ]
+    },
+    "credentials": {
+      "user": "admin",
+      "stripeToken": "abcd"
+    },
+    "secretKeyRef": {"name": "db-secret", "key": "password"}
+  }
+}`
+
+	// the maps and lists of sensitive keys are walked, only their own sensitive keys are redacted
+	expected := `{
+  "spec": {
+    "password": "********",
+    "pwd": "********",
+    "user": "admin",
+    "tls": null,
+    "connection": {
+      "host": "db.example.com",
+      "access_token": "********",
+      "options": [
+        {"timeout": 10, "apiKey": "********"},
+        [{"secret": "********"}, "value"]
+      ]
+    },
+    "credentials": {
+      "user": "admin",
+      "stripeToken": "********"
+    },
+    "secretKeyRef": {"name": "db-secret", "key": "password"}
+  }
+}`
+
+	var obj, expectedObj map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(raw), &obj))
+	require.NoError(t, json.Unmarshal([]byte(expected), &expectedObj))
+
+	ScrubUnstructured(obj, NewDefaultDataScrubber())
+	assert.Equal(t, expectedObj, obj)
+}
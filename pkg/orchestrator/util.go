@@ -52,6 +52,8 @@ const (
 	K8sClusterRoleBinding
 	// K8sServiceAccount represents a Kubernetes ServiceAccount
 	K8sServiceAccount
+	// K8sCustomResource represents a Kubernetes Custom Resource
+	K8sCustomResource
 )
 
 // NodeTypes returns the current existing NodesTypes as a slice to iterate over.
@@ -74,6 +76,7 @@ func NodeTypes() []NodeType {
 		K8sClusterRole,
 		K8sClusterRoleBinding,
 		K8sServiceAccount,
+		K8sCustomResource,
 	}
 }
 
@@ -113,6 +116,8 @@ func (n NodeType) String() string {
 		return "ClusterRoleBinding"
 	case K8sServiceAccount:
 		return "ServiceAccount"
+	case K8sCustomResource:
+		return "CustomResource"
 	default:
 		log.Errorf("Trying to convert unknown NodeType iota: %d", n)
 		return "Unknown"
@@ -138,7 +143,8 @@ func (n NodeType) Orchestrator() string {
 		K8sRoleBinding,
 		K8sClusterRole,
 		K8sClusterRoleBinding,
-		K8sServiceAccount:
+		K8sServiceAccount,
+		K8sCustomResource:
 		return "k8s"
 	default:
 		log.Errorf("Unknown NodeType %v", n)
@@ -62,7 +62,7 @@ type ProcessCheck struct {
 // Init initializes the singleton ProcessCheck.
 func (p *ProcessCheck) Init(cfg *config.AgentConfig, info *model.SystemInfo) {
 	p.sysInfo = info
-	p.probe = getProcessProbe(cfg)
+	p.probe = newProcessCheckProbe(cfg)
 
 	p.notInitializedLogLimit = util.NewLogLimit(1, time.Minute*10)
 
@@ -138,6 +138,10 @@ func (p *ProcessCheck) run(cfg *config.AgentConfig, groupID int32, collectRealTi
 		mergeProcWithSysprobeStats(p.lastPIDs, procs, sysProbeUtil)
 	}
 
+	if cfg.ExtendedStats {
+		reportExtendedStats(procs)
+	}
+
 	ctrList, _ := util.GetContainers()
 
 	// Keep track of containers addresses
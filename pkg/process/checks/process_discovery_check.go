@@ -2,11 +2,15 @@ package checks
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	model "github.com/DataDog/agent-payload/v5/process"
 	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/process/discovery"
 	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
 // ProcessDiscovery is a ProcessDiscoveryCheck singleton. ProcessDiscovery should not be instantiated elsewhere.
@@ -19,6 +23,18 @@ type ProcessDiscoveryCheck struct {
 	probe      procutil.Probe
 	info       *model.SystemInfo
 	initCalled bool
+
+	detector *discovery.Detector
+
+	servicesMu sync.RWMutex
+	services   []DiscoveredService
+}
+
+// DiscoveredService is the service detected for a process by the last run of the ProcessDiscoveryCheck
+type DiscoveredService struct {
+	Pid     int32  `json:"pid"`
+	Command string `json:"command"`
+	discovery.Service
 }
 
 // Init initializes the ProcessDiscoveryCheck. It is a runtime error to call Run without first having called Init.
@@ -26,6 +42,7 @@ func (d *ProcessDiscoveryCheck) Init(cfg *config.AgentConfig, info *model.System
 	d.info = info
 	d.initCalled = true
 	d.probe = getProcessProbe(cfg)
+	d.detector = discovery.NewDetector(util.HostProc())
 }
 
 // Name returns the name of the ProcessDiscoveryCheck.
@@ -46,6 +63,7 @@ func (d *ProcessDiscoveryCheck) Run(cfg *config.AgentConfig, groupID int32) ([]m
 	if err != nil {
 		return nil, err
 	}
+	d.detectServices(procs)
 
 	host := &model.Host{
 		Name:        cfg.HostName,
@@ -67,6 +85,38 @@ func (d *ProcessDiscoveryCheck) Run(cfg *config.AgentConfig, groupID int32) ([]m
 	return payload, nil
 }
 
+// detectServices infers the languages and the services of the processes, and whether they are instrumented.
+// The model of the payload has no field to carry them, so they are kept until the next run to be served
+// by the process-agent API.
+func (d *ProcessDiscoveryCheck) detectServices(procs map[int32]*procutil.Process) {
+	detected := d.detector.Detect(procs)
+
+	services := make([]DiscoveredService, 0, len(detected))
+	for pid, service := range detected {
+		var command string
+		if proc := procs[pid]; len(proc.Cmdline) > 0 {
+			command = proc.Cmdline[0]
+		}
+		services = append(services, DiscoveredService{
+			Pid:     pid,
+			Command: command,
+			Service: *service,
+		})
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Pid < services[j].Pid })
+
+	d.servicesMu.Lock()
+	d.services = services
+	d.servicesMu.Unlock()
+}
+
+// Services returns the services detected by the last run of the check, sorted by pid
+func (d *ProcessDiscoveryCheck) Services() []DiscoveredService {
+	d.servicesMu.RLock()
+	defer d.servicesMu.RUnlock()
+	return d.services
+}
+
 func pidMapToProcDiscoveries(pidMap map[int32]*procutil.Process) []*model.ProcessDiscovery {
 	pd := make([]*model.ProcessDiscovery, 0, len(pidMap))
 	for _, proc := range pidMap {
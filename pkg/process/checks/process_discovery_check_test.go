@@ -31,6 +31,13 @@ func TestProcessDiscoveryCheck(t *testing.T) {
 				cfg.MaxPerMessage, len(collectorProcDiscovery.ProcessDiscoveries))
 		}
 	}
+
+	// Test that a service is detected for every discovered process
+	var discovered int
+	for _, elem := range result {
+		discovered += len(elem.(*model.CollectorProcDiscovery).ProcessDiscoveries)
+	}
+	assert.Len(t, ProcessDiscovery.Services(), discovered)
 }
 
 func TestProcessDiscoveryChunking(t *testing.T) {
@@ -0,0 +1,137 @@
+package checks
+
+import (
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+	"github.com/DataDog/datadog-agent/pkg/process/statsd"
+)
+
+// processMetric is a system.processes.* metric computed from the extended stats of processes
+type processMetric struct {
+	name  string
+	value float64
+	tags  []string
+}
+
+type processNameStats struct {
+	openFDs      float64
+	fdLimitPct   float64
+	hasFDLimit   bool
+	tcpByState   map[string]float64
+	udp          float64
+	unix         float64
+	otherSockets float64
+	hasSockets   bool
+	pressure     *procutil.PressureStat
+}
+
+// reportExtendedStats submits the extended stats of processes as metrics, aggregated by process name
+func reportExtendedStats(procs map[int32]*procutil.Process) {
+	for _, m := range extendedStatsMetrics(procs) {
+		statsd.Client.Gauge(m.name, m.value, m.tags, 1) //nolint:errcheck
+	}
+}
+
+// extendedStatsMetrics sums the open file descriptors and sockets of the processes sharing a name,
+// and keeps the highest file descriptor limit usage and cgroup pressure among them
+func extendedStatsMetrics(procs map[int32]*procutil.Process) []processMetric {
+	byName := make(map[string]*processNameStats)
+	for _, proc := range procs {
+		if proc.Stats == nil || proc.Name == "" {
+			continue
+		}
+
+		s, ok := byName[proc.Name]
+		if !ok {
+			s = &processNameStats{tcpByState: make(map[string]float64)}
+			byName[proc.Name] = s
+		}
+
+		// a negative count means the file descriptors couldn't be listed
+		if openFDs := proc.Stats.OpenFdCount; openFDs >= 0 {
+			s.openFDs += float64(openFDs)
+			if limit := proc.Stats.FDLimit; limit != nil && limit.Soft > 0 {
+				s.hasFDLimit = true
+				if pct := float64(openFDs) / float64(limit.Soft) * 100; pct > s.fdLimitPct {
+					s.fdLimitPct = pct
+				}
+			}
+		}
+
+		if sockets := proc.Stats.Sockets; sockets != nil {
+			s.hasSockets = true
+			for state, count := range sockets.TCPByState {
+				s.tcpByState[state] += float64(count)
+			}
+			s.udp += float64(sockets.UDP)
+			s.unix += float64(sockets.Unix)
+			s.otherSockets += float64(sockets.Other)
+		}
+
+		if pressure := proc.Stats.Pressure; pressure != nil {
+			if s.pressure == nil {
+				s.pressure = &procutil.PressureStat{}
+			}
+			s.pressure.CPUSome = maxFloat(s.pressure.CPUSome, pressure.CPUSome)
+			s.pressure.MemorySome = maxFloat(s.pressure.MemorySome, pressure.MemorySome)
+			s.pressure.MemoryFull = maxFloat(s.pressure.MemoryFull, pressure.MemoryFull)
+			s.pressure.IOSome = maxFloat(s.pressure.IOSome, pressure.IOSome)
+			s.pressure.IOFull = maxFloat(s.pressure.IOFull, pressure.IOFull)
+		}
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var metrics []processMetric
+	for _, name := range names {
+		s := byName[name]
+		tags := []string{"process_name:" + name}
+		withTags := func(extra ...string) []string {
+			return append(append(make([]string, 0, len(tags)+len(extra)), tags...), extra...)
+		}
+
+		metrics = append(metrics, processMetric{"system.processes.open_file_descriptors", s.openFDs, tags})
+		if s.hasFDLimit {
+			metrics = append(metrics, processMetric{"system.processes.open_file_descriptors_limit_pct", s.fdLimitPct, tags})
+		}
+
+		if s.hasSockets {
+			states := make([]string, 0, len(s.tcpByState))
+			for state := range s.tcpByState {
+				states = append(states, state)
+			}
+			sort.Strings(states)
+			for _, state := range states {
+				metrics = append(metrics, processMetric{"system.processes.sockets", s.tcpByState[state], withTags("socket_type:tcp", "state:"+state)})
+			}
+			metrics = append(metrics,
+				processMetric{"system.processes.sockets", s.udp, withTags("socket_type:udp")},
+				processMetric{"system.processes.sockets", s.unix, withTags("socket_type:unix")},
+				processMetric{"system.processes.sockets", s.otherSockets, withTags("socket_type:other")},
+			)
+		}
+
+		if p := s.pressure; p != nil {
+			metrics = append(metrics,
+				processMetric{"system.processes.pressure.cpu.some", p.CPUSome, tags},
+				processMetric{"system.processes.pressure.memory.some", p.MemorySome, tags},
+				processMetric{"system.processes.pressure.memory.full", p.MemoryFull, tags},
+				processMetric{"system.processes.pressure.io.some", p.IOSome, tags},
+				processMetric{"system.processes.pressure.io.full", p.IOFull, tags},
+			)
+		}
+	}
+	return metrics
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,58 @@
+package checks
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+)
+
+func TestExtendedStatsMetrics(t *testing.T) {
+	procs := map[int32]*procutil.Process{
+		1: {
+			Pid:  1,
+			Name: "nginx",
+			Stats: &procutil.Stats{
+				OpenFdCount: 100,
+				FDLimit:     &procutil.FDLimitStat{Soft: 1000, Hard: 4096},
+				Sockets:     &procutil.SocketStat{TCPByState: map[string]int32{"listen": 2, "established": 10}, Unix: 1},
+				Pressure:    &procutil.PressureStat{CPUSome: 1.5, MemorySome: 0.5, IOSome: 3, IOFull: 1},
+			},
+		},
+		2: {
+			Pid:  2,
+			Name: "nginx",
+			Stats: &procutil.Stats{
+				OpenFdCount: 900,
+				FDLimit:     &procutil.FDLimitStat{Soft: 1000, Hard: 4096},
+				Sockets:     &procutil.SocketStat{TCPByState: map[string]int32{"established": 5}, UDP: 1, Other: 2},
+				Pressure:    &procutil.PressureStat{CPUSome: 2.5, MemoryFull: 0.25, IOSome: 1},
+			},
+		},
+		// no permission to list the file descriptors, nor extended stats
+		3: {
+			Pid:   3,
+			Name:  "sshd",
+			Stats: &procutil.Stats{OpenFdCount: -1, FDLimit: &procutil.FDLimitStat{Soft: -1, Hard: -1}},
+		},
+	}
+
+	nginx := []string{"process_name:nginx"}
+	sshd := []string{"process_name:sshd"}
+	assert.Equal(t, []processMetric{
+		{"system.processes.open_file_descriptors", 1000, nginx},
+		{"system.processes.open_file_descriptors_limit_pct", 90, nginx},
+		{"system.processes.sockets", 15, []string{"process_name:nginx", "socket_type:tcp", "state:established"}},
+		{"system.processes.sockets", 2, []string{"process_name:nginx", "socket_type:tcp", "state:listen"}},
+		{"system.processes.sockets", 1, []string{"process_name:nginx", "socket_type:udp"}},
+		{"system.processes.sockets", 1, []string{"process_name:nginx", "socket_type:unix"}},
+		{"system.processes.sockets", 2, []string{"process_name:nginx", "socket_type:other"}},
+		{"system.processes.pressure.cpu.some", 2.5, nginx},
+		{"system.processes.pressure.memory.some", 0.5, nginx},
+		{"system.processes.pressure.memory.full", 0.25, nginx},
+		{"system.processes.pressure.io.some", 3, nginx},
+		{"system.processes.pressure.io.full", 1, nginx},
+		{"system.processes.open_file_descriptors", 0, sshd},
+	}, extendedStatsMetrics(procs))
+}
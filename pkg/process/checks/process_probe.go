@@ -29,3 +29,12 @@ func getProcessProbe(cfg *config.AgentConfig) procutil.Probe {
 	})
 	return processProbe
 }
+
+// newProcessCheckProbe returns the probe of the process check. The extended stats are only collected
+// by the process check, so it gets its own probe when they are enabled instead of configuring the shared one.
+func newProcessCheckProbe(cfg *config.AgentConfig) procutil.Probe {
+	if !cfg.ExtendedStats || runtime.GOOS != "linux" {
+		return getProcessProbe(cfg)
+	}
+	return procutil.NewProcessProbe(procutil.WithExtendedStats(true))
+}
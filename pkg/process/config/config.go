@@ -129,6 +129,8 @@ type AgentConfig struct {
 	// Check config
 	EnabledChecks  []string
 	CheckIntervals map[string]time.Duration
+	// ExtendedStats enables the collection of the file descriptor limits, sockets and cgroup pressure of processes
+	ExtendedStats bool
 
 	// Internal store of a proxy used for generating the Transport
 	proxy proxyFunc
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+	"github.com/DataDog/datadog-agent/pkg/util/cmdlinescrubber"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -29,6 +30,7 @@ type DataScrubber struct {
 	Enabled           bool
 	StripAllArguments bool
 	SensitivePatterns []*regexp.Regexp
+	Rules             *cmdlinescrubber.RuleSet // structured rules scrubbing the arguments of specific executables
 	seenProcess       map[string]struct{}
 	scrubbedCmdlines  map[string][]string
 	cacheCycles       uint32 // used to control the cache age
@@ -141,6 +143,21 @@ func (ds *DataScrubber) ScrubProcessCommand(p *procutil.Process) []string {
 	return p.Cmdline
 }
 
+// ScrubCmdline scrubs a command line as ScrubProcessCommand does, honoring StripAllArguments and Enabled,
+// without caching the result
+func (ds *DataScrubber) ScrubCmdline(cmdline []string) []string {
+	if ds.StripAllArguments {
+		return ds.stripArguments(cmdline)
+	}
+
+	if !ds.Enabled {
+		return cmdline
+	}
+
+	scrubbed, _ := ds.ScrubCommand(cmdline)
+	return scrubbed
+}
+
 // IncrementCacheAge increments one cycle of cache memory age. If it reaches
 // cacheMaxCycles, the cache is restarted
 func (ds *DataScrubber) IncrementCacheAge() {
@@ -152,12 +169,12 @@ func (ds *DataScrubber) IncrementCacheAge() {
 	}
 }
 
-// ScrubCommand hides the argument value for any key which matches a "sensitive word" pattern.
+// ScrubCommand hides the argument value for any key which matches a "sensitive word" pattern,
+// or targeted by one of the scrubbing rules.
 // It returns the updated cmdline, as well as a boolean representing whether it was scrubbed
 func (ds *DataScrubber) ScrubCommand(cmdline []string) ([]string, bool) {
-	newCmdline := cmdline
-	rawCmdline := strings.Join(cmdline, " ")
-	changed := false
+	newCmdline, changed := ds.Rules.Scrub(cmdline)
+	rawCmdline := strings.Join(newCmdline, " ")
 	for _, pattern := range ds.SensitivePatterns {
 		if pattern.MatchString(rawCmdline) {
 			changed = true
@@ -186,3 +203,11 @@ func (ds *DataScrubber) AddCustomSensitiveWords(words []string) {
 	newPatterns := CompileStringsToRegex(words)
 	ds.SensitivePatterns = append(ds.SensitivePatterns, newPatterns...)
 }
+
+// AddScrubRules adds structured scrubbing rules on the DataScrubber object
+func (ds *DataScrubber) AddScrubRules(rules []cmdlinescrubber.Rule) error {
+	if ds.Rules == nil {
+		ds.Rules = &cmdlinescrubber.RuleSet{}
+	}
+	return ds.Rules.Add(rules...)
+}
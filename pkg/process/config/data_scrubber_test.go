@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+	"github.com/DataDog/datadog-agent/pkg/util/cmdlinescrubber"
 )
 
 func setupDataScrubber(t *testing.T) *DataScrubber {
@@ -417,6 +418,46 @@ func TestScrubWithCache(t *testing.T) {
 	assert.Equal(t, sensible, len(scrubber.scrubbedCmdlines))
 }
 
+func TestScrubCommandRules(t *testing.T) {
+	scrubber := NewDefaultDataScrubber()
+	err := scrubber.AddScrubRules([]cmdlinescrubber.Rule{
+		{Executable: "mysql*", Flags: []string{"-p", "--password"}, AttachedOnly: true},
+		{Executable: "vault-helper", Positions: []int{2}},
+	})
+	assert.NoError(t, err)
+
+	cases := []testCase{
+		{[]string{"mysqldump", "-u", "root", "-phunter2", "db"}, []string{"mysqldump", "-u", "root", "-p********", "db"}},
+		{[]string{"mysql", "--password=hunter2"}, []string{"mysql", "--password=********"}},
+		{[]string{"mysql", "-p", "db"}, []string{"mysql", "-p", "db"}},
+		{[]string{"/opt/bin/vault-helper", "login", "s.hunter2"}, []string{"/opt/bin/vault-helper", "login", "********"}},
+		// the regular sensitive words are still scrubbed
+		{[]string{"mysql", "--api_key", "1234"}, []string{"mysql", "--api_key", "********"}},
+		{[]string{"psql", "-phunter2"}, []string{"psql", "-phunter2"}},
+	}
+
+	for i := range cases {
+		cases[i].cmdline, _ = scrubber.ScrubCommand(cases[i].cmdline)
+		assert.Equal(t, cases[i].parsedCmdline, cases[i].cmdline)
+	}
+
+	assert.Error(t, scrubber.AddScrubRules([]cmdlinescrubber.Rule{{Flags: []string{"password"}}}))
+}
+
+func TestScrubCmdline(t *testing.T) {
+	scrubber := NewDefaultDataScrubber()
+	assert.NoError(t, scrubber.AddScrubRules([]cmdlinescrubber.Rule{{Executable: "mysql", Flags: []string{"-p"}}}))
+	cmdline := []string{"mysql", "-phunter2", "--api_key", "1234"}
+
+	assert.Equal(t, []string{"mysql", "-p********", "--api_key", "********"}, scrubber.ScrubCmdline(cmdline))
+
+	scrubber.Enabled = false
+	assert.Equal(t, cmdline, scrubber.ScrubCmdline(cmdline))
+
+	scrubber.StripAllArguments = true
+	assert.Equal(t, []string{"mysql"}, scrubber.ScrubCmdline(cmdline))
+}
+
 func BenchmarkRegexMatching1(b *testing.B)    { benchmarkRegexMatching(1, b) }
 func BenchmarkRegexMatching10(b *testing.B)   { benchmarkRegexMatching(10, b) }
 func BenchmarkRegexMatching100(b *testing.B)  { benchmarkRegexMatching(100, b) }
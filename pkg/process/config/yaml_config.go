@@ -14,6 +14,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	apicfg "github.com/DataDog/datadog-agent/pkg/process/util/api/config"
+	"github.com/DataDog/datadog-agent/pkg/util/cmdlinescrubber"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/profiling"
@@ -145,11 +146,25 @@ func (a *AgentConfig) LoadProcessYamlConfig(path string) error {
 		a.Scrubber.AddCustomSensitiveWords(config.Datadog.GetStringSlice(k))
 	}
 
+	// Structured rules scrubbing the arguments of specific executables
+	if k := key(ns, "custom_scrub_rules"); config.Datadog.IsSet(k) {
+		var rules []cmdlinescrubber.Rule
+		if err := config.Datadog.UnmarshalKey(k, &rules); err != nil {
+			return errors.Errorf("invalid %s -- %s", k, err)
+		}
+		if err := a.Scrubber.AddScrubRules(rules); err != nil {
+			return errors.Errorf("invalid %s -- %s", k, err)
+		}
+	}
+
 	// Strips all process arguments
 	if config.Datadog.GetBool(key(ns, "strip_proc_arguments")) {
 		a.Scrubber.StripAllArguments = true
 	}
 
+	// Collects the file descriptor limits, sockets and cgroup pressure of processes, submitted as system.processes.* metrics
+	a.ExtendedStats = config.Datadog.GetBool(key(ns, "extended_stats.enabled"))
+
 	// How many check results to buffer in memory when POST fails. The default is usually fine.
 	if k := key(ns, "queue_size"); config.Datadog.IsSet(k) {
 		if queueSize := config.Datadog.GetInt(k); queueSize > 0 {
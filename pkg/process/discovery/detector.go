@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package discovery
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+)
+
+type processKey struct {
+	pid        int32
+	createTime int64
+}
+
+// Detector detects the services run by processes. Detections are cached for the lifetime
+// of the processes, as neither their command line nor their environment change.
+type Detector struct {
+	procRoot string
+	services map[processKey]*Service
+
+	// the following are replaced in tests
+	readEnv    func(procRoot string, pid int32) map[string]string
+	isGoBinary func(procRoot string, pid int32) bool
+}
+
+// NewDetector returns a Detector reading the environment and the executables of processes from procRoot
+func NewDetector(procRoot string) *Detector {
+	return &Detector{
+		procRoot:   procRoot,
+		services:   make(map[processKey]*Service),
+		readEnv:    readEnv,
+		isGoBinary: isGoBinary,
+	}
+}
+
+// Detect returns the services run by the given processes, by pid.
+// The detections of the processes which are not part of procs anymore are dropped.
+func (d *Detector) Detect(procs map[int32]*procutil.Process) map[int32]*Service {
+	services := make(map[int32]*Service, len(procs))
+	seen := make(map[processKey]struct{}, len(procs))
+
+	for pid, proc := range procs {
+		key := processKey{pid: pid}
+		if proc.Stats != nil {
+			key.createTime = proc.Stats.CreateTime
+		}
+		seen[key] = struct{}{}
+
+		s, ok := d.services[key]
+		if !ok {
+			s = d.detect(proc)
+			d.services[key] = s
+		}
+		services[pid] = s
+	}
+
+	for key := range d.services {
+		if _, ok := seen[key]; !ok {
+			delete(d.services, key)
+		}
+	}
+	return services
+}
+
+func (d *Detector) detect(proc *procutil.Process) *Service {
+	s := Detect(ProcessInfo{
+		Cmdline: proc.Cmdline,
+		Cwd:     proc.Cwd,
+		Env:     d.readEnv(d.procRoot, proc.Pid),
+		IsGoBinary: func() bool {
+			return d.isGoBinary(d.procRoot, proc.Pid)
+		},
+	})
+	return &s
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/process/procutil"
+)
+
+func TestDetectorCache(t *testing.T) {
+	envReads := make(map[int32]int)
+	d := NewDetector("/proc")
+	d.readEnv = func(_ string, pid int32) map[string]string {
+		envReads[pid]++
+		if pid == 2 {
+			return map[string]string{"DD_SERVICE": "cart"}
+		}
+		return nil
+	}
+	d.isGoBinary = func(_ string, pid int32) bool { return pid == 3 }
+
+	procs := map[int32]*procutil.Process{
+		1: {Pid: 1, Cmdline: []string{"java", "-jar", "orders.jar"}, Stats: &procutil.Stats{CreateTime: 100}},
+		2: {Pid: 2, Cmdline: []string{"node", "index.js"}, Stats: &procutil.Stats{CreateTime: 100}},
+		3: {Pid: 3, Cmdline: []string{"/bin/router"}, Stats: &procutil.Stats{CreateTime: 100}},
+	}
+
+	services := d.Detect(procs)
+	require.Len(t, services, 3)
+	assert.Equal(t, &Service{Language: LanguageJava, Name: "orders", NameSource: SourceJar}, services[1])
+	assert.Equal(t, &Service{Language: LanguageNode, Name: "cart", NameSource: SourceEnv}, services[2])
+	assert.Equal(t, &Service{Language: LanguageGo, Name: "router", NameSource: SourceExecutable}, services[3])
+
+	// known processes are not inspected again
+	d.Detect(procs)
+	assert.Equal(t, map[int32]int{1: 1, 2: 1, 3: 1}, envReads)
+
+	// the pid 1 is reused by a new process, and the process 3 exits
+	procs[1] = &procutil.Process{Pid: 1, Cmdline: []string{"python", "-m", "gunicorn"}, Stats: &procutil.Stats{CreateTime: 200}}
+	delete(procs, 3)
+
+	services = d.Detect(procs)
+	require.Len(t, services, 2)
+	assert.Equal(t, &Service{Language: LanguagePython, Name: "gunicorn", NameSource: SourceModule}, services[1])
+	assert.Equal(t, map[int32]int{1: 2, 2: 1, 3: 1}, envReads)
+	assert.Len(t, d.services, 2)
+}
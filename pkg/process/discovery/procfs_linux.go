@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build linux
+
+package discovery
+
+import (
+	"bytes"
+	"debug/elf"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+)
+
+// readEnv returns the environment variables of a process listed in EnvVars.
+// The other variables are never kept around, as they may hold secrets.
+func readEnv(procRoot string, pid int32) map[string]string {
+	data, err := ioutil.ReadFile(filepath.Join(procRoot, strconv.Itoa(int(pid)), "environ"))
+	if err != nil {
+		return nil
+	}
+	return parseEnviron(data)
+}
+
+func parseEnviron(data []byte) map[string]string {
+	var env map[string]string
+	for _, kv := range bytes.Split(data, []byte{0}) {
+		idx := bytes.IndexByte(kv, '=')
+		if idx <= 0 {
+			continue
+		}
+
+		key := string(kv[:idx])
+		for _, name := range EnvVars {
+			if key != name {
+				continue
+			}
+			if env == nil {
+				env = make(map[string]string)
+			}
+			env[key] = string(kv[idx+1:])
+			break
+		}
+	}
+	return env
+}
+
+// isGoBinary returns whether the executable of a process holds the sections added by the Go linker
+func isGoBinary(procRoot string, pid int32) bool {
+	// the exe link is opened rather than resolved, as the path it points to belongs to the mount namespace of the process
+	f, err := elf.Open(filepath.Join(procRoot, strconv.Itoa(int(pid)), "exe"))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	return f.Section(".go.buildinfo") != nil || f.Section(".note.go.buildid") != nil
+}
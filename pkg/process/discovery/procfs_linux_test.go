@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build linux
+
+package discovery
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnviron(t *testing.T) {
+	environ := []byte("HOME=/root\x00DD_SERVICE=billing\x00API_KEY=secret\x00NODE_OPTIONS=--require=dd-trace/init\x00=bad\x00")
+	assert.Equal(t, map[string]string{
+		"DD_SERVICE":   "billing",
+		"NODE_OPTIONS": "--require=dd-trace/init",
+	}, parseEnviron(environ))
+
+	assert.Nil(t, parseEnviron([]byte("HOME=/root\x00")))
+}
+
+func TestIsGoBinary(t *testing.T) {
+	// the test binary itself is a go binary
+	assert.True(t, isGoBinary("/proc", int32(os.Getpid())))
+	assert.False(t, isGoBinary("/proc", -1))
+}
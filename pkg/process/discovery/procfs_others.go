@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build !linux
+
+package discovery
+
+// readEnv is only implemented on linux
+func readEnv(procRoot string, pid int32) map[string]string {
+	return nil
+}
+
+// isGoBinary is only implemented on linux
+func isGoBinary(procRoot string, pid int32) bool {
+	return false
+}
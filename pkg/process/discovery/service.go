@@ -0,0 +1,282 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package discovery infers the language and the service name of the processes found by the
+// process discovery check, along with whether they already run a Datadog tracing library.
+package discovery
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Language is the language or runtime a process is running
+type Language string
+
+// Languages detected from the command lines and the executables of processes
+const (
+	LanguageUnknown Language = ""
+	LanguageJava    Language = "java"
+	LanguagePython  Language = "python"
+	LanguageNode    Language = "node"
+	LanguageGo      Language = "go"
+	LanguageDotNet  Language = "dotnet"
+)
+
+// NameSource tells where the name of a service was inferred from
+type NameSource string
+
+// Sources of the service names, from the most to the least reliable
+const (
+	SourceEnv          NameSource = "env"
+	SourceJavaProperty NameSource = "java_property"
+	SourceJar          NameSource = "jar"
+	SourceMainClass    NameSource = "main_class"
+	SourceModule       NameSource = "module"
+	SourceScript       NameSource = "script"
+	SourceDLL          NameSource = "dll"
+	SourceWorkingDir   NameSource = "working_dir"
+	SourceExecutable   NameSource = "executable"
+)
+
+// Service is what is inferred of the service a process is running
+type Service struct {
+	Language   Language   `json:"language"`
+	Name       string     `json:"name"`
+	NameSource NameSource `json:"name_source"`
+	// Instrumented is set when the process is found to load a Datadog tracing library
+	Instrumented bool `json:"instrumented"`
+}
+
+// ProcessInfo is the information about a process the detection is based on
+type ProcessInfo struct {
+	Cmdline []string
+	Cwd     string
+	// Env only holds the environment variables listed in EnvVars
+	Env map[string]string
+	// IsGoBinary reports whether the executable of the process was built by the Go toolchain.
+	// It is only called when the language can't be inferred from the command line.
+	IsGoBinary func() bool
+}
+
+// EnvVars are the only environment variables of processes the detection looks at
+var EnvVars = []string{
+	"DD_SERVICE",
+	"JAVA_TOOL_OPTIONS",
+	"PYTHONPATH",
+	"NODE_OPTIONS",
+	"CORECLR_ENABLE_PROFILING",
+	"CORECLR_PROFILER_PATH",
+}
+
+var (
+	pythonExecutable = regexp.MustCompile(`^(python|pypy)[0-9.]*$`)
+	// jarVersion matches the version suffixes of jar names, e.g. `-1.2.3` or `-2.0-SNAPSHOT`
+	jarVersion = regexp.MustCompile(`-[0-9]+(\.[0-9]+)*([.-][A-Za-z0-9]+)*$`)
+
+	// genericScripts are script names which don't tell anything about the service they run
+	genericScripts = map[string]struct{}{
+		"__main__": {},
+		"app":      {},
+		"index":    {},
+		"main":     {},
+		"manage":   {},
+		"run":      {},
+		"server":   {},
+		"start":    {},
+		"wsgi":     {},
+	}
+
+	// javaOptionsWithValue are the options of the java launcher followed by a value
+	javaOptionsWithValue = map[string]struct{}{
+		"-cp":                   {},
+		"-classpath":            {},
+		"--class-path":          {},
+		"-p":                    {},
+		"--module-path":         {},
+		"--add-modules":         {},
+		"--add-opens":           {},
+		"--add-exports":         {},
+		"--add-reads":           {},
+		"--patch-module":        {},
+		"--upgrade-module-path": {},
+	}
+)
+
+// Detect infers the language and the name of the service run by a process
+func Detect(p ProcessInfo) Service {
+	if len(p.Cmdline) == 0 {
+		return Service{}
+	}
+
+	var s Service
+	s.Language = detectLanguage(p)
+	s.Instrumented = isInstrumented(s.Language, p)
+
+	if name := p.Env["DD_SERVICE"]; name != "" {
+		s.Name, s.NameSource = name, SourceEnv
+		return s
+	}
+
+	args := p.Cmdline[1:]
+	switch s.Language {
+	case LanguageJava:
+		s.Name, s.NameSource = javaServiceName(args)
+	case LanguagePython:
+		s.Name, s.NameSource = pythonServiceName(args, p.Cwd)
+	case LanguageNode:
+		s.Name, s.NameSource = nodeServiceName(args, p.Cwd)
+	case LanguageDotNet:
+		s.Name, s.NameSource = dotnetServiceName(args)
+	}
+
+	if s.Name == "" {
+		s.Name, s.NameSource = executableName(p.Cmdline[0]), SourceExecutable
+	}
+	return s
+}
+
+func executableName(exe string) string {
+	return filepath.Base(exe)
+}
+
+func detectLanguage(p ProcessInfo) Language {
+	exe := executableName(p.Cmdline[0])
+	switch {
+	case exe == "java":
+		return LanguageJava
+	case pythonExecutable.MatchString(exe):
+		return LanguagePython
+	case exe == "node" || exe == "nodejs":
+		return LanguageNode
+	case exe == "dotnet":
+		return LanguageDotNet
+	case p.IsGoBinary != nil && p.IsGoBinary():
+		return LanguageGo
+	}
+	return LanguageUnknown
+}
+
+func isInstrumented(lang Language, p ProcessInfo) bool {
+	switch lang {
+	case LanguageJava:
+		options := append([]string{p.Env["JAVA_TOOL_OPTIONS"]}, p.Cmdline[1:]...)
+		for _, option := range options {
+			if strings.Contains(option, "-javaagent:") && strings.Contains(option, "dd-java-agent") {
+				return true
+			}
+		}
+	case LanguagePython:
+		// ddtrace-run execs the python interpreter with the bootstrap of ddtrace in the python path
+		return strings.Contains(p.Env["PYTHONPATH"], filepath.Join("ddtrace", "bootstrap"))
+	case LanguageNode:
+		options := append([]string{p.Env["NODE_OPTIONS"]}, p.Cmdline[1:]...)
+		for _, option := range options {
+			if strings.Contains(option, "dd-trace") {
+				return true
+			}
+		}
+	case LanguageDotNet:
+		return p.Env["CORECLR_ENABLE_PROFILING"] == "1" &&
+			strings.Contains(strings.ToLower(p.Env["CORECLR_PROFILER_PATH"]), "datadog")
+	}
+	return false
+}
+
+func javaServiceName(args []string) (string, NameSource) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case strings.HasPrefix(arg, "-Ddd.service="):
+			if name := strings.TrimPrefix(arg, "-Ddd.service="); name != "" {
+				return name, SourceJavaProperty
+			}
+		case arg == "-jar":
+			if i+1 < len(args) {
+				jar := strings.TrimSuffix(filepath.Base(args[i+1]), ".jar")
+				return jarVersion.ReplaceAllString(jar, ""), SourceJar
+			}
+			return "", ""
+		case strings.HasPrefix(arg, "-"):
+			if _, ok := javaOptionsWithValue[arg]; ok {
+				i++
+			}
+		default:
+			// the first argument which is not an option is the main class, followed by its own arguments
+			mainClass := arg
+			if idx := strings.LastIndex(mainClass, "."); idx >= 0 {
+				mainClass = mainClass[idx+1:]
+			}
+			return mainClass, SourceMainClass
+		}
+	}
+	return "", ""
+}
+
+func pythonServiceName(args []string, cwd string) (string, NameSource) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-m":
+			if i+1 < len(args) {
+				return strings.TrimSuffix(args[i+1], ".__main__"), SourceModule
+			}
+			return "", ""
+		case arg == "-c":
+			// inline program, nothing to infer the service from
+			return "", ""
+		case arg == "-W" || arg == "-X" || arg == "--check-hash-based-pycs":
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			return scriptServiceName(arg, ".py", cwd)
+		}
+	}
+	return "", ""
+}
+
+func nodeServiceName(args []string, cwd string) (string, NameSource) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-e" || arg == "--eval" || arg == "-p" || arg == "--print":
+			return "", ""
+		case arg == "-r" || arg == "--require" || arg == "--loader" || arg == "--import":
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			return scriptServiceName(arg, filepath.Ext(arg), cwd)
+		}
+	}
+	return "", ""
+}
+
+// scriptServiceName returns the name of a script without its extension, falling back to the name of
+// the directory it belongs to when the name of the script is too generic to identify a service
+func scriptServiceName(script, ext, cwd string) (string, NameSource) {
+	name := strings.TrimSuffix(filepath.Base(script), ext)
+	if _, ok := genericScripts[name]; !ok {
+		return name, SourceScript
+	}
+
+	dir := filepath.Dir(script)
+	if !filepath.IsAbs(dir) && cwd != "" {
+		dir = filepath.Join(cwd, dir)
+	}
+	if base := filepath.Base(dir); dir != "." && base != string(filepath.Separator) {
+		return base, SourceWorkingDir
+	}
+	return name, SourceScript
+}
+
+func dotnetServiceName(args []string) (string, NameSource) {
+	for _, arg := range args {
+		if strings.HasSuffix(arg, ".dll") {
+			return strings.TrimSuffix(filepath.Base(arg), ".dll"), SourceDLL
+		}
+	}
+	return "", ""
+}
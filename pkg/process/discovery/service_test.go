@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		process  ProcessInfo
+		expected Service
+	}{
+		{
+			name: "jar",
+			process: ProcessInfo{
+				Cmdline: []string{"/usr/bin/java", "-Xmx1g", "-cp", "lib/*", "-jar", "/opt/billing-api-1.4.2-SNAPSHOT.jar", "--port", "8080"},
+			},
+			expected: Service{Language: LanguageJava, Name: "billing-api", NameSource: SourceJar},
+		},
+		{
+			name: "java main class",
+			process: ProcessInfo{
+				Cmdline: []string{"java", "-classpath", "/app/classes", "com.example.orders.OrdersApplication", "serve"},
+			},
+			expected: Service{Language: LanguageJava, Name: "OrdersApplication", NameSource: SourceMainClass},
+		},
+		{
+			name: "java instrumented with dd.service property",
+			process: ProcessInfo{
+				Cmdline: []string{"java", "-javaagent:/opt/dd-java-agent.jar", "-Ddd.service=payments", "-jar", "app.jar"},
+			},
+			expected: Service{Language: LanguageJava, Name: "payments", NameSource: SourceJavaProperty, Instrumented: true},
+		},
+		{
+			name: "java instrumented through JAVA_TOOL_OPTIONS",
+			process: ProcessInfo{
+				Cmdline: []string{"java", "-jar", "app.jar"},
+				Env:     map[string]string{"JAVA_TOOL_OPTIONS": "-javaagent:/dd/dd-java-agent.jar"},
+			},
+			expected: Service{Language: LanguageJava, Name: "app", NameSource: SourceJar, Instrumented: true},
+		},
+		{
+			name: "DD_SERVICE takes precedence",
+			process: ProcessInfo{
+				Cmdline: []string{"java", "-Ddd.service=payments", "-jar", "app.jar"},
+				Env:     map[string]string{"DD_SERVICE": "checkout"},
+			},
+			expected: Service{Language: LanguageJava, Name: "checkout", NameSource: SourceEnv},
+		},
+		{
+			name: "python module",
+			process: ProcessInfo{
+				Cmdline: []string{"/usr/bin/python3.9", "-u", "-m", "celery", "worker"},
+			},
+			expected: Service{Language: LanguagePython, Name: "celery", NameSource: SourceModule},
+		},
+		{
+			name: "python script",
+			process: ProcessInfo{
+				Cmdline: []string{"python", "-W", "ignore", "/srv/scripts/ingest.py"},
+			},
+			expected: Service{Language: LanguagePython, Name: "ingest", NameSource: SourceScript},
+		},
+		{
+			name: "python generic script in working directory",
+			process: ProcessInfo{
+				Cmdline: []string{"python3", "manage.py", "runserver"},
+				Cwd:     "/home/web/inventory",
+				Env:     map[string]string{"PYTHONPATH": "/usr/lib/python3/site-packages/ddtrace/bootstrap"},
+			},
+			expected: Service{Language: LanguagePython, Name: "inventory", NameSource: SourceWorkingDir, Instrumented: true},
+		},
+		{
+			name: "python inline program",
+			process: ProcessInfo{
+				Cmdline: []string{"pypy3", "-c", "print(1)"},
+			},
+			expected: Service{Language: LanguagePython, Name: "pypy3", NameSource: SourceExecutable},
+		},
+		{
+			name: "node generic script",
+			process: ProcessInfo{
+				Cmdline: []string{"node", "--require", "dd-trace/init", "/srv/frontend/server.js"},
+			},
+			expected: Service{Language: LanguageNode, Name: "frontend", NameSource: SourceWorkingDir, Instrumented: true},
+		},
+		{
+			name: "node script",
+			process: ProcessInfo{
+				Cmdline: []string{"nodejs", "worker.mjs"},
+			},
+			expected: Service{Language: LanguageNode, Name: "worker", NameSource: SourceScript},
+		},
+		{
+			name: "dotnet",
+			process: ProcessInfo{
+				Cmdline: []string{"dotnet", "/app/Shipping.Api.dll"},
+				Env: map[string]string{
+					"CORECLR_ENABLE_PROFILING": "1",
+					"CORECLR_PROFILER_PATH":    "/opt/datadog/Datadog.Trace.ClrProfiler.Native.so",
+				},
+			},
+			expected: Service{Language: LanguageDotNet, Name: "Shipping.Api", NameSource: SourceDLL, Instrumented: true},
+		},
+		{
+			name: "go binary",
+			process: ProcessInfo{
+				Cmdline:    []string{"/usr/local/bin/router", "--config", "/etc/router.yaml"},
+				IsGoBinary: func() bool { return true },
+			},
+			expected: Service{Language: LanguageGo, Name: "router", NameSource: SourceExecutable},
+		},
+		{
+			name: "unknown",
+			process: ProcessInfo{
+				Cmdline:    []string{"/usr/sbin/nginx", "-g", "daemon off;"},
+				IsGoBinary: func() bool { return false },
+			},
+			expected: Service{Language: LanguageUnknown, Name: "nginx", NameSource: SourceExecutable},
+		},
+		{
+			name:     "empty command line",
+			process:  ProcessInfo{},
+			expected: Service{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Detect(tc.process))
+		})
+	}
+}
+
+func TestDetectGoBinaryOnlyWhenNeeded(t *testing.T) {
+	Detect(ProcessInfo{
+		Cmdline: []string{"java", "-jar", "app.jar"},
+		IsGoBinary: func() bool {
+			t.Fatal("the executable shouldn't be read when the command line tells the language")
+			return false
+		},
+	})
+}
@@ -0,0 +1,293 @@
+// +build linux
+
+package procutil
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+var tcpStates = map[string]string{
+	"01": "established",
+	"02": "syn_sent",
+	"03": "syn_recv",
+	"04": "fin_wait1",
+	"05": "fin_wait2",
+	"06": "time_wait",
+	"07": "close",
+	"08": "close_wait",
+	"09": "last_ack",
+	"0A": "listen",
+	"0B": "closing",
+}
+
+type socketKind int
+
+const (
+	socketTCP socketKind = iota
+	socketUDP
+	socketUnix
+)
+
+type socketInfo struct {
+	kind     socketKind
+	tcpState string
+}
+
+// extendedStatsCache holds what is shared by processes during a collection:
+// the sockets of the network namespaces, and the pressure of the cgroups
+type extendedStatsCache struct {
+	socketsByNetNS   map[string]map[uint64]socketInfo
+	pressureByCgroup map[string]*PressureStat
+}
+
+func newExtendedStatsCache() *extendedStatsCache {
+	return &extendedStatsCache{
+		socketsByNetNS:   make(map[string]map[uint64]socketInfo),
+		pressureByCgroup: make(map[string]*PressureStat),
+	}
+}
+
+// fillExtendedStats collects the file descriptor limits, the sockets and the cgroup pressure of a process.
+// Unlike the open file descriptor count, the sockets are collected whether or not the probe is allowed to
+// collect the fields requiring elevated permissions, as the system-probe doesn't provide them: they are
+// only missing for the processes whose file descriptors the agent isn't allowed to list.
+func (p *probe) fillExtendedStats(pidPath string, stats *Stats, cache *extendedStatsCache) {
+	stats.FDLimit = p.parseLimits(pidPath)         // /proc/[pid]/limits
+	stats.Pressure = p.getPressure(pidPath, cache) // /proc/[pid]/cgroup, /sys/fs/cgroup/[path]/*.pressure
+	stats.Sockets = p.getSockets(pidPath, cache)   // /proc/[pid]/fd, /proc/[pid]/net/*, requires permission checks
+}
+
+// parseLimits extracts the limits on open files from /proc/(pid)/limits
+func (p *probe) parseLimits(pidPath string) *FDLimitStat {
+	content, err := ioutil.ReadFile(filepath.Join(pidPath, "limits"))
+	if err != nil {
+		return nil
+	}
+
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if !bytes.HasPrefix(line, []byte("Max open files")) {
+			continue
+		}
+		// Max open files            1024                 524288               files
+		fields := strings.Fields(string(line[len("Max open files"):]))
+		if len(fields) < 2 {
+			return nil
+		}
+		return &FDLimitStat{
+			Soft: parseLimit(fields[0]),
+			Hard: parseLimit(fields[1]),
+		}
+	}
+	return nil
+}
+
+func parseLimit(value string) int64 {
+	if value == "unlimited" {
+		return -1
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return limit
+}
+
+// getSockets counts the sockets opened by a process, looking up the state of its TCP sockets
+// in the tables of its network namespace
+func (p *probe) getSockets(pidPath string, cache *extendedStatsCache) *SocketStat {
+	fdPath := filepath.Join(pidPath, "fd")
+	if err := p.ensurePathReadable(fdPath); err != nil {
+		return nil
+	}
+
+	d, err := os.Open(fdPath)
+	if err != nil {
+		return nil
+	}
+	fds, err := d.Readdirnames(-1)
+	d.Close()
+	if err != nil {
+		return nil
+	}
+
+	var inodes []uint64
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(fdPath, fd))
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+		if err != nil {
+			continue
+		}
+		inodes = append(inodes, inode)
+	}
+
+	stats := &SocketStat{TCPByState: make(map[string]int32)}
+	if len(inodes) == 0 {
+		return stats
+	}
+
+	// processes sharing a network namespace share its socket tables, which are only read once
+	netNS, _ := os.Readlink(filepath.Join(pidPath, "ns", "net"))
+	sockets, ok := cache.socketsByNetNS[netNS]
+	if !ok {
+		sockets = readSocketTables(filepath.Join(pidPath, "net"))
+		if netNS != "" {
+			cache.socketsByNetNS[netNS] = sockets
+		}
+	}
+
+	for _, inode := range inodes {
+		socket, ok := sockets[inode]
+		switch {
+		case !ok:
+			stats.Other++
+		case socket.kind == socketTCP:
+			stats.TCPByState[socket.tcpState]++
+		case socket.kind == socketUDP:
+			stats.UDP++
+		case socket.kind == socketUnix:
+			stats.Unix++
+		}
+	}
+	return stats
+}
+
+// readSocketTables returns the sockets of a network namespace by inode
+func readSocketTables(netPath string) map[uint64]socketInfo {
+	sockets := make(map[uint64]socketInfo)
+	for _, table := range []struct {
+		file       string
+		kind       socketKind
+		inodeField int
+	}{
+		{"tcp", socketTCP, 9},
+		{"tcp6", socketTCP, 9},
+		{"udp", socketUDP, 9},
+		{"udp6", socketUDP, 9},
+		{"unix", socketUnix, 6},
+	} {
+		f, err := os.Open(filepath.Join(netPath, table.file))
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		// skip the header
+		scanner.Scan()
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) <= table.inodeField {
+				continue
+			}
+			inode, err := strconv.ParseUint(fields[table.inodeField], 10, 64)
+			if err != nil || inode == 0 {
+				continue
+			}
+
+			socket := socketInfo{kind: table.kind}
+			if table.kind == socketTCP {
+				// st is the 4th field of the tcp tables
+				socket.tcpState = tcpStates[fields[3]]
+				if socket.tcpState == "" {
+					socket.tcpState = "unknown"
+				}
+			}
+			sockets[inode] = socket
+		}
+		f.Close()
+	}
+	return sockets
+}
+
+// getPressure returns the pressure stall information of the cgroup v2 of a process
+func (p *probe) getPressure(pidPath string, cache *extendedStatsCache) *PressureStat {
+	content, err := ioutil.ReadFile(filepath.Join(pidPath, "cgroup"))
+	if err != nil {
+		return nil
+	}
+
+	var cgroupPath string
+	for _, line := range strings.Split(string(content), "\n") {
+		// the unified hierarchy is listed as 0::/path
+		if strings.HasPrefix(line, "0::") {
+			cgroupPath = strings.TrimPrefix(line, "0::")
+			break
+		}
+	}
+	if cgroupPath == "" {
+		return nil
+	}
+
+	if pressure, ok := cache.pressureByCgroup[cgroupPath]; ok {
+		return pressure
+	}
+
+	dir := filepath.Join(unifiedCgroupRoot(p.cgroupRootLoc), cgroupPath)
+	cpuSome, _, cpuErr := parsePressure(filepath.Join(dir, "cpu.pressure"))
+	memorySome, memoryFull, memoryErr := parsePressure(filepath.Join(dir, "memory.pressure"))
+	ioSome, ioFull, ioErr := parsePressure(filepath.Join(dir, "io.pressure"))
+
+	var pressure *PressureStat
+	// pressure files are missing when the kernel doesn't support PSI or when it is disabled
+	if cpuErr == nil || memoryErr == nil || ioErr == nil {
+		pressure = &PressureStat{
+			CPUSome:    cpuSome,
+			MemorySome: memorySome,
+			MemoryFull: memoryFull,
+			IOSome:     ioSome,
+			IOFull:     ioFull,
+		}
+	}
+	cache.pressureByCgroup[cgroupPath] = pressure
+	return pressure
+}
+
+// unifiedCgroupRoot returns where the cgroup v2 hierarchy is mounted: at the root of the cgroup
+// filesystems in unified mode, or in its `unified` directory in hybrid mode
+func unifiedCgroupRoot(cgroupRoot string) string {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err == nil {
+		return cgroupRoot
+	}
+	if hybridRoot := filepath.Join(cgroupRoot, "unified"); util.PathExists(hybridRoot) {
+		return hybridRoot
+	}
+	return cgroupRoot
+}
+
+// parsePressure returns the avg10 values of the `some` and `full` lines of a pressure file,
+// e.g. `some avg10=0.00 avg60=0.00 avg300=0.00 total=0`
+func parsePressure(path string) (some float64, full float64, err error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "avg10=") {
+			continue
+		}
+		value, err := strconv.ParseFloat(strings.TrimPrefix(fields[1], "avg10="), 64)
+		if err != nil {
+			continue
+		}
+
+		switch fields[0] {
+		case "some":
+			some = value
+		case "full":
+			full = value
+		}
+	}
+	return some, full, nil
+}
@@ -0,0 +1,176 @@
+// +build linux
+
+package procutil
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestParseLimits(t *testing.T) {
+	pidPath := t.TempDir()
+	probe := getProbe()
+
+	assert.Nil(t, probe.parseLimits(pidPath))
+
+	writeTestFile(t, filepath.Join(pidPath, "limits"), `Limit                     Soft Limit           Hard Limit           Units
+Max cpu time              unlimited            unlimited            seconds
+Max open files            1024                 524288               files
+Max locked memory         65536                65536                bytes
+`)
+	assert.Equal(t, &FDLimitStat{Soft: 1024, Hard: 524288}, probe.parseLimits(pidPath))
+
+	writeTestFile(t, filepath.Join(pidPath, "limits"), "Max open files            unlimited            unlimited            files\n")
+	assert.Equal(t, &FDLimitStat{Soft: -1, Hard: -1}, probe.parseLimits(pidPath))
+}
+
+func TestGetSockets(t *testing.T) {
+	procRoot := t.TempDir()
+	probe := getProbeWithPermission()
+	cache := newExtendedStatsCache()
+
+	tcp := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1001 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:1F90 0100007F:C350 01 00000000:00000000 00:00000000 00000000     0        0 1002 1 0000000000000000 20 4 30 10 -1
+`
+	tcp6 := `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000001000000:1F91 00000000000000000000000001000000:C351 08 00000000:00000000 00:00000000 00000000     0        0 1003 1 0000000000000000 20 4 30 10 -1
+`
+	udp := `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  100: 00000000:0044 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 1004 2 0000000000000000 0
+`
+	unix := `Num       RefCount Protocol Flags    Type St Inode Path
+0000000000000000: 00000002 00000000 00010000 0001 01 1005 /run/app.sock
+`
+
+	for _, pid := range []string{"1", "2"} {
+		pidPath := filepath.Join(procRoot, pid)
+		writeTestFile(t, filepath.Join(pidPath, "net", "tcp"), tcp)
+		writeTestFile(t, filepath.Join(pidPath, "net", "tcp6"), tcp6)
+		writeTestFile(t, filepath.Join(pidPath, "net", "udp"), udp)
+		writeTestFile(t, filepath.Join(pidPath, "net", "unix"), unix)
+		require.NoError(t, os.MkdirAll(filepath.Join(pidPath, "ns"), 0755))
+		require.NoError(t, os.Symlink("net:[4026531992]", filepath.Join(pidPath, "ns", "net")))
+		require.NoError(t, os.MkdirAll(filepath.Join(pidPath, "fd"), 0755))
+	}
+
+	links := map[string]string{
+		"0": "/dev/null",
+		"1": "socket:[1001]",
+		"2": "socket:[1002]",
+		"3": "socket:[1003]",
+		"4": "socket:[1004]",
+		"5": "socket:[1005]",
+		"6": "socket:[9999]",
+		"7": "anon_inode:[eventpoll]",
+	}
+	for fd, link := range links {
+		require.NoError(t, os.Symlink(link, filepath.Join(procRoot, "1", "fd", fd)))
+	}
+	require.NoError(t, os.Symlink("socket:[1002]", filepath.Join(procRoot, "2", "fd", "0")))
+
+	assert.Equal(t, &SocketStat{
+		TCPByState: map[string]int32{"listen": 1, "established": 1, "close_wait": 1},
+		UDP:        1,
+		Unix:       1,
+		Other:      1,
+	}, probe.getSockets(filepath.Join(procRoot, "1"), cache))
+
+	// the tables of the network namespace are read once
+	require.Len(t, cache.socketsByNetNS, 1)
+	require.NoError(t, os.RemoveAll(filepath.Join(procRoot, "2", "net")))
+	assert.Equal(t, &SocketStat{
+		TCPByState: map[string]int32{"established": 1},
+	}, probe.getSockets(filepath.Join(procRoot, "2"), cache))
+}
+
+func TestGetSocketsSelf(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	probe := getProbeWithPermission()
+	sockets := probe.getSockets(filepath.Join("/proc", strconv.Itoa(os.Getpid())), newExtendedStatsCache())
+	require.NotNil(t, sockets)
+	assert.GreaterOrEqual(t, sockets.TCPByState["listen"], int32(1))
+}
+
+func TestGetPressure(t *testing.T) {
+	procRoot := t.TempDir()
+	probe := getProbe()
+	probe.cgroupRootLoc = t.TempDir()
+	cache := newExtendedStatsCache()
+
+	v1 := filepath.Join(procRoot, "1")
+	writeTestFile(t, filepath.Join(v1, "cgroup"), "12:memory:/docker/abc\n1:name=systemd:/docker/abc\n")
+	assert.Nil(t, probe.getPressure(v1, cache))
+
+	v2 := filepath.Join(procRoot, "2")
+	writeTestFile(t, filepath.Join(v2, "cgroup"), "0::/system.slice/app.service\n")
+	// no pressure files when PSI is disabled
+	assert.Nil(t, probe.getPressure(v2, cache))
+
+	cgroup := filepath.Join(probe.cgroupRootLoc, "system.slice", "app.service")
+	writeTestFile(t, filepath.Join(cgroup, "cpu.pressure"), "some avg10=12.50 avg60=3.00 avg300=1.00 total=123456\n")
+	writeTestFile(t, filepath.Join(cgroup, "memory.pressure"), "some avg10=4.25 avg60=1.00 avg300=0.50 total=1234\nfull avg10=2.00 avg60=0.50 avg300=0.10 total=567\n")
+	writeTestFile(t, filepath.Join(cgroup, "io.pressure"), "some avg10=0.75 avg60=0.10 avg300=0.00 total=89\nfull avg10=0.50 avg60=0.00 avg300=0.00 total=12\n")
+
+	expected := &PressureStat{CPUSome: 12.5, MemorySome: 4.25, MemoryFull: 2, IOSome: 0.75, IOFull: 0.5}
+	assert.Equal(t, expected, probe.getPressure(v2, newExtendedStatsCache()))
+
+	// the pressure of a cgroup is only read once per collection
+	cache = newExtendedStatsCache()
+	probe.getPressure(v2, cache)
+	require.NoError(t, os.RemoveAll(cgroup))
+	assert.Equal(t, expected, probe.getPressure(v2, cache))
+}
+
+func TestGetPressureHybrid(t *testing.T) {
+	procRoot := t.TempDir()
+	probe := getProbe()
+	probe.cgroupRootLoc = t.TempDir()
+
+	// in hybrid mode, the cgroup v2 hierarchy is mounted next to the v1 controllers
+	require.NoError(t, os.MkdirAll(filepath.Join(probe.cgroupRootLoc, "memory"), 0755))
+	cgroup := filepath.Join(probe.cgroupRootLoc, "unified", "system.slice", "app.service")
+	writeTestFile(t, filepath.Join(cgroup, "cpu.pressure"), "some avg10=1.50 avg60=0.00 avg300=0.00 total=1234\n")
+
+	pidPath := filepath.Join(procRoot, "1")
+	writeTestFile(t, filepath.Join(pidPath, "cgroup"), "4:memory:/system.slice/app.service\n0::/system.slice/app.service\n")
+	assert.Equal(t, &PressureStat{CPUSome: 1.5}, probe.getPressure(pidPath, newExtendedStatsCache()))
+}
+
+func TestProcessesByPIDExtendedStats(t *testing.T) {
+	// the sockets don't depend on the permission to collect the open file descriptor count
+	probe := getProbe(WithExtendedStats(true))
+	defer probe.Close()
+
+	procs, err := probe.ProcessesByPID(time.Now(), true)
+	require.NoError(t, err)
+
+	self, ok := procs[int32(os.Getpid())]
+	require.True(t, ok)
+	require.NotNil(t, self.Stats.FDLimit)
+	assert.NotZero(t, self.Stats.FDLimit.Soft)
+	assert.NotNil(t, self.Stats.Sockets)
+
+	// the extended stats aren't collected by the real-time collection
+	stats, err := probe.StatsForPIDs([]int32{int32(os.Getpid())}, time.Now())
+	require.NoError(t, err)
+	require.Contains(t, stats, int32(os.Getpid()))
+	assert.Nil(t, stats[int32(os.Getpid())].FDLimit)
+	assert.Nil(t, stats[int32(os.Getpid())].Sockets)
+}
@@ -20,3 +20,9 @@ func WithPermission(enabled bool) Option {
 func WithBootTimeRefreshInterval(bootTimeRefreshInterval time.Duration) Option {
 	return func(p Probe) {}
 }
+
+// WithExtendedStats configures if process collection should fetch the file descriptor limits,
+// the sockets and the cgroup pressure of processes
+func WithExtendedStats(enabled bool) Option {
+	return func(p Probe) {}
+}
@@ -74,6 +74,17 @@ func WithPermission(enabled bool) Option {
 	}
 }
 
+// WithExtendedStats configures if process collection should fetch the file descriptor limits,
+// the sockets and the cgroup pressure of processes. They are only collected along with the other
+// stats of ProcessesByPID, not by StatsForPIDs, which is called at a higher frequency.
+func WithExtendedStats(enabled bool) Option {
+	return func(p Probe) {
+		if linuxProbe, ok := p.(*probe); ok {
+			linuxProbe.withExtendedStats = enabled
+		}
+	}
+}
+
 // WithBootTimeRefreshInterval configures the boot time refresh interval
 func WithBootTimeRefreshInterval(bootTimeRefreshInterval time.Duration) Option {
 	return func(p Probe) {
@@ -93,8 +104,11 @@ type probe struct {
 	bootTime     uint64
 	exit         chan struct{}
 
+	cgroupRootLoc string // cgroup filesystems, where the cgroup v2 hierarchy is read for the pressure of cgroups
+
 	// configurations
 	withPermission          bool
+	withExtendedStats       bool
 	returnZeroPermStats     bool
 	bootTimeRefreshInterval time.Duration
 }
@@ -109,6 +123,7 @@ func NewProcessProbe(options ...Option) Probe {
 
 	p := &probe{
 		procRootLoc:             hostProc,
+		cgroupRootLoc:           util.HostSys("fs", "cgroup"),
 		uid:                     uint32(os.Getuid()),
 		euid:                    uint32(os.Geteuid()),
 		clockTicks:              getClockTicks(),
@@ -202,6 +217,10 @@ func (p *probe) ProcessesByPID(now time.Time, collectStats bool) (map[int32]*Pro
 	}
 
 	procsByPID := make(map[int32]*Process, len(pids))
+	var extendedCache *extendedStatsCache
+	if collectStats && p.withExtendedStats {
+		extendedCache = newExtendedStatsCache()
+	}
 	for _, pid := range pids {
 		pathForPID := filepath.Join(p.procRootLoc, strconv.Itoa(int(pid)))
 		if !util.PathExists(pathForPID) {
@@ -261,6 +280,9 @@ func (p *probe) ProcessesByPID(now time.Time, collectStats bool) (map[int32]*Pro
 				WriteBytes: -1,
 			} // use -1 values to represent "no permission"
 		}
+		if extendedCache != nil {
+			p.fillExtendedStats(pathForPID, proc.Stats, extendedCache)
+		}
 		procsByPID[pid] = proc
 	}
 
@@ -69,6 +69,11 @@ type Stats struct {
 	IOStat      *IOCountersStat
 	IORateStat  *IOCountersRateStat
 	CtxSwitches *NumCtxSwitchesStat
+
+	// The following are only collected when extended stats are enabled
+	FDLimit  *FDLimitStat
+	Sockets  *SocketStat
+	Pressure *PressureStat
 }
 
 // DeepCopy creates a deep copy of Stats
@@ -108,6 +113,17 @@ func (s *Stats) DeepCopy() *Stats {
 		copy.CtxSwitches = &NumCtxSwitchesStat{}
 		*copy.CtxSwitches = *s.CtxSwitches
 	}
+	if s.FDLimit != nil {
+		copy.FDLimit = &FDLimitStat{}
+		*copy.FDLimit = *s.FDLimit
+	}
+	if s.Sockets != nil {
+		copy.Sockets = s.Sockets.DeepCopy()
+	}
+	if s.Pressure != nil {
+		copy.Pressure = &PressureStat{}
+		*copy.Pressure = *s.Pressure
+	}
 	return copy
 }
 
@@ -190,6 +206,48 @@ type NumCtxSwitchesStat struct {
 	Involuntary int64
 }
 
+// FDLimitStat holds the limits on the number of files a process can open, -1 meaning unlimited
+type FDLimitStat struct {
+	Soft int64
+	Hard int64
+}
+
+// SocketStat holds the number of sockets opened by a process
+type SocketStat struct {
+	// TCPByState is the number of TCP sockets by state, e.g. `established` or `listen`
+	TCPByState map[string]int32
+	UDP        int32
+	Unix       int32
+	// Other are the sockets of other families, or which couldn't be found in the tables of the kernel
+	Other int32
+}
+
+// DeepCopy creates a deep copy of SocketStat
+func (s *SocketStat) DeepCopy() *SocketStat {
+	copy := &SocketStat{
+		UDP:   s.UDP,
+		Unix:  s.Unix,
+		Other: s.Other,
+	}
+	if s.TCPByState != nil {
+		copy.TCPByState = make(map[string]int32, len(s.TCPByState))
+		for state, count := range s.TCPByState {
+			copy.TCPByState[state] = count
+		}
+	}
+	return copy
+}
+
+// PressureStat holds the pressure stall information of the cgroup of a process, as the
+// percentage of the last 10 seconds some or all of its tasks were stalled on a resource
+type PressureStat struct {
+	CPUSome    float64
+	MemorySome float64
+	MemoryFull float64
+	IOSome     float64
+	IOFull     float64
+}
+
 // ConvertAllFilledProcesses takes a group of FilledProcess objects and convert them into Process
 func ConvertAllFilledProcesses(processes map[int32]*process.FilledProcess) map[int32]*Process {
 	result := make(map[int32]*Process, len(processes))
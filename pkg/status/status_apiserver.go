@@ -9,6 +9,7 @@ package status
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
@@ -28,7 +29,24 @@ func getLeaderElectionDetails() map[string]string {
 	leaderElectionStats["acquiredTime"] = record.AcquireTime.Format(time.RFC1123)
 	leaderElectionStats["renewedTime"] = record.RenewTime.Format(time.RFC1123)
 	leaderElectionStats["transitions"] = fmt.Sprintf("%d transitions", record.LeaderTransitions)
+	leaderElectionStats["leaseAge"] = time.Since(record.AcquireTime.Time).Round(time.Second).String()
 	leaderElectionStats["status"] = "Running"
+
+	le, err := leaderelection.GetLeaderEngine()
+	if err != nil {
+		return leaderElectionStats
+	}
+
+	health := le.GetHealth()
+	if health.LastCheck.IsZero() {
+		return leaderElectionStats
+	}
+	leaderElectionStats["lastHealthCheck"] = health.LastCheck.Format(time.RFC1123)
+	leaderElectionStats["stepDowns"] = fmt.Sprintf("%d", health.StepDowns)
+	if health.SplitBrain {
+		leaderElectionStats["status"] = "Split-brain"
+		leaderElectionStats["conflictingLeaders"] = strings.Join(health.ConflictingLeaders, ", ")
+	}
 	return leaderElectionStats
 }
 
@@ -79,7 +79,7 @@ func TestCgroupV2CPUStats(t *testing.T) {
 			Avg10:  float64Ptr(42.64),
 			Avg60:  float64Ptr(43.72),
 			Avg300: float64Ptr(25.76),
-			Total:  uint64Ptr(114289003),
+			Total:  uint64Ptr(114289003 * uint64(time.Microsecond)),
 		},
 	}, *stats))
 
@@ -104,7 +104,7 @@ func TestCgroupV2CPUStats(t *testing.T) {
 			Avg10:  float64Ptr(42.64),
 			Avg60:  float64Ptr(43.72),
 			Avg300: float64Ptr(25.76),
-			Total:  uint64Ptr(114289003),
+			Total:  uint64Ptr(114289003 * uint64(time.Microsecond)),
 		},
 	}, *stats))
 }
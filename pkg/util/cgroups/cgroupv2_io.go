@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func (c *cgroupV2) GetIOStats(stats *IOStats) error {
@@ -54,6 +55,7 @@ func (c *cgroupV2) GetIOStats(stats *IOStats) error {
 }
 
 // format for io.stat "259:0 rbytes=278528 wbytes=9700089856 rios=6 wios=2289428 dbytes=0 dios=0"
+// with io.latency configured, io.stat also holds "depth=max avg_lat=2153 win=100", avg_lat being in microseconds
 // format for io.max "8:16 rbps=2097152 wbps=max riops=max wiops=120"
 func parseV2IOFn(stats *IOStats) func([]string) error {
 	return func(fields []string) error {
@@ -71,6 +73,11 @@ func parseV2IOFn(stats *IOStats) func([]string) error {
 				continue
 			}
 
+			// other controllers (e.g. io.cost) add their own stats, some of them not being integers
+			if !knownV2IOKeys[parts[0]] {
+				continue
+			}
+
 			// max appears in io.max, it means no limit, not reporting in this case
 			if parts[1] == "max" {
 				continue
@@ -111,6 +118,9 @@ func parseV2IOFn(stats *IOStats) func([]string) error {
 			case "wiops":
 				written = true
 				device.WriteOperationsLimit = &val
+			case "avg_lat":
+				written = true
+				device.AvgLatency = uint64Ptr(val * uint64(time.Microsecond))
 			}
 		}
 
@@ -121,3 +131,15 @@ func parseV2IOFn(stats *IOStats) func([]string) error {
 		return nil
 	}
 }
+
+var knownV2IOKeys = map[string]bool{
+	"rbytes":  true,
+	"wbytes":  true,
+	"rios":    true,
+	"wios":    true,
+	"rbps":    true,
+	"wbps":    true,
+	"riops":   true,
+	"wiops":   true,
+	"avg_lat": true,
+}
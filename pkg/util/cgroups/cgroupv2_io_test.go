@@ -17,7 +17,7 @@ import (
 
 const (
 	sampleCgroupV2IOStat = `259:0 rbytes=278528 wbytes=11623899136 rios=6 wios=2744940 dbytes=0 dios=0
-8:16 rbytes=278528 wbytes=11623899136 rios=6 wios=2744940 dbytes=0 dios=0`
+8:16 rbytes=278528 wbytes=11623899136 rios=6 wios=2744940 dbytes=0 dios=0 use_delay=0 delay_nsec=0 depth=max avg_lat=2153 win=100 cost.vrate=98.76 cost.usage=1234`
 	sampleCgroupV2IOMax     = "8:16 rbps=2097152 wbps=max riops=max wiops=120"
 	sampleCroupV2IOPressure = `some avg10=0.00 avg60=0.00 avg300=0.00 total=0
 full avg10=0.00 avg60=0.00 avg300=0.00 total=0`
@@ -74,6 +74,7 @@ func TestCgroupV2IOStats(t *testing.T) {
 				WriteOperations:      uint64Ptr(2744940),
 				ReadBytesLimit:       uint64Ptr(2097152),
 				WriteOperationsLimit: uint64Ptr(120),
+				AvgLatency:           uint64Ptr(2153000),
 			},
 		},
 		PSISome: PSIStats{
@@ -81,6 +81,7 @@ func (c *cgroupV2) GetMemoryStats(stats *MemoryStats) error {
 	if err := parse2ColumnStatsWithMapping(c.fr, c.pathFor("memory.events"), 0, 1, map[string]**uint64{
 		"oom":      &stats.OOMEvents,
 		"oom_kill": &stats.OOMKiilEvents,
+		"high":     &stats.HighEvents,
 	}); err != nil {
 		reportError(err)
 	}
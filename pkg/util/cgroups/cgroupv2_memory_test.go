@@ -129,6 +129,7 @@ func TestCgroupV2MemoryStats(t *testing.T) {
 		KernelMemory:  uint64Ptr(49152),
 		OOMEvents:     uint64Ptr(3),
 		OOMKiilEvents: uint64Ptr(0),
+		HighEvents:    uint64Ptr(1),
 		PSISome: PSIStats{
 			Avg10:  float64Ptr(0),
 			Avg60:  float64Ptr(0),
@@ -16,6 +16,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -198,7 +199,8 @@ func parsePSI(fr fileReader, path string, somePsi, fullPsi *PSIStats) error {
 					reportError(newValueError("", fmt.Errorf("unexpected format for psi file at: %s, part: %d, content: %v", path, i, fields[i])))
 					continue
 				}
-				psiStats.Total = &total
+				// total is in microseconds
+				psiStats.Total = uint64Ptr(total * uint64(time.Microsecond))
 			}
 		}
 
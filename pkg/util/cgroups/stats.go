@@ -49,6 +49,7 @@ type MemoryStats struct {
 	// This field is mapped to `memory.failcnt` for cgroupv1 and to "oom" in `memory.event`, it does not mean an OOMKill event happened.
 	OOMEvents     *uint64 // Number (no unit).
 	OOMKiilEvents *uint64 // cgroupv2 only
+	HighEvents    *uint64 // cgroupv2 only, number of times the usage went over the high threshold
 
 	Limit             *uint64
 	MinThreshold      *uint64 // cgroupv2 only
@@ -101,6 +102,8 @@ type DeviceIOStats struct {
 	WriteBytesLimit      *uint64 // cgroupv2 only (bytes/s)
 	ReadOperationsLimit  *uint64 // cgroupv2 only (ops/s)
 	WriteOperationsLimit *uint64 // cgroupv2 only (ops/s)
+
+	AvgLatency *uint64 // cgroupv2 only, requires io.latency to be configured for the device
 }
 
 // IOStats store I/O statistics about a cgroup. Devices identifier in map is MAJOR:MINOR
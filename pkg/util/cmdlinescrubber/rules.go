@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package cmdlinescrubber implements the structured scrubbing rules applied to the
+// command lines of processes, targeting the flags and positional arguments of specific executables.
+package cmdlinescrubber
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Replacement is the value replacing the scrubbed arguments
+const Replacement = "********"
+
+// Rule scrubs arguments from the command lines of the executables it targets
+type Rule struct {
+	// Executable is a glob pattern matched against the base name of the executable, e.g. `java` or `python*`.
+	// Rules without executable apply to all command lines.
+	Executable string `mapstructure:"executable" yaml:"executable" json:"executable"`
+	// Flags are the flags whose values are scrubbed. The value of a flag is either part of the same
+	// argument, e.g. `-Djavax.net.ssl.keyStorePassword=value`, `--password=value` or `-pvalue` for
+	// single letter flags, or the following argument, e.g. `--password value`.
+	Flags []string `mapstructure:"flags" yaml:"flags" json:"flags"`
+	// AttachedOnly restricts the values of the flags to the ones part of the same argument, for the flags
+	// whose value is optional, e.g. `mysql -p dbname` prompts for the password and `dbname` isn't scrubbed.
+	AttachedOnly bool `mapstructure:"attached_only" yaml:"attached_only" json:"attached_only"`
+	// Positions are the 1-based positions of the arguments scrubbed, the executable being at position 0
+	Positions []int `mapstructure:"positions" yaml:"positions" json:"positions"`
+}
+
+// Validate returns an error if the rule is malformed
+func (r Rule) Validate() error {
+	if _, err := path.Match(r.Executable, ""); err != nil {
+		return fmt.Errorf("invalid executable pattern %q: %w", r.Executable, err)
+	}
+	if len(r.Flags) == 0 && len(r.Positions) == 0 {
+		return fmt.Errorf("rule for executable %q has neither flags nor positions", r.Executable)
+	}
+	if r.AttachedOnly && len(r.Flags) == 0 {
+		return fmt.Errorf("rule for executable %q is attached only but has no flags", r.Executable)
+	}
+	for _, flag := range r.Flags {
+		if len(flag) < 2 || flag[0] != '-' || flag == "--" {
+			return fmt.Errorf("invalid flag %q, flags must start with a dash", flag)
+		}
+	}
+	for _, position := range r.Positions {
+		if position < 1 {
+			return fmt.Errorf("invalid position %d, positions start at 1", position)
+		}
+	}
+	return nil
+}
+
+// Matches returns whether the rule applies to the given executable
+func (r Rule) Matches(executable string) bool {
+	if r.Executable == "" {
+		return true
+	}
+	matched, _ := path.Match(r.Executable, filepath.Base(executable))
+	return matched
+}
+
+// RuleSet scrubs command lines according to a list of rules
+type RuleSet struct {
+	rules []Rule
+}
+
+// NewRuleSet validates the given rules and returns a RuleSet applying them
+func NewRuleSet(rules []Rule) (*RuleSet, error) {
+	for i, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid scrubbing rule #%d: %w", i, err)
+		}
+	}
+	return &RuleSet{rules: rules}, nil
+}
+
+// Len returns the number of rules of the set
+func (rs *RuleSet) Len() int {
+	if rs == nil {
+		return 0
+	}
+	return len(rs.rules)
+}
+
+// Add appends rules to the set
+func (rs *RuleSet) Add(rules ...Rule) error {
+	for i, r := range rules {
+		if err := r.Validate(); err != nil {
+			return fmt.Errorf("invalid scrubbing rule #%d: %w", i, err)
+		}
+	}
+	rs.rules = append(rs.rules, rules...)
+	return nil
+}
+
+// MatchingRules returns the rules applying to the given command line
+func (rs *RuleSet) MatchingRules(cmdline []string) []Rule {
+	if rs.Len() == 0 {
+		return nil
+	}
+
+	args := splitCmdline(cmdline)
+	if len(args) == 0 {
+		return nil
+	}
+
+	var matching []Rule
+	for _, r := range rs.rules {
+		if r.Matches(args[0]) {
+			matching = append(matching, r)
+		}
+	}
+	return matching
+}
+
+// Scrub hides the arguments targeted by the rules applying to the command line.
+// It returns the updated command line, as well as a boolean representing whether it was scrubbed.
+// Like the sensitive words scrubbers, command lines made of a single space separated string are
+// returned split on spaces when scrubbed.
+func (rs *RuleSet) Scrub(cmdline []string) ([]string, bool) {
+	matching := rs.MatchingRules(cmdline)
+	if len(matching) == 0 {
+		return cmdline, false
+	}
+
+	args := append([]string(nil), splitCmdline(cmdline)...)
+	changed := false
+	for _, r := range matching {
+		if scrubArgs(r, args) {
+			changed = true
+		}
+	}
+
+	if !changed {
+		return cmdline, false
+	}
+	return args, true
+}
+
+func scrubArgs(r Rule, args []string) (changed bool) {
+	scrub := func(i int, value string) {
+		if args[i] != value {
+			args[i] = value
+			changed = true
+		}
+	}
+
+	for _, position := range r.Positions {
+		if position < len(args) {
+			scrub(position, Replacement)
+		}
+	}
+
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		for _, flag := range r.Flags {
+			switch {
+			case arg == flag:
+				// the value is the next argument, unless the value of the flag is optional
+				if !r.AttachedOnly && i+1 < len(args) {
+					i++
+					scrub(i, Replacement)
+				}
+			case strings.HasPrefix(arg, flag+"="):
+				scrub(i, flag+"="+Replacement)
+			case isShortFlag(flag) && strings.HasPrefix(arg, flag):
+				// single letter flags can be directly followed by their value, e.g. `mysql -psecret`
+				scrub(i, flag+Replacement)
+			default:
+				continue
+			}
+			break
+		}
+	}
+	return changed
+}
+
+func isShortFlag(flag string) bool {
+	return len(flag) == 2 && flag[0] == '-' && flag[1] != '-'
+}
+
+// splitCmdline splits command lines made of a single space separated string
+func splitCmdline(cmdline []string) []string {
+	if len(cmdline) == 1 && strings.Contains(cmdline[0], " ") {
+		return strings.Split(cmdline[0], " ")
+	}
+	return cmdline
+}
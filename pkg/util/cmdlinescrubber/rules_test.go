@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package cmdlinescrubber
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRuleSet(t *testing.T) *RuleSet {
+	rs, err := NewRuleSet([]Rule{
+		{Executable: "java", Flags: []string{"-Djavax.net.ssl.keyStorePassword", "-Djavax.net.ssl.trustStorePassword"}},
+		{Executable: "mysql*", Flags: []string{"-p", "--password"}, AttachedOnly: true},
+		{Executable: "redis-cli", Flags: []string{"-a"}},
+		{Executable: "vault-agent", Positions: []int{2}},
+	})
+	require.NoError(t, err)
+	return rs
+}
+
+func TestRuleSetScrub(t *testing.T) {
+	rs := testRuleSet(t)
+
+	cases := []struct {
+		cmdline  []string
+		expected []string
+		changed  bool
+	}{
+		{
+			[]string{"/usr/bin/java", "-Djavax.net.ssl.keyStorePassword=changeit", "-Dfoo=bar", "-jar", "app.jar"},
+			[]string{"/usr/bin/java", "-Djavax.net.ssl.keyStorePassword=********", "-Dfoo=bar", "-jar", "app.jar"},
+			true,
+		},
+		{
+			[]string{"java -Djavax.net.ssl.trustStorePassword=changeit -jar app.jar"},
+			[]string{"java", "-Djavax.net.ssl.trustStorePassword=********", "-jar", "app.jar"},
+			true,
+		},
+		{
+			[]string{"mysql", "-u", "root", "-psecret", "db"},
+			[]string{"mysql", "-u", "root", "-p********", "db"},
+			true,
+		},
+		{
+			[]string{"redis-cli", "-a", "secret", "ping"},
+			[]string{"redis-cli", "-a", "********", "ping"},
+			true,
+		},
+		{
+			// the password is prompted, the next argument isn't the value of the flag
+			[]string{"mysql", "-p", "dbname"},
+			[]string{"mysql", "-p", "dbname"},
+			false,
+		},
+		{
+			[]string{"mysqldump", "--password", "dbname"},
+			[]string{"mysqldump", "--password", "dbname"},
+			false,
+		},
+		{
+			[]string{"mysql", "--password=secret", "--port=3306"},
+			[]string{"mysql", "--password=********", "--port=3306"},
+			true,
+		},
+		{
+			[]string{"vault-agent", "login", "s.token", "extra"},
+			[]string{"vault-agent", "login", "********", "extra"},
+			true,
+		},
+		{
+			// flags of other executables are left untouched
+			[]string{"psql", "-psecret", "-Djavax.net.ssl.keyStorePassword=changeit"},
+			[]string{"psql", "-psecret", "-Djavax.net.ssl.keyStorePassword=changeit"},
+			false,
+		},
+		{
+			// the flag value is missing
+			[]string{"mysql", "-u", "root", "--password"},
+			[]string{"mysql", "-u", "root", "--password"},
+			false,
+		},
+		{
+			// the position is out of range
+			[]string{"vault-agent", "login"},
+			[]string{"vault-agent", "login"},
+			false,
+		},
+		{
+			nil,
+			nil,
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		scrubbed, changed := rs.Scrub(c.cmdline)
+		assert.Equal(t, c.expected, scrubbed)
+		assert.Equal(t, c.changed, changed)
+	}
+}
+
+func TestRuleSetScrubDoesNotModifyInput(t *testing.T) {
+	rs := testRuleSet(t)
+
+	cmdline := []string{"mysql", "-psecret"}
+	scrubbed, changed := rs.Scrub(cmdline)
+	assert.True(t, changed)
+	assert.Equal(t, []string{"mysql", "-p********"}, scrubbed)
+	assert.Equal(t, []string{"mysql", "-psecret"}, cmdline)
+}
+
+func TestRuleSetMatchingRules(t *testing.T) {
+	rs := testRuleSet(t)
+	require.NoError(t, rs.Add(Rule{Flags: []string{"--token"}}))
+	assert.Equal(t, 5, rs.Len())
+
+	matching := rs.MatchingRules([]string{"/opt/java/bin/java", "-version"})
+	require.Len(t, matching, 2)
+	assert.Equal(t, "java", matching[0].Executable)
+	assert.Equal(t, "", matching[1].Executable)
+
+	assert.Empty(t, (*RuleSet)(nil).MatchingRules([]string{"java"}))
+}
+
+func TestRuleValidate(t *testing.T) {
+	for _, r := range []Rule{
+		{Executable: "java"},
+		{Executable: "[java", Flags: []string{"-p"}},
+		{Executable: "java", Flags: []string{"password"}},
+		{Executable: "java", Flags: []string{"--"}},
+		{Executable: "java", Positions: []int{0}},
+		{Executable: "java", Positions: []int{1}, AttachedOnly: true},
+	} {
+		assert.Error(t, r.Validate(), "%+v", r)
+		_, err := NewRuleSet([]Rule{r})
+		assert.Error(t, err)
+	}
+
+	assert.NoError(t, Rule{Executable: "java", Flags: []string{"-p"}, Positions: []int{1}}.Validate())
+}
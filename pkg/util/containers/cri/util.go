@@ -28,6 +28,7 @@ var (
 
 type CRIClient interface {
 	ListContainerStats() (map[string]*pb.ContainerStats, error)
+	GetContainerStats(containerID string) (*pb.ContainerStats, error)
 	GetContainerStatus(containerID string) (*pb.ContainerStatus, error)
 	GetRuntime() string
 	GetRuntimeVersion() string
@@ -122,6 +123,19 @@ func (c *CRIUtil) ListContainerStats() (map[string]*pb.ContainerStats, error) {
 	return stats, nil
 }
 
+// GetContainerStats requests the stats of a container by its ID
+func (c *CRIUtil) GetContainerStats(containerID string) (*pb.ContainerStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
+	defer cancel()
+	request := &pb.ContainerStatsRequest{ContainerId: containerID}
+	r, err := c.client.ContainerStats(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.GetStats(), nil
+}
+
 // GetContainerStatus requests a container status by its ID
 func (c *CRIUtil) GetContainerStatus(containerID string) (*pb.ContainerStatus, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout)
@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"fmt"
+	"time"
+
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/cri"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics/provider"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	criCollectorID    = "cri"
+	criCacheKeyPrefix = "cri-stats-"
+	criCacheTTL       = 10 * time.Second
+)
+
+func init() {
+	provider.GetProvider().RegisterCollector(provider.CollectorMetadata{
+		ID:       criCollectorID,
+		Priority: 2, // Less than the "system" and runtime specific collectors, as the CRI only exposes a few stats
+		Runtimes: []string{provider.RuntimeNameCRIO, provider.RuntimeNameContainerd},
+		Factory: func() (provider.Collector, error) {
+			return newCRICollector()
+		},
+	})
+}
+
+type criCollector struct {
+	client cri.CRIClient
+}
+
+// NewCollector returns a collector getting stats from the CRI.
+// Unlike the collector returned by the provider, it's never replaced by a collector with a higher priority,
+// which allows the cri check to keep reporting the stats exposed by the CRI.
+func NewCollector() (provider.Collector, error) {
+	return newCRICollector()
+}
+
+func newCRICollector() (*criCollector, error) {
+	if !config.IsFeaturePresent(config.Cri) {
+		return nil, provider.ErrPermaFail
+	}
+
+	client, err := cri.GetUtil()
+	if err != nil {
+		return nil, provider.ConvertRetrierErr(err)
+	}
+
+	return &criCollector{client: client}, nil
+}
+
+// ID returns the collector ID.
+func (c *criCollector) ID() string {
+	return criCollectorID
+}
+
+// GetContainerStats returns stats by container ID.
+func (c *criCollector) GetContainerStats(containerID string, cacheValidity time.Duration) (*provider.ContainerStats, error) {
+	stats, err := c.getCRIContainerStats(containerID, cacheValidity)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildContainerStats(stats), nil
+}
+
+// GetContainerNetworkStats returns network stats by container ID.
+func (c *criCollector) GetContainerNetworkStats(containerID string, cacheValidity time.Duration, networks map[string]string) (*provider.ContainerNetworkStats, error) {
+	// Network stats are not available through the CRI
+	return nil, nil
+}
+
+func (c *criCollector) getCRIContainerStats(containerID string, cacheValidity time.Duration) (*pb.ContainerStats, error) {
+	cacheKey := criCacheKeyPrefix + containerID
+	if cachedStats, found := cache.Cache.Get(cacheKey); found {
+		entry := cachedStats.(criCacheEntry)
+		if entry.timestamp.Add(cacheValidity).After(time.Now()) {
+			log.Debugf("Got CRI stats from cache for container %s", containerID)
+			return entry.stats, nil
+		}
+	}
+
+	stats, err := c.client.GetContainerStats(containerID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get stats for container with ID %s: %w", containerID, err)
+	}
+	if stats == nil {
+		return nil, fmt.Errorf("no stats returned for container with ID %s", containerID)
+	}
+
+	cache.Cache.Set(cacheKey, criCacheEntry{stats: stats, timestamp: time.Now()}, criCacheTTL)
+
+	return stats, nil
+}
+
+type criCacheEntry struct {
+	stats     *pb.ContainerStats
+	timestamp time.Time
+}
+
+func buildContainerStats(stats *pb.ContainerStats) *provider.ContainerStats {
+	containerStats := &provider.ContainerStats{
+		Timestamp: time.Now(),
+	}
+
+	if cpuUsage := stats.GetCpu().GetUsageCoreNanoSeconds(); cpuUsage != nil {
+		containerStats.CPU = &provider.ContainerCPUStats{
+			Total: util.UIntToFloatPtr(cpuUsage.GetValue()),
+		}
+	}
+
+	// The working set is the closest stat to the RSS exposed by the CRI
+	if workingSet := stats.GetMemory().GetWorkingSetBytes(); workingSet != nil {
+		containerStats.Memory = &provider.ContainerMemStats{
+			RSS: util.UIntToFloatPtr(workingSet.GetValue()),
+		}
+	}
+
+	return containerStats
+}
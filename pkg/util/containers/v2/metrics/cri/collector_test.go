@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build cri
+
+package cri
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/DataDog/datadog-agent/pkg/util"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/cri/crimock"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics/provider"
+)
+
+func TestGetContainerStats(t *testing.T) {
+	const containerID = "cID"
+
+	mockedCriUtil := new(crimock.MockCRIClient)
+	mockedCriUtil.On("GetContainerStats", containerID).Return(&pb.ContainerStats{
+		Cpu: &pb.CpuUsage{
+			UsageCoreNanoSeconds: &pb.UInt64Value{Value: 1000},
+		},
+		Memory: &pb.MemoryUsage{
+			WorkingSetBytes: &pb.UInt64Value{Value: 2048},
+		},
+	}, nil).Once()
+
+	collector := &criCollector{client: mockedCriUtil}
+	stats, err := collector.GetContainerStats(containerID, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, cmp.Diff(&provider.ContainerCPUStats{Total: util.Float64Ptr(1000)}, stats.CPU))
+	assert.Empty(t, cmp.Diff(&provider.ContainerMemStats{RSS: util.Float64Ptr(2048)}, stats.Memory))
+
+	// Stats are served from the cache while they are valid
+	cachedStats, err := collector.GetContainerStats(containerID, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, stats.CPU, cachedStats.CPU)
+	mockedCriUtil.AssertExpectations(t)
+}
+
+func TestBuildContainerStatsMissingStats(t *testing.T) {
+	stats := buildContainerStats(&pb.ContainerStats{})
+	assert.Nil(t, stats.CPU)
+	assert.Nil(t, stats.Memory)
+}
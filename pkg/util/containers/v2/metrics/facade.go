@@ -10,6 +10,7 @@ import (
 
 	// Register all the collectors
 	_ "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics/containerd"
+	_ "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics/cri"
 	_ "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics/docker"
 	_ "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics/ecsfargate"
 	_ "github.com/DataDog/datadog-agent/pkg/util/containers/v2/metrics/system"
@@ -20,10 +20,15 @@ type ContainerMemStats struct {
 	Softlimit    *float64
 
 	// Linux-only fields
-	RSS       *float64
-	Cache     *float64
-	Swap      *float64
-	OOMEvents *float64 // Number of events where memory allocation failed
+	RSS              *float64
+	Cache            *float64
+	Swap             *float64
+	SwapLimit        *float64 // Memory+swap limit with cgroupv1, swap limit with cgroupv2
+	OOMEvents        *float64 // Number of events where memory allocation failed
+	OOMKillEvents    *float64 // Number of processes killed by the OOM killer, cgroupv2 only
+	HighEvents       *float64 // Number of times usage went over the high threshold, cgroupv2 only
+	PartialStallTime *float64 // Time at least one task was stalled on memory, cgroupv2 only
+	FullStallTime    *float64 // Time all tasks were stalled on memory, cgroupv2 only
 
 	// Windows-only fields
 	PrivateWorkingSet *float64
@@ -44,6 +49,7 @@ type ContainerCPUStats struct {
 	ElapsedPeriods   *float64
 	ThrottledPeriods *float64
 	ThrottledTime    *float64
+	PartialStallTime *float64 // Time at least one task was stalled on CPU, cgroupv2 only
 }
 
 // DeviceIOStats stores Device IO stats.
@@ -53,6 +59,9 @@ type DeviceIOStats struct {
 	WriteBytes      *float64
 	ReadOperations  *float64
 	WriteOperations *float64
+
+	// Linux-only fields
+	AvgLatency *float64 // Moving average of the I/O latency, cgroupv2 with io.latency only
 }
 
 // ContainerIOStats store I/O statistics about a container.
@@ -64,6 +73,10 @@ type ContainerIOStats struct {
 	WriteOperations *float64
 	OpenFiles       *float64
 
+	// Linux-only fields
+	PartialStallTime *float64 // Time at least one task was stalled on I/O, cgroupv2 only
+	FullStallTime    *float64 // Time all tasks were stalled on I/O, cgroupv2 only
+
 	Devices map[string]DeviceIOStats
 }
 
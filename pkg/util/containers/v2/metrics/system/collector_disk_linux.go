@@ -38,6 +38,8 @@ func buildIOStats(procPath string, cgs *cgroups.IOStats) *provider.ContainerIOSt
 	convertField(cgs.WriteBytes, &cs.WriteBytes)
 	convertField(cgs.ReadOperations, &cs.ReadOperations)
 	convertField(cgs.WriteOperations, &cs.WriteOperations)
+	convertField(cgs.PSISome.Total, &cs.PartialStallTime)
+	convertField(cgs.PSIFull.Total, &cs.FullStallTime)
 
 	deviceMapping, err := GetDiskDeviceMapping(procPath)
 	if err != nil {
@@ -50,10 +52,10 @@ func buildIOStats(procPath string, cgs *cgroups.IOStats) *provider.ContainerIOSt
 		if deviceName, found := deviceMapping[deviceID]; found {
 			targetDeviceStats := provider.DeviceIOStats{}
 			convertField(deviceStats.ReadBytes, &targetDeviceStats.ReadBytes)
-			convertField(deviceStats.ReadBytes, &targetDeviceStats.ReadBytes)
 			convertField(deviceStats.WriteBytes, &targetDeviceStats.WriteBytes)
 			convertField(deviceStats.ReadOperations, &targetDeviceStats.ReadOperations)
 			convertField(deviceStats.WriteOperations, &targetDeviceStats.WriteOperations)
+			convertField(deviceStats.AvgLatency, &targetDeviceStats.AvgLatency)
 
 			csDevicesStats[deviceName] = targetDeviceStats
 		}
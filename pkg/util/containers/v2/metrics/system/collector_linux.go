@@ -148,7 +148,12 @@ func buildMemoryStats(cgs *cgroups.MemoryStats) *provider.ContainerMemStats {
 	convertField(cgs.RSS, &cs.RSS)
 	convertField(cgs.Cache, &cs.Cache)
 	convertField(cgs.Swap, &cs.Swap)
+	convertField(cgs.SwapLimit, &cs.SwapLimit)
 	convertField(cgs.OOMEvents, &cs.OOMEvents)
+	convertField(cgs.OOMKiilEvents, &cs.OOMKillEvents)
+	convertField(cgs.HighEvents, &cs.HighEvents)
+	convertField(cgs.PSISome.Total, &cs.PartialStallTime)
+	convertField(cgs.PSIFull.Total, &cs.FullStallTime)
 
 	return cs
 }
@@ -167,6 +172,7 @@ func buildCPUStats(cgs *cgroups.CPUStats) *provider.ContainerCPUStats {
 	convertField(cgs.ElapsedPeriods, &cs.ElapsedPeriods)
 	convertField(cgs.ThrottledPeriods, &cs.ThrottledPeriods)
 	convertField(cgs.ThrottledTime, &cs.ThrottledTime)
+	convertField(cgs.PSISome.Total, &cs.PartialStallTime)
 
 	// Compute complex fields
 	cs.Limit = computeCPULimitPct(cgs)
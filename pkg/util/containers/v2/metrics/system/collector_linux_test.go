@@ -53,6 +53,7 @@ func TestBuildContainerMetrics(t *testing.T) {
 					RSS:          util.UInt64Ptr(300),
 					Cache:        util.UInt64Ptr(200),
 					Swap:         util.UInt64Ptr(0),
+					SwapLimit:    util.UInt64Ptr(500),
 					OOMEvents:    util.UInt64Ptr(10),
 				},
 				IO: &cgroups.IOStats{
@@ -95,6 +96,7 @@ func TestBuildContainerMetrics(t *testing.T) {
 					RSS:          util.Float64Ptr(300),
 					Cache:        util.Float64Ptr(200),
 					Swap:         util.Float64Ptr(0),
+					SwapLimit:    util.Float64Ptr(500),
 					OOMEvents:    util.Float64Ptr(10),
 				},
 				IO: &provider.ContainerIOStats{
@@ -110,6 +112,43 @@ func TestBuildContainerMetrics(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "cgroupv2 pressure and memory events",
+			cgs: cgroups.Stats{
+				CPU: &cgroups.CPUStats{
+					CPUCount: util.UInt64Ptr(10),
+					PSISome:  cgroups.PSIStats{Avg10: util.Float64Ptr(0.5), Total: util.UInt64Ptr(1000)},
+				},
+				Memory: &cgroups.MemoryStats{
+					OOMEvents:     util.UInt64Ptr(3),
+					OOMKiilEvents: util.UInt64Ptr(1),
+					HighEvents:    util.UInt64Ptr(42),
+					PSISome:       cgroups.PSIStats{Total: util.UInt64Ptr(2000)},
+					PSIFull:       cgroups.PSIStats{Total: util.UInt64Ptr(1500)},
+				},
+				IO: &cgroups.IOStats{
+					PSISome: cgroups.PSIStats{Total: util.UInt64Ptr(3000)},
+					PSIFull: cgroups.PSIStats{Total: util.UInt64Ptr(2500)},
+				},
+			},
+			want: &provider.ContainerStats{
+				CPU: &provider.ContainerCPUStats{
+					Limit:            util.Float64Ptr(1000),
+					PartialStallTime: util.Float64Ptr(1000),
+				},
+				Memory: &provider.ContainerMemStats{
+					OOMEvents:        util.Float64Ptr(3),
+					OOMKillEvents:    util.Float64Ptr(1),
+					HighEvents:       util.Float64Ptr(42),
+					PartialStallTime: util.Float64Ptr(2000),
+					FullStallTime:    util.Float64Ptr(1500),
+				},
+				IO: &provider.ContainerIOStats{
+					PartialStallTime: util.Float64Ptr(3000),
+					FullStallTime:    util.Float64Ptr(2500),
+				},
+			},
+		},
 		{
 			name: "limit cpu count no quota",
 			cgs: cgroups.Stats{
@@ -266,6 +266,54 @@ func (d *DockerUtil) dockerContainers(ctx context.Context, cfg *ContainerListCon
 	return ret, nil
 }
 
+// GetContainerNetworkNames returns the docker network name of the network interfaces of the given
+// running containers, keyed by container ID then by interface name.
+func (d *DockerUtil) GetContainerNetworkNames(ctx context.Context, cList []types.Container) map[string]map[string]string {
+	if time.Now().Sub(d.lastInvalidate) > invalidationInterval {
+		d.cleanupCaches(cList)
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	for _, c := range cList {
+		if c.State != containers.ContainerRunningState {
+			continue
+		}
+
+		// FIXME: We might need to invalidate this cache if a containers networks are changed live.
+		if _, ok := d.networkMappings[c.ID]; ok {
+			continue
+		}
+
+		i, err := d.Inspect(ctx, c.ID, false)
+		if err != nil {
+			log.Debugf("Error inspecting container %s: %s", c.ID, err)
+			continue
+		}
+		d.networkMappings[c.ID] = findDockerNetworks(c.ID, i.State.Pid, c)
+	}
+
+	// Resolve docker networks after we've processed all containers so all
+	// routing maps are available.
+	resolveDockerNetworks(d.networkMappings)
+
+	networkNames := make(map[string]map[string]string, len(cList))
+	for _, c := range cList {
+		networks, found := d.networkMappings[c.ID]
+		if !found {
+			continue
+		}
+
+		networkNames[c.ID] = make(map[string]string, len(networks))
+		for _, network := range networks {
+			networkNames[c.ID][network.iface] = network.dockerName
+		}
+	}
+
+	return networkNames
+}
+
 // Parse the health out of a container status. The format is either:
 //  - 'Up 5 seconds (health: starting)'
 //  - 'Up 18 hours (unhealthy)'
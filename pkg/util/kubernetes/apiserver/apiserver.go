@@ -89,6 +89,10 @@ type APIClient struct {
 	// DDInformerFactory gives access to informers for all datadoghq/ custom types
 	DDInformerFactory dynamicinformer.DynamicSharedInformerFactory
 
+	// DynamicInformerFactory gives access to informers for any resource type,
+	// it's used to collect the custom resources for the orchestrator explorer
+	DynamicInformerFactory dynamicinformer.DynamicSharedInformerFactory
+
 	// initRetry used to setup the APIClient
 	initRetry retry.Retrier
 
@@ -206,7 +210,8 @@ func GetKubeClient(timeout time.Duration) (kubernetes.Interface, error) {
 	return kubernetes.NewForConfig(clientConfig)
 }
 
-func getKubeDynamicClient(timeout time.Duration) (dynamic.Interface, error) {
+// GetKubeDynamicClient returns a dynamic client to the API server, 0 disables the timeout
+func GetKubeDynamicClient(timeout time.Duration) (dynamic.Interface, error) {
 	clientConfig, err := getClientConfig(timeout)
 	if err != nil {
 		return nil, err
@@ -236,7 +241,7 @@ func getKubeVPAClient(timeout time.Duration) (vpa.Interface, error) {
 func getWPAInformerFactory() (dynamicinformer.DynamicSharedInformerFactory, error) {
 	// default to 300s
 	resyncPeriodSeconds := time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period"))
-	client, err := getKubeDynamicClient(0) // No timeout for the Informers, to allow long watch.
+	client, err := GetKubeDynamicClient(0) // No timeout for the Informers, to allow long watch.
 	if err != nil {
 		log.Infof("Could not get apiserver client: %v", err)
 		return nil, err
@@ -256,7 +261,7 @@ func getDDClient(timeout time.Duration) (dynamic.Interface, error) {
 func getDDInformerFactory() (dynamicinformer.DynamicSharedInformerFactory, error) {
 	// default to 300s
 	resyncPeriodSeconds := time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period"))
-	client, err := getKubeDynamicClient(0) // No timeout for the Informers, to allow long watch.
+	client, err := GetKubeDynamicClient(0) // No timeout for the Informers, to allow long watch.
 	if err != nil {
 		log.Infof("Could not get apiserver client: %v", err)
 		return nil, err
@@ -264,6 +269,16 @@ func getDDInformerFactory() (dynamicinformer.DynamicSharedInformerFactory, error
 	return dynamicinformer.NewDynamicSharedInformerFactory(client, resyncPeriodSeconds*time.Second), nil
 }
 
+func getDynamicInformerFactory() (dynamicinformer.DynamicSharedInformerFactory, error) {
+	resyncPeriodSeconds := time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period"))
+	client, err := GetKubeDynamicClient(0) // No timeout for the Informers, to allow long watch.
+	if err != nil {
+		log.Infof("Could not get apiserver dynamic client: %v", err)
+		return nil, err
+	}
+	return dynamicinformer.NewDynamicSharedInformerFactory(client, resyncPeriodSeconds*time.Second), nil
+}
+
 func getInformerFactory() (informers.SharedInformerFactory, error) {
 	resyncPeriodSeconds := time.Duration(config.Datadog.GetInt64("kubernetes_informers_resync_period"))
 	client, err := GetKubeClient(0) // No timeout for the Informers, to allow long watch.
@@ -299,7 +314,7 @@ func (c *APIClient) connect() error {
 	}
 
 	if config.Datadog.GetBool("admission_controller.enabled") || config.Datadog.GetBool("compliance_config.enabled") {
-		c.DynamicCl, err = getKubeDynamicClient(time.Duration(c.timeoutSeconds) * time.Second)
+		c.DynamicCl, err = GetKubeDynamicClient(time.Duration(c.timeoutSeconds) * time.Second)
 		if err != nil {
 			log.Infof("Could not get apiserver dynamic client: %v", err)
 			return err
@@ -325,6 +340,11 @@ func (c *APIClient) connect() error {
 		c.UnassignedPodInformerFactory, err = getInformerFactoryWithOption(
 			informers.WithTweakListOptions(tweakListOptions),
 		)
+
+		// the custom resources are optional, failing to collect them must not prevent the client from connecting
+		if c.DynamicInformerFactory, err = getDynamicInformerFactory(); err != nil {
+			log.Errorf("Error getting the dynamic informer factory, custom resources won't be collected: %s", err.Error())
+		}
 	}
 
 	if config.Datadog.GetBool("admission_controller.enabled") {
@@ -351,7 +371,7 @@ func (c *APIClient) connect() error {
 			log.Errorf("Error getting WPA Informer Factory: %s", err.Error())
 			return err
 		}
-		if c.WPAClient, err = getKubeDynamicClient(time.Duration(c.timeoutSeconds) * time.Second); err != nil {
+		if c.WPAClient, err = GetKubeDynamicClient(time.Duration(c.timeoutSeconds) * time.Second); err != nil {
 			log.Errorf("Error getting WPA Client: %s", err.Error())
 			return err
 		}
@@ -557,6 +577,24 @@ func (c *APIClient) GetNodeForPod(ctx context.Context, namespace, podName string
 	return pod.Spec.NodeName, nil
 }
 
+// GetNodeForPodIP returns the name of the node a pod with the given IP is scheduled on.
+// Pods using the host network share the IP of their node, so any of them is a match.
+func (c *APIClient) GetNodeForPodIP(ctx context.Context, podIP string) (string, error) {
+	pods, err := c.Cl.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector:  fields.OneTermEqualSelector("status.podIP", podIP).String(),
+		TimeoutSeconds: &c.timeoutSeconds,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" {
+			return pod.Spec.NodeName, nil
+		}
+	}
+	return "", fmt.Errorf("no scheduled pod found with IP %s", podIP)
+}
+
 // GetMetadataMapBundleOnAllNodes is used for the CLI svcmap command to run fetch the metadata map of all nodes.
 func GetMetadataMapBundleOnAllNodes(cl *APIClient) (*apiv1.MetadataResponse, error) {
 	stats := apiv1.NewMetadataResponse()
@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -65,6 +66,24 @@ type LeaderEngine struct {
 
 	// leaderMetric indicates whether this instance is leader
 	leaderMetric telemetry.Gauge
+
+	// cancelRun interrupts the current leader election run, it's used to step down
+	cancelRun context.CancelFunc
+	runMutex  sync.Mutex
+
+	// health is the result of the last health check of the leader election
+	health      Health
+	healthMutex sync.RWMutex
+	// leaseHolder is the lease holder reported in the telemetry, only used by the health check
+	leaseHolder string
+
+	// getPeerState queries the leader election state of another replica given its IP
+	getPeerState func(ip string) (*LeaderState, error)
+
+	leaseAgeMetric    telemetry.Gauge
+	transitionsMetric telemetry.Gauge
+	splitBrainMetric  telemetry.Gauge
+	stepDownMetric    telemetry.Counter
 }
 
 func newLeaderEngine() *LeaderEngine {
@@ -74,6 +93,12 @@ func newLeaderEngine() *LeaderEngine {
 		ServiceName:     config.Datadog.GetString("cluster_agent.kubernetes_service_name"),
 		leaderMetric:    metrics.NewLeaderMetric(),
 		subscribers:     []chan struct{}{},
+
+		getPeerState:      queryPeerState,
+		leaseAgeMetric:    metrics.NewLeaseAgeMetric(),
+		transitionsMetric: metrics.NewTransitionsMetric(),
+		splitBrainMetric:  metrics.NewSplitBrainMetric(),
+		stepDownMetric:    metrics.NewStepDownMetric(),
 	}
 }
 
@@ -200,7 +225,22 @@ func (le *LeaderEngine) EnsureLeaderElectionRuns() error {
 func (le *LeaderEngine) runLeaderElection() {
 	for {
 		log.Infof("Starting leader election process for %q...", le.HolderIdentity)
-		le.leaderElector.Run(context.Background())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		le.runMutex.Lock()
+		le.cancelRun = cancel
+		le.runMutex.Unlock()
+
+		// The health check runs along with the leader election run, a new one is started with the next run
+		healthCheckDone := make(chan struct{})
+		go func() {
+			defer close(healthCheckDone)
+			le.runHealthCheck(ctx)
+		}()
+
+		le.leaderElector.Run(ctx)
+		cancel()
+		<-healthCheckDone
 		log.Info("Leader election lost")
 	}
 }
@@ -268,11 +308,17 @@ func GetLeaderElectionRecord() (leaderDetails rl.LeaderElectionRecord, err error
 		return led, err
 	}
 	log.Debugf("LeaderElection cm is %#v", leaderElectionCM)
-	annotation, found := leaderElectionCM.Annotations[rl.LeaderElectionRecordAnnotationKey]
+	return leaderElectionRecordFromConfigMap(leaderElectionCM)
+}
+
+// leaderElectionRecordFromConfigMap parses the leader election record stored in the annotations of the lease
+func leaderElectionRecordFromConfigMap(cm *v1.ConfigMap) (rl.LeaderElectionRecord, error) {
+	var led rl.LeaderElectionRecord
+	annotation, found := cm.Annotations[rl.LeaderElectionRecordAnnotationKey]
 	if !found {
 		return led, apiserver.ErrNotFound
 	}
-	err = json.Unmarshal([]byte(annotation), &led)
+	err := json.Unmarshal([]byte(annotation), &led)
 	if err != nil {
 		return led, err
 	}
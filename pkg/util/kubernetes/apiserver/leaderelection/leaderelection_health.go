@@ -0,0 +1,267 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// +build kubeapiserver
+
+package leaderelection
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	rl "k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver/leaderelection/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// LeaderStatePath is the path of the cluster agent API endpoint exposing the LeaderState
+	LeaderStatePath  = "/api/v1/leader"
+	peerQueryTimeout = 5 * time.Second
+	// peerIdleConnTimeout closes the connections to the replicas that are gone
+	peerIdleConnTimeout = time.Minute
+)
+
+// peerClient is shared by the queries to all the replicas, to reuse their connections
+var peerClient = &http.Client{
+	Timeout: peerQueryTimeout,
+	Transport: &http.Transport{
+		// The cluster agent API uses a self-signed certificate
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		IdleConnTimeout: peerIdleConnTimeout,
+	},
+}
+
+// LeaderState is the leader election state of a cluster agent replica, exposed to its peers
+type LeaderState struct {
+	Identity string `json:"identity"`
+	Leader   string `json:"leader"`
+	IsLeader bool   `json:"is_leader"`
+}
+
+// Health is the result of the last health check of the leader election
+type Health struct {
+	LastCheck time.Time
+	// SplitBrain is true if another replica believes it is leader while this replica is leader
+	SplitBrain bool
+	// ConflictingLeaders contains the identities of the other replicas believing they are leader
+	ConflictingLeaders []string
+	// StepDowns is the number of times this replica stepped down after detecting a split-brain
+	StepDowns int
+}
+
+// GetState returns the leader election state of this replica
+func (le *LeaderEngine) GetState() LeaderState {
+	leader := le.GetLeader()
+	return LeaderState{
+		Identity: le.HolderIdentity,
+		Leader:   leader,
+		IsLeader: leader == le.HolderIdentity,
+	}
+}
+
+// GetHealth returns the result of the last health check of the leader election
+func (le *LeaderEngine) GetHealth() Health {
+	le.healthMutex.RLock()
+	defer le.healthMutex.RUnlock()
+
+	return le.health
+}
+
+// runHealthCheck periodically checks that this replica is the only one believing it is leader,
+// until the context of the leader election run is cancelled
+func (le *LeaderEngine) runHealthCheck(ctx context.Context) {
+	// Checking as often as the leader elector retries to renew the lease
+	ticker := time.NewTicker(le.LeaseDuration / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			le.checkHealth(ctx)
+		}
+	}
+}
+
+// checkHealth reports the lease metrics and detects split-brains.
+// A split-brain happens when this replica believes it is leader while the lease holder
+// is another replica, or while another replica reports being leader on its LeaderState endpoint.
+// In that case, the replica which doesn't hold the lease steps down.
+func (le *LeaderEngine) checkHealth(ctx context.Context) {
+	record, err := le.getLeaderElectionRecord(ctx)
+	if err != nil {
+		log.Debugf("Cannot check the leader election health: %v", err)
+		return
+	}
+	le.reportLeaseMetrics(record)
+
+	var conflictingLeaders []string
+	if le.IsLeader() {
+		if record.HolderIdentity != le.HolderIdentity {
+			conflictingLeaders = append(conflictingLeaders, record.HolderIdentity)
+		}
+		conflictingLeaders = appendUnique(conflictingLeaders, le.getPeerLeaders(ctx)...)
+	}
+
+	splitBrain := len(conflictingLeaders) > 0
+	stepDown := splitBrain && record.HolderIdentity != le.HolderIdentity
+
+	le.healthMutex.Lock()
+	le.health.LastCheck = time.Now()
+	le.health.SplitBrain = splitBrain
+	le.health.ConflictingLeaders = conflictingLeaders
+	if stepDown {
+		le.health.StepDowns++
+	}
+	le.healthMutex.Unlock()
+
+	if splitBrain {
+		le.splitBrainMetric.Set(1.0, metrics.JoinLeaderValue)
+		log.Warnf("Split-brain detected: %q and %v believe they are leader, the lease holder is %q", le.HolderIdentity, conflictingLeaders, record.HolderIdentity)
+	} else {
+		le.splitBrainMetric.Set(0.0, metrics.JoinLeaderValue)
+	}
+
+	if stepDown {
+		le.stepDown()
+	}
+}
+
+// stepDown interrupts the current leader election run, the election is then restarted as a candidate
+func (le *LeaderEngine) stepDown() {
+	le.runMutex.Lock()
+	defer le.runMutex.Unlock()
+
+	if le.cancelRun == nil {
+		return
+	}
+
+	log.Warnf("Stepping down as leader %q as it doesn't hold the lease", le.HolderIdentity)
+	le.stepDownMetric.Inc(metrics.JoinLeaderValue)
+	le.cancelRun()
+	le.cancelRun = nil
+}
+
+// reportLeaseMetrics updates the telemetry of the lease
+func (le *LeaderEngine) reportLeaseMetrics(record rl.LeaderElectionRecord) {
+	le.transitionsMetric.Set(float64(record.LeaderTransitions), metrics.JoinLeaderValue)
+
+	// We want to expose only the current holder
+	if le.leaseHolder != record.HolderIdentity {
+		le.leaseAgeMetric.Delete(metrics.JoinLeaderValue, le.leaseHolder)
+		le.leaseHolder = record.HolderIdentity
+	}
+
+	if record.HolderIdentity != "" {
+		le.leaseAgeMetric.Set(time.Since(record.AcquireTime.Time).Seconds(), metrics.JoinLeaderValue, record.HolderIdentity)
+	}
+}
+
+// getLeaderElectionRecord returns the current leader election record of the lease
+func (le *LeaderEngine) getLeaderElectionRecord(ctx context.Context) (rl.LeaderElectionRecord, error) {
+	configMap, err := le.coreClient.ConfigMaps(le.LeaderNamespace).Get(ctx, le.LeaseName, metav1.GetOptions{})
+	if err != nil {
+		return rl.LeaderElectionRecord{}, err
+	}
+
+	return leaderElectionRecordFromConfigMap(configMap)
+}
+
+// getPeerLeaders returns the identities of the other replicas reporting they are leader
+func (le *LeaderEngine) getPeerLeaders(ctx context.Context) []string {
+	if le.getPeerState == nil {
+		return nil
+	}
+
+	endpoints, err := le.coreClient.Endpoints(le.LeaderNamespace).Get(ctx, le.ServiceName, metav1.GetOptions{})
+	if err != nil {
+		log.Debugf("Cannot list the cluster agent replicas: %v", err)
+		return nil
+	}
+
+	var leaders []string
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef != nil && address.TargetRef.Name == le.HolderIdentity {
+				continue
+			}
+
+			state, err := le.getPeerState(address.IP)
+			if err != nil {
+				log.Debugf("Cannot get the leader election state of the replica %s: %v", address.IP, err)
+				continue
+			}
+
+			if state.IsLeader && state.Identity != le.HolderIdentity {
+				leaders = append(leaders, state.Identity)
+			}
+		}
+	}
+
+	return leaders
+}
+
+// queryPeerState queries the LeaderState endpoint of another cluster agent replica
+func queryPeerState(ip string) (*LeaderState, error) {
+	url := fmt.Sprintf("https://%s%s", net.JoinHostPort(ip, strconv.Itoa(config.Datadog.GetInt("cluster_agent.cmd_port"))), LeaderStatePath)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+util.GetDCAAuthToken())
+
+	resp, err := peerClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	// the body is read until EOF for the connection to be reused
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &LeaderState{}
+	if err := json.Unmarshal(body, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, v := range list {
+			if v == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+
+	return list
+}
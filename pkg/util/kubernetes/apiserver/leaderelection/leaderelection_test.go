@@ -23,6 +23,7 @@ import (
 	rl "k8s.io/client-go/tools/leaderelection/resourcelock"
 
 	dderrors "github.com/DataDog/datadog-agent/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
 )
 
 func makeLeaderCM(name, namespace, leaderIdentity string, leaseDuration int) *v1.ConfigMap {
@@ -75,6 +76,11 @@ func TestNewLeaseAcquiring(t *testing.T) {
 		LeaseDuration:   1 * time.Second,
 		coreClient:      client.CoreV1(),
 		leaderMetric:    &dummyGauge{},
+
+		leaseAgeMetric:    &dummyGauge{},
+		transitionsMetric: &dummyGauge{},
+		splitBrainMetric:  &dummyGauge{},
+		stepDownMetric:    &dummyCounter{},
 	}
 	_, err := client.CoreV1().ConfigMaps("default").Get(context.TODO(), leaseName, metav1.GetOptions{})
 	require.True(t, errors.IsNotFound(err))
@@ -110,6 +116,11 @@ func TestSubscribe(t *testing.T) {
 		LeaseDuration:   1 * time.Second,
 		coreClient:      client.CoreV1(),
 		leaderMetric:    &dummyGauge{},
+
+		leaseAgeMetric:    &dummyGauge{},
+		transitionsMetric: &dummyGauge{},
+		splitBrainMetric:  &dummyGauge{},
+		stepDownMetric:    &dummyCounter{},
 	}
 
 	notif1 := le.Subscribe()
@@ -167,6 +178,11 @@ func TestGetLeaderIPFollower(t *testing.T) {
 		LeaseDuration:   120 * time.Second,
 		coreClient:      client.CoreV1(),
 		leaderMetric:    &dummyGauge{},
+
+		leaseAgeMetric:    &dummyGauge{},
+		transitionsMetric: &dummyGauge{},
+		splitBrainMetric:  &dummyGauge{},
+		stepDownMetric:    &dummyCounter{},
 	}
 
 	// Create leader-election configmap with current node as follower
@@ -232,6 +248,148 @@ func TestGetLeaderIPFollower(t *testing.T) {
 	assert.True(t, dderrors.IsNotFound(err))
 }
 
+func TestCheckHealth(t *testing.T) {
+	const leaseName = "datadog-leader-election"
+	const endpointsName = "datadog-cluster-agent"
+
+	tests := []struct {
+		name               string
+		leaseHolder        string
+		leader             string
+		peerStates         map[string]*LeaderState
+		expectedSplitBrain bool
+		expectedConflicts  []string
+		expectedStepDown   bool
+	}{
+		{
+			name:        "healthy leader",
+			leaseHolder: "foo",
+			leader:      "foo",
+			peerStates: map[string]*LeaderState{
+				"1.1.1.2": {Identity: "bar", Leader: "foo", IsLeader: false},
+			},
+			expectedSplitBrain: false,
+		},
+		{
+			name:        "healthy follower",
+			leaseHolder: "bar",
+			leader:      "bar",
+			peerStates: map[string]*LeaderState{
+				"1.1.1.2": {Identity: "bar", Leader: "bar", IsLeader: true},
+			},
+			expectedSplitBrain: false,
+		},
+		{
+			name:        "peer believes it is leader, lease held",
+			leaseHolder: "foo",
+			leader:      "foo",
+			peerStates: map[string]*LeaderState{
+				"1.1.1.2": {Identity: "bar", Leader: "bar", IsLeader: true},
+			},
+			expectedSplitBrain: true,
+			expectedConflicts:  []string{"bar"},
+			expectedStepDown:   false,
+		},
+		{
+			name:        "lease held by peer",
+			leaseHolder: "bar",
+			leader:      "foo",
+			peerStates: map[string]*LeaderState{
+				"1.1.1.2": {Identity: "bar", Leader: "bar", IsLeader: true},
+			},
+			expectedSplitBrain: true,
+			expectedConflicts:  []string{"bar"},
+			expectedStepDown:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+
+			_, err := client.CoreV1().ConfigMaps("default").Create(context.TODO(), makeLeaderCM(leaseName, "default", tt.leaseHolder, 120), metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			_, err = client.CoreV1().Endpoints("default").Create(context.TODO(), &v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      endpointsName,
+					Namespace: "default",
+				},
+				Subsets: []v1.EndpointSubset{
+					{
+						Addresses: []v1.EndpointAddress{
+							{IP: "1.1.1.1", TargetRef: &v1.ObjectReference{Kind: "pod", Namespace: "default", Name: "foo"}},
+							{IP: "1.1.1.2", TargetRef: &v1.ObjectReference{Kind: "pod", Namespace: "default", Name: "bar"}},
+						},
+					},
+				},
+			}, metav1.CreateOptions{})
+			require.NoError(t, err)
+
+			stepDownCalled := false
+			le := &LeaderEngine{
+				HolderIdentity:  "foo",
+				LeaseName:       leaseName,
+				ServiceName:     endpointsName,
+				LeaderNamespace: "default",
+				LeaseDuration:   120 * time.Second,
+				coreClient:      client.CoreV1(),
+				leaderMetric:    &dummyGauge{},
+				cancelRun:       func() { stepDownCalled = true },
+				getPeerState: func(ip string) (*LeaderState, error) {
+					require.NotEqual(t, "1.1.1.1", ip, "the replica must not query itself")
+					return tt.peerStates[ip], nil
+				},
+				leaseAgeMetric:    &dummyGauge{},
+				transitionsMetric: &dummyGauge{},
+				splitBrainMetric:  &dummyGauge{},
+				stepDownMetric:    &dummyCounter{},
+			}
+			le.updateLeaderIdentity(tt.leader)
+
+			le.checkHealth(context.TODO())
+
+			health := le.GetHealth()
+			assert.False(t, health.LastCheck.IsZero())
+			assert.Equal(t, tt.expectedSplitBrain, health.SplitBrain)
+			assert.Equal(t, tt.expectedConflicts, health.ConflictingLeaders)
+			assert.Equal(t, tt.expectedStepDown, stepDownCalled)
+		})
+	}
+}
+
+func TestRunHealthCheckStopsWithContext(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	le := &LeaderEngine{
+		HolderIdentity:  "foo",
+		LeaseName:       "datadog-leader-election",
+		LeaderNamespace: "default",
+		LeaseDuration:   40 * time.Millisecond,
+		coreClient:      client.CoreV1(),
+		leaderMetric:    &dummyGauge{},
+
+		leaseAgeMetric:    &dummyGauge{},
+		transitionsMetric: &dummyGauge{},
+		splitBrainMetric:  &dummyGauge{},
+		stepDownMetric:    &dummyCounter{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		le.runHealthCheck(ctx)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the health check did not stop with its context")
+	}
+}
+
 type dummyGauge struct{}
 
 func (g *dummyGauge) Set(value float64, tagsValue ...string) {}
@@ -240,3 +398,10 @@ func (g *dummyGauge) Dec(tagsValue ...string)                {}
 func (g *dummyGauge) Add(value float64, tagsValue ...string) {}
 func (g *dummyGauge) Sub(value float64, tagsValue ...string) {}
 func (g *dummyGauge) Delete(tagsValue ...string)             {}
+
+// dummyCounter only implements the methods used by the LeaderEngine
+type dummyCounter struct {
+	telemetry.Counter
+}
+
+func (c *dummyCounter) Inc(tagsValue ...string) {}
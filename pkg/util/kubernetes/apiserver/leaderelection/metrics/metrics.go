@@ -16,6 +16,8 @@ const (
 	JoinLeaderValue = "true"
 	// isLeaderLabel represents the is_leader label
 	isLeaderLabel = "is_leader"
+	// holderLabel represents the holder label
+	holderLabel = "holder"
 )
 
 // NewLeaderMetric returns the leader_election_is_leader metric
@@ -28,3 +30,47 @@ func NewLeaderMetric() telemetry.Gauge {
 		telemetry.Options{NoDoubleUnderscoreSep: true},
 	)
 }
+
+// NewLeaseAgeMetric returns the leader_election_lease_age_seconds metric
+func NewLeaseAgeMetric() telemetry.Gauge {
+	return telemetry.NewGaugeWithOpts(
+		"leader_election",
+		"lease_age_seconds",
+		[]string{JoinLeaderLabel, holderLabel},
+		"Number of seconds since the lease holder acquired the leadership.",
+		telemetry.Options{NoDoubleUnderscoreSep: true},
+	)
+}
+
+// NewTransitionsMetric returns the leader_election_transitions metric
+func NewTransitionsMetric() telemetry.Gauge {
+	return telemetry.NewGaugeWithOpts(
+		"leader_election",
+		"transitions",
+		[]string{JoinLeaderLabel},
+		"Number of leadership transitions recorded in the lease.",
+		telemetry.Options{NoDoubleUnderscoreSep: true},
+	)
+}
+
+// NewSplitBrainMetric returns the leader_election_split_brain metric
+func NewSplitBrainMetric() telemetry.Gauge {
+	return telemetry.NewGaugeWithOpts(
+		"leader_election",
+		"split_brain",
+		[]string{JoinLeaderLabel},
+		"Equals 1 if the reporting pod and another replica both believe they are leader, 0 otherwise.",
+		telemetry.Options{NoDoubleUnderscoreSep: true},
+	)
+}
+
+// NewStepDownMetric returns the leader_election_step_downs metric
+func NewStepDownMetric() telemetry.Counter {
+	return telemetry.NewCounterWithOpts(
+		"leader_election",
+		"step_downs",
+		[]string{JoinLeaderLabel},
+		"Number of times the reporting pod stepped down after detecting a split-brain.",
+		telemetry.Options{NoDoubleUnderscoreSep: true},
+	)
+}
@@ -14,8 +14,10 @@ import (
 var tmplVarRegex = regexp.MustCompile(`%%.+?%%`)
 
 // TemplateVar is the info for a parsed template variable.
+// Default is the value following a pipe, like 8080 in %%port_http|8080%%,
+// it is nil when the template variable has no default value.
 type TemplateVar struct {
-	Raw, Name, Key []byte
+	Raw, Name, Key, Default []byte
 }
 
 // ParseString returns parsed template variables found in the input string.
@@ -28,21 +30,25 @@ func Parse(b []byte) []TemplateVar {
 	var parsed []TemplateVar
 	vars := tmplVarRegex.FindAll(b, -1)
 	for _, v := range vars {
-		name, key := parseTemplateVar(v)
-		parsed = append(parsed, TemplateVar{v, name, key})
+		name, key, def := parseTemplateVar(v)
+		parsed = append(parsed, TemplateVar{v, name, key, def})
 	}
 	return parsed
 }
 
-// parseTemplateVar extracts the name of the var and the key (or index if it can be
-// cast to an int)
-func parseTemplateVar(v []byte) (name, key []byte) {
+// parseTemplateVar extracts the name of the var, the key (or index if it can be
+// cast to an int) and the default value
+func parseTemplateVar(v []byte) (name, key, def []byte) {
 	stripped := bytes.Map(func(r rune) rune {
 		if unicode.IsSpace(r) || r == '%' {
 			return -1
 		}
 		return r
 	}, v)
+	if i := bytes.IndexByte(stripped, '|'); i >= 0 {
+		def = append([]byte{}, stripped[i+1:]...)
+		stripped = stripped[:i]
+	}
 	split := bytes.SplitN(stripped, []byte("_"), 2)
 	name = split[0]
 	if len(split) == 2 {
@@ -50,5 +56,5 @@ func parseTemplateVar(v []byte) (name, key []byte) {
 	} else {
 		key = []byte("")
 	}
-	return name, key
+	return name, key, def
 }
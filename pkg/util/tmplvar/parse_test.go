@@ -14,40 +14,81 @@ import (
 
 func TestParseTemplateVar(t *testing.T) {
 	testCases := []struct {
-		tmpl, name, key string
+		tmpl, name, key, def string
+		hasDefault           bool
 	}{
 		{
 			"%%host%%",
 			"host",
 			"",
+			"",
+			false,
 		},
 		{
 			"%%host_0%%",
 			"host",
 			"0",
+			"",
+			false,
 		},
 		{
 			"%%host 0%%",
 			"host0",
 			"",
+			"",
+			false,
 		},
 		{
 			"%%host_0_1%%",
 			"host",
 			"0_1",
+			"",
+			false,
 		},
 		{
 			"%%host_network_name%%",
 			"host",
 			"network_name",
+			"",
+			false,
+		},
+		{
+			"%%port_http|8080%%",
+			"port",
+			"http",
+			"8080",
+			true,
+		},
+		{
+			"%%host|localhost%%",
+			"host",
+			"",
+			"localhost",
+			true,
+		},
+		{
+			"%%kube_pod_label_app.kubernetes.io/name|unknown|app%%",
+			"kube",
+			"pod_label_app.kubernetes.io/name",
+			"unknown|app",
+			true,
+		},
+		{
+			"%%env_TAG|%%",
+			"env",
+			"TAG",
+			"",
+			true,
 		},
 	}
 
 	for i, testCase := range testCases {
 		t.Run(fmt.Sprintf("#%d", i), func(t *testing.T) {
-			name, key := parseTemplateVar([]byte(testCase.tmpl))
+			name, key, def := parseTemplateVar([]byte(testCase.tmpl))
 			assert.Equal(t, testCase.name, string(name))
 			assert.Equal(t, testCase.key, string(key))
+			assert.Equal(t, testCase.def, string(def))
+			assert.Equal(t, testCase.hasDefault, def != nil)
 		})
 	}
 }
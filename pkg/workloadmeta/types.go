@@ -66,6 +66,7 @@ const (
 	ContainerRuntimeDocker     ContainerRuntime = "docker"
 	ContainerRuntimeContainerd ContainerRuntime = "containerd"
 	ContainerRuntimePodman     ContainerRuntime = "podman"
+	ContainerRuntimeCRIO       ContainerRuntime = "cri-o"
 
 	ECSLaunchTypeEC2     ECSLaunchType = "ec2"
 	ECSLaunchTypeFargate ECSLaunchType = "fargate"